@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/encrypt"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+// atRest holds the [encryption] keys, for the helpers below to save and
+// read recordings and transcripts with. It is nil until a command loads its
+// config, and stays nil when nothing is configured, which leaves every file
+// in plaintext as before.
+var atRest *encrypt.Keys
+
+// useEncryption sets atRest from cfg's [encryption].
+func useEncryption(cfg *config.Config) {
+	e := cfg.ResolveEncryption()
+	if len(e.Recipients) == 0 && e.RecipientsFile == "" && e.IdentityFile == "" {
+		atRest = nil
+		return
+	}
+	atRest = &encrypt.Keys{Recipients: e.Recipients, RecipientsFile: e.RecipientsFile, IdentityFile: e.IdentityFile}
+}
+
+// recordingBase is path without its extension, nor the .age after it: the
+// name a recording and every file derived from it share.
+func recordingBase(path string) string {
+	path = encrypt.Plain(path)
+	return strings.TrimSuffix(path, filepath.Ext(path))
+}
+
+// recordingExt is the extension recordingBase takes off.
+func recordingExt(path string) string {
+	return strings.TrimPrefix(path, recordingBase(path))
+}
+
+// saveFile writes data to path, or, when encrypting, encrypted to path.age.
+// Whichever of the two it does not write is deleted, so a transcript saved
+// again is not left beside an older copy in the other form. It returns the
+// path written.
+func saveFile(path string, data []byte) (string, error) {
+	if atRest.Encrypting() {
+		sealed := path + encrypt.Ext
+		if err := atRest.WriteFile(context.Background(), sealed, data); err != nil {
+			return "", err
+		}
+		os.Remove(path)
+		return sealed, nil
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	os.Remove(path + encrypt.Ext)
+	return path, nil
+}
+
+// readFile reads a file saveFile wrote, given either name: an encrypted file
+// is decrypted in memory.
+func readFile(path string) ([]byte, error) {
+	if !encrypt.IsEncrypted(path) {
+		data, err := os.ReadFile(path)
+		if !errors.Is(err, fs.ErrNotExist) || !fileExists(path+encrypt.Ext) {
+			return data, err
+		}
+		path += encrypt.Ext
+	}
+	return atRest.ReadFile(context.Background(), path)
+}
+
+// pathOnDisk is where saveFile's file for path is: path itself, or path.age
+// when only that exists.
+func pathOnDisk(path string) string {
+	if !fileExists(path) && fileExists(path+encrypt.Ext) {
+		return path + encrypt.Ext
+	}
+	return path
+}
+
+// savedFileExists reports whether saveFile has written path, in either form.
+func savedFileExists(path string) bool {
+	return fileExists(pathOnDisk(path))
+}
+
+// plainRecording returns a path the recording at path can be read from by
+// ffmpeg and the backends, with a func to call once it is no longer needed.
+// An encrypted recording is decrypted into a private temp directory, which
+// the func deletes; any other is its own path.
+func plainRecording(ctx context.Context, path string) (string, func(), error) {
+	if !encrypt.IsEncrypted(path) {
+		return path, func() {}, nil
+	}
+	return atRest.DecryptToTemp(ctx, path)
+}
+
+// sealRecording encrypts a recording ffmpeg has finished with, when
+// encrypting, and returns its new path. Otherwise it is left as it is.
+func sealRecording(ctx context.Context, path string) (string, error) {
+	if !atRest.Encrypting() {
+		return path, nil
+	}
+	return atRest.EncryptFile(ctx, path)
+}
+
+// sealRecorded encrypts a recording ffmpeg has finished writing, when
+// encrypting, and returns where it now is. A failure leaves it in plaintext
+// and goes to warn: the recording is worth more than its encryption.
+func sealRecorded(path string, warn func(error)) string {
+	sealed, err := sealRecording(context.Background(), path)
+	if err != nil {
+		warn(fmt.Errorf("failed to encrypt %s, which stays in plaintext: %w", path, err))
+		return path
+	}
+	return sealed
+}
+
+// startLive starts s on pcm with its transcript at livePath, or, when
+// encrypting, encrypted at livePath.age by way of a liveStore.
+func startLive(s *transcribe.Streamer, pcm io.Reader, livePath string) error {
+	if atRest.Encrypting() {
+		s.SetTranscriptStore(liveStore{livePath})
+		livePath = ""
+	}
+	return s.Start(context.Background(), pcm, livePath)
+}
+
+// liveStore keeps a live transcript encrypted. age cannot append to a file,
+// so each commit encrypts the whole transcript again; a transcript is small
+// enough, and commits far enough apart, for that to cost nothing noticeable.
+type liveStore struct {
+	path string // the transcript's plain name
+}
+
+func (s liveStore) Load() ([]byte, error) {
+	data, err := readFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (s liveStore) Save(text []byte) error {
+	_, err := saveFile(s.path, text)
+	return err
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joegoldin/audiomemo/internal/encrypt"
+	"github.com/joegoldin/audiomemo/internal/meta"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+// TestMain removes the private temp directory the tests' temp files went in,
+// as execute does for a real run.
+func TestMain(m *testing.M) {
+	code := m.Run()
+	encrypt.RemoveTempDir()
+	os.Exit(code)
+}
+
+func TestEncryptedRecordingPaths(t *testing.T) {
+	rec := "/rec/standup-2026-10-18T09-30-00.ogg.age"
+	if got := recordingBase(rec); got != "/rec/standup-2026-10-18T09-30-00" {
+		t.Errorf("recordingBase = %q", got)
+	}
+	if got := recordingExt(rec); got != ".ogg.age" {
+		t.Errorf("recordingExt = %q", got)
+	}
+	if got := recordingExt("/rec/standup.ogg"); got != ".ogg" {
+		t.Errorf("recordingExt of a plain recording = %q", got)
+	}
+	for _, c := range []struct{ got, want string }{
+		{transcriptPathFor(rec, transcribe.FormatJSON), "/rec/standup-2026-10-18T09-30-00.json"},
+		{liveTranscriptPathFor(rec), "/rec/standup-2026-10-18T09-30-00-live.txt"},
+		{summaryPathFor(rec), "/rec/standup-2026-10-18T09-30-00.summary.md"},
+		{translationPathFor(rec, "fr", transcribe.FormatText), "/rec/standup-2026-10-18T09-30-00.fr.txt"},
+		{meta.PathFor(rec), "/rec/standup-2026-10-18T09-30-00.meta.json"},
+		{hookLabel(rec), "standup-2026-10-18T09-30-00"},
+		{summaryPathFor(transcriptPathFor(rec, transcribe.FormatText) + ".age"), "/rec/standup-2026-10-18T09-30-00.summary.md"},
+	} {
+		if c.got != c.want {
+			t.Errorf("got %q, want %q", c.got, c.want)
+		}
+	}
+	if !isRecording(rec) || isRecording("/rec/standup.txt.age") {
+		t.Error("isRecording should look past .age")
+	}
+}
+
+func TestRenameWithLabelEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "recording-2026-10-18T09-30-00.ogg.age")
+	os.WriteFile(path, []byte("sealed"), 0600)
+	renamed, err := renameWithLabel(path, "standup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(renamed) != "recording-2026-10-18T09-30-00-standup.ogg.age" {
+		t.Errorf("renamed to %q", renamed)
+	}
+}
+
+func TestRelabelRecordingMovesEncryptedFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "recording-2026-10-18T09-30-00")
+	for _, suffix := range []string{".ogg.age", ".txt.age", "-live.txt.age", ".fr.txt.age", ".meta.json"} {
+		os.WriteFile(base+suffix, []byte("x"), 0600)
+	}
+	renamed, err := relabelRecording(base+".ogg.age", "standup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newBase := strings.TrimSuffix(renamed, ".ogg.age")
+	for _, suffix := range []string{".ogg.age", ".txt.age", "-live.txt.age", ".fr.txt.age", ".meta.json"} {
+		if !fileExists(newBase + suffix) {
+			t.Errorf("%s was not moved", suffix)
+		}
+	}
+}
+
+func TestFindAudioForEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "standup.ogg.age")
+	os.WriteFile(audio, []byte("sealed"), 0600)
+	if got := findAudioFor(filepath.Join(dir, "standup.json.age")); got != audio {
+		t.Errorf("findAudioFor = %q, want %q", got, audio)
+	}
+}
+
+func TestSaveFileInPlaintext(t *testing.T) {
+	atRest = nil
+	path := filepath.Join(t.TempDir(), "standup.txt")
+	// Left from when encryption was on: saving again replaces it.
+	os.WriteFile(path+".age", []byte("sealed"), 0600)
+	got, err := saveFile(path, []byte("Hello.\n"))
+	if err != nil || got != path {
+		t.Fatalf("saveFile = %q, %v", got, err)
+	}
+	if fileExists(path + ".age") {
+		t.Error("the older encrypted copy was left beside the new one")
+	}
+	if data, err := readFile(path); err != nil || string(data) != "Hello.\n" {
+		t.Errorf("readFile = %q, %v", data, err)
+	}
+}
+
+func TestReadFileNeedsIdentityForEncrypted(t *testing.T) {
+	atRest = nil
+	path := filepath.Join(t.TempDir(), "standup.txt")
+	os.WriteFile(path+".age", []byte("sealed"), 0600)
+	if !savedFileExists(path) || pathOnDisk(path) != path+".age" {
+		t.Errorf("pathOnDisk = %q", pathOnDisk(path))
+	}
+	if _, err := readFile(path); err == nil || !strings.Contains(err.Error(), "identity_file") {
+		t.Errorf("readFile without an identity = %v", err)
+	}
+}
+
+func TestPromoteEncryptedLiveTranscript(t *testing.T) {
+	// A recording-only machine has recipients but no identity, and still
+	// promotes the live transcript, as it is.
+	atRest = nil
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "standup.ogg.age")
+	os.WriteFile(filepath.Join(dir, "standup-live.txt.age"), []byte("sealed live"), 0600)
+	os.WriteFile(filepath.Join(dir, "standup.txt"), []byte("stale"), 0644)
+
+	promoted, err := promoteLiveTranscript(audio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if promoted != filepath.Join(dir, "standup.txt.age") {
+		t.Errorf("promoted to %q", promoted)
+	}
+	if data, _ := os.ReadFile(promoted); string(data) != "sealed live" {
+		t.Errorf("promoted = %q", data)
+	}
+	if fileExists(filepath.Join(dir, "standup.txt")) {
+		t.Error("a plaintext transcript was left beside the encrypted one")
+	}
+}
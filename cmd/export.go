@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/encrypt"
+	"github.com/joegoldin/audiomemo/internal/meta"
+	"github.com/joegoldin/audiomemo/internal/notes"
+	"github.com/joegoldin/audiomemo/internal/summarize"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+	"github.com/spf13/cobra"
+)
+
+var (
+	eConfig string
+	eDir    string
+)
+
+// rExportNote asks record's batch pass to write the recording's note, as it
+// does whenever [export.notes] names a vault. Set by runRecord.
+var rExportNote bool
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write recordings out for other tools",
+}
+
+var exportNoteCmd = &cobra.Command{
+	Use:   "note <recording|transcript>",
+	Short: "Write a recording as a Markdown note into a vault",
+	Long: `Write a note for the recording into the [export.notes] directory, named after
+the recording: YAML front matter with the date, duration, device, speakers,
+tags and a link to the audio, then the summary if there is one, then the
+transcript by speaker with each turn linked to its time in the recording.
+
+The transcript saved next to the recording is used, JSON first because it
+keeps the speakers and timings. Exporting again updates the note in place:
+only the front matter keys audiomemo sets and the part between its
+<!-- audiomemo:begin --> and <!-- audiomemo:end --> lines change, so notes
+written around it are kept.
+
+record writes the note by itself after Q or -t when [export.notes] is set,
+and so does transcribe --export-note.
+
+Examples:
+  audiomemo export note ~/Recordings/standup-2026-10-18T09-30-00.ogg
+  audiomemo export note --dir ~/vault/Inbox interview.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExportNote,
+}
+
+func init() {
+	exportCmd.PersistentFlags().StringVar(&eConfig, "config", "", "config file path")
+	exportNoteCmd.Flags().StringVar(&eDir, "dir", "", "directory to write the note in (export.notes.dir)")
+	exportCmd.AddCommand(exportNoteCmd)
+}
+
+func runExportNote(cmd *cobra.Command, args []string) error {
+	var cfg *config.Config
+	var err error
+	if eConfig != "" {
+		cfg, err = config.LoadFrom(eConfig)
+	} else {
+		cfg, err = config.Load()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	useEncryption(cfg)
+
+	if eDir != "" {
+		cfg.Export.Notes.Dir = eDir
+	}
+	dir, err := notesDir(cfg)
+	if err != nil {
+		return err
+	}
+	result, transcriptPath, err := loadSavedTranscript(args[0])
+	if err != nil {
+		return err
+	}
+	recording := args[0]
+	if recording == transcriptPath {
+		if audio := findAudioFor(transcriptPath); audio != "" {
+			recording = audio
+		}
+	}
+	_, err = exportNote(cfg, dir, recording, result, nil)
+	return err
+}
+
+// notesDir is the vault notes go into, which must have been configured.
+func notesDir(cfg *config.Config) (string, error) {
+	dir := cfg.ResolveNotesDir()
+	if dir == "" {
+		return "", fmt.Errorf("no notes directory: set dir under [export.notes] in the config, or pass --dir")
+	}
+	return dir, nil
+}
+
+// loadSavedTranscript reads the transcript for a recording, or the transcript
+// given, and returns it with its path. JSON comes first because it keeps the
+// speakers and the timings a note links to; plain text is used as it is.
+func loadSavedTranscript(path string) (*transcribe.Result, string, error) {
+	if isRecording(path) {
+		base := recordingBase(path)
+		found := ""
+		for _, ext := range []string{".json", ".txt"} {
+			if savedFileExists(base + ext) {
+				found = base + ext
+				break
+			}
+		}
+		if found == "" {
+			return nil, "", fmt.Errorf("no transcript found for %s: run transcribe first", path)
+		}
+		path = found
+	}
+
+	data, err := readFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	switch filepath.Ext(encrypt.Plain(path)) {
+	case ".json":
+		var r transcribe.Result
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, "", fmt.Errorf("%s is not a JSON transcript: %w", path, err)
+		}
+		return &r, path, nil
+	case ".txt":
+		return &transcribe.Result{Text: string(data)}, path, nil
+	}
+	return nil, "", fmt.Errorf("cannot read a transcript from %s: give the recording, or its .json or .txt transcript", path)
+}
+
+// exportNote writes the note for the recording at path, from result and,
+// when summary is nil, the summary saved beside the recording if there is
+// one. It returns the note's path.
+func exportNote(cfg *config.Config, dir, path string, result *transcribe.Result, summary *summarize.Summary) (string, error) {
+	n, err := buildNote(cfg, path, result, summary)
+	if err != nil {
+		return "", err
+	}
+	notePath := filepath.Join(dir, hookLabel(path)+".md")
+	changed, err := n.Write(notePath)
+	if err != nil {
+		return "", err
+	}
+	if changed {
+		fmt.Fprintf(os.Stderr, "Saved note to %s\n", notePath)
+	} else {
+		fmt.Fprintf(os.Stderr, "Note %s is up to date\n", notePath)
+	}
+	return notePath, nil
+}
+
+// buildNote gathers what the note says about a recording: the names given
+// to its speakers and the device it was made from, from its sidecar, and
+// its summary.
+func buildNote(cfg *config.Config, path string, result *transcribe.Result, summary *summarize.Summary) (*notes.Note, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	md, err := meta.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	// A copy, so that the names given since are not written back into a
+	// result the caller still holds.
+	r := *result
+	r.Segments = append([]transcribe.Segment(nil), result.Segments...)
+	r.RenameSpeakers(md.Speakers)
+
+	n := &notes.Note{
+		Title:    hookLabel(path),
+		Date:     recordingTime(path),
+		Duration: r.Duration,
+		Device:   md.Device,
+		Tags:     cfg.Export.Notes.Tags,
+		Result:   &r,
+	}
+	if isRecording(path) {
+		n.Audio = abs
+		if n.Duration == 0 {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			n.Duration, _ = transcribe.ProbeDuration(ctx, path)
+			cancel()
+		}
+	}
+	if summary == nil {
+		if data, err := readFile(summaryPathFor(path)); err == nil {
+			summary = summarize.ParseMarkdown(string(data))
+		}
+	}
+	if summary != nil {
+		if summary.Title != "" {
+			n.Title = summary.Title
+		}
+		n.Summary = summary.Summary
+		n.ActionItems = summary.ActionItems
+	}
+	return n, nil
+}
+
+// recordingStamp matches the time record puts in every file name.
+var recordingStamp = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2}`)
+
+// recordingTime is when the recording at path was made: the time in its
+// name, as record gives it, or else when the file was last written. It is
+// zero when neither is known.
+func recordingTime(path string) time.Time {
+	if stamp := recordingStamp.FindString(filepath.Base(path)); stamp != "" {
+		if t, err := time.ParseInLocation("2006-01-02T15-04-05", stamp, time.Local); err == nil {
+			return t
+		}
+	}
+	if info, err := os.Stat(path); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
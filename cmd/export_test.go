@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/meta"
+	"github.com/joegoldin/audiomemo/internal/summarize"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+func TestExportNote(t *testing.T) {
+	rec := t.TempDir()
+	vault := filepath.Join(t.TempDir(), "Meetings")
+	audio := filepath.Join(rec, "standup-2026-10-18T09-30-00.ogg")
+	os.WriteFile(audio, []byte("not audio"), 0644)
+	result := &transcribe.Result{
+		Text:     "Morning. Hi.",
+		Duration: 95,
+		Segments: []transcribe.Segment{
+			{Start: 0, End: 1, Text: "Morning.", Speaker: "Speaker 0"},
+			{Start: 61, End: 62, Text: "Hi.", Speaker: "Speaker 1"},
+		},
+	}
+	os.WriteFile(transcriptPathFor(audio, transcribe.FormatJSON), []byte(result.Format(transcribe.FormatJSON)), 0644)
+	md := &meta.Metadata{Device: "mic"}
+	md.SetSpeakerNames(map[string]string{"Speaker 0": "Alice"})
+	md.Save(audio)
+	s := &summarize.Summary{Title: "Daily standup", Summary: "Short one.", ActionItems: "None."}
+	os.WriteFile(summaryPathFor(audio), []byte(s.Markdown()), 0644)
+
+	cfg := config.Default()
+	cfg.Export.Notes.Tags = []string{"meeting"}
+	loaded, transcriptPath, err := loadSavedTranscript(audio)
+	if err != nil || transcriptPath != transcriptPathFor(audio, transcribe.FormatJSON) {
+		t.Fatalf("loadSavedTranscript = %v, %v", transcriptPath, err)
+	}
+	notePath, err := exportNote(cfg, vault, audio, loaded, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notePath != filepath.Join(vault, "standup-2026-10-18T09-30-00.md") {
+		t.Errorf("note path = %s", notePath)
+	}
+	data, _ := os.ReadFile(notePath)
+	note := string(data)
+	for _, want := range []string{
+		`title: "Daily standup"`,
+		"date: 2026-10-18T09:30:00",
+		`duration: "1:35"`,
+		`device: "mic"`,
+		"speakers:\n  - \"Alice\"\n  - \"Speaker 1\"\n",
+		"tags:\n  - \"meeting\"\n",
+		"## Summary\n\nShort one.",
+		"**Alice** [0:00](file://" + filepath.ToSlash(audio) + "#t=0) Morning.",
+	} {
+		if !strings.Contains(note, want) {
+			t.Errorf("note lacks %q:\n%s", want, note)
+		}
+	}
+	// The names came from the sidecar, not from the caller's result.
+	if loaded.Segments[0].Speaker != "Speaker 0" {
+		t.Errorf("the caller's result was renamed")
+	}
+
+	os.WriteFile(notePath, []byte(note+"\nMy own notes.\n"), 0644)
+	if _, err := exportNote(cfg, vault, audio, loaded, nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(notePath); !strings.HasSuffix(string(data), "\nMy own notes.\n") {
+		t.Errorf("re-export lost the user's notes:\n%s", data)
+	}
+}
+
+func TestLoadSavedTranscript(t *testing.T) {
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "memo.ogg")
+	if _, _, err := loadSavedTranscript(audio); err == nil || !strings.Contains(err.Error(), "run transcribe first") {
+		t.Errorf("no transcript: %v", err)
+	}
+	os.WriteFile(filepath.Join(dir, "memo.txt"), []byte("Plain.\n"), 0644)
+	r, path, err := loadSavedTranscript(audio)
+	if err != nil || r.Text != "Plain.\n" || path != filepath.Join(dir, "memo.txt") {
+		t.Errorf("text transcript = %+v, %s, %v", r, path, err)
+	}
+	if _, _, err := loadSavedTranscript(filepath.Join(dir, "memo.srt")); err == nil {
+		t.Error("want an error for a subtitle file")
+	}
+}
+
+func TestNotesDirRequired(t *testing.T) {
+	if _, err := notesDir(config.Default()); err == nil {
+		t.Error("want an error with no vault configured")
+	}
+}
+
+func TestRecordingTime(t *testing.T) {
+	want := time.Date(2026, 10, 18, 9, 30, 5, 0, time.Local)
+	if got := recordingTime("/r/standup-002-2026-10-18T09-30-05.ogg"); !got.Equal(want) {
+		t.Errorf("from the name = %v, want %v", got, want)
+	}
+	path := filepath.Join(t.TempDir(), "interview.ogg")
+	os.WriteFile(path, nil, 0644)
+	mtime := time.Date(2025, 1, 2, 3, 4, 5, 0, time.Local)
+	os.Chtimes(path, mtime, mtime)
+	if got := recordingTime(path); !got.Equal(mtime) {
+		t.Errorf("from the file = %v, want %v", got, mtime)
+	}
+}
+
+func TestBatchPassExportsNote(t *testing.T) {
+	rExportNote = true
+	t.Cleanup(func() { rExportNote = false })
+	_, args, err := newPostTranscribeCmd("/r/memo.ogg", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(args, "--export-note") {
+		t.Errorf("args = %v", args)
+	}
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/hooks"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+// Hook reasons for a recording's end, in AUDIOMEMO_REASON. With --stream
+// they are the end event's reasons, which also tell a signal or a stop
+// command apart.
+const (
+	hookReasonStopped = "stopped"
+	hookReasonSilence = "silence"
+)
+
+// newHookRunner builds the runner for cfg's [hooks]. A bad timeout fails the
+// run before anything is recorded rather than after.
+func newHookRunner(cfg *config.Config) (*hooks.Runner, error) {
+	timeout, err := parseFlagDuration("hooks.timeout", cfg.Hooks.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &hooks.Runner{
+		Commands: map[string]string{
+			hooks.PostRecord:     cfg.Hooks.PostRecord,
+			hooks.PostTranscribe: cfg.Hooks.PostTranscribe,
+			hooks.OnError:        cfg.Hooks.OnError,
+		},
+		Dir:     cfg.ResolveHooksDir(),
+		Timeout: timeout,
+	}, nil
+}
+
+// runHook runs ev's hooks and hands a failure to warn. It never fails the
+// run: whatever the hook was told about is already on disk.
+func runHook(ctx context.Context, r *hooks.Runner, ev hooks.Event, warn func(error)) {
+	if err := r.Run(ctx, ev); err != nil {
+		warn(err)
+	}
+}
+
+func warnStderr(err error) {
+	fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+}
+
+// hookLabel is the name a hook knows a recording by: its file name without
+// the directory or extension.
+func hookLabel(audioPath string) string {
+	return filepath.Base(recordingBase(audioPath))
+}
+
+// recordHookEvent describes a saved recording to post_record. The duration
+// is the file's own when ffprobe can read it, which leaves out time spent
+// paused; failing that it is the time since started, unless that is zero.
+func recordHookEvent(audioPath, backend, reason string, started time.Time) hooks.Event {
+	ev := hooks.Event{
+		Hook:    hooks.PostRecord,
+		Command: "record",
+		Audio:   audioPath,
+		Label:   hookLabel(audioPath),
+		Backend: backend,
+		Reason:  reason,
+	}
+	if path := pathOnDisk(transcriptPathFor(audioPath, transcribe.FormatText)); fileExists(path) {
+		ev.Transcript = path
+	}
+	if path := pathOnDisk(liveTranscriptPathFor(audioPath)); fileExists(path) {
+		ev.LiveTranscript = path
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if d, err := transcribe.ProbeDuration(ctx, audioPath); err == nil && d > 0 {
+		ev.Duration = d
+	} else if !started.IsZero() {
+		ev.Duration = time.Since(started).Seconds()
+	}
+	return ev
+}
+
+// recordHookReason is AUDIOMEMO_REASON for a recording that ended the way
+// reason says, or was stopped by --max-silence.
+func recordHookReason(reason string, silence bool) string {
+	if silence {
+		return hookReasonSilence
+	}
+	if reason == "" {
+		return hookReasonStopped
+	}
+	return reason
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
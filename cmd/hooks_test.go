@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/hooks"
+	"github.com/joegoldin/audiomemo/internal/stream"
+)
+
+func TestRecordHookEvent(t *testing.T) {
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "2026-10-18_standup.ogg")
+	os.WriteFile(audio, []byte("not audio"), 0644)
+	os.WriteFile(filepath.Join(dir, "2026-10-18_standup-live.txt"), []byte("Hi.\n"), 0644)
+
+	ev := recordHookEvent(audio, "elevenlabs-realtime", hookReasonStopped, time.Now().Add(-90*time.Second))
+	if ev.Hook != hooks.PostRecord || ev.Command != "record" || ev.Label != "2026-10-18_standup" {
+		t.Errorf("event = %+v", ev)
+	}
+	// Only the files that exist are named.
+	if ev.Transcript != "" || ev.LiveTranscript != filepath.Join(dir, "2026-10-18_standup-live.txt") {
+		t.Errorf("transcript = %q, live = %q", ev.Transcript, ev.LiveTranscript)
+	}
+	// ffprobe cannot read the file, so the wall clock stands in.
+	if ev.Duration < 90 || ev.Duration > 95 {
+		t.Errorf("duration = %v, want about 90s", ev.Duration)
+	}
+	if ev := recordHookEvent(audio, "", hookReasonStopped, time.Time{}); ev.Duration != 0 {
+		t.Errorf("duration without a start = %v, want none", ev.Duration)
+	}
+}
+
+func TestRecordHookReason(t *testing.T) {
+	tests := []struct {
+		reason  string
+		silence bool
+		want    string
+	}{
+		{"", false, hookReasonStopped},
+		{"", true, hookReasonSilence},
+		{stream.ReasonSignal, false, stream.ReasonSignal},
+		{stream.ReasonStopped, true, hookReasonSilence},
+	}
+	for _, tt := range tests {
+		if got := recordHookReason(tt.reason, tt.silence); got != tt.want {
+			t.Errorf("recordHookReason(%q, %v) = %q, want %q", tt.reason, tt.silence, got, tt.want)
+		}
+	}
+}
+
+func TestNewHookRunner(t *testing.T) {
+	cfg := config.Default()
+	cfg.Hooks = config.HooksConfig{PostRecord: "notify-send saved", Dir: "/etc/audiomemo-hooks", Timeout: "10s"}
+	r, err := newHookRunner(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Commands[hooks.PostRecord] != "notify-send saved" || r.Dir != "/etc/audiomemo-hooks" || r.Timeout != 10*time.Second {
+		t.Errorf("runner = %+v", r)
+	}
+
+	cfg.Hooks.Timeout = "soon"
+	if _, err := newHookRunner(cfg); err == nil || !strings.Contains(err.Error(), "hooks.timeout") {
+		t.Errorf("bad timeout: err = %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+	"github.com/spf13/pflag"
+)
+
+// postProcessFromConfig turns the [transcribe.postprocess] section into the
+// steps it enables.
+func postProcessFromConfig(cfg config.PostProcessConfig) transcribe.PostProcess {
+	p := transcribe.PostProcess{
+		RemoveFillers:     cfg.RemoveFillers,
+		Fillers:           cfg.Fillers,
+		FixCapitalization: cfg.FixCapitalization,
+	}
+	if cfg.Paragraphs {
+		p.ParagraphGap = cfg.ParagraphGap
+	}
+	for _, r := range cfg.Replace {
+		p.Replacements = append(p.Replacements, transcribe.Replacement{From: r.From, To: r.To, Regex: r.Regex})
+	}
+	return p
+}
+
+// resolvePostProcess merges the config with this invocation's flags. A flag
+// only overrides the config when it was given, so `--fix-caps=false` turns off
+// a step the config enables and an absent flag leaves the config alone.
+func resolvePostProcess(cfg config.PostProcessConfig, flags *pflag.FlagSet) (*transcribe.PostProcessor, error) {
+	if tRaw {
+		return nil, nil
+	}
+	if flags.Changed("strip-fillers") {
+		cfg.RemoveFillers = tStripFillers
+	}
+	if flags.Changed("fix-caps") {
+		cfg.FixCapitalization = tFixCaps
+	}
+	if flags.Changed("paragraphs") {
+		cfg.Paragraphs = tParagraphs
+	}
+	if !tReplace {
+		cfg.Replace = nil
+	}
+	return newPostProcessor(cfg)
+}
+
+// newPostProcessor builds the processor a config describes, naming the config
+// section in any error so a bad pattern is easy to find.
+func newPostProcessor(cfg config.PostProcessConfig) (*transcribe.PostProcessor, error) {
+	if cfg.Paragraphs && cfg.ParagraphGap <= 0 {
+		return nil, fmt.Errorf("transcribe.postprocess: paragraph_gap must be positive when paragraphs is on")
+	}
+	p, err := transcribe.NewPostProcessor(postProcessFromConfig(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("transcribe.postprocess: %w", err)
+	}
+	return p, nil
+}
+
+// livePostProcessor is the cleanup applied to record's live commits. Live
+// text follows the config; `record --raw` turns it off along with the batch
+// pass's.
+func livePostProcessor(cfg *config.Config) (*transcribe.PostProcessor, error) {
+	if rRaw {
+		return nil, nil
+	}
+	return newPostProcessor(cfg.Transcribe.PostProcess)
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/spf13/pflag"
+)
+
+// postProcessFlags binds the post-processing globals to a fresh flag set, so
+// each case sees its own Changed state, and restores them afterwards.
+func postProcessFlags(t *testing.T, args ...string) *pflag.FlagSet {
+	t.Helper()
+	fs := pflag.NewFlagSet("transcribe", pflag.ContinueOnError)
+	fs.BoolVar(&tStripFillers, "strip-fillers", false, "")
+	fs.BoolVar(&tReplace, "replace", true, "")
+	fs.BoolVar(&tFixCaps, "fix-caps", false, "")
+	fs.BoolVar(&tParagraphs, "paragraphs", false, "")
+	fs.BoolVar(&tRaw, "raw", false, "")
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tStripFillers, tReplace, tFixCaps, tParagraphs, tRaw = false, true, false, false, false })
+	return fs
+}
+
+func TestResolvePostProcessFlagsOverrideConfig(t *testing.T) {
+	cfg := config.PostProcessConfig{
+		RemoveFillers:     true,
+		FixCapitalization: true,
+		Replace:           []config.ReplacementConfig{{From: "teh", To: "the"}},
+	}
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"config alone", nil, "The plan"},
+		{"step turned off", []string{"--fix-caps=false"}, "the plan"},
+		{"dictionary off", []string{"--replace=false"}, "Teh plan"},
+		{"raw", []string{"--raw"}, "um teh plan"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := resolvePostProcess(cfg, postProcessFlags(t, tt.args...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.ApplyText("um teh plan"); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolvePostProcessFlagTurnsStepOn(t *testing.T) {
+	p, err := resolvePostProcess(config.PostProcessConfig{}, postProcessFlags(t, "--strip-fillers"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.ApplyText("uh ok"); got != "ok" {
+		t.Errorf("got %q, want %q", got, "ok")
+	}
+}
+
+func TestResolvePostProcessReportsConfigSection(t *testing.T) {
+	cfg := config.PostProcessConfig{Replace: []config.ReplacementConfig{{From: "[", Regex: true}}}
+	_, err := resolvePostProcess(cfg, postProcessFlags(t))
+	if err == nil {
+		t.Fatal("expected an error for an invalid pattern")
+	}
+}
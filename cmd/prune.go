@@ -0,0 +1,304 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/encrypt"
+	"github.com/joegoldin/audiomemo/internal/meta"
+	"github.com/joegoldin/audiomemo/internal/retention"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pConfig string
+	pDryRun bool
+	pUndo   bool
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Clear old recordings out of the output directory under [retention]",
+	Long: `Apply the [retention] policy to the output directory: delete transcribed
+audio past an age, re-encode old WAV and FLAC to Opus, delete -live.txt
+files once the batch transcript exists, and delete the oldest transcribed
+audio while the directory is over its size cap. Transcripts, summaries and
+sidecars are never deleted, nor is audio that has no batch transcript.
+Recordings tagged with "prune keep" are left alone.
+
+Each change is listed with its reason, then what it came to. With
+--dry-run nothing is changed and the list is what a run would do.
+
+Examples:
+  audiomemo prune --dry-run
+  audiomemo prune
+  audiomemo prune keep ~/Recordings/interview-2026-10-18T09-30-00.wav`,
+	Args: cobra.NoArgs,
+	RunE: runPrune,
+}
+
+var pruneKeepCmd = &cobra.Command{
+	Use:   "keep [--undo] <recording>...",
+	Short: "Protect recordings from prune",
+	Long: `Tag each recording "keep" in its sidecar, <name>.meta.json, so that prune
+never deletes or re-encodes it. --undo takes the tag off again.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runPruneKeep,
+}
+
+func init() {
+	pruneCmd.PersistentFlags().StringVar(&pConfig, "config", "", "config file path")
+	pruneCmd.Flags().BoolVarP(&pDryRun, "dry-run", "n", false, "list what would be removed without changing anything")
+	pruneKeepCmd.Flags().BoolVar(&pUndo, "undo", false, "take the keep tag off instead")
+	pruneCmd.AddCommand(pruneKeepCmd)
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	var cfg *config.Config
+	var err error
+	if pConfig != "" {
+		cfg, err = config.LoadFrom(pConfig)
+	} else {
+		cfg, err = config.Load()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	policy, err := retentionPolicy(cfg.Retention)
+	if err != nil {
+		return err
+	}
+	if policy.IsZero() {
+		return fmt.Errorf("no [retention] policy configured: nothing to prune")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	dir := cfg.ResolveOutputDir()
+	recs, total, err := scanRecordings(ctx, dir, policy.TranscodeAfter > 0)
+	if err != nil {
+		return err
+	}
+	plan := retention.MakePlan(recs, total, policy, time.Now())
+	if pDryRun {
+		printPrunePlan(os.Stdout, dir, plan, policy, nil)
+		return nil
+	}
+
+	failed := map[int]error{}
+	for i, a := range plan.Actions {
+		if err := ctx.Err(); err != nil {
+			failed[i] = err
+		} else if err := a.Do(ctx); err != nil {
+			failed[i] = err
+		}
+	}
+	if _, after, err := scanRecordings(ctx, dir, false); err == nil {
+		plan.After = after
+	}
+	printPrunePlan(os.Stdout, dir, plan, policy, failed)
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d changes failed", len(failed), len(plan.Actions))
+	}
+	return nil
+}
+
+// retentionPolicy reads [retention] into a policy.
+func retentionPolicy(rc config.RetentionConfig) (retention.Policy, error) {
+	if rc.DeleteAudioAfterDays < 0 || rc.TranscodeAfterDays < 0 {
+		return retention.Policy{}, fmt.Errorf("retention ages must not be negative")
+	}
+	p := retention.Policy{
+		DeleteAudioAfter: time.Duration(rc.DeleteAudioAfterDays) * 24 * time.Hour,
+		TranscodeAfter:   time.Duration(rc.TranscodeAfterDays) * 24 * time.Hour,
+		DeleteLive:       rc.DeleteLive,
+	}
+	if rc.MaxSize != "" {
+		size, err := retention.ParseSize(rc.MaxSize)
+		if err != nil {
+			return retention.Policy{}, fmt.Errorf("retention.max_size: %w", err)
+		}
+		p.MaxSize = size
+	}
+	return p, nil
+}
+
+// scanRecordings lists the recordings in dir, and the live transcripts of
+// recordings whose audio has gone, with the size of everything in it. Only
+// dir itself is read, as record writes nowhere else. With durations, the
+// length of each WAV and FLAC is probed, to size its transcode.
+func scanRecordings(ctx context.Context, dir string, durations bool) ([]retention.Recording, int64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, 0, err
+	}
+	var total int64
+	var audio, live []string
+	sizes := map[string]int64{}
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		total += info.Size()
+		sizes[path] = info.Size()
+		name := encrypt.Plain(e.Name())
+		switch {
+		case strings.HasSuffix(name, "-live.txt"):
+			live = append(live, path)
+		case audioExtensions[strings.ToLower(filepath.Ext(name))] && !strings.HasPrefix(name, ".") && !isBleepedCopy(name):
+			audio = append(audio, path)
+		}
+	}
+	sort.Strings(audio)
+
+	var recs []retention.Recording
+	byBase := map[string]int{}
+	for _, path := range audio {
+		base := recordingBase(path)
+		r := retention.Recording{Audio: path, Size: sizes[path], Made: recordingTime(path)}
+		if durations && retention.Lossless(path) && !encrypt.IsEncrypted(path) {
+			probeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			r.Duration, _ = transcribe.ProbeDuration(probeCtx, path)
+			cancel()
+		}
+		if _, ok := byBase[base]; !ok {
+			byBase[base] = len(recs)
+		}
+		recs = append(recs, r)
+	}
+	for _, path := range live {
+		base := strings.TrimSuffix(encrypt.Plain(path), "-live.txt")
+		i, ok := byBase[base]
+		if !ok {
+			i = len(recs)
+			recs = append(recs, retention.Recording{Made: recordingTime(path)})
+			byBase[base] = i
+		}
+		recs[i].Live, recs[i].LiveSize = path, sizes[path]
+	}
+	for i := range recs {
+		path := recs[i].Audio
+		if path == "" {
+			path = strings.TrimSuffix(encrypt.Plain(recs[i].Live), "-live.txt") + ".txt"
+		}
+		recs[i].Transcribed = hasBatchTranscript(path)
+		if md, err := meta.Load(path); err == nil {
+			recs[i].Kept = md.HasTag(retention.KeepTag)
+		} else {
+			// An unreadable sidecar may be the one that says keep.
+			recs[i].Kept = true
+		}
+	}
+	return recs, total, nil
+}
+
+// hasBatchTranscript reports whether transcribe has saved a transcript of the
+// recording at path, in any format, encrypted or not.
+func hasBatchTranscript(path string) bool {
+	base := recordingBase(path)
+	for _, ext := range []string{".txt", ".json", ".srt", ".vtt"} {
+		if savedFileExists(base + ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// printPrunePlan reports each change and what they came to. failed holds
+// the changes a real run could not make, by index; it is nil for a dry run.
+func printPrunePlan(w io.Writer, dir string, plan *retention.Plan, p retention.Policy, failed map[int]error) {
+	dry := failed == nil
+	var deleted, transcoded int
+	var freed int64
+	for i, a := range plan.Actions {
+		verb := map[retention.Op]string{retention.Delete: "delete", retention.Transcode: "transcode"}[a.Op]
+		size := retention.FormatSize(a.Freed)
+		if a.Op == retention.Transcode {
+			size = "~" + size
+		}
+		if err, ok := failed[i]; ok {
+			fmt.Fprintf(w, "Failed to %s %s: %v\n", verb, filepath.Base(a.Path), err)
+			continue
+		}
+		switch {
+		case dry:
+			verb = "would " + verb
+		case a.Op == retention.Delete:
+			verb = "deleted"
+		default:
+			verb = "transcoded"
+		}
+		fmt.Fprintf(w, "%-15s %-48s %10s  %s\n", verb, filepath.Base(a.Path), size, a.Reason)
+		if a.Op == retention.Delete {
+			deleted++
+		} else {
+			transcoded++
+		}
+		freed += a.Freed
+	}
+
+	for _, a := range plan.Skipped {
+		fmt.Fprintf(w, "%-15s %-48s %10s  %s\n", "skip transcode", filepath.Base(a.Path), "", a.Reason)
+	}
+
+	if len(plan.Actions) == 0 {
+		fmt.Fprintln(w, "Nothing to prune.")
+	} else if dry {
+		fmt.Fprintf(w, "Would delete %d and transcode %d, freeing about %s.\n", deleted, transcoded, retention.FormatSize(freed))
+	} else {
+		fmt.Fprintf(w, "Deleted %d and transcoded %d, freeing %s.\n", deleted, transcoded, retention.FormatSize(plan.Before-plan.After))
+	}
+	size := fmt.Sprintf("%s is %s", dir, retention.FormatSize(plan.After))
+	if dry {
+		size = fmt.Sprintf("%s would be about %s", dir, retention.FormatSize(plan.After))
+	}
+	if p.MaxSize > 0 {
+		size += fmt.Sprintf(" (max_size %s)", retention.FormatSize(p.MaxSize))
+	}
+	fmt.Fprintln(w, size+".")
+	if plan.Over > 0 {
+		fmt.Fprintf(w, "Still %s over max_size: the rest is untranscribed or kept.\n", retention.FormatSize(plan.Over))
+	}
+	if plan.Kept > 0 {
+		fmt.Fprintf(w, "%d kept recording(s) left alone.\n", plan.Kept)
+	}
+}
+
+func runPruneKeep(cmd *cobra.Command, args []string) error {
+	for _, path := range args {
+		if !isRecording(path) {
+			return fmt.Errorf("%s is not a recording", path)
+		}
+		md, err := meta.Load(path)
+		if err != nil {
+			return err
+		}
+		if !md.SetTag(retention.KeepTag, !pUndo) {
+			continue
+		}
+		if err := md.Save(path); err != nil {
+			return err
+		}
+		if pUndo {
+			fmt.Fprintf(os.Stderr, "%s may be pruned again\n", filepath.Base(path))
+		} else {
+			fmt.Fprintf(os.Stderr, "Keeping %s\n", filepath.Base(path))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/meta"
+	"github.com/joegoldin/audiomemo/internal/retention"
+)
+
+func TestScanRecordings(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, size int) {
+		os.WriteFile(filepath.Join(dir, name), make([]byte, size), 0644)
+	}
+	write("standup-2026-01-02T09-00-00.ogg", 100)
+	write("standup-2026-01-02T09-00-00.txt", 10)
+	write("standup-2026-01-02T09-00-00-live.txt", 7)
+	write("idea-2026-03-04T10-00-00.wav", 200)
+	write("old-2025-01-01T08-00-00-live.txt", 3)
+	write("old-2025-01-01T08-00-00.json", 3)
+	write(".tagging-x.ogg", 1)
+	(&meta.Metadata{Tags: []string{"keep"}}).Save(filepath.Join(dir, "idea-2026-03-04T10-00-00.wav"))
+
+	recs, total, err := scanRecordings(context.Background(), dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 3 {
+		t.Fatalf("recordings = %+v", recs)
+	}
+	byName := map[string]retention.Recording{}
+	for _, r := range recs {
+		name := filepath.Base(r.Audio)
+		if r.Audio == "" {
+			name = filepath.Base(r.Live)
+		}
+		byName[name] = r
+	}
+	standup := byName["standup-2026-01-02T09-00-00.ogg"]
+	if !standup.Transcribed || standup.Kept || standup.LiveSize != 7 || standup.Made.Month() != time.January {
+		t.Errorf("standup = %+v", standup)
+	}
+	if idea := byName["idea-2026-03-04T10-00-00.wav"]; idea.Transcribed || !idea.Kept {
+		t.Errorf("idea = %+v", idea)
+	}
+	if old := byName["old-2025-01-01T08-00-00-live.txt"]; !old.Transcribed || old.Made.Year() != 2025 {
+		t.Errorf("audio-less = %+v", old)
+	}
+	if total < 100+10+7+200+3+3+1 {
+		t.Errorf("total = %d", total)
+	}
+}
+
+func TestScanEncryptedRecordings(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string) {
+		os.WriteFile(filepath.Join(dir, name), []byte("sealed"), 0600)
+	}
+	write("standup-2026-01-02T09-00-00.ogg.age")
+	write("standup-2026-01-02T09-00-00.txt.age")
+	write("standup-2026-01-02T09-00-00-live.txt.age")
+	write(".sealing-standup-2026-01-02T09-00-00.json.age")
+
+	recs, _, err := scanRecordings(context.Background(), dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 1 {
+		t.Fatalf("recordings = %+v", recs)
+	}
+	r := recs[0]
+	if filepath.Base(r.Audio) != "standup-2026-01-02T09-00-00.ogg.age" || filepath.Base(r.Live) != "standup-2026-01-02T09-00-00-live.txt.age" {
+		t.Errorf("recording = %+v", r)
+	}
+	if !r.Transcribed || r.Made.Year() != 2026 {
+		t.Errorf("recording = %+v", r)
+	}
+}
+
+func TestRetentionPolicy(t *testing.T) {
+	p, err := retentionPolicy(config.RetentionConfig{DeleteAudioAfterDays: 90, MaxSize: "20GB"})
+	if err != nil || p.DeleteAudioAfter != 90*24*time.Hour || p.MaxSize != 20e9 {
+		t.Errorf("policy = %+v, %v", p, err)
+	}
+	if _, err := retentionPolicy(config.RetentionConfig{MaxSize: "big"}); err == nil {
+		t.Error("want an error for a bad max_size")
+	}
+	if p, _ := retentionPolicy(config.RetentionConfig{}); !p.IsZero() {
+		t.Error("the default policy should clear nothing")
+	}
+}
+
+func TestPrintPrunePlan(t *testing.T) {
+	plan := &retention.Plan{
+		Actions: []retention.Action{
+			{Op: retention.Delete, Path: "/r/a.ogg", Freed: 2_000_000, Reason: "transcribed, older than 90 days"},
+			{Op: retention.Transcode, Path: "/r/b.wav", Freed: 9_000_000, Reason: "lossless, older than 30 days"},
+		},
+		Skipped: []retention.Action{
+			{Op: retention.Transcode, Path: "/r/c.wav.age", Reason: "lossless, older than 30 days, but encrypted"},
+		},
+		Kept:   1,
+		Before: 20_000_000,
+		After:  9_000_000,
+		Over:   1_000_000,
+	}
+	var dry bytes.Buffer
+	printPrunePlan(&dry, "/r", plan, retention.Policy{MaxSize: 8_000_000}, nil)
+	for _, want := range []string{"would delete", "a.ogg", "~9.0 MB", "Would delete 1 and transcode 1, freeing about 11.0 MB.", "(max_size 8.0 MB)", "Still 1.0 MB over", "1 kept", "skip transcode  c.wav.age"} {
+		if !strings.Contains(dry.String(), want) {
+			t.Errorf("dry run lacks %q:\n%s", want, dry.String())
+		}
+	}
+
+	var real bytes.Buffer
+	printPrunePlan(&real, "/r", plan, retention.Policy{}, map[int]error{1: os.ErrExist})
+	if !strings.Contains(real.String(), "Failed to transcode b.wav") || !strings.Contains(real.String(), "Deleted 1 and transcoded 0") {
+		t.Errorf("real run:\n%s", real.String())
+	}
+}
+
+func TestPruneKeep(t *testing.T) {
+	audio := filepath.Join(t.TempDir(), "memo.ogg")
+	os.WriteFile(audio, nil, 0644)
+	t.Cleanup(func() { pUndo = false })
+	if err := runPruneKeep(nil, []string{audio}); err != nil {
+		t.Fatal(err)
+	}
+	if md, _ := meta.Load(audio); !md.HasTag(retention.KeepTag) {
+		t.Errorf("tags = %v", md.Tags)
+	}
+	pUndo = true
+	if err := runPruneKeep(nil, []string{audio}); err != nil {
+		t.Fatal(err)
+	}
+	if md, _ := meta.Load(audio); md.HasTag(retention.KeepTag) {
+		t.Errorf("tags = %v", md.Tags)
+	}
+	if err := runPruneKeep(nil, []string{audio + ".txt"}); err == nil {
+		t.Error("want an error for a transcript")
+	}
+}
@@ -6,17 +6,23 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/encrypt"
+	"github.com/joegoldin/audiomemo/internal/hooks"
+	"github.com/joegoldin/audiomemo/internal/meta"
 	"github.com/joegoldin/audiomemo/internal/record"
 	"github.com/joegoldin/audiomemo/internal/stream"
 	"github.com/joegoldin/audiomemo/internal/transcribe"
 	"github.com/joegoldin/audiomemo/internal/tui"
+	"github.com/joegoldin/audiomemo/internal/typist"
 	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
 )
@@ -43,6 +49,14 @@ var (
 	rMaxSilence      string
 	rSilenceDB       float64
 	rPrint           string
+	rRaw             bool
+	rServeEvents     string
+	rStreamTee       string
+	rInputFile       string
+	rRealtime        bool
+	rVoiceCmds       bool
+	rTypeInto        string
+	rTypeDryRun      bool
 )
 
 var recordCmd = &cobra.Command{
@@ -68,7 +82,8 @@ Examples:
   rect standup -t
   recw private notes
   record -d 5m --no-tui
-  record -D "Built-in Microphone" -t --transcribe-args="--backend deepgram"`,
+  record -D "Built-in Microphone" -t --transcribe-args="--backend deepgram"
+  record --input-file meeting.ogg --realtime`,
 	Args: cobra.ArbitraryArgs,
 	RunE: runRecord,
 }
@@ -94,12 +109,21 @@ func init() {
 	recordCmd.Flags().StringVar(&rConfig, "config", "", "config file path")
 	recordCmd.Flags().BoolVarP(&rClips, "clips", "C", false, "clips mode: record multiple clips sequentially")
 	recordCmd.Flags().BoolVar(&rNoLive, "no-live-transcription", false, "disable live transcription while recording")
+	recordCmd.Flags().BoolVar(&rRaw, "raw", false, "skip transcript post-processing, live and batch (see transcribe.postprocess)")
 	recordCmd.Flags().BoolVar(&rStream, "stream", false, "emit newline-delimited JSON events on stdout while recording (implies --no-tui)")
+	recordCmd.Flags().StringVar(&rServeEvents, "serve-events", "", "also serve the --stream events over WebSocket and SSE at this address (e.g. 127.0.0.1:8766)")
+	recordCmd.Flags().StringVar(&rStreamTee, "stream-tee", "", "also save the --stream events to this file, for audiomemo replay")
+	recordCmd.Flags().StringVar(&rInputFile, "input-file", "", "play an audio file through the live pipeline instead of recording a device")
+	recordCmd.Flags().BoolVar(&rRealtime, "realtime", false, "read --input-file at its own pace, as a device would deliver it")
+	recordCmd.Flags().BoolVar(&rVoiceCmds, "voice-commands", false, "act on commands spoken while dictating, such as \"new paragraph\" and \"scratch that\" (record.voice_commands)")
+	recordCmd.Flags().StringVar(&rTypeInto, "type-into", "", "type each live commit into the focused window: auto, wtype, xdotool, ydotool, command, or a named pipe path (record.type_into)")
+	recordCmd.Flags().Lookup("type-into").NoOptDefVal = "auto"
+	recordCmd.Flags().BoolVar(&rTypeDryRun, "type-dry-run", false, "print the --type-into typing commands to stderr instead of running them")
 }
 
 func ExecuteRecord() {
 	rootCmd.SetArgs(append([]string{"record"}, os.Args[1:]...))
-	if err := rootCmd.Execute(); err != nil {
+	if err := execute(); err != nil {
 		os.Exit(1)
 	}
 }
@@ -142,6 +166,31 @@ func validateStreamFlags(streamFlag, clips, listDevices bool) error {
 	return nil
 }
 
+// validateInputFileFlags rejects what --input-file cannot honour. The file
+// stands in for the device, so naming a device as well is a contradiction
+// rather than something to pick a winner for; clips mode would replay the
+// same file as every clip.
+func validateInputFileFlags(inputFile string, realtime, deviceSet, clips, listDevices bool) error {
+	if inputFile == "" {
+		if realtime {
+			return fmt.Errorf("--realtime requires --input-file")
+		}
+		return nil
+	}
+	switch {
+	case deviceSet:
+		return fmt.Errorf("--input-file cannot be combined with --device: the file is the input")
+	case clips:
+		return fmt.Errorf("--input-file cannot be combined with --clips")
+	case listDevices:
+		return fmt.Errorf("--input-file cannot be combined with --list-devices")
+	}
+	if _, err := os.Stat(inputFile); err != nil {
+		return fmt.Errorf("--input-file: %w", err)
+	}
+	return nil
+}
+
 // resolveStreamMode reports what the start event should claim. It answers one
 // question for the consumer: will partial events arrive? A batch pass alone
 // produces a single final at the end and nothing before it.
@@ -156,9 +205,8 @@ func resolveStreamMode(liveActive, batchTranscribe bool) string {
 	}
 }
 
-func runRecord(cmd *cobra.Command, args []string) error {
+func runRecord(cmd *cobra.Command, args []string) (err error) {
 	var cfg *config.Config
-	var err error
 	if rConfig != "" {
 		cfg, err = config.LoadFrom(rConfig)
 	} else {
@@ -168,9 +216,36 @@ func runRecord(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	hookRunner, err := newHookRunner(cfg)
+	if err != nil {
+		return err
+	}
+	// failedAudio is set once the recording has a path, so that on_error can
+	// say what may have been saved before the failure.
+	failedAudio := ""
+	defer func() {
+		if err == nil {
+			return
+		}
+		ev := hooks.Event{Hook: hooks.OnError, Command: "record", Audio: failedAudio, Error: err.Error()}
+		if failedAudio != "" {
+			ev.Label = hookLabel(failedAudio)
+		}
+		runHook(context.Background(), hookRunner, ev, warnStderr)
+	}()
+
 	if err := validateStreamFlags(rStream, rClips, rListDevices); err != nil {
 		return err
 	}
+	if rServeEvents != "" && !rStream {
+		return fmt.Errorf("--serve-events requires --stream")
+	}
+	if rStreamTee != "" && !rStream {
+		return fmt.Errorf("--stream-tee requires --stream")
+	}
+	if err := validateInputFileFlags(rInputFile, rRealtime, cmd.Flags().Changed("device"), rClips, rListDevices); err != nil {
+		return err
+	}
 
 	printFlag, err := parsePrintMode(rPrint)
 	if err != nil {
@@ -219,6 +294,7 @@ func runRecord(cmd *cobra.Command, args []string) error {
 	}
 
 	cfg.ApplyEnv()
+	useEncryption(cfg)
 
 	if rListDevices {
 		devices, err := record.ListDevices()
@@ -252,7 +328,12 @@ func runRecord(cmd *cobra.Command, args []string) error {
 	var devices []string
 	var deviceLabel string
 
-	if !cmd.Flags().Changed("device") && !rNoTUI {
+	if rInputFile != "" {
+		// The file is reported wherever a device would be, so the TUI's mic
+		// line and the start event both say what is being heard.
+		devices = []string{rInputFile}
+		deviceLabel = filepath.Base(rInputFile)
+	} else if !cmd.Flags().Changed("device") && !rNoTUI {
 		result, err := tui.RunRecordPicker(cfg, ui.Options()...)
 		if err != nil {
 			return err
@@ -287,15 +368,17 @@ func runRecord(cmd *cobra.Command, args []string) error {
 	// profile rename like "HiFi__Line1__sink" -> "HiFi__Line__sink"). Applied
 	// for both the TUI-picker and explicit-device paths so a stale alias
 	// stored in config doesn't blow up ffmpeg.
-	if availDevices, listErr := record.ListDevices(); listErr == nil {
-		devices = record.ResolveDeviceNames(devices, availDevices)
-		for i, n := range devices {
-			if record.HasDevice(n, availDevices) {
-				continue
-			}
-			if matched, ok := record.FuzzyMatchDevice(n, availDevices); ok {
-				fmt.Fprintf(os.Stderr, "warning: device %q not found; auto-substituting %q (fuzzy match)\n", n, matched.Name)
-				devices[i] = matched.Name
+	if rInputFile == "" {
+		if availDevices, listErr := record.ListDevices(); listErr == nil {
+			devices = record.ResolveDeviceNames(devices, availDevices)
+			for i, n := range devices {
+				if record.HasDevice(n, availDevices) {
+					continue
+				}
+				if matched, ok := record.FuzzyMatchDevice(n, availDevices); ok {
+					fmt.Fprintf(os.Stderr, "warning: device %q not found; auto-substituting %q (fuzzy match)\n", n, matched.Name)
+					devices[i] = matched.Name
+				}
 			}
 		}
 	}
@@ -305,6 +388,9 @@ func runRecord(cmd *cobra.Command, args []string) error {
 	if len(args) > 0 {
 		name = strings.Join(args, "_")
 	}
+	if name == "" && rInputFile != "" {
+		name = strings.TrimSuffix(filepath.Base(rInputFile), filepath.Ext(rInputFile))
+	}
 
 	if rClips && name == "" {
 		return fmt.Errorf("clips mode requires a name: record --clips <name>")
@@ -322,12 +408,31 @@ func runRecord(cmd *cobra.Command, args []string) error {
 	}
 
 	liveDisabled, shouldTranscribe := resolveRecordTranscriptionMode(rNoLive, rWhisperShortcut, rTranscribe)
+	livePost, err := livePostProcessor(cfg)
+	if err != nil {
+		return err
+	}
+	voice := recordVoice(cfg.Record.VoiceCommands, cmd.Flags())
+	rVoiceBatch = voice != nil && !cfg.Record.VoiceCommands.Keep
+	rExportNote = cfg.ResolveNotesDir() != ""
+
+	if err := validateTypeIntoFlags(rTypeInto, rTypeDryRun, liveDisabled, cfg.Transcribe.ElevenLabs.APIKey != ""); err != nil {
+		return err
+	}
+	typed, err := newRecordTypist(rTypeInto, rTypeDryRun, cfg.Record.TypeInto, os.Stderr)
+	if err != nil {
+		return err
+	}
+	warnTyping := func(err error) { fmt.Fprintf(os.Stderr, "Warning: %v\n", err) }
 
 	if rClips {
-		return runClips(cfg, name, format, sampleRate, channels, devices, deviceLabel, outputDir, liveDisabled, stops, ui)
+		err := runClips(cfg, name, format, sampleRate, channels, devices, deviceLabel, outputDir, liveDisabled, livePost, voice, typed, hookRunner, stops, ui)
+		closeTypist(typed, warnTyping)
+		return err
 	}
 
 	outputPath := filepath.Join(outputDir, record.GenerateFilename(format, name))
+	failedAudio = outputPath
 
 	var streamer *transcribe.Streamer
 	streamNote := ""
@@ -338,6 +443,11 @@ func runRecord(cmd *cobra.Command, args []string) error {
 			cfg.Transcribe.ElevenLabs.APIKey,
 			cfg.Transcribe.ElevenLabs.StoreInCloud,
 		)
+		streamer.SetPostProcess(livePost)
+		streamer.SetVoiceCommands(voice)
+		if typed != nil {
+			streamer.SetSink(typed)
+		}
 	} else {
 		streamNote = "live transcription unavailable: no ElevenLabs API key configured"
 	}
@@ -351,17 +461,41 @@ func runRecord(cmd *cobra.Command, args []string) error {
 		Channels:    channels,
 		OutputPath:  outputPath,
 		LivePCM:     streamer != nil,
+		InputFile:   rInputFile,
+		Realtime:    rRealtime,
 	})
 
+	// Bound before recording starts, so a taken port fails the run instead
+	// of leaving a recording nobody can watch.
+	var eventsLn net.Listener
+	if rServeEvents != "" {
+		if eventsLn, err = net.Listen("tcp", rServeEvents); err != nil {
+			return fmt.Errorf("--serve-events: %w", err)
+		}
+		defer eventsLn.Close()
+	}
+	var streamTee io.Writer
+	if rStreamTee != "" {
+		f, err := os.Create(rStreamTee)
+		if err != nil {
+			return fmt.Errorf("--stream-tee: %w", err)
+		}
+		defer f.Close()
+		streamTee = f
+	}
+
 	rec, err := record.Start(opts)
 	if err != nil {
 		return err
 	}
+	started := time.Now()
+	if rInputFile == "" {
+		saveRecordingDevice(outputPath, deviceLabel)
+	}
 
 	var streamStartErr error
 	if streamer != nil {
-		transcriptPath := liveTranscriptPathFor(outputPath)
-		if err := streamer.Start(context.Background(), rec.PCMReader, transcriptPath); err != nil {
+		if err := startLive(streamer, rec.PCMReader, liveTranscriptPathFor(outputPath)); err != nil {
 			if !rStream {
 				fmt.Fprintf(os.Stderr, "Warning: live transcription failed to start: %v\n", err)
 			}
@@ -382,13 +516,32 @@ func runRecord(cmd *cobra.Command, args []string) error {
 	// the only thing that can produce the words the user asked for.
 	shouldTranscribe = batchNeededForText(stdoutMode, streamer != nil, shouldTranscribe)
 
+	// post_record runs once the recording and its live transcript are on
+	// disk, before any batch pass, which runs post_transcribe itself. The
+	// same goes for the sync, which the batch pass repeats for its files.
+	// path is where the recording ended up, which once it is sealed is no
+	// longer outputPath.
+	postRecord := func(path, reason string, warn func(error)) {
+		backend := ""
+		if streamer != nil {
+			backend = transcribe.RealtimeBackendName
+		}
+		runHook(context.Background(), hookRunner, recordHookEvent(path, backend, reason, started), warn)
+		autoSync(context.Background(), cfg, path, warn)
+	}
+
 	var model *tui.Model
 	if rStream {
 		// The start event carries the same facts as the stderr line the plain
 		// headless path prints, so that line is redundant here.
-		return runRecordStream(cfg, opts, rec, streamer, streamStartErr, shouldTranscribe)
+		return runRecordStream(cfg, opts, rec, started, streamer, typed, streamStartErr, shouldTranscribe, eventsLn, streamTee, postRecord)
 	} else if rNoTUI {
 		fmt.Fprintf(os.Stderr, "Recording to %s (%s)...\n", outputPath, stops.hint())
+		if streamer != nil {
+			go followVoice(streamer.Voice, outputPath, started, nil, rec.Stop, func(err error) {
+				fmt.Fprintf(os.Stderr, "Warning: failed to save mark: %v\n", err)
+			})
+		}
 		if err := <-rec.Done; err != nil {
 			return err
 		}
@@ -399,6 +552,7 @@ func runRecord(cmd *cobra.Command, args []string) error {
 			model = tui.NewModel(rec, opts)
 			model.SetStreamNote(streamNote)
 		}
+		model.SetMarkFunc(func(at time.Duration) error { return saveMark(outputPath, at, "") })
 		p := tea.NewProgram(model, ui.Options(tea.WithAltScreen())...)
 		if _, err := p.Run(); err != nil {
 			return err
@@ -421,11 +575,17 @@ func runRecord(cmd *cobra.Command, args []string) error {
 	if streamer != nil {
 		streamer.Stop()
 	}
+	closeTypist(typed, warnTyping)
 
 	if rec.StoppedForSilence() {
 		fmt.Fprintf(os.Stderr, "Stopped after %s of silence.\n", stops.MaxSilence)
 	}
 
+	// Before anything else sees the recording, so that hooks and the batch
+	// pass are given the encrypted file.
+	outputPath = sealRecorded(outputPath, warnStderr)
+	failedAudio = outputPath
+
 	// Promote the live transcript to the canonical <base>.txt so a transcript
 	// always exists. When batch transcription runs next (Q or -t), it
 	// overwrites the canonical file with the higher-quality result.
@@ -434,6 +594,7 @@ func runRecord(cmd *cobra.Command, args []string) error {
 	} else if promoted != "" && rVerbose {
 		fmt.Fprintf(os.Stderr, "Saved live transcript to %s\n", promoted)
 	}
+	postRecord(outputPath, recordHookReason("", rec.StoppedForSilence()), warnStderr)
 
 	// The path goes out before transcription starts, so `record --print path`
 	// answers as soon as the recording is safe on disk.
@@ -460,7 +621,7 @@ func runRecord(cmd *cobra.Command, args []string) error {
 	}
 
 	if wantsStdoutText(stdoutMode) {
-		text := resolveStdoutText(batchText, transcriptPathFor(outputPath, transcribe.FormatText), os.ReadFile)
+		text := resolveStdoutText(batchText, transcriptPathFor(outputPath, transcribe.FormatText), readFile)
 		if text == "" {
 			fmt.Fprintln(os.Stderr, "Warning: no transcript was produced; recording saved to "+outputPath)
 			return nil
@@ -471,7 +632,7 @@ func runRecord(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func runClips(cfg *config.Config, name, format string, sampleRate, channels int, devices []string, deviceLabel, outputDir string, liveDisabled bool, stops stopConditions, ui tuiTarget) error {
+func runClips(cfg *config.Config, name, format string, sampleRate, channels int, devices []string, deviceLabel, outputDir string, liveDisabled bool, livePost *transcribe.PostProcessor, voice *transcribe.VoiceInterpreter, typed *typist.Typist, hookRunner *hooks.Runner, stops stopConditions, ui tuiTarget) error {
 	var savedPaths []string
 	clipNumber := 1
 	savedMessage := ""
@@ -507,11 +668,17 @@ func runClips(cfg *config.Config, name, format string, sampleRate, channels int,
 			if err != nil {
 				return nil, nil, "", err
 			}
+			saveRecordingDevice(outputPath, deviceLabel)
 			if apiKey == "" {
 				return rec, nil, streamNote, nil
 			}
 			s := transcribe.NewStreamer(apiKey, cfg.Transcribe.ElevenLabs.StoreInCloud)
-			if err := s.Start(context.Background(), rec.PCMReader, livePath); err != nil {
+			s.SetPostProcess(livePost)
+			s.SetVoiceCommands(voice)
+			if typed != nil {
+				s.SetSink(typed)
+			}
+			if err := startLive(s, rec.PCMReader, livePath); err != nil {
 				// Nothing else reads the PCM pipe; drain it so ffmpeg doesn't
 				// block on pipe writes. This clip records without live text;
 				// the next clip retries with a fresh streamer.
@@ -535,6 +702,7 @@ func runClips(cfg *config.Config, name, format string, sampleRate, channels int,
 			// Subsequent clips: show ready state, wait for user to start
 			model = tui.NewClipsModel(startRec, nil, nil, opts, clipNumber, savedMessage)
 		}
+		model.SetMarkFunc(func(at time.Duration) error { return saveMark(outputPath, at, "") })
 
 		p := tea.NewProgram(model, ui.Options(tea.WithAltScreen())...)
 		if _, err := p.Run(); err != nil {
@@ -555,11 +723,18 @@ func runClips(cfg *config.Config, name, format string, sampleRate, channels int,
 				// warning; if the file is corrupt the batch step fails loudly.
 				fmt.Fprintf(os.Stderr, "Warning: recording exited with error: %v\n", err)
 			}
+			outputPath := sealRecorded(outputPath, warnStderr)
 			savedPaths = append(savedPaths, outputPath)
 			fmt.Println(outputPath)
 			if _, perr := promoteLiveTranscript(outputPath); perr != nil {
 				fmt.Fprintf(os.Stderr, "Warning: failed to promote live transcript: %v\n", perr)
 			}
+			backend := ""
+			if clipStreamer != nil {
+				backend = transcribe.RealtimeBackendName
+			}
+			runHook(context.Background(), hookRunner, recordHookEvent(outputPath, backend, recordHookReason("", rec.StoppedForSilence()), time.Time{}), warnStderr)
+			autoSync(context.Background(), cfg, outputPath, warnStderr)
 		}
 
 		if model.ShouldTranscribe() {
@@ -590,7 +765,17 @@ func newPostTranscribeCmd(audioPath string, plainText bool) (*exec.Cmd, []string
 	if err != nil {
 		self = "transcribe"
 	}
-	args, err := buildPostTranscribeArgs(audioPath, rTranscribeArgs, rVerbose, rWhisperShortcut, plainText, exec.LookPath)
+	transcribeArgs := rTranscribeArgs
+	if rRaw {
+		transcribeArgs = strings.TrimSpace("--raw " + transcribeArgs)
+	}
+	if rVoiceBatch {
+		transcribeArgs = strings.TrimSpace("--voice-commands " + transcribeArgs)
+	}
+	if rExportNote {
+		transcribeArgs = strings.TrimSpace("--export-note " + transcribeArgs)
+	}
+	args, err := buildPostTranscribeArgs(audioPath, transcribeArgs, rVerbose, rWhisperShortcut, plainText, exec.LookPath)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -677,13 +862,30 @@ func preferredLocalWhisperBackend(lookPath func(string) (string, error)) (string
 	return "", fmt.Errorf("recw requires a local Whisper backend: install whisper-cpp (whisper-cli) or whisper")
 }
 
+// saveRecordingDevice notes in the recording's sidecar which input it was
+// made from, for the notes exported later. Failing to is only worth a
+// warning: the recording itself is unaffected.
+func saveRecordingDevice(audioPath, device string) {
+	if device == "" {
+		return
+	}
+	md, err := meta.Load(audioPath)
+	if err == nil {
+		md.Device = device
+		err = md.Save(audioPath)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save the recording's device: %v\n", err)
+	}
+}
+
 // promoteLiveTranscript copies the live transcript (<base>-live.txt) to the
 // canonical transcript path (<base>.txt) so a transcript always exists after
 // recording, even without a batch run. The live file is preserved; a later
 // batch transcription overwrites the canonical file with the diarized result.
 // Missing or blank live files are skipped (returns "", nil).
 func promoteLiveTranscript(audioPath string) (string, error) {
-	livePath := liveTranscriptPathFor(audioPath)
+	livePath := pathOnDisk(liveTranscriptPathFor(audioPath))
 	data, err := os.ReadFile(livePath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -691,12 +893,19 @@ func promoteLiveTranscript(audioPath string) (string, error) {
 		}
 		return "", err
 	}
+	dest := transcriptPathFor(audioPath, transcribe.FormatText)
+	if encrypt.IsEncrypted(livePath) {
+		// Copied as it is, still encrypted to the same recipients, so that
+		// a machine that only records needs no identity to promote it.
+		sealed := dest + encrypt.Ext
+		if err := os.WriteFile(sealed, data, 0600); err != nil {
+			return "", err
+		}
+		os.Remove(dest)
+		return sealed, nil
+	}
 	if strings.TrimSpace(string(data)) == "" {
 		return "", nil
 	}
-	dest := transcriptPathFor(audioPath, transcribe.FormatText)
-	if err := os.WriteFile(dest, data, 0644); err != nil {
-		return "", err
-	}
-	return dest, nil
+	return saveFile(dest, data)
 }
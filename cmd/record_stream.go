@@ -2,7 +2,11 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"strings"
@@ -14,6 +18,7 @@ import (
 	"github.com/joegoldin/audiomemo/internal/record"
 	"github.com/joegoldin/audiomemo/internal/stream"
 	"github.com/joegoldin/audiomemo/internal/transcribe"
+	"github.com/joegoldin/audiomemo/internal/typist"
 )
 
 // levelInterval is the coalescing window for mic readings. ffmpeg prints one
@@ -60,13 +65,15 @@ func pumpErrors(em *stream.Emitter, errs <-chan error) {
 	}
 }
 
-// endReason classifies why the stream is closing. A signal outranks a
-// non-zero ffmpeg status, because tearing down the PCM pipe on stop routinely
-// produces one and the user still got what they asked for.
-func endReason(signalled bool, runErr error) string {
+// endReason classifies why the stream is closing. A signal or a stop command
+// outranks a non-zero ffmpeg status, because tearing down the PCM pipe on
+// stop routinely produces one and the user still got what they asked for.
+func endReason(signalled, commanded bool, runErr error) string {
 	switch {
 	case signalled:
 		return stream.ReasonSignal
+	case commanded:
+		return stream.ReasonCommand
 	case runErr != nil:
 		return stream.ReasonError
 	default:
@@ -74,13 +81,101 @@ func endReason(signalled bool, runErr error) string {
 	}
 }
 
-func endExitCode(signalled bool, runErr error) int {
-	if signalled || runErr == nil {
+func endExitCode(deliberate bool, runErr error) int {
+	if deliberate || runErr == nil {
 		return 0
 	}
 	return 1
 }
 
+// muteControl is the part of the Recorder that mute commands drive.
+type muteControl interface {
+	ToggleMute()
+	IsMuted() bool
+}
+
+// readCommands acknowledges and carries out each command a wrapper writes to
+// stdin. A stop is handed to stop, which ends the recording, and nothing after
+// it is read. A mark is handed to mark, which saves it as a spoken mark is
+// saved. EOF is not a stop: a wrapper with nothing to say may well start
+// audiomemo with stdin closed.
+func readCommands(em *stream.Emitter, r io.Reader, rec muteControl, mark func(label string), stop func(transcribe *bool)) {
+	err := stream.ReadCommands(r, func(c stream.Command, err error) bool {
+		ack := stream.AckEvent{Cmd: c.Cmd, ID: c.ID, OK: err == nil}
+		if err != nil {
+			ack.Error = err.Error()
+			em.Ack(ack)
+			return true
+		}
+		switch c.Cmd {
+		case stream.CmdStop:
+			// Acked first, so the ack precedes the final and end it leads to.
+			em.Ack(ack)
+			stop(c.Transcribe)
+			return false
+		case stream.CmdMute, stream.CmdUnmute, stream.CmdPause:
+			// ToggleMute is the only primitive; mute and unmute are
+			// idempotent on top of it, pause is the TUI's toggle.
+			if c.Cmd == stream.CmdPause || rec.IsMuted() != (c.Cmd == stream.CmdMute) {
+				rec.ToggleMute()
+			}
+			muted := rec.IsMuted()
+			ack.Muted = &muted
+		case stream.CmdMark:
+			ack.Label = c.Label
+			mark(c.Label)
+		}
+		em.Ack(ack)
+		return true
+	})
+	if err != nil {
+		em.Error(stream.ScopeRecord, false, fmt.Errorf("reading commands: %w", err))
+	}
+}
+
+// commandMarker returns readCommands' mark: it saves a mark from stdin to the
+// sidecar of the recording at audioPath as followVoice saves a spoken one,
+// timed from started, and reports a failure as a non-fatal error event.
+func commandMarker(em *stream.Emitter, audioPath string, started time.Time) func(label string) {
+	return func(label string) {
+		if err := saveMark(audioPath, time.Since(started), label); err != nil {
+			em.Error(stream.ScopeRecord, false, fmt.Errorf("saving mark: %w", err))
+		}
+	}
+}
+
+// serveEvents publishes everything em emits to browsers connecting to ln.
+// The returned func, run after the end event, lets connected clients read
+// that event before the server goes away.
+func serveEvents(em *stream.Emitter, ln net.Listener) func() {
+	hub := stream.NewHub()
+	em.SetHub(hub)
+	return serveHub(hub, ln)
+}
+
+// serveHub serves hub's lines on ln until the returned func closes both.
+func serveHub(hub *stream.Hub, ln net.Listener) func() {
+	srv := &http.Server{Handler: stream.NewHandler(hub), ReadHeaderTimeout: 10 * time.Second}
+	go srv.Serve(ln)
+	return func() {
+		hub.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}
+}
+
+// teeWriter writes every line to both writers. Unlike io.MultiWriter it
+// carries on past a failure, so the saved copy outlives a consumer that went
+// away, and the consumer a full disk.
+type teeWriter struct{ a, b io.Writer }
+
+func (t teeWriter) Write(p []byte) (int, error) {
+	_, errA := t.a.Write(p)
+	_, errB := t.b.Write(p)
+	return len(p), errors.Join(errA, errB)
+}
+
 // runRecordStream is the --stream counterpart of runRecord's --no-tui branch.
 // It receives an already-started streamer (or nil plus the reason it is nil)
 // so the start event's mode is a fact rather than an intention.
@@ -88,11 +183,26 @@ func runRecordStream(
 	cfg *config.Config,
 	opts record.RecordOpts,
 	rec *record.Recorder,
+	started time.Time,
 	streamer *transcribe.Streamer,
+	typed *typist.Typist,
 	streamErr error,
 	batchTranscribe bool,
+	eventsLn net.Listener,
+	tee io.Writer,
+	postRecord func(path, reason string, warn func(error)),
 ) error {
-	em := stream.NewEmitter(os.Stdout)
+	var out io.Writer = os.Stdout
+	if tee != nil {
+		out = teeWriter{os.Stdout, tee}
+	}
+	em := stream.NewEmitter(out)
+	eventsURL := ""
+	if eventsLn != nil {
+		stopEvents := serveEvents(em, eventsLn)
+		defer stopEvents()
+		eventsURL = "http://" + eventsLn.Addr().String() + "/"
+	}
 
 	// The streamer failed to connect but the recording is fine, so the
 	// consumer is told and the run continues without partials.
@@ -109,7 +219,9 @@ func runRecordStream(
 		Format:      opts.Format,
 		SampleRate:  opts.SampleRate,
 		Channels:    opts.Channels,
+		InputFile:   opts.InputFile,
 		Mode:        mode,
+		EventsURL:   eventsURL,
 	}
 	if streamer != nil {
 		startEv.Backend = transcribe.RealtimeBackendName
@@ -132,6 +244,10 @@ func runRecordStream(
 	sigCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stopSignals()
 
+	// A signal and a stop command can race; ffmpeg is asked to stop once.
+	var stopOnce sync.Once
+	stopRecording := func() { stopOnce.Do(rec.Stop) }
+
 	signalled := make(chan struct{})
 	var signalOnce sync.Once
 	go func() {
@@ -140,9 +256,36 @@ func runRecordStream(
 		// process outright rather than waiting on a wedged ffmpeg.
 		stopSignals()
 		signalOnce.Do(func() { close(signalled) })
-		rec.Stop()
+		stopRecording()
 	}()
 
+	// commanded carries a stop command's transcribe override. It is sent
+	// before ffmpeg is stopped, so it is there by the time Done fires. The
+	// reader is left blocked on stdin when ffmpeg stops some other way; the
+	// process is about to exit.
+	commanded := make(chan *bool, 1)
+	go readCommands(em, os.Stdin, rec, commandMarker(em, opts.OutputPath, started), func(transcribe *bool) {
+		commanded <- transcribe
+		stopRecording()
+	})
+	// A spoken stop is a stop command with no override. Either can come
+	// first; the one that fills commanded is the one that counts.
+	if streamer != nil {
+		pumps.Add(1)
+		go func() {
+			defer pumps.Done()
+			followVoice(streamer.Voice, opts.OutputPath, started, em.Voice, func() {
+				select {
+				case commanded <- nil:
+				default:
+				}
+				stopRecording()
+			}, func(err error) {
+				em.Error(stream.ScopeRecord, false, fmt.Errorf("saving mark: %w", err))
+			})
+		}()
+	}
+
 	runErr := <-rec.Done
 	wasSignalled := false
 	select {
@@ -150,8 +293,17 @@ func runRecordStream(
 		wasSignalled = true
 	default:
 	}
+	wasCommanded := false
+	select {
+	case transcribe := <-commanded:
+		wasCommanded = true
+		if transcribe != nil {
+			batchTranscribe = *transcribe
+		}
+	default:
+	}
 
-	if err := rec.Wait(); err != nil && !wasSignalled {
+	if err := rec.Wait(); err != nil && !wasSignalled && !wasCommanded {
 		// ffmpeg exits non-zero on a broken PCM pipe even when the audio file
 		// is valid, so this is reported and not returned.
 		em.Error(stream.ScopeRecord, false, err)
@@ -161,19 +313,25 @@ func runRecordStream(
 		streamer.Stop()
 	}
 	pumps.Wait()
+	closeTypist(typed, func(err error) { em.Error(stream.ScopeRecord, false, err) })
+	opts.OutputPath = sealRecorded(opts.OutputPath, func(err error) { em.Error(stream.ScopeRecord, false, err) })
 
 	if promoted, err := promoteLiveTranscript(opts.OutputPath); err != nil {
 		em.Error(stream.ScopeRecord, false, fmt.Errorf("promoting live transcript: %w", err))
 	} else if promoted != "" {
 		_ = promoted
 	}
+	reason := endReason(wasSignalled, wasCommanded, runErr)
+	postRecord(opts.OutputPath, recordHookReason(reason, rec.StoppedForSilence()), func(err error) {
+		em.Error(stream.ScopeRecord, false, err)
+	})
 
 	emitFinal(em, cfg, opts.OutputPath, streamer, batchTranscribe)
 
 	em.End(stream.EndEvent{
-		Reason:   endReason(wasSignalled, runErr),
+		Reason:   reason,
 		Path:     opts.OutputPath,
-		ExitCode: endExitCode(wasSignalled, runErr),
+		ExitCode: endExitCode(wasSignalled || wasCommanded, runErr),
 	})
 	return nil
 }
@@ -216,7 +374,7 @@ func emitFinal(em *stream.Emitter, cfg *config.Config, audioPath string, streame
 			em.Final(stream.FinalEvent{
 				Text:           text,
 				Path:           audioPath,
-				TranscriptPath: transcriptPath,
+				TranscriptPath: pathOnDisk(transcriptPath),
 				Backend:        backendFromArgs(cfg, args),
 				Source:         stream.SourceBatch,
 			})
@@ -230,7 +388,7 @@ func emitFinal(em *stream.Emitter, cfg *config.Config, audioPath string, streame
 	em.Final(stream.FinalEvent{
 		Text:           liveText,
 		Path:           audioPath,
-		TranscriptPath: transcriptPath,
+		TranscriptPath: pathOnDisk(transcriptPath),
 		Backend:        transcribe.RealtimeBackendName,
 		Source:         stream.SourceLive,
 	})
@@ -1,15 +1,21 @@
 package cmd
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"errors"
+	"io"
 	"math"
+	"net"
+	"net/http"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/meta"
 	"github.com/joegoldin/audiomemo/internal/stream"
 )
 
@@ -127,20 +133,23 @@ func TestPumpErrorsMarksSessionFailuresNonFatal(t *testing.T) {
 }
 
 func TestEndReasonForSignal(t *testing.T) {
-	if got := endReason(true, nil); got != stream.ReasonSignal {
+	if got := endReason(true, false, nil); got != stream.ReasonSignal {
 		t.Errorf("endReason(signalled) = %q, want signal", got)
 	}
-	if got := endReason(false, nil); got != stream.ReasonStopped {
+	if got := endReason(false, false, nil); got != stream.ReasonStopped {
 		t.Errorf("endReason(clean) = %q, want stopped", got)
 	}
-	if got := endReason(false, errors.New("boom")); got != stream.ReasonError {
+	if got := endReason(false, false, errors.New("boom")); got != stream.ReasonError {
 		t.Errorf("endReason(failed) = %q, want error", got)
 	}
 	// A signal is a deliberate stop, so it outranks whatever non-zero status
 	// ffmpeg produced while tearing down.
-	if got := endReason(true, errors.New("exit status 255")); got != stream.ReasonSignal {
+	if got := endReason(true, false, errors.New("exit status 255")); got != stream.ReasonSignal {
 		t.Errorf("endReason(signalled, ffmpeg error) = %q, want signal", got)
 	}
+	if got := endReason(false, true, errors.New("exit status 255")); got != stream.ReasonCommand {
+		t.Errorf("endReason(commanded, ffmpeg error) = %q, want command", got)
+	}
 }
 
 func TestEndExitCode(t *testing.T) {
@@ -155,6 +164,110 @@ func TestEndExitCode(t *testing.T) {
 	}
 }
 
+// fakeMute stands in for the Recorder's PulseAudio mute.
+type fakeMute struct{ muted bool }
+
+func (f *fakeMute) ToggleMute()   { f.muted = !f.muted }
+func (f *fakeMute) IsMuted() bool { return f.muted }
+
+func TestReadCommandsAcksEachCommand(t *testing.T) {
+	in := strings.Join([]string{
+		`{"cmd":"mute","id":"1"}`,
+		`{"cmd":"mute","id":"2"}`,
+		`{"cmd":"pause","id":"3"}`,
+		`{"cmd":"unmute","id":"4"}`,
+		`{"cmd":"mark","label":"intro"}`,
+		`{"cmd":"rewind","id":"5"}`,
+		`{"cmd":"stop","transcribe":true}`,
+		`{"cmd":"mute","id":"after stop"}`,
+	}, "\n")
+	buf := &bytes.Buffer{}
+	rec := &fakeMute{}
+	var stops []*bool
+	var marks []string
+	readCommands(stream.NewEmitter(buf), strings.NewReader(in), rec, func(label string) {
+		marks = append(marks, label)
+	}, func(transcribe *bool) {
+		stops = append(stops, transcribe)
+	})
+
+	lines := decodeStreamLines(t, buf.String())
+	if len(lines) != 7 {
+		t.Fatalf("got %d acks, want 7 (nothing after stop): %s", len(lines), buf.String())
+	}
+	// mute is idempotent, pause toggles, unmute of an unmuted input is a
+	// no-op that still succeeds.
+	for i, want := range []bool{true, true, false, false} {
+		if lines[i]["type"] != "ack" || lines[i]["ok"] != true || lines[i]["muted"] != want {
+			t.Errorf("ack %d = %v, want muted %v", i, lines[i], want)
+		}
+	}
+	if lines[4]["cmd"] != "mark" || lines[4]["label"] != "intro" {
+		t.Errorf("mark ack = %v", lines[4])
+	}
+	if len(marks) != 1 || marks[0] != "intro" {
+		t.Errorf("marks = %q, want the one labelled intro", marks)
+	}
+	if lines[5]["ok"] != false || lines[5]["id"] != "5" || !strings.Contains(lines[5]["error"].(string), "unknown command") {
+		t.Errorf("unknown command ack = %v", lines[5])
+	}
+	if lines[6]["cmd"] != "stop" || lines[6]["ok"] != true {
+		t.Errorf("stop ack = %v", lines[6])
+	}
+	if len(stops) != 1 || stops[0] == nil || !*stops[0] {
+		t.Errorf("stop called %d times with %v, want once with transcribe", len(stops), stops)
+	}
+	if rec.muted {
+		t.Error("input left muted")
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func TestCommandMarkerSavesToTheSidecar(t *testing.T) {
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "memo.ogg")
+	buf := &bytes.Buffer{}
+	mark := commandMarker(stream.NewEmitter(buf), audio, time.Now().Add(-90*time.Second))
+	mark("intro")
+	mark("")
+
+	md, err := meta.Load(audio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(md.Marks) != 2 || md.Marks[0].Label != "intro" || md.Marks[1].Label != "" {
+		t.Fatalf("marks = %v, want intro then an unlabelled one", md.Marks)
+	}
+	if at := md.Marks[0].At; at < 90 || at > 95 {
+		t.Errorf("mark at %vs, want about 90s in, as a spoken mark is timed", at)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("unexpected events: %s", buf.String())
+	}
+
+	// A sidecar that cannot be written is reported, not fatal.
+	mark = commandMarker(stream.NewEmitter(buf), filepath.Join(dir, "gone", "memo.ogg"), time.Now())
+	mark("outro")
+	lines := decodeStreamLines(t, buf.String())
+	if len(lines) != 1 || lines[0]["type"] != "error" || lines[0]["fatal"] != false ||
+		!strings.Contains(lines[0]["message"].(string), "saving mark") {
+		t.Errorf("events = %v, want a non-fatal error", lines)
+	}
+}
+
+func TestTeeWriterOutlivesAFailedConsumer(t *testing.T) {
+	var saved bytes.Buffer
+	em := stream.NewEmitter(teeWriter{failingWriter{}, &saved})
+	em.Commit("one")
+	em.Commit("two")
+	if n := len(decodeStreamLines(t, saved.String())); n != 2 {
+		t.Errorf("saved %d lines after stdout broke, want 2", n)
+	}
+}
+
 func TestBackendFromArgsTakesTheLastOccurrence(t *testing.T) {
 	cfg := config.Default()
 	// recw appends its local-only backend after the user's --transcribe-args
@@ -201,3 +314,49 @@ func TestEmitFinalSkippedWhenThereIsNoText(t *testing.T) {
 		t.Errorf("a run with no transcript emitted %q", buf.String())
 	}
 }
+
+func TestServeEventsMirrorsStdoutAndEndsAfterEnd(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout bytes.Buffer
+	em := stream.NewEmitter(&stdout)
+	stop := serveEvents(em, ln)
+
+	em.Start(stream.StartEvent{Mode: stream.ModeLive})
+	em.Commit("hello")
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	// The backlog is written as soon as the client subscribes.
+	r := bufio.NewReader(resp.Body)
+	if line, _ := r.ReadString('\n'); !strings.Contains(line, `"type":"start"`) {
+		t.Fatalf("first SSE line = %q", line)
+	}
+
+	em.End(stream.EndEvent{Reason: stream.ReasonStopped})
+	stop()
+
+	rest, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sseTypes []string
+	for _, line := range strings.Split(string(rest), "\n") {
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			var ev map[string]any
+			json.Unmarshal([]byte(data), &ev)
+			sseTypes = append(sseTypes, ev["type"].(string))
+		}
+	}
+	if got := strings.Join(sseTypes, ","); got != "commit,end" {
+		t.Errorf("SSE after start = %s, want commit,end", got)
+	}
+	if n := len(decodeStreamLines(t, stdout.String())); n != 3 {
+		t.Errorf("stdout has %d lines, want 3", n)
+	}
+}
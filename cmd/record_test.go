@@ -199,6 +199,42 @@ func TestValidateStreamFlags(t *testing.T) {
 	}
 }
 
+func TestValidateInputFileFlags(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "meeting.ogg")
+	if err := os.WriteFile(file, []byte("audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name                        string
+		inputFile                   string
+		realtime, device, clips, ls bool
+		wantErr                     string
+	}{
+		{"no input file", "", false, true, true, true, ""},
+		{"realtime without a file", "", true, false, false, false, "--realtime requires --input-file"},
+		{"file alone", file, false, false, false, false, ""},
+		{"file at real time", file, true, false, false, false, ""},
+		{"file and a device", file, false, true, false, false, "--device"},
+		{"file and clips", file, false, false, true, false, "--clips"},
+		{"file and list-devices", file, false, false, false, true, "--list-devices"},
+		{"missing file", file + ".gone", false, false, false, false, "meeting.ogg.gone"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateInputFileFlags(c.inputFile, c.realtime, c.device, c.clips, c.ls)
+			if c.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("err = %v, want it to mention %q", err, c.wantErr)
+			}
+		})
+	}
+}
+
 func TestResolveStreamMode(t *testing.T) {
 	cases := []struct {
 		name                  string
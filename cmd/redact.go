@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/encrypt"
+	"github.com/joegoldin/audiomemo/internal/media"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+	"github.com/spf13/pflag"
+)
+
+// resolveRedactor merges [transcribe.redact] with --redact and --bleep, and
+// builds the redactor they ask for, or nil for none. "all" stands for every
+// category but terms, and terms too when some are configured; "none" turns
+// off what the config turns on. It also says whether to save a bleeped copy
+// of the recording.
+func resolveRedactor(cfg config.RedactConfig, flags *pflag.FlagSet, fromStdin bool) (*transcribe.Redactor, bool, error) {
+	categories := cfg.Categories
+	if flags.Changed("redact") {
+		categories = tRedact
+	}
+	var expanded []string
+	for _, c := range categories {
+		switch c = strings.ToLower(strings.TrimSpace(c)); c {
+		case "none", "":
+		case "all":
+			expanded = append(expanded, transcribe.RedactEmail, transcribe.RedactPhone, transcribe.RedactCard)
+			if len(cfg.Terms) > 0 {
+				expanded = append(expanded, transcribe.RedactTerms)
+			}
+		default:
+			expanded = append(expanded, c)
+		}
+	}
+	redactor, err := transcribe.NewRedactor(expanded, cfg.Terms)
+	if err != nil {
+		return nil, false, fmt.Errorf("transcribe.redact: %w", err)
+	}
+
+	bleep := cfg.Bleep
+	if flags.Changed("bleep") {
+		bleep = tBleep
+	}
+	if bleep && redactor == nil {
+		if flags.Changed("bleep") {
+			return nil, false, fmt.Errorf("--bleep bleeps what is redacted; give --redact too")
+		}
+		bleep = false
+	}
+	if bleep && fromStdin {
+		if flags.Changed("bleep") {
+			return nil, false, fmt.Errorf("--bleep copies the recording, and stdin is not one")
+		}
+		bleep = false
+	}
+	return redactor, bleep, nil
+}
+
+// redactionsPathFor returns <base>.redactions.json for a recording or any
+// file derived from it.
+func redactionsPathFor(path string) string {
+	return recordingBase(path) + ".redactions.json"
+}
+
+// redactionReport is what <base>.redactions.json holds: what was masked in
+// the transcript beside it, and where, but never what it said.
+type redactionReport struct {
+	Backend    string                 `json:"backend"`
+	Categories []string               `json:"categories"`
+	Counts     map[string]int         `json:"counts"`
+	Redactions []transcribe.Redaction `json:"redactions"`
+}
+
+func newRedactionReport(backend string, categories []string, redactions []transcribe.Redaction) redactionReport {
+	r := redactionReport{
+		Backend:    backend,
+		Categories: categories,
+		Counts:     map[string]int{},
+		Redactions: redactions,
+	}
+	if r.Redactions == nil {
+		r.Redactions = []transcribe.Redaction{}
+	}
+	for _, rd := range redactions {
+		r.Counts[rd.Category]++
+	}
+	return r
+}
+
+// String sums the report up in a line, such as "Redacted 3: 2 email, 1
+// phone".
+func (r redactionReport) String() string {
+	if len(r.Redactions) == 0 {
+		return "Nothing to redact"
+	}
+	var parts []string
+	for _, c := range transcribe.RedactCategories {
+		if n := r.Counts[c]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, c))
+		}
+	}
+	return fmt.Sprintf("Redacted %d: %s", len(r.Redactions), strings.Join(parts, ", "))
+}
+
+// saveRedactionReport writes the report beside the recording at audioPath and
+// returns where.
+func saveRedactionReport(audioPath string, r redactionReport) (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return saveFile(redactionsPathFor(audioPath), append(data, '\n'))
+}
+
+// bleepedPathFor is where the bleeped copy of the recording at audioPath
+// goes: <name>-redacted.<ext>, or .ogg when the audio was taken out of a
+// video, since the copy is audio only.
+func bleepedPathFor(audioPath string, extracted bool) string {
+	ext := filepath.Ext(encrypt.Plain(audioPath))
+	if extracted {
+		ext = ".ogg"
+	}
+	return media.BleepedPath(recordingBase(audioPath) + ext)
+}
+
+// isBleepedCopy reports whether the file named name is the bleeped copy of a
+// recording rather than a recording of its own.
+func isBleepedCopy(name string) bool {
+	return strings.HasSuffix(recordingBase(name), "-redacted")
+}
+
+// bleepRecording saves a copy of the audio at input, which is what the backend
+// was given, with each redaction bleeped, beside the recording at audioPath.
+// It is encrypted like the recording when encrypting.
+func bleepRecording(ctx context.Context, input, audioPath string, extracted bool, redactions []transcribe.Redaction) error {
+	spans := make([]media.Span, 0, len(redactions))
+	for _, rd := range redactions {
+		spans = append(spans, media.Span{Start: rd.Start, End: rd.End})
+	}
+	out := bleepedPathFor(audioPath, extracted)
+	if err := media.Bleep(ctx, input, spans, out); err != nil {
+		return err
+	}
+	sealed, err := sealRecording(ctx, out)
+	if err != nil {
+		// Not left in plaintext beside a recording that is encrypted.
+		os.Remove(out)
+		return err
+	}
+	if !atRest.Encrypting() {
+		// An encrypted copy from an earlier run is now out of date.
+		os.Remove(out + encrypt.Ext)
+	}
+	fmt.Fprintf(os.Stderr, "Saved redacted audio to %s\n", sealed)
+	return nil
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+	"github.com/spf13/pflag"
+)
+
+// redactFlags binds --redact and --bleep to a fresh flag set, as
+// postProcessFlags does for the post-processing flags.
+func redactFlags(t *testing.T, args ...string) *pflag.FlagSet {
+	t.Helper()
+	fs := pflag.NewFlagSet("transcribe", pflag.ContinueOnError)
+	fs.StringSliceVar(&tRedact, "redact", nil, "")
+	fs.BoolVar(&tBleep, "bleep", false, "")
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tRedact, tBleep = nil, false })
+	return fs
+}
+
+func TestResolveRedactor(t *testing.T) {
+	cfg := config.RedactConfig{Categories: []string{"email"}, Terms: []string{"Falcon"}, Bleep: true}
+	tests := []struct {
+		name  string
+		args  []string
+		want  []string
+		bleep bool
+	}{
+		{"config alone", nil, []string{"email"}, true},
+		{"flag replaces config", []string{"--redact", "phone,card"}, []string{"phone", "card"}, true},
+		{"all takes configured terms", []string{"--redact", "all"}, transcribe.RedactCategories, true},
+		{"none", []string{"--redact", "none"}, nil, false},
+		{"bleep off", []string{"--bleep=false"}, []string{"email"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, bleep, err := resolveRedactor(cfg, redactFlags(t, tt.args...), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(r.Categories(), tt.want) || bleep != tt.bleep {
+				t.Errorf("got %v, bleep %v; want %v, bleep %v", r.Categories(), bleep, tt.want, tt.bleep)
+			}
+		})
+	}
+
+	r, _, _ := resolveRedactor(config.RedactConfig{}, redactFlags(t, "--redact", "all"), false)
+	if slices.Contains(r.Categories(), transcribe.RedactTerms) {
+		t.Error("all without terms configured asked for terms")
+	}
+	// From stdin the config's bleep is dropped, and the flag's refused.
+	if _, bleep, err := resolveRedactor(cfg, redactFlags(t), true); bleep || err != nil {
+		t.Errorf("stdin with bleep configured = %v, %v", bleep, err)
+	}
+	for _, args := range [][]string{{"--bleep"}, {"--redact", "ssn"}, {"--redact", "terms"}} {
+		c := config.RedactConfig{}
+		if _, _, err := resolveRedactor(c, redactFlags(t, args...), true); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
+
+func TestRedactionReport(t *testing.T) {
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "call.ogg")
+	redactions := []transcribe.Redaction{
+		{Category: transcribe.RedactPhone, Mask: "[PHONE]", Start: 4, End: 6, Source: transcribe.RedactedByBackend},
+		{Category: transcribe.RedactEmail, Mask: "[EMAIL]", Start: 1, End: 2, Source: transcribe.RedactedLocally},
+		{Category: transcribe.RedactEmail, Mask: "[EMAIL]", Start: 9, End: 10, Source: transcribe.RedactedLocally},
+	}
+	report := newRedactionReport("deepgram", []string{"email", "phone"}, redactions)
+	if got := report.String(); got != "Redacted 3: 2 email, 1 phone" {
+		t.Errorf("String() = %q", got)
+	}
+	path, err := saveRedactionReport(audio, report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(dir, "call.redactions.json") {
+		t.Errorf("saved to %s", path)
+	}
+	data, _ := os.ReadFile(path)
+	var got redactionReport
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Counts["email"] != 2 || len(got.Redactions) != 3 || got.Backend != "deepgram" {
+		t.Errorf("report = %+v", got)
+	}
+
+	empty := newRedactionReport("whisper", []string{"card"}, nil)
+	if empty.String() != "Nothing to redact" {
+		t.Errorf("String() = %q", empty.String())
+	}
+	if data, _ := json.Marshal(empty); !strings.Contains(string(data), `"redactions":[]`) {
+		t.Errorf("empty report = %s", data)
+	}
+}
+
+func TestBleepedPathFor(t *testing.T) {
+	tests := []struct {
+		path      string
+		extracted bool
+		want      string
+	}{
+		{"/r/call.ogg", false, "/r/call-redacted.ogg"},
+		{"/r/call.m4a.age", false, "/r/call-redacted.m4a"},
+		{"/r/demo.mkv", true, "/r/demo-redacted.ogg"},
+	}
+	for _, tt := range tests {
+		if got := bleepedPathFor(tt.path, tt.extracted); got != tt.want {
+			t.Errorf("bleepedPathFor(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+	if !isBleepedCopy("call-redacted.ogg.age") || isBleepedCopy("call.ogg") {
+		t.Error("isBleepedCopy picked the wrong file")
+	}
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/joegoldin/audiomemo/internal/stream"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rpSpeed       string
+	rpServeEvents string
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay <file.ndjson>",
+	Short: "Replay a saved --stream session",
+	Long: `Re-emit the events saved by record --stream-tee on stdout, spaced as they
+were recorded, so a consumer can be developed and tested without speaking
+into a microphone. The lines are passed on unchanged, t included.
+
+With --serve-events the events are also served to browsers, as record
+--serve-events would, and the replay waits for the first one to connect.
+
+Examples:
+  audiomemo replay standup.ndjson | my-caption-ui
+  audiomemo replay --speed 4x --serve-events 127.0.0.1:8766 standup.ndjson
+  audiomemo replay --speed max standup.ndjson > got.ndjson`,
+	Args: cobra.ExactArgs(1),
+	RunE: runReplay,
+}
+
+func init() {
+	replayCmd.Flags().StringVar(&rpSpeed, "speed", "1x", "playback speed, such as 2x or 0.5x; max replays without waiting")
+	replayCmd.Flags().StringVar(&rpServeEvents, "serve-events", "", "also serve the events over WebSocket and SSE at this address (e.g. 127.0.0.1:8766)")
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	speed, err := stream.ParseSpeed(rpSpeed)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	emit := func(eventType string, line []byte) { os.Stdout.Write(line) }
+	if rpServeEvents != "" {
+		ln, err := net.Listen("tcp", rpServeEvents)
+		if err != nil {
+			return fmt.Errorf("--serve-events: %w", err)
+		}
+		hub := stream.NewHub()
+		stop := serveHub(hub, ln)
+		defer stop()
+		emit = func(eventType string, line []byte) {
+			os.Stdout.Write(line)
+			hub.Publish(eventType, line)
+		}
+		fmt.Fprintf(os.Stderr, "Waiting for a client at http://%s/\n", ln.Addr())
+		select {
+		case <-hub.Joined():
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	if err := stream.Replay(ctx, f, speed, emit); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	return nil
+}
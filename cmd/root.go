@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/joegoldin/audiomemo/internal/encrypt"
 	"github.com/spf13/cobra"
 )
 
@@ -17,11 +18,28 @@ func init() {
 	rootCmd.AddCommand(recordCmd)
 	rootCmd.AddCommand(transcribeCmd)
 	rootCmd.AddCommand(deviceCmd)
+	rootCmd.AddCommand(summarizeCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(wyomingCmd)
+	rootCmd.AddCommand(schemaCmd)
+	rootCmd.AddCommand(replayCmd)
+	rootCmd.AddCommand(webhookCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(tagCmd)
+	rootCmd.AddCommand(pruneCmd)
+	rootCmd.AddCommand(syncCmd)
 }
 
 func ExecuteRoot() {
-	if err := rootCmd.Execute(); err != nil {
+	if err := execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
+
+// execute runs rootCmd and then deletes the private temp directory that
+// decrypted audio is kept in, before an exit status can skip it.
+func execute() error {
+	defer encrypt.RemoveTempDir()
+	return rootCmd.Execute()
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/joegoldin/audiomemo/internal/schema"
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema stream|result|webhook",
+	Short: "Print the JSON Schema of the stream events, the JSON transcript or the webhook payload",
+	Long: `Print a JSON Schema (draft 2020-12) generated from the types audiomemo
+writes, so a consumer can validate or generate code instead of reading the
+source.
+
+  stream   one line of record --stream
+  result   the transcript written by transcribe -f json
+  webhook  the body POSTed to a [webhooks] endpoint
+
+Each output carries a schema_version. A minor bump adds optional fields or
+event types and is safe for a consumer that ignores what it does not know;
+a major bump removes, renames or retypes something. The same schemas are
+committed under docs/schema.
+
+Examples:
+  audiomemo schema stream > stream.schema.json
+  audiomemo schema result | jq '.properties | keys'`,
+	ValidArgs: schema.Names,
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		b, err := schema.Generate(args[0])
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(b)
+		return err
+	},
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/server"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+	"github.com/spf13/cobra"
+)
+
+var (
+	svConfig        string
+	svListen        string
+	svBackend       string
+	svTokenFile     string
+	svMaxConcurrent int
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve an OpenAI-compatible transcription API",
+	Long: `Run a local HTTP server that answers POST /v1/audio/transcriptions the way
+OpenAI's API does, so editors, scripts and apps that speak to OpenAI can
+transcribe through whichever backend audiomemo is configured with.
+
+The request's model field picks the backend: "deepgram" or "whisperx" names
+one, "deepgram/nova-3" a backend and its model. Anything else, such as the
+"whisper-1" clients send by default, uses --backend or the configured
+default. Config defaults for diarisation, vocabulary, language and
+post-processing apply to every request; the prompt field is read as extra
+comma-separated vocabulary.
+
+Set a token (serve.token, serve.token_file, AUDIOMEMO_SERVE_TOKEN or
+--token-file) to require "Authorization: Bearer <token>" on every request.
+
+Examples:
+  audiomemo serve
+  audiomemo serve --listen 127.0.0.1:9000 --backend whisper-cpp
+  curl -F file=@memo.ogg -F response_format=srt http://127.0.0.1:8765/v1/audio/transcriptions`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&svConfig, "config", "", "config file path")
+	serveCmd.Flags().StringVar(&svListen, "listen", "", "address to listen on (serve.listen, default 127.0.0.1:8765)")
+	serveCmd.Flags().StringVarP(&svBackend, "backend", "b", "", "backend for requests whose model names none")
+	serveCmd.Flags().StringVar(&svTokenFile, "token-file", "", "file holding the bearer token clients must send (serve.token_file)")
+	serveCmd.Flags().IntVar(&svMaxConcurrent, "max-concurrent", 0, "transcriptions to run at once (serve.max_concurrent, default 2)")
+}
+
+// serveModel splits a request's model field into a backend and a model for
+// it. Model names the backends do not know, like OpenAI's "whisper-1", leave
+// both to the server's defaults rather than failing every stock client.
+func serveModel(model string) (backend, backendModel string) {
+	name, rest, found := strings.Cut(model, "/")
+	if !slices.Contains(transcribe.BackendNames, name) {
+		return "", ""
+	}
+	if found {
+		return name, rest
+	}
+	return name, ""
+}
+
+// serveResolver builds each request's backend and options from the config,
+// as `transcribe` would with no flags given.
+func serveResolver(cfg *config.Config, defaultBackend string, vocabulary []string) server.Resolver {
+	return func(model string) (transcribe.Transcriber, transcribe.TranscribeOpts, error) {
+		name, backendModel := serveModel(model)
+		if name == "" {
+			name = defaultBackend
+		}
+		backend, err := transcribe.NewDispatcher(cfg, name)
+		if err != nil {
+			return nil, transcribe.TranscribeOpts{}, err
+		}
+		opts := configOpts(cfg, backend.Name())
+		opts.Model = backendModel
+		opts.Language = cfg.Transcribe.Language
+		opts.Vocabulary = slices.Clone(vocabulary)
+		return backend, opts, nil
+	}
+}
+
+// isLoopback reports whether addr only accepts connections from this machine.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	var cfg *config.Config
+	var err error
+	if svConfig != "" {
+		cfg, err = config.LoadFrom(svConfig)
+	} else {
+		cfg, err = config.Load()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	cfg.ApplyEnv()
+	if svTokenFile != "" {
+		data, err := os.ReadFile(svTokenFile)
+		if err != nil {
+			return fmt.Errorf("reading --token-file: %w", err)
+		}
+		if cfg.Serve.Token = strings.TrimSpace(string(data)); cfg.Serve.Token == "" {
+			return fmt.Errorf("--token-file %s is empty", svTokenFile)
+		}
+	}
+
+	listen := cfg.Serve.Listen
+	if svListen != "" {
+		listen = svListen
+	}
+	maxConcurrent := cfg.Serve.MaxConcurrent
+	if cmd.Flags().Changed("max-concurrent") {
+		maxConcurrent = svMaxConcurrent
+	}
+
+	vocabulary, err := resolveVocabulary(cfg.Transcribe.Vocabulary, "")
+	if err != nil {
+		return err
+	}
+	post, err := newPostProcessor(cfg.Transcribe.PostProcess)
+	if err != nil {
+		return err
+	}
+	resolve := serveResolver(cfg, svBackend, vocabulary)
+	// Fail now rather than on the first request when nothing is configured.
+	backend, _, err := resolve("")
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+	if cfg.Serve.Token == "" && !isLoopback(listen) {
+		fmt.Fprintf(os.Stderr, "Warning: %s is reachable from other machines and no token is set\n", listen)
+	}
+	fmt.Fprintf(os.Stderr, "Serving %s on http://%s/v1/audio/transcriptions\n", backend.Name(), ln.Addr())
+
+	srv := &http.Server{
+		Handler: server.New(server.Options{
+			Resolve:       resolve,
+			Post:          post,
+			Token:         cfg.Serve.Token,
+			MaxConcurrent: maxConcurrent,
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	// Give transcriptions in flight a moment to finish before exiting.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return nil
+}
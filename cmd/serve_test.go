@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+)
+
+func TestServeModel(t *testing.T) {
+	tests := []struct {
+		model, backend, backendModel string
+	}{
+		{"", "", ""},
+		{"whisper-1", "", ""},
+		{"gpt-4o-transcribe", "", ""},
+		{"deepgram", "deepgram", ""},
+		{"deepgram/nova-3", "deepgram", "nova-3"},
+		{"whisperx/large-v3", "whisperx", "large-v3"},
+		{"acme/model", "", ""},
+	}
+	for _, tt := range tests {
+		backend, backendModel := serveModel(tt.model)
+		if backend != tt.backend || backendModel != tt.backendModel {
+			t.Errorf("serveModel(%q) = %q, %q, want %q, %q", tt.model, backend, backendModel, tt.backend, tt.backendModel)
+		}
+	}
+}
+
+func TestServeResolver(t *testing.T) {
+	cfg := config.Default()
+	cfg.Transcribe.Language = "de"
+	cfg.Transcribe.Deepgram.APIKey = "dg"
+	cfg.Transcribe.Deepgram.SmartFormat = false
+	cfg.Transcribe.OpenAI.APIKey = "oa"
+	vocab := []string{"audiomemo"}
+	resolve := serveResolver(cfg, "openai", vocab)
+
+	backend, opts, err := resolve("whisper-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backend.Name() != "openai" || opts.Model != "" || opts.Diarize {
+		t.Errorf("whisper-1 resolved to %s with %+v, want the default backend", backend.Name(), opts)
+	}
+
+	backend, opts, err = resolve("deepgram/nova-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backend.Name() != "deepgram" || opts.Model != "nova-2" || opts.Language != "de" {
+		t.Errorf("got %s with %+v", backend.Name(), opts)
+	}
+	if !opts.Diarize || opts.SmartFormat || !opts.Punctuate {
+		t.Errorf("deepgram config defaults not applied: %+v", opts)
+	}
+	if !reflect.DeepEqual(opts.Vocabulary, vocab) {
+		t.Errorf("vocabulary = %q", opts.Vocabulary)
+	}
+	opts.Vocabulary[0] = "changed"
+	if vocab[0] != "audiomemo" {
+		t.Error("a request's vocabulary aliases the server's")
+	}
+
+	if _, _, err := resolve("mistral"); err == nil {
+		t.Error("expected an error for a backend without a key")
+	}
+}
+
+func TestIsLoopback(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1:8765": true,
+		"localhost:8765": true,
+		"[::1]:8765":     true,
+		"0.0.0.0:8765":   false,
+		":8765":          false,
+		"192.168.1.5:80": false,
+		"bogus":          false,
+	}
+	for addr, want := range tests {
+		if got := isLoopback(addr); got != want {
+			t.Errorf("isLoopback(%q) = %v, want %v", addr, got, want)
+		}
+	}
+}
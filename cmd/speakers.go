@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/encrypt"
+	"github.com/joegoldin/audiomemo/internal/media"
+	"github.com/joegoldin/audiomemo/internal/meta"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+	"github.com/joegoldin/audiomemo/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+// maxSpeakerSample bounds how much of a speaker's longest turn is played. A
+// few seconds identifies a voice; a two-minute monologue does not need to be
+// sat through to name it.
+const maxSpeakerSample = 8.0
+
+// maxSpeakerQuote bounds the quote shown beside each speaker, in runes.
+const maxSpeakerQuote = 80
+
+var transcribeLabelSpeakersCmd = &cobra.Command{
+	Use:   "label-speakers <transcript.json>",
+	Short: "Name the speakers in a diarized transcript",
+	Long: `Play a short sample of each diarized speaker and ask who it is, then rewrite
+the transcript with the names.
+
+The JSON transcript is rewritten along with any .txt, .srt, or .vtt written
+from the same recording. The names are also saved in the recording's
+metadata (<base>.meta.json) and offered again the next time it is labelled.
+
+Samples are played with ffplay from the audio file next to the transcript.
+Without ffplay or the audio, speakers are named from their quotes alone.
+
+Examples:
+  transcribe label-speakers ~/Recordings/standup-2026-08-18T14-30-05.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runLabelSpeakers,
+}
+
+// parseSpeakerNames splits the --speakers value. Empty entries are kept so
+// "Alice,,Carol" names the first and third speakers and skips the second.
+func parseSpeakerNames(flag string) []string {
+	if strings.TrimSpace(flag) == "" {
+		return nil
+	}
+	names := strings.Split(flag, ",")
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
+	}
+	return names
+}
+
+// applySpeakerNames renames speakers by order of appearance and returns the
+// mapping that was applied, for the metadata sidecar.
+func applySpeakerNames(result *transcribe.Result, names []string) (map[string]string, error) {
+	speakers := result.Speakers()
+	if len(speakers) == 0 {
+		return nil, fmt.Errorf("--speakers needs speaker labels, but the transcript has none (pass --diarize)")
+	}
+	mapping, err := transcribe.MapSpeakersInOrder(speakers, names)
+	if err != nil {
+		return nil, err
+	}
+	result.RenameSpeakers(mapping)
+	return mapping, nil
+}
+
+// saveSpeakerNames records a speaker mapping in the recording's metadata.
+func saveSpeakerNames(path string, names map[string]string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	m, err := meta.Load(path)
+	if err != nil {
+		return err
+	}
+	m.SetSpeakerNames(names)
+	return m.Save(path)
+}
+
+// speakerSamples picks each speaker's longest turn as their sample: the
+// longer the turn, the less likely it is crosstalk or a one-word "yeah".
+func speakerSamples(result *transcribe.Result, md *meta.Metadata) []tui.SpeakerSample {
+	longest := map[string]transcribe.Segment{}
+	for _, seg := range result.Segments {
+		if seg.Speaker == "" {
+			continue
+		}
+		if cur, ok := longest[seg.Speaker]; !ok || seg.End-seg.Start > cur.End-cur.Start {
+			longest[seg.Speaker] = seg
+		}
+	}
+	var samples []tui.SpeakerSample
+	for _, label := range result.Speakers() {
+		seg := longest[label]
+		samples = append(samples, tui.SpeakerSample{
+			Label:    label,
+			Quote:    truncateRunes(strings.TrimSpace(seg.Text), maxSpeakerQuote),
+			Start:    seg.Start,
+			Duration: min(seg.End-seg.Start, maxSpeakerSample),
+			Name:     md.SpeakerName(label),
+		})
+	}
+	return samples
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(r[:n-1])) + "…"
+}
+
+// findAudioFor locates the recording a transcript was made from: the audio
+// or video file sharing its base name. Extensions are tried in a fixed order,
+// audio first, so the answer does not depend on map iteration.
+func findAudioFor(transcriptPath string) string {
+	base := recordingBase(transcriptPath)
+	exts := make([]string, 0, len(audioExtensions))
+	for ext := range audioExtensions {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	exts = append(exts, media.VideoExtensions...)
+	for _, ext := range exts {
+		for _, path := range []string{base + ext, base + ext + encrypt.Ext} {
+			if fileExists(path) {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// ffplayPlayer plays samples from audioPath, or returns nil when there is
+// nothing to play them with.
+func ffplayPlayer(audioPath string) tui.SpeakerPlayer {
+	if audioPath == "" {
+		return nil
+	}
+	if _, err := exec.LookPath("ffplay"); err != nil {
+		return nil
+	}
+	return func(s tui.SpeakerSample) *exec.Cmd {
+		return exec.Command("ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet",
+			"-ss", strconv.FormatFloat(s.Start, 'f', 3, 64),
+			"-t", strconv.FormatFloat(s.Duration, 'f', 3, 64),
+			audioPath)
+	}
+}
+
+// rewriteTranscripts writes result over the JSON transcript and over every
+// other format already derived from the same recording. Formats nobody asked
+// for are not created.
+func rewriteTranscripts(jsonPath string, result *transcribe.Result) ([]string, error) {
+	jsonPath, err := saveFile(encrypt.Plain(jsonPath), []byte(result.Format(transcribe.FormatJSON)))
+	if err != nil {
+		return nil, err
+	}
+	written := []string{jsonPath}
+	for _, f := range []transcribe.OutputFormat{transcribe.FormatText, transcribe.FormatSRT, transcribe.FormatVTT} {
+		path := transcriptPathFor(jsonPath, f)
+		if !savedFileExists(path) {
+			continue
+		}
+		path, err := saveFile(path, []byte(result.Format(f)))
+		if err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+func runLabelSpeakers(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	useEncryption(cfg)
+	jsonPath := args[0]
+	data, err := readFile(jsonPath)
+	if err != nil {
+		return err
+	}
+	var result transcribe.Result
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("%s is not a JSON transcript: %w", jsonPath, err)
+	}
+	if len(result.Speakers()) == 0 {
+		return fmt.Errorf("%s has no speaker labels: transcribe with --diarize -f json first", jsonPath)
+	}
+
+	md, err := meta.Load(jsonPath)
+	if err != nil {
+		return err
+	}
+
+	ui := resolveTUITarget()
+	defer ui.Close()
+	if !ui.Available {
+		return fmt.Errorf("label-speakers needs an interactive terminal")
+	}
+	warnTUITarget(ui)
+
+	audio := findAudioFor(jsonPath)
+	if audio != "" {
+		plain, cleanup, err := plainRecording(context.Background(), audio)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		audio = plain
+	}
+	res, err := tui.RunLabelSpeakers(speakerSamples(&result, md), ffplayPlayer(audio), ui.Options()...)
+	if err != nil {
+		return err
+	}
+	if res.Cancelled || len(res.Names) == 0 {
+		fmt.Fprintln(os.Stderr, "No speakers renamed.")
+		return nil
+	}
+
+	result.RenameSpeakers(res.Names)
+	written, err := rewriteTranscripts(jsonPath, &result)
+	if err != nil {
+		return err
+	}
+	md.SetSpeakerNames(res.Names)
+	if err := md.Save(jsonPath); err != nil {
+		return fmt.Errorf("saving speaker names: %w", err)
+	}
+	for _, p := range written {
+		fmt.Fprintf(os.Stderr, "Updated %s\n", p)
+	}
+	return nil
+}
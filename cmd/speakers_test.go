@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joegoldin/audiomemo/internal/meta"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+func TestParseSpeakerNames(t *testing.T) {
+	got := parseSpeakerNames(" Alice, ,Carol ")
+	want := []string{"Alice", "", "Carol"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if parseSpeakerNames("  ") != nil {
+		t.Error("expected no names for a blank flag")
+	}
+}
+
+func TestApplySpeakerNamesNeedsDiarization(t *testing.T) {
+	r := &transcribe.Result{Segments: []transcribe.Segment{{Text: "hello"}}}
+	if _, err := applySpeakerNames(r, []string{"Alice"}); err == nil {
+		t.Error("expected an error for a transcript without speakers")
+	}
+}
+
+func TestSpeakerSamplesPicksLongestTurn(t *testing.T) {
+	r := &transcribe.Result{Segments: []transcribe.Segment{
+		{Start: 0, End: 1, Text: "yeah", Speaker: "S0"},
+		{Start: 1, End: 4, Text: "let me explain", Speaker: "S1"},
+		{Start: 4, End: 30, Text: strings.Repeat("word ", 40), Speaker: "S0"},
+	}}
+	md := &meta.Metadata{Speakers: map[string]string{"S1": "Bob"}}
+	got := speakerSamples(r, md)
+	if len(got) != 2 || got[0].Label != "S0" || got[1].Label != "S1" {
+		t.Fatalf("samples = %+v", got)
+	}
+	if got[0].Start != 4 || got[0].Duration != maxSpeakerSample {
+		t.Errorf("S0 sample at %v for %v, want 4 for %v", got[0].Start, got[0].Duration, maxSpeakerSample)
+	}
+	if n := len([]rune(got[0].Quote)); n > maxSpeakerQuote {
+		t.Errorf("quote is %d runes, want at most %d", n, maxSpeakerQuote)
+	}
+	if got[1].Name != "Bob" {
+		t.Errorf("S1 name = %q, want Bob from metadata", got[1].Name)
+	}
+}
+
+func TestRewriteTranscriptsOnlyTouchesExistingFormats(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "memo.json")
+	srtPath := filepath.Join(dir, "memo.srt")
+	os.WriteFile(jsonPath, []byte("{}"), 0644)
+	os.WriteFile(srtPath, []byte("old"), 0644)
+
+	r := &transcribe.Result{Text: "hi", Segments: []transcribe.Segment{{Start: 0, End: 1, Text: "hi", Speaker: "Alice"}}}
+	written, err := rewriteTranscripts(jsonPath, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(written, []string{jsonPath, srtPath}) {
+		t.Errorf("written = %v", written)
+	}
+	if data, _ := os.ReadFile(srtPath); !strings.Contains(string(data), "Alice") {
+		t.Errorf("srt not rewritten with speaker name:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "memo.txt")); err == nil {
+		t.Error("rewrite created a text transcript nobody asked for")
+	}
+}
+
+func TestFindAudioFor(t *testing.T) {
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "memo.ogg")
+	os.WriteFile(audio, nil, 0644)
+	if got := findAudioFor(filepath.Join(dir, "memo.json")); got != audio {
+		t.Errorf("got %q, want %q", got, audio)
+	}
+	if got := findAudioFor(filepath.Join(dir, "other.json")); got != "" {
+		t.Errorf("expected no audio, got %q", got)
+	}
+
+	// A screen recording is found too, after any audio of the same name.
+	video := filepath.Join(dir, "screencast.mkv")
+	os.WriteFile(video, nil, 0644)
+	if got := findAudioFor(filepath.Join(dir, "screencast.srt")); got != video {
+		t.Errorf("got %q, want %q", got, video)
+	}
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/encrypt"
+	"github.com/joegoldin/audiomemo/internal/summarize"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sConfig    string
+	sAutoLabel bool
+	sQuiet     bool
+)
+
+var summarizeCmd = &cobra.Command{
+	Use:   "summarize <transcript|recording>",
+	Short: "Summarise a transcript with an LLM",
+	Long: `Ask an OpenAI-compatible chat endpoint for a summary, the action items and a
+suggested title, and save them as <base>.summary.md next to the recording.
+
+Takes a transcript (.json, .txt, .srt, .vtt) or a recording, in which case
+the transcript saved next to it is used. The endpoint, model and prompt
+templates come from the [summarize] config section; a local llama.cpp or
+Ollama server works as well as OpenAI.
+
+With --auto-label (or summarize.auto_label), the recording and the files
+derived from it are renamed to include the suggested title.
+
+Examples:
+  audiomemo summarize ~/Recordings/recording-2026-08-18T14-30-05.json
+  audiomemo summarize --auto-label ~/Recordings/recording-2026-08-18T14-30-05.ogg`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSummarizeCmd,
+}
+
+func init() {
+	summarizeCmd.Flags().StringVar(&sConfig, "config", "", "config file path")
+	summarizeCmd.Flags().BoolVar(&sAutoLabel, "auto-label", false, "rename the recording with the suggested title (summarize.auto_label)")
+	summarizeCmd.Flags().BoolVarP(&sQuiet, "quiet", "q", false, "save the summary without printing it to stdout")
+}
+
+// recordingSuffixes are the files derived from a recording, named after it
+// with these suffixes in place of its extension. They move with it when it
+// is relabelled.
+var recordingSuffixes = []string{".txt", ".json", ".srt", ".vtt", "-live.txt", ".meta.json", ".summary.md", ".redactions.json"}
+
+// summaryPathFor returns <base>.summary.md for a recording or any file
+// derived from it.
+func summaryPathFor(path string) string {
+	return recordingBase(path) + ".summary.md"
+}
+
+// loadSummaryInput reads the transcript to summarise. A recording is resolved
+// to the transcript saved beside it, JSON first because it keeps speakers.
+func loadSummaryInput(path string) (summarize.Data, error) {
+	if isRecording(path) {
+		base := recordingBase(path)
+		found := ""
+		for _, ext := range []string{".json", ".txt"} {
+			if savedFileExists(base + ext) {
+				found = base + ext
+				break
+			}
+		}
+		if found == "" {
+			return summarize.Data{}, fmt.Errorf("no transcript found for %s: run transcribe first", path)
+		}
+		path = found
+	}
+
+	data, err := readFile(path)
+	if err != nil {
+		return summarize.Data{}, err
+	}
+	if filepath.Ext(encrypt.Plain(path)) == ".json" {
+		var r transcribe.Result
+		if err := json.Unmarshal(data, &r); err != nil {
+			return summarize.Data{}, fmt.Errorf("%s is not a JSON transcript: %w", path, err)
+		}
+		return summaryData(&r), nil
+	}
+	return summarize.Data{Transcript: string(data)}, nil
+}
+
+// summaryData renders a result as the model should see it: plain text, with
+// speaker names when there are any, since "who said what" is most of what an
+// action item needs.
+func summaryData(r *transcribe.Result) summarize.Data {
+	return summarize.Data{Transcript: r.Format(transcribe.FormatText), Language: r.Language}
+}
+
+func summarizePrompts(cfg *config.Config) summarize.Prompts {
+	p := cfg.Summarize.Prompts
+	return summarize.Prompts{Summary: p.Summary, ActionItems: p.ActionItems, Title: p.Title}
+}
+
+func runSummary(ctx context.Context, cfg *config.Config, data summarize.Data) (*summarize.Summary, error) {
+	client := summarize.NewClient(cfg.Summarize.BaseURL, cfg.Summarize.APIKey, cfg.Summarize.Model)
+	return summarize.Summarize(ctx, client, summarizePrompts(cfg), data)
+}
+
+// relabelRecording renames a recording to include label, and every file
+// derived from it along with it, so the transcript and summary keep sharing
+// the recording's base name.
+func relabelRecording(audioPath, label string) (string, error) {
+	if label == "" {
+		return audioPath, nil
+	}
+	renamed, err := renameWithLabel(audioPath, label)
+	if err != nil || renamed == audioPath {
+		return renamed, err
+	}
+	oldBase := recordingBase(audioPath)
+	newBase := recordingBase(renamed)
+	suffixes := slices.Clone(recordingSuffixes)
+	// Translations carry their language in the name: <base>.<lang>.<ext>.
+	matches, _ := filepath.Glob(oldBase + ".*")
+	for _, m := range matches {
+		if suffix := strings.TrimPrefix(m, oldBase); translationSuffix.MatchString(suffix) {
+			suffixes = append(suffixes, suffix)
+		}
+	}
+	for _, suffix := range suffixes {
+		for _, suffix := range []string{suffix, suffix + encrypt.Ext} {
+			err := os.Rename(oldBase+suffix, newBase+suffix)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return renamed, err
+			}
+		}
+	}
+	return renamed, nil
+}
+
+var translationSuffix = regexp.MustCompile(`^\.[a-z]{2,3}(-[a-z0-9]+)?\.(txt|json|srt|vtt)(\.age)?$`)
+
+// saveSummary writes the summary next to the recording and, when asked,
+// relabels the recording with its title. It returns the recording's path
+// afterwards, which is path itself unless it was relabelled.
+func saveSummary(s *summarize.Summary, path string, autoLabel bool) (string, error) {
+	summaryPath, err := saveFile(summaryPathFor(path), []byte(s.Markdown()))
+	if err != nil {
+		return path, err
+	}
+	fmt.Fprintf(os.Stderr, "Saved summary to %s\n", summaryPath)
+	if !autoLabel {
+		return path, nil
+	}
+	audio := path
+	if !isRecording(path) {
+		if audio = findAudioFor(path); audio == "" {
+			return path, fmt.Errorf("cannot auto-label: no recording found next to %s", path)
+		}
+	}
+	renamed, err := relabelRecording(audio, s.Label())
+	if err != nil {
+		return path, fmt.Errorf("failed to rename recording: %w", err)
+	}
+	if renamed != audio {
+		fmt.Fprintf(os.Stderr, "Renamed recording to %s\n", filepath.Base(renamed))
+	}
+	return renamed, nil
+}
+
+func runSummarizeCmd(cmd *cobra.Command, args []string) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	var cfg *config.Config
+	var err error
+	if sConfig != "" {
+		cfg, err = config.LoadFrom(sConfig)
+	} else {
+		cfg, err = config.Load()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	cfg.ApplyEnv()
+	useEncryption(cfg)
+
+	data, err := loadSummaryInput(args[0])
+	if err != nil {
+		return err
+	}
+	s, err := runSummary(ctx, cfg, data)
+	if err != nil {
+		return fmt.Errorf("summarize: %w", err)
+	}
+	if !sQuiet {
+		fmt.Print(s.Markdown())
+	}
+
+	autoLabel := cfg.Summarize.AutoLabel
+	if cmd.Flags().Changed("auto-label") {
+		autoLabel = sAutoLabel
+	}
+	_, err = saveSummary(s, args[0], autoLabel)
+	return err
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSummaryInputFromRecording(t *testing.T) {
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "memo.ogg")
+	os.WriteFile(audio, nil, 0644)
+	os.WriteFile(filepath.Join(dir, "memo.txt"), []byte("plain"), 0644)
+	os.WriteFile(filepath.Join(dir, "memo.json"), []byte(`{
+		"text": "hi there",
+		"language": "en",
+		"segments": [{"start": 0, "end": 1, "text": "hi there", "speaker": "Alice"}]
+	}`), 0644)
+
+	data, err := loadSummaryInput(audio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Transcript != "Alice: hi there" || data.Language != "en" {
+		t.Errorf("got %+v, want the JSON transcript with speakers", data)
+	}
+}
+
+func TestLoadSummaryInputWithoutTranscript(t *testing.T) {
+	audio := filepath.Join(t.TempDir(), "memo.ogg")
+	os.WriteFile(audio, nil, 0644)
+	if _, err := loadSummaryInput(audio); err == nil || !strings.Contains(err.Error(), "run transcribe first") {
+		t.Errorf("expected a missing-transcript error, got %v", err)
+	}
+}
+
+func TestRelabelRecordingMovesDerivedFiles(t *testing.T) {
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "recording-2026-01-01T10-00-00.ogg")
+	for _, name := range []string{
+		"recording-2026-01-01T10-00-00.ogg",
+		"recording-2026-01-01T10-00-00.txt",
+		"recording-2026-01-01T10-00-00-live.txt",
+		"recording-2026-01-01T10-00-00.summary.md",
+		"recording-2026-01-01T10-00-00.de.srt",
+		"recording-2026-01-01T10-00-00-other.txt",
+	} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+
+	renamed, err := relabelRecording(audio, "release-planning")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(renamed) != "recording-2026-01-01T10-00-00-release-planning.ogg" {
+		t.Errorf("renamed to %s", renamed)
+	}
+	for _, name := range []string{
+		"recording-2026-01-01T10-00-00-release-planning.txt",
+		"recording-2026-01-01T10-00-00-release-planning-live.txt",
+		"recording-2026-01-01T10-00-00-release-planning.summary.md",
+		"recording-2026-01-01T10-00-00-release-planning.de.srt",
+		"recording-2026-01-01T10-00-00-other.txt",
+	} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s: %v", name, err)
+		}
+	}
+}
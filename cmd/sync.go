@@ -0,0 +1,321 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/encrypt"
+	"github.com/joegoldin/audiomemo/internal/s3"
+	"github.com/spf13/cobra"
+)
+
+var syConfig string
+
+var syncCmd = &cobra.Command{
+	Use:   "sync [recording...]",
+	Short: "Back recordings up to the S3-compatible bucket in [sync.s3]",
+	Long: `Upload the output directory to the bucket configured in [sync.s3], such as
+a MinIO server's: every recording with its transcripts, translations,
+summary and metadata sidecar. Given recordings, only they and their files
+are uploaded.
+
+Each object carries the SHA-256 of its file, and one whose content is
+already in the bucket is skipped, so a sync after the first sends only
+what changed. Large files go up in parts; an upload that was interrupted
+carries on from the last part that made it when sync is run again.
+Encrypted files are uploaded as they are, still encrypted.
+
+With auto = true under [sync], record and transcribe sync each recording
+as they finish with it.
+
+Examples:
+  audiomemo sync
+  audiomemo sync ~/Recordings/standup-2026-10-18T09-30-00.ogg
+  audiomemo transcribe "$(audiomemo sync pull standup-2026-10-18T09-30-00)"`,
+	RunE: runSync,
+}
+
+var syncPullCmd = &cobra.Command{
+	Use:   "pull <recording>...",
+	Short: "Fetch recordings back from the bucket",
+	Long: `Download each recording, named as it is in the bucket with or without its
+extension, into the output directory with its transcripts and sidecar,
+and print the recording's path, ready for transcribe. Files that are
+already there with the same content are left alone, and an interrupted
+download carries on from where it stopped.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runSyncPull,
+}
+
+func init() {
+	syncCmd.PersistentFlags().StringVar(&syConfig, "config", "", "config file path")
+	syncCmd.AddCommand(syncPullCmd)
+}
+
+func loadSyncConfig() (*config.Config, error) {
+	var cfg *config.Config
+	var err error
+	if syConfig != "" {
+		cfg, err = config.LoadFrom(syConfig)
+	} else {
+		cfg, err = config.Load()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return cfg, nil
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	cfg, err := loadSyncConfig()
+	if err != nil {
+		return err
+	}
+	b, err := newSyncBucket(cfg)
+	if err != nil {
+		return err
+	}
+	var files []string
+	if len(args) == 0 {
+		if files, err = syncableFiles(cfg.ResolveOutputDir()); err != nil {
+			return err
+		}
+	} else {
+		for _, arg := range args {
+			fs, err := recordingFiles(arg)
+			if err != nil {
+				return err
+			}
+			if len(fs) == 0 {
+				return fmt.Errorf("%s: no such recording", arg)
+			}
+			files = append(files, fs...)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return b.push(ctx, files, os.Stderr)
+}
+
+func runSyncPull(cmd *cobra.Command, args []string) error {
+	cfg, err := loadSyncConfig()
+	if err != nil {
+		return err
+	}
+	b, err := newSyncBucket(cfg)
+	if err != nil {
+		return err
+	}
+	dir := cfg.ResolveOutputDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	for _, arg := range args {
+		audio, err := b.pull(ctx, arg, dir, os.Stderr)
+		if err != nil {
+			return err
+		}
+		fmt.Println(audio)
+	}
+	return nil
+}
+
+// syncBucket is the [sync.s3] bucket, and the prefix recordings are kept
+// under in it.
+type syncBucket struct {
+	client *s3.Client
+	prefix string
+}
+
+// newSyncBucket connects to [sync.s3] with its credentials file, or the
+// AWS_* environment variables when it names none.
+func newSyncBucket(cfg *config.Config) (*syncBucket, error) {
+	sc := cfg.ResolveS3()
+	if sc.Endpoint == "" || sc.Bucket == "" {
+		return nil, fmt.Errorf("no bucket to sync to: set endpoint and bucket under [sync.s3]")
+	}
+	creds := s3.CredentialsFromEnv()
+	if sc.CredentialsFile != "" {
+		var err error
+		if creds, err = s3.LoadCredentials(sc.CredentialsFile, sc.Profile); err != nil {
+			return nil, err
+		}
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, fmt.Errorf("no S3 credentials: set credentials_file under [sync.s3], or AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	prefix := strings.Trim(sc.Prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return &syncBucket{
+		client: &s3.Client{Endpoint: sc.Endpoint, Region: sc.Region, Bucket: sc.Bucket, Credentials: creds},
+		prefix: prefix,
+	}, nil
+}
+
+// String is the bucket as s3://bucket/prefix, for messages.
+func (b *syncBucket) String() string {
+	return "s3://" + b.client.Bucket + "/" + b.prefix
+}
+
+// push uploads files, each to its name under the prefix, listing on w what
+// it sends. A file that fails does not stop the rest.
+func (b *syncBucket) push(ctx context.Context, files []string, w io.Writer) error {
+	sent, unchanged, failed := 0, 0, 0
+	for _, path := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name := filepath.Base(path)
+		outcome, err := b.client.Upload(ctx, b.prefix+name, path)
+		switch {
+		case err != nil:
+			fmt.Fprintf(w, "failed    %s: %v\n", name, err)
+			failed++
+		case outcome == s3.Unchanged:
+			unchanged++
+		default:
+			fmt.Fprintf(w, "%-9s %s\n", outcome, name)
+			sent++
+		}
+	}
+	fmt.Fprintf(w, "Synced %d of %d files to %s (%d unchanged)\n", sent, len(files), b, unchanged)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed to sync", failed, len(files))
+	}
+	return nil
+}
+
+// pull downloads the recording named name and its files into dir, listing
+// on w what it fetches, and returns the recording's path.
+func (b *syncBucket) pull(ctx context.Context, name, dir string, w io.Writer) (string, error) {
+	base := recordingBase(filepath.Base(name))
+	objs, err := b.client.List(ctx, b.prefix+base)
+	if err != nil {
+		return "", err
+	}
+	audio := ""
+	var keys []string
+	for _, o := range objs {
+		file := strings.TrimPrefix(o.Key, b.prefix)
+		if strings.Contains(file, "/") || !derivedFrom(base, file) {
+			continue
+		}
+		keys = append(keys, o.Key)
+		if isRecordingName(base, file) {
+			audio = filepath.Join(dir, file)
+		}
+	}
+	if audio == "" {
+		return "", fmt.Errorf("%s: no such recording in %s", name, b)
+	}
+	for _, key := range keys {
+		file := strings.TrimPrefix(key, b.prefix)
+		outcome, err := b.client.Download(ctx, key, filepath.Join(dir, file))
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", file, err)
+		}
+		if outcome != s3.Unchanged {
+			fmt.Fprintf(w, "%-9s %s\n", outcome, file)
+		}
+	}
+	return audio, nil
+}
+
+// autoSync backs the recording at audioPath up with its files when [sync]
+// has auto on. A failure is only a warning: the files are safe on disk, and
+// the next sync sends them.
+func autoSync(ctx context.Context, cfg *config.Config, audioPath string, warn func(error)) {
+	if !cfg.Sync.Auto || audioPath == "" {
+		return
+	}
+	b, err := newSyncBucket(cfg)
+	if err != nil {
+		warn(fmt.Errorf("sync: %w", err))
+		return
+	}
+	files, err := recordingFiles(audioPath)
+	if err != nil {
+		warn(fmt.Errorf("sync: %w", err))
+		return
+	}
+	for _, path := range files {
+		if _, err := b.client.Upload(ctx, b.prefix+filepath.Base(path), path); err != nil {
+			warn(fmt.Errorf("sync %s: %w", filepath.Base(path), err))
+		}
+	}
+}
+
+// syncableFiles lists the files in dir that sync uploads: every regular
+// file but the hidden ones, which are partial writes and the like.
+func syncableFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if e.Type().IsRegular() && !strings.HasPrefix(e.Name(), ".") {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+	return files, nil
+}
+
+// recordingFiles lists the recording at path and the files beside it that
+// were derived from it.
+func recordingFiles(path string) ([]string, error) {
+	dir := filepath.Dir(path)
+	base := recordingBase(filepath.Base(path))
+	files, err := syncableFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, f := range files {
+		if derivedFrom(base, filepath.Base(f)) {
+			out = append(out, f)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// recordingName matches what follows a recording's base name in its own
+// file name, and in a copy of it such as --embed-subs and --bleep make.
+var recordingName = regexp.MustCompile(`^(-subtitled|-redacted)?\.[A-Za-z0-9]+$`)
+
+// derivedFrom reports whether the file named name is the recording whose
+// base name is base, or one of the files derived from it.
+func derivedFrom(base, name string) bool {
+	rest, ok := strings.CutPrefix(encrypt.Plain(name), base)
+	if !ok {
+		return false
+	}
+	return slices.Contains(recordingSuffixes, rest) || translationSuffix.MatchString(rest) || recordingName.MatchString(rest)
+}
+
+// isRecordingName reports whether the file named name is the recording
+// whose base name is base itself, rather than a file derived from it.
+func isRecordingName(base, name string) bool {
+	rest, ok := strings.CutPrefix(encrypt.Plain(name), base)
+	if !ok || slices.Contains(recordingSuffixes, rest) || translationSuffix.MatchString(rest) {
+		return false
+	}
+	return !strings.HasPrefix(rest, "-") && recordingName.MatchString(rest)
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/s3/s3test"
+)
+
+func testSyncConfig(t *testing.T) (*config.Config, *s3test.Server) {
+	t.Helper()
+	srv := s3test.NewServer("recordings", "minioadmin")
+	t.Cleanup(srv.Close)
+	creds := filepath.Join(t.TempDir(), "credentials")
+	os.WriteFile(creds, []byte("[default]\naws_access_key_id = minioadmin\naws_secret_access_key = minio-secret\n"), 0600)
+	cfg := config.Default()
+	cfg.Record.OutputDir = t.TempDir()
+	cfg.Sync.S3 = config.S3Config{Endpoint: srv.URL, Bucket: "recordings", Prefix: "joe", CredentialsFile: creds}
+	return cfg, srv
+}
+
+func TestRecordingFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"standup.ogg", "standup.txt.age", "standup.meta.json", "standup-live.txt",
+		"standup.summary.md", "standup.fr.srt", "standup-subtitled.mp4",
+		"standup-redacted.ogg", "standup.redactions.json", "standup-2.ogg", "standup-2.txt", ".pulling-standup.ogg", "notes.md",
+	} {
+		os.WriteFile(filepath.Join(dir, name), []byte(name), 0644)
+	}
+	files, err := recordingFiles(filepath.Join(dir, "standup.ogg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(f))
+	}
+	want := []string{
+		"standup-live.txt", "standup-redacted.ogg", "standup-subtitled.mp4", "standup.fr.srt",
+		"standup.meta.json", "standup.ogg", "standup.redactions.json", "standup.summary.md", "standup.txt.age",
+	}
+	if !slices.Equal(names, want) {
+		t.Errorf("recordingFiles = %q, want %q", names, want)
+	}
+	if !isRecordingName("standup", "standup.ogg.age") || isRecordingName("standup", "standup.txt") || isRecordingName("standup", "standup-subtitled.mp4") || isRecordingName("standup", "standup-redacted.ogg") {
+		t.Error("isRecordingName picked the wrong file")
+	}
+}
+
+func TestSyncPushAndPull(t *testing.T) {
+	cfg, srv := testSyncConfig(t)
+	dir := cfg.ResolveOutputDir()
+	audio := filepath.Join(dir, "standup-2026-10-18T09-30-00.ogg")
+	os.WriteFile(audio, []byte("OggS"), 0644)
+	os.WriteFile(transcriptPathFor(audio, "txt"), []byte("Morning.\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "other.ogg"), []byte("OggS"), 0644)
+
+	b, err := newSyncBucket(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _ := syncableFiles(dir)
+	var out strings.Builder
+	if err := b.push(t.Context(), files, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Synced 3 of 3 files to s3://recordings/joe/ (0 unchanged)") {
+		t.Errorf("output:\n%s", out.String())
+	}
+	if data, ok := srv.Object("joe/standup-2026-10-18T09-30-00.txt"); !ok || string(data) != "Morning.\n" {
+		t.Errorf("transcript object = %q, %v", data, ok)
+	}
+	out.Reset()
+	b.push(t.Context(), files, &out)
+	if !strings.Contains(out.String(), "Synced 0 of 3 files") {
+		t.Errorf("second sync:\n%s", out.String())
+	}
+
+	elsewhere := t.TempDir()
+	got, err := b.pull(t.Context(), "standup-2026-10-18T09-30-00", elsewhere, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != filepath.Join(elsewhere, "standup-2026-10-18T09-30-00.ogg") {
+		t.Errorf("pull = %s", got)
+	}
+	if data, _ := os.ReadFile(filepath.Join(elsewhere, "standup-2026-10-18T09-30-00.txt")); string(data) != "Morning.\n" {
+		t.Errorf("pulled transcript = %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(elsewhere, "other.ogg")); !os.IsNotExist(err) {
+		t.Error("pull fetched another recording")
+	}
+	if _, err := b.pull(t.Context(), "missing", elsewhere, io.Discard); err == nil || !strings.Contains(err.Error(), "no such recording") {
+		t.Errorf("pull of a missing recording = %v", err)
+	}
+}
+
+func TestAutoSync(t *testing.T) {
+	cfg, srv := testSyncConfig(t)
+	audio := filepath.Join(cfg.ResolveOutputDir(), "standup.ogg")
+	os.WriteFile(audio, []byte("OggS"), 0644)
+	warn := func(err error) { t.Errorf("warned: %v", err) }
+
+	autoSync(t.Context(), cfg, audio, warn)
+	if _, ok := srv.Object("joe/standup.ogg"); ok {
+		t.Error("synced with auto off")
+	}
+	cfg.Sync.Auto = true
+	autoSync(t.Context(), cfg, audio, warn)
+	if _, ok := srv.Object("joe/standup.ogg"); !ok {
+		t.Error("not synced with auto on")
+	}
+
+	cfg.Sync.S3.Bucket = ""
+	var warned error
+	autoSync(t.Context(), cfg, audio, func(err error) { warned = err })
+	if warned == nil || !strings.Contains(warned.Error(), "[sync.s3]") {
+		t.Errorf("warning = %v", warned)
+	}
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/audiotag"
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/encrypt"
+	"github.com/joegoldin/audiomemo/internal/meta"
+	"github.com/joegoldin/audiomemo/internal/summarize"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+	"github.com/spf13/cobra"
+)
+
+var tgChapters string
+
+var tagCmd = &cobra.Command{
+	Use:   "tag <recording>",
+	Short: "Write a recording's transcript and chapters into its metadata",
+	Long: `Write the transcript saved next to a recording into the recording itself,
+so that it goes wherever the audio goes. The file is remuxed with ffmpeg; the
+audio is copied, not re-encoded.
+
+Ogg, Opus and FLAC get Vorbis comments: LYRICS, TITLE and DATE. MP3 gets
+ID3v2.4 USLT lyrics and SYLT lyrics timed to each segment. M4A gets the
+iTunes lyrics atom. Every format also carries the JSON transcript under
+AUDIOMEMO_TRANSCRIPT, which transcribe --use-embedded reads back, and
+chapter markers: one per bookmark made while recording, or else one per
+speaker's turn.
+
+The title is the one --summarize suggested, or else the recording's label.
+Tags the file already has are kept.
+
+transcribe --embed, or embed = true under [transcribe], does the same after
+every transcription.
+
+Examples:
+  audiomemo tag ~/Recordings/standup-2026-10-18T09-30-00.ogg
+  audiomemo tag --chapters none interview.mp3`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTag,
+}
+
+func init() {
+	tagCmd.Flags().StringVar(&tgChapters, "chapters", chaptersAuto, "chapter markers from: auto (bookmarks, else speaker turns), marks, segments or none")
+}
+
+// The sources of a recording's chapters.
+const (
+	chaptersAuto     = "auto"
+	chaptersMarks    = "marks"
+	chaptersSegments = "segments"
+	chaptersNone     = "none"
+)
+
+func runTag(cmd *cobra.Command, args []string) error {
+	if err := validateChapters(tgChapters); err != nil {
+		return err
+	}
+	audio := args[0]
+	if encrypt.IsEncrypted(audio) {
+		return fmt.Errorf("cannot tag %s: it is encrypted, and tagging would write it out in plaintext", audio)
+	}
+	if !audioExtensions[strings.ToLower(filepath.Ext(audio))] {
+		return fmt.Errorf("%s is not a recording: tag writes into the audio file", audio)
+	}
+	if _, err := audiotag.KindOf(audio); err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	useEncryption(cfg)
+	result, _, err := loadSavedTranscript(audio)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := embedTranscript(ctx, audio, result, tgChapters); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Tagged %s\n", audio)
+	return nil
+}
+
+func validateChapters(mode string) error {
+	switch mode {
+	case chaptersAuto, chaptersMarks, chaptersSegments, chaptersNone:
+		return nil
+	}
+	return fmt.Errorf("invalid --chapters %q: use auto, marks, segments or none", mode)
+}
+
+// embedTranscript writes result into the recording at path, with the speaker
+// names from its sidecar, a title, the day it was made, and the chapters mode
+// asks for.
+func embedTranscript(ctx context.Context, path string, result *transcribe.Result, mode string) error {
+	md, err := meta.Load(path)
+	if err != nil {
+		return err
+	}
+	r := *result
+	r.Segments = append([]transcribe.Segment(nil), result.Segments...)
+	r.RenameSpeakers(md.Speakers)
+
+	duration := r.Duration
+	if duration == 0 {
+		probeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		duration, _ = transcribe.ProbeDuration(probeCtx, path)
+		cancel()
+	}
+
+	t := audiotag.Tags{
+		Title:    labelTitle(path),
+		Date:     recordingTime(path),
+		Result:   &r,
+		Chapters: recordingChapters(md, r.Segments, duration, mode),
+	}
+	if data, err := readFile(summaryPathFor(path)); err == nil {
+		if s := summarize.ParseMarkdown(string(data)); s.Title != "" {
+			t.Title = s.Title
+		}
+	}
+	return audiotag.Write(ctx, path, t)
+}
+
+// recordingChapters picks the chapters for a recording: its bookmarks, or
+// its speakers' turns, or whichever it has of the two for auto.
+func recordingChapters(md *meta.Metadata, segs []transcribe.Segment, duration float64, mode string) []audiotag.Chapter {
+	marks := make([]float64, len(md.Marks))
+	for i, m := range md.Marks {
+		marks[i] = m.At
+	}
+	switch mode {
+	case chaptersMarks:
+		return audiotag.ChaptersFromMarks(marks, duration)
+	case chaptersSegments:
+		return audiotag.ChaptersFromSegments(segs, duration)
+	case chaptersAuto:
+		if len(marks) > 0 {
+			return audiotag.ChaptersFromMarks(marks, duration)
+		}
+		return audiotag.ChaptersFromSegments(segs, duration)
+	}
+	return nil
+}
+
+// labelTitle is the label record gave a recording, read back from its name
+// with the time taken out: "standup-2026-10-18T09-30-00.ogg" is "standup".
+// An unlabelled recording has no title.
+func labelTitle(path string) string {
+	base := recordingStamp.ReplaceAllString(hookLabel(path), "")
+	base = strings.Trim(strings.ReplaceAll(base, "--", "-"), "-")
+	if rest, ok := strings.CutPrefix(base, "recording"); ok && (rest == "" || rest[0] == '-') {
+		base = strings.TrimPrefix(rest, "-")
+	}
+	return strings.ReplaceAll(base, "-", " ")
+}
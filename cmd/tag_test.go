@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/joegoldin/audiomemo/internal/meta"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+func TestLabelTitle(t *testing.T) {
+	for path, want := range map[string]string{
+		"/r/standup-2026-10-18T09-30-00.ogg":               "standup",
+		"/r/recording-2026-10-18T09-30-00.ogg":             "",
+		"/r/recording-2026-10-18T09-30-00-Weekly-sync.ogg": "Weekly sync",
+		"/r/standup-002-2026-10-18T09-30-00.ogg":           "standup 002",
+		"/r/recordings-review.mp3":                         "recordings review",
+	} {
+		if got := labelTitle(path); got != want {
+			t.Errorf("labelTitle(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestRecordingChapters(t *testing.T) {
+	segs := []transcribe.Segment{
+		{Start: 0, End: 5, Text: "Hello.", Speaker: "Alice"},
+		{Start: 5, End: 9, Text: "Hi.", Speaker: "Bob"},
+	}
+	marked := &meta.Metadata{Marks: []meta.Mark{{At: 4}}}
+	if got := recordingChapters(marked, segs, 10, chaptersAuto); len(got) != 2 || got[1].Title != "Mark 1" {
+		t.Errorf("auto with marks = %+v", got)
+	}
+	if got := recordingChapters(&meta.Metadata{}, segs, 10, chaptersAuto); len(got) != 2 || got[1].Title != "Bob: Hi." {
+		t.Errorf("auto without marks = %+v", got)
+	}
+	if got := recordingChapters(marked, segs, 10, chaptersSegments); len(got) != 2 || got[0].Title != "Alice: Hello." {
+		t.Errorf("segments = %+v", got)
+	}
+	if got := recordingChapters(marked, segs, 10, chaptersNone); got != nil {
+		t.Errorf("none = %+v", got)
+	}
+	if err := validateChapters("bookmarks"); err == nil {
+		t.Error("want an error for an unknown --chapters")
+	}
+}
+
+func TestTranscribeOrReadEmbeddedFallsBack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memo.wav")
+	os.WriteFile(path, []byte("not audio"), 0644)
+	backend := &fakeBackend{name: "fake", result: &transcribe.Result{Text: "Transcribed."}}
+	// Whether ffprobe is missing or finds nothing to read, the recording is
+	// transcribed instead.
+	r, source, err := transcribeOrReadEmbedded(context.Background(), backend, path, transcribe.TranscribeOpts{}, true)
+	if err != nil || r.Text != "Transcribed." || source != "fake" {
+		t.Errorf("got %+v, %q, %v", r, source, err)
+	}
+	backend.err = errors.New("quota")
+	if _, _, err := transcribeOrReadEmbedded(context.Background(), backend, path, transcribe.TranscribeOpts{}, false); err == nil {
+		t.Error("want the backend's error")
+	}
+}
+
+type fakeBackend struct {
+	name   string
+	result *transcribe.Result
+	err    error
+}
+
+func (f *fakeBackend) Transcribe(ctx context.Context, path string, opts transcribe.TranscribeOpts) (*transcribe.Result, error) {
+	return f.result, f.err
+}
+
+func (f *fakeBackend) Name() string { return f.name }
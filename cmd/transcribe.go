@@ -2,17 +2,24 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"os/exec"
 	"os/signal"
-	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
 
+	"github.com/joegoldin/audiomemo/internal/audiotag"
 	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/encrypt"
+	"github.com/joegoldin/audiomemo/internal/hooks"
+	"github.com/joegoldin/audiomemo/internal/media"
+	"github.com/joegoldin/audiomemo/internal/stream"
+	"github.com/joegoldin/audiomemo/internal/summarize"
 	"github.com/joegoldin/audiomemo/internal/transcribe"
 	"github.com/spf13/cobra"
 )
@@ -33,6 +40,30 @@ var (
 	tNumerals    bool
 	tQuiet        bool
 	tStoreInCloud bool
+	tVocab        string
+	tSpeakers     string
+	tStripFillers bool
+	tReplace      bool
+	tFixCaps      bool
+	tParagraphs   bool
+	tRaw          bool
+	tSummarize    bool
+	tAutoLabel    bool
+	tTranslateTo  string
+	tTranslateVia string
+	tStream       bool
+	tLive         bool
+	tInputFormat  string
+	tInputRate    int
+	tInputChans   int
+	tVoiceCmds    bool
+	tExportNote   bool
+	tEmbed        bool
+	tUseEmbedded  bool
+	tAudioStream  int
+	tEmbedSubs    bool
+	tRedact       []string
+	tBleep        bool
 )
 
 var transcribeCmd = &cobra.Command{
@@ -47,13 +78,18 @@ Examples:
   transcribe -b elevenlabs -f srt interview.wav
   transcribe -b deepgram -f srt interview.wav
   transcribe -b whisper -l en lecture.mp3
-  cat audio.ogg | transcribe -`,
+  transcribe --audio-stream 1 --embed-subs screencast.mkv
+  transcribe --redact all --bleep support-call.ogg
+  cat audio.ogg | transcribe -
+  transcribe --stream -b whisper-cpp lecture.mp3
+  parec --format=s16le --rate=16000 --channels=1 | transcribe --live --input-format s16le -`,
 	Args: cobra.ExactArgs(1),
 	RunE: runTranscribe,
 }
 
 func init() {
 	transcribeCmd.AddCommand(transcribeLatestCmd)
+	transcribeCmd.AddCommand(transcribeLabelSpeakersCmd)
 	transcribeCmd.PersistentFlags().StringVarP(&tBackend, "backend", "b", "", "transcription backend (elevenlabs, whisper, whisper-cpp, whisperx, ffmpeg-whisper, deepgram, openai, mistral)")
 	transcribeCmd.PersistentFlags().StringVarP(&tModel, "model", "m", "", "model name (backend-specific)")
 	transcribeCmd.PersistentFlags().StringVarP(&tLanguage, "language", "l", "", "language hint (ISO 639-1)")
@@ -69,21 +105,51 @@ func init() {
 	transcribeCmd.PersistentFlags().BoolVar(&tNumerals, "numerals", false, "convert numbers to numerals (Deepgram)")
 	transcribeCmd.PersistentFlags().BoolVarP(&tQuiet, "quiet", "q", false, "save transcript to file without printing to stdout")
 	transcribeCmd.PersistentFlags().BoolVar(&tStoreInCloud, "store-in-cloud", false, "keep transcript stored in cloud provider (ElevenLabs)")
+	transcribeCmd.PersistentFlags().StringVar(&tVocab, "vocab", "", "file of names and terms to favour, one per line (added to transcribe.vocabulary)")
+	transcribeCmd.PersistentFlags().StringVar(&tSpeakers, "speakers", "", "comma-separated speaker names, in order of first appearance (needs --diarize)")
+	transcribeCmd.PersistentFlags().BoolVar(&tStripFillers, "strip-fillers", false, "remove filler words such as um and uh (transcribe.postprocess.remove_fillers)")
+	transcribeCmd.PersistentFlags().BoolVar(&tReplace, "replace", true, "apply the transcribe.postprocess.replace dictionary")
+	transcribeCmd.PersistentFlags().BoolVar(&tFixCaps, "fix-caps", false, "fix sentence capitalisation and spacing (transcribe.postprocess.fix_capitalization)")
+	transcribeCmd.PersistentFlags().BoolVar(&tParagraphs, "paragraphs", false, "merge segments into paragraphs at pauses (transcribe.postprocess.paragraphs)")
+	transcribeCmd.PersistentFlags().BoolVar(&tRaw, "raw", false, "skip all post-processing and keep the backend's output as is")
+	transcribeCmd.PersistentFlags().BoolVar(&tVoiceCmds, "voice-commands", false, "carry out dictation commands such as \"new paragraph\" and \"scratch that\" (record.voice_commands)")
+	transcribeCmd.PersistentFlags().BoolVar(&tSummarize, "summarize", false, "also save a summary, action items and title as <base>.summary.md")
+	transcribeCmd.PersistentFlags().StringVar(&tTranslateTo, "translate-to", "", "also save a translation into this language (ISO 639-1) as <base>.<lang>.<format>")
+	transcribeCmd.PersistentFlags().StringVar(&tTranslateVia, "translate-with", "auto", "how to translate: auto, native (backend, English only) or llm")
+	transcribeCmd.PersistentFlags().BoolVar(&tAutoLabel, "auto-label", false, "with --summarize, rename the recording with the suggested title (summarize.auto_label)")
+	transcribeCmd.PersistentFlags().BoolVar(&tExportNote, "export-note", false, "also write the recording as a Markdown note into export.notes.dir")
+	transcribeCmd.PersistentFlags().BoolVar(&tEmbed, "embed", false, "also write the transcript and chapters into the recording's tags (transcribe.embed)")
+	transcribeCmd.PersistentFlags().BoolVar(&tUseEmbedded, "use-embedded", false, "read the transcript embedded in the recording instead of transcribing it again")
+	transcribeCmd.PersistentFlags().IntVar(&tAudioStream, "audio-stream", 0, "audio stream of a video, or of a file with several, to transcribe, counting from 0")
+	transcribeCmd.PersistentFlags().BoolVar(&tEmbedSubs, "embed-subs", false, "also save a copy of the video with the transcript as a subtitle track, as <name>-subtitled.<ext>")
+	transcribeCmd.PersistentFlags().StringSliceVar(&tRedact, "redact", nil, "mask these in the transcript: email, phone, card, terms (transcribe.redact.terms), all or none (transcribe.redact.categories)")
+	transcribeCmd.PersistentFlags().BoolVar(&tBleep, "bleep", false, "with --redact, also save a copy of the audio with what was masked bleeped, as <name>-redacted.<ext> (transcribe.redact.bleep)")
+	transcribeCmd.Flags().BoolVar(&tStream, "stream", false, "emit progress, segments and the result as newline-delimited JSON on stdout")
+	transcribeCmd.Flags().BoolVar(&tLive, "live", false, "transcribe through the realtime API as the audio arrives, printing each line as it is committed")
+	transcribeCmd.Flags().StringVar(&tInputFormat, "input-format", "", "ffmpeg format of the --live input, for raw audio such as s16le (default: detect)")
+	transcribeCmd.Flags().IntVar(&tInputRate, "input-rate", 16000, "sample rate of raw --input-format audio")
+	transcribeCmd.Flags().IntVar(&tInputChans, "input-channels", 1, "channel count of raw --input-format audio")
 }
 
 func ExecuteTranscribe() {
 	rootCmd.SetArgs(append([]string{"transcribe"}, os.Args[1:]...))
-	if err := rootCmd.Execute(); err != nil {
+	if err := execute(); err != nil {
 		os.Exit(1)
 	}
 }
 
-func runTranscribe(cmd *cobra.Command, args []string) error {
+func runTranscribe(cmd *cobra.Command, args []string) (err error) {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
+	// With --stream every way out, failures included, ends the stream.
+	var em *stream.Emitter
+	if tStream {
+		em = stream.NewEmitter(os.Stdout)
+		defer func() { err = endTranscribeStream(ctx, em, args[0], err) }()
+	}
+
 	var cfg *config.Config
-	var err error
 	if tConfig != "" {
 		cfg, err = config.LoadFrom(tConfig)
 	} else {
@@ -93,11 +159,40 @@ func runTranscribe(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 	cfg.ApplyEnv()
+	useEncryption(cfg)
+
+	hookRunner, err := newHookRunner(cfg)
+	if err != nil {
+		return err
+	}
+	sender, endpoints, err := newWebhookSender(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		ev := hooks.Event{Hook: hooks.OnError, Command: "transcribe", Error: err.Error()}
+		if args[0] != "-" {
+			ev.Audio = args[0]
+			ev.Label = hookLabel(args[0])
+		}
+		runHook(context.Background(), hookRunner, ev, warnStderr)
+	}()
+
+	if err := validateLiveFlags(tLive, tBackend, tFormat, cmd.Flags().Changed); err != nil {
+		return err
+	}
+	if tLive {
+		return runTranscribeLive(ctx, cmd, cfg, em, args[0])
+	}
 
 	audioPath := args[0]
+	fromStdin := audioPath == "-"
 
 	// Handle stdin
-	if audioPath == "-" {
+	if fromStdin {
 		tmp, err := bufferStdin()
 		if err != nil {
 			return err
@@ -106,6 +201,24 @@ func runTranscribe(cmd *cobra.Command, args []string) error {
 		audioPath = tmp
 	}
 
+	// What the backend is given: the recording itself, decrypted if it is
+	// encrypted, or the audio taken out of a video. audioPath stays the
+	// recording, which the transcript and everything else is saved beside.
+	input, cleanup, err := plainRecording(ctx, audioPath)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	extracted := media.IsVideo(encrypt.Plain(args[0])) || cmd.Flags().Changed("audio-stream")
+	if extracted {
+		audio, err := extractAudio(ctx, input, tAudioStream, tVerbose)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(audio)
+		input = audio
+	}
+
 	// Apply --store-in-cloud override before creating backend.
 	if cmd.Flags().Changed("store-in-cloud") {
 		cfg.Transcribe.ElevenLabs.StoreInCloud = tStoreInCloud
@@ -117,46 +230,100 @@ func runTranscribe(cmd *cobra.Command, args []string) error {
 	}
 
 	// Merge config defaults with CLI flags for diarize/smart-format/punctuate.
-	// CLI flags override config defaults. Determine active backend name to read
-	// the correct config section.
-	diarize := tDiarize
-	smartFormat := tSmartFormat
-	punctuate := tPunctuate
+	// CLI flags override config defaults.
+	defaults := configOpts(cfg, backend.Name())
+	diarize := defaults.Diarize
+	smartFormat := defaults.SmartFormat
+	punctuate := defaults.Punctuate
+	fillerWords := defaults.FillerWords
+	numerals := defaults.Numerals
+
+	if cmd.Flags().Changed("diarize") {
+		diarize = tDiarize
+	}
+	if cmd.Flags().Changed("smart-format") {
+		smartFormat = tSmartFormat
+	}
+	if cmd.Flags().Changed("punctuate") {
+		punctuate = tPunctuate
+	}
+	if cmd.Flags().Changed("filler-words") {
+		fillerWords = tFillerWords
+	}
+	if cmd.Flags().Changed("numerals") {
+		numerals = tNumerals
+	}
+
+	vocabulary, err := resolveVocabulary(cfg.Transcribe.Vocabulary, tVocab)
+	if err != nil {
+		return err
+	}
 
-	if !cmd.Flags().Changed("diarize") {
-		switch backend.Name() {
-		case "elevenlabs":
-			diarize = cfg.Transcribe.ElevenLabs.Diarize
-		case "deepgram":
-			diarize = cfg.Transcribe.Deepgram.Diarize
-		case "whisperx":
-			diarize = cfg.Transcribe.Whisper.Diarize
+	// Built before transcribing so a bad pattern fails before the upload.
+	post, err := resolvePostProcess(cfg.Transcribe.PostProcess, cmd.Flags())
+	if err != nil {
+		return err
+	}
+	if tSummarize {
+		if err := summarizePrompts(cfg).Validate(); err != nil {
+			return err
 		}
 	}
-	if !cmd.Flags().Changed("smart-format") {
-		if backend.Name() == "deepgram" {
-			smartFormat = cfg.Transcribe.Deepgram.SmartFormat
+	var noteDir string
+	if tExportNote {
+		if fromStdin {
+			return fmt.Errorf("--export-note needs a recording to link to, not stdin")
 		}
+		if noteDir, err = notesDir(cfg); err != nil {
+			return err
+		}
+	}
+	embed := cfg.Transcribe.Embed
+	if cmd.Flags().Changed("embed") {
+		embed = tEmbed
 	}
-	if !cmd.Flags().Changed("punctuate") {
-		if backend.Name() == "deepgram" {
-			punctuate = cfg.Transcribe.Deepgram.Punctuate
+	if embed && fromStdin {
+		if cmd.Flags().Changed("embed") {
+			return fmt.Errorf("--embed writes into the recording, and stdin is not one")
 		}
+		embed = false
 	}
-
-	fillerWords := tFillerWords
-	numerals := tNumerals
-
-	if !cmd.Flags().Changed("filler-words") {
-		if backend.Name() == "deepgram" {
-			fillerWords = cfg.Transcribe.Deepgram.FillerWords
+	if embed && encrypt.IsEncrypted(audioPath) {
+		if cmd.Flags().Changed("embed") {
+			return fmt.Errorf("--embed writes into the recording, and %s is encrypted", filepath.Base(audioPath))
+		}
+		embed = false
+	}
+	if embed && cmd.Flags().Changed("embed") {
+		if _, err := audiotag.KindOf(audioPath); err != nil {
+			return err
 		}
 	}
-	if !cmd.Flags().Changed("numerals") {
-		if backend.Name() == "deepgram" {
-			numerals = cfg.Transcribe.Deepgram.Numerals
+	if tUseEmbedded && fromStdin {
+		return fmt.Errorf("--use-embedded reads the recording's tags, and stdin has none")
+	}
+	if tEmbedSubs {
+		if fromStdin {
+			return fmt.Errorf("--embed-subs copies the video with subtitles, and stdin is not one")
+		}
+		if encrypt.IsEncrypted(audioPath) {
+			return fmt.Errorf("--embed-subs would leave a plaintext copy of %s", filepath.Base(audioPath))
+		}
+		if err := media.CheckSubtitles(audioPath); err != nil {
+			return err
 		}
 	}
+	translateTo := transcribe.NormalizeLanguage(tTranslateTo)
+	var translateWith translateMode
+	if translateTo != "" {
+		if translateWith, err = resolveTranslateMode(tTranslateVia, translateTo, backend); err != nil {
+			return err
+		}
+	}
+	redactor, bleep, err := resolveRedactor(cfg.Transcribe.Redact, cmd.Flags(), fromStdin)
+	if err != nil {
+		return err
+	}
 
 	opts := transcribe.TranscribeOpts{
 		Model:       tModel,
@@ -168,12 +335,32 @@ func runTranscribe(cmd *cobra.Command, args []string) error {
 		Punctuate:   punctuate,
 		FillerWords: fillerWords,
 		Numerals:    numerals,
+		Vocabulary:  vocabulary,
+	}
+	// A backend that masks for itself is asked to, so what it masks is
+	// never in what it sends back; the local pass still catches the rest.
+	if redactor != nil && transcribe.NativeRedaction(backend) {
+		opts.Redact = redactor.Categories()
 	}
 
 	if tVerbose {
 		fmt.Fprintf(os.Stderr, "Transcribing with %s...\n", backend.Name())
 	}
 
+	var progress *streamProgress
+	if em != nil {
+		// Without a duration the start event leaves it out, and only a tool
+		// that counts for itself reports progress.
+		duration, _ := transcribe.ProbeDuration(ctx, input)
+		model := opts.Model
+		if model == "" {
+			model = configModel(cfg, backend.Name())
+		}
+		em.TranscribeStart(stream.TranscribeStartEvent{File: args[0], Backend: backend.Name(), Model: model, Duration: duration})
+		progress = newStreamProgress(em, duration)
+		opts.Progress = progress
+	}
+
 	start := time.Now()
 
 	// Show elapsed time ticker when verbose
@@ -194,26 +381,73 @@ func runTranscribe(cmd *cobra.Command, args []string) error {
 		}()
 	}
 
-	result, err := backend.Transcribe(ctx, audioPath, opts)
+	result, source, err := transcribeOrReadEmbedded(ctx, backend, input, opts, tUseEmbedded)
 	close(done)
 	if err != nil {
 		return err
 	}
+	if progress != nil {
+		progress.finish(result)
+	}
 
 	if tVerbose {
 		elapsed := time.Since(start).Truncate(time.Millisecond)
 		fmt.Fprintf(os.Stderr, "Done in %s\n", elapsed)
 	}
 
+	if names := parseSpeakerNames(tSpeakers); len(names) > 0 {
+		renamed, err := applySpeakerNames(result, names)
+		if err != nil {
+			return err
+		}
+		// A temp file from stdin has no recording to remember the names for.
+		if !fromStdin {
+			if err := saveSpeakerNames(audioPath, renamed); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to save speaker names: %v\n", err)
+			}
+		}
+	}
+
+	// Before the cleanup, which with paragraphs on rebuilds the text from
+	// the segments the commands have already thinned.
+	if tVoiceCmds {
+		transcribe.NewVoiceInterpreter(voiceCommandsFromConfig(cfg.Record.VoiceCommands)).Apply(result)
+	}
+
+	// After naming, so two labels given the same name merge into one
+	// paragraph like any other single speaker.
+	post.Apply(result)
+
+	// Last of all, so nothing after it can put back what it masks, and
+	// before anything is written.
+	var redactions []transcribe.Redaction
+	if redactor != nil {
+		native := source == backend.Name() && len(opts.Redact) > 0
+		redactions = redactor.Apply(result, native)
+		report := newRedactionReport(source, redactor.Categories(), redactions)
+		if !fromStdin {
+			if path, err := saveRedactionReport(audioPath, report); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to save the redaction report: %v\n", err)
+			} else if tVerbose {
+				fmt.Fprintf(os.Stderr, "Saved redaction report to %s\n", path)
+			}
+		}
+		fmt.Fprintln(os.Stderr, report)
+	}
+
 	output := result.Format(opts.Format)
 
 	// Auto-save transcript alongside the audio file.
+	savedPath := ""
 	if audioPath != "" && audioPath != "-" {
-		transcriptPath := transcriptPathFor(audioPath, opts.Format)
-		if err := os.WriteFile(transcriptPath, []byte(output), 0644); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to save transcript to %s: %v\n", transcriptPath, err)
-		} else if tVerbose {
-			fmt.Fprintf(os.Stderr, "Saved transcript to %s\n", transcriptPath)
+		transcriptPath, err := saveFile(transcriptPathFor(audioPath, opts.Format), []byte(output))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save transcript to %s: %v\n", transcriptPathFor(audioPath, opts.Format), err)
+		} else {
+			savedPath = transcriptPath
+			if tVerbose {
+				fmt.Fprintf(os.Stderr, "Saved transcript to %s\n", transcriptPath)
+			}
 		}
 	}
 
@@ -221,7 +455,7 @@ func runTranscribe(cmd *cobra.Command, args []string) error {
 		if err := os.WriteFile(tOutput, []byte(output), 0644); err != nil {
 			return err
 		}
-	} else if !tQuiet {
+	} else if !tQuiet && em == nil {
 		fmt.Println(output)
 	}
 
@@ -233,9 +467,235 @@ func runTranscribe(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if em != nil {
+		// A temp file from stdin was saved next to nothing worth naming.
+		if fromStdin {
+			savedPath = ""
+		}
+		em.Final(stream.FinalEvent{
+			Text:           result.Format(transcribe.FormatText),
+			Path:           args[0],
+			TranscriptPath: savedPath,
+			Backend:        source,
+			Source:         stream.SourceBatch,
+		})
+	}
+
+	// As soon as the transcript is delivered, so a slow translation or
+	// summary does not hold up a sync; a failure in either runs on_error.
+	transcribed := hooks.Event{
+		Hook:     hooks.PostTranscribe,
+		Command:  "transcribe",
+		Backend:  source,
+		Duration: result.Duration,
+		Result:   result,
+	}
+	if !fromStdin {
+		transcribed.Audio = args[0]
+		transcribed.Label = hookLabel(args[0])
+		transcribed.Transcript = savedPath
+	}
+	runHook(ctx, hookRunner, transcribed, warnStderr)
+
+	// Deferred, so the sync has every file this run writes, under the
+	// recording's final name, and is made even when a later step fails.
+	if cfg.Sync.Auto && !fromStdin {
+		defer func() { autoSync(ctx, cfg, transcribed.Audio, warnStderr) }()
+	}
+
+	// The webhooks go last, so that they carry the summary and the new name
+	// from --auto-label, but they go even when a translation or summary then
+	// fails: the transcript they deliver is written.
+	var summary *summarize.Summary
+	if len(endpoints) > 0 {
+		defer func() {
+			p := transcriptPayload(transcribed.Audio, transcribed.Transcript, source, result, summary)
+			deliverWebhooks(ctx, sender, endpoints, p, warnStderr)
+		}()
+	}
+
+	if translateTo != "" {
+		translated, err := translateTranscript(ctx, cfg, backend, translateWith, input, opts, result, translateTo, post)
+		if err != nil {
+			return fmt.Errorf("translate: %w", err)
+		}
+		if translated == nil {
+			fmt.Fprintf(os.Stderr, "Transcript is already in %s; no translation written\n", translateTo)
+		} else {
+			// Masked as the transcript was; a second pass over the audio
+			// brings back what the first masked.
+			redactor.Apply(translated, false)
+			if err := writeTranslation(translated, audioPath, translateTo, opts.Format, fromStdin); err != nil {
+				return err
+			}
+		}
+	}
+
+	if tSummarize {
+		var renamed string
+		summary, renamed, err = summarizeAfterTranscribe(ctx, cmd, cfg, result, audioPath, fromStdin)
+		if renamed != "" && renamed != audioPath {
+			transcribed.Audio = renamed
+			transcribed.Transcript = pathOnDisk(transcriptPathFor(renamed, opts.Format))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// After the summary, whose title the tags take, and after the rename, so
+	// the tags go into the recording under its final name. The transcript is
+	// saved beside it either way, so a failure is only a warning.
+	if embed {
+		if err := embedTranscript(ctx, transcribed.Audio, result, chaptersAuto); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to embed the transcript in %s: %v\n", transcribed.Audio, err)
+		} else if tVerbose {
+			fmt.Fprintf(os.Stderr, "Embedded the transcript in %s\n", transcribed.Audio)
+		}
+	}
+
+	// Last, so the note has the summary and is named after the recording's
+	// final name.
+	if tExportNote {
+		if _, err := exportNote(cfg, noteDir, transcribed.Audio, result, summary); err != nil {
+			return fmt.Errorf("export note: %w", err)
+		}
+	}
+
+	if tEmbedSubs {
+		if err := embedSubtitles(ctx, transcribed.Audio, result, opts); err != nil {
+			return fmt.Errorf("embed subtitles: %w", err)
+		}
+	}
+
+	if bleep {
+		// The recording itself may have been renamed since by --auto-label;
+		// a decrypted or extracted temp file has not.
+		from := input
+		if input == audioPath {
+			from = transcribed.Audio
+		}
+		if err := bleepRecording(ctx, from, transcribed.Audio, extracted, redactions); err != nil {
+			return fmt.Errorf("bleep: %w", err)
+		}
+	}
+	return nil
+}
+
+// extractAudio takes audio stream n out of the video, or multi-stream file,
+// at path into a temp file for the backend, and returns its path. The file
+// is in encrypt.TempDir, as path may be a decrypted recording.
+func extractAudio(ctx context.Context, path string, n int, verbose bool) (string, error) {
+	dir, err := encrypt.TempDir()
+	if err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(dir, "audio-*.ogg")
+	if err != nil {
+		return "", err
+	}
+	tmp.Close()
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Extracting audio stream %d...\n", n)
+	}
+	if err := media.ExtractAudio(ctx, path, n, tmp.Name()); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// embedSubtitles saves a copy of the video at path with result as a soft
+// subtitle track, in the subtitle format asked for with -f, or else SRT,
+// tagged with the transcript's language.
+func embedSubtitles(ctx context.Context, path string, result *transcribe.Result, opts transcribe.TranscribeOpts) error {
+	format := "srt"
+	if opts.Format == transcribe.FormatVTT {
+		format = "vtt"
+	}
+	format = media.SubtitleFormat(path, format)
+	subFormat := transcribe.FormatSRT
+	if format == "vtt" {
+		subFormat = transcribe.FormatVTT
+	}
+
+	// The subtitles are the transcript, so they are kept out of the shared
+	// temp directory like decrypted audio.
+	dir, err := encrypt.TempDir()
+	if err != nil {
+		return err
+	}
+	subs, err := os.CreateTemp(dir, "subs-*."+format)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(subs.Name())
+	_, err = subs.WriteString(result.Format(subFormat))
+	if err := errors.Join(err, subs.Close()); err != nil {
+		return err
+	}
+
+	lang := result.Language
+	if lang == "" {
+		lang = opts.Language
+	}
+	out := media.SubtitledPath(path)
+	if err := media.MuxSubtitles(ctx, path, subs.Name(), format, transcribe.LanguageCode3(lang), out); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Saved subtitled video to %s\n", out)
 	return nil
 }
 
+// transcribeOrReadEmbedded transcribes the audio at path, or with useEmbedded
+// reads back the transcript a previous run embedded in it, and says where the
+// result came from: the backend's name, or "embedded". A recording with
+// nothing embedded, or whose tags cannot be read, is transcribed as usual.
+func transcribeOrReadEmbedded(ctx context.Context, backend transcribe.Transcriber, path string, opts transcribe.TranscribeOpts, useEmbedded bool) (*transcribe.Result, string, error) {
+	if useEmbedded {
+		result, err := audiotag.Read(ctx, path)
+		if err == nil {
+			if opts.Verbose {
+				fmt.Fprintf(os.Stderr, "Read the transcript embedded in %s\n", path)
+			}
+			return result, embeddedSource, nil
+		}
+		if !errors.Is(err, audiotag.ErrNoTranscript) {
+			fmt.Fprintf(os.Stderr, "Warning: cannot read an embedded transcript from %s: %v\n", path, err)
+		} else if opts.Verbose {
+			fmt.Fprintf(os.Stderr, "No transcript embedded in %s; transcribing\n", path)
+		}
+	}
+	result, err := backend.Transcribe(ctx, path, opts)
+	return result, backend.Name(), err
+}
+
+// embeddedSource names a transcript read back from the recording's tags
+// where a backend's name would otherwise go.
+const embeddedSource = "embedded"
+
+// summarizeAfterTranscribe runs --summarize. The transcript has already been
+// delivered by now, so a failure here costs only the summary. It returns the
+// summary and the recording's path afterwards, which --auto-label may have
+// changed; the path is empty for audio read from stdin.
+func summarizeAfterTranscribe(ctx context.Context, cmd *cobra.Command, cfg *config.Config, result *transcribe.Result, audioPath string, fromStdin bool) (*summarize.Summary, string, error) {
+	s, err := runSummary(ctx, cfg, summaryData(result))
+	if err != nil {
+		return nil, "", fmt.Errorf("summarize: %w", err)
+	}
+	if fromStdin {
+		// There is no recording on disk to save it next to.
+		fmt.Fprint(os.Stderr, s.Markdown())
+		return s, "", nil
+	}
+	autoLabel := cfg.Summarize.AutoLabel
+	if cmd.Flags().Changed("auto-label") {
+		autoLabel = tAutoLabel
+	}
+	renamed, err := saveSummary(s, audioPath, autoLabel)
+	return s, renamed, err
+}
+
 func copyToClipboard(text string) error {
 	var cmd *exec.Cmd
 	switch runtime.GOOS {
@@ -265,20 +725,77 @@ func transcriptPathFor(audioPath string, format transcribe.OutputFormat) string
 	case transcribe.FormatVTT:
 		ext = ".vtt"
 	}
-	base := strings.TrimSuffix(audioPath, filepath.Ext(audioPath))
-	return base + ext
+	return recordingBase(audioPath) + ext
 }
 
 // liveTranscriptPathFor returns the path for the live realtime transcript
 // alongside the audio file. The -live suffix keeps it separate from the batch
 // transcript at <base>.txt so both are preserved after a -t recording.
 func liveTranscriptPathFor(audioPath string) string {
-	base := strings.TrimSuffix(audioPath, filepath.Ext(audioPath))
-	return base + "-live.txt"
+	return recordingBase(audioPath) + "-live.txt"
+}
+
+// resolveVocabulary merges the configured vocabulary with the terms in the
+// --vocab file. The file holds one term per line; blank lines and lines
+// starting with # are skipped so a team can keep a commented list in a repo.
+// Duplicates are dropped case-insensitively, keeping the first spelling.
+func resolveVocabulary(configured []string, path string) ([]string, error) {
+	terms := append([]string(nil), configured...)
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading --vocab: %w", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			terms = append(terms, line)
+		}
+	}
+	seen := make(map[string]bool, len(terms))
+	var out []string
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		key := strings.ToLower(term)
+		if term == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, term)
+	}
+	return out, nil
+}
+
+// configOpts returns the diarize, smart-format, punctuate, filler-word and
+// numeral defaults from the config section of the named backend. Backends
+// without such a setting get false, which is also what they support.
+func configOpts(cfg *config.Config, backend string) transcribe.TranscribeOpts {
+	switch backend {
+	case "elevenlabs":
+		return transcribe.TranscribeOpts{Diarize: cfg.Transcribe.ElevenLabs.Diarize}
+	case "whisperx":
+		return transcribe.TranscribeOpts{Diarize: cfg.Transcribe.Whisper.Diarize}
+	case "deepgram":
+		dg := cfg.Transcribe.Deepgram
+		return transcribe.TranscribeOpts{
+			Diarize:     dg.Diarize,
+			SmartFormat: dg.SmartFormat,
+			Punctuate:   dg.Punctuate,
+			FillerWords: dg.FillerWords,
+			Numerals:    dg.Numerals,
+		}
+	}
+	return transcribe.TranscribeOpts{}
 }
 
 func bufferStdin() (string, error) {
-	tmp, err := os.CreateTemp("", "audiomemo-stdin-*")
+	dir, err := encrypt.TempDir()
+	if err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(dir, "stdin-*")
 	if err != nil {
 		return "", err
 	}
@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/encrypt"
+	"github.com/joegoldin/audiomemo/internal/media"
 	"github.com/spf13/cobra"
 )
 
@@ -43,6 +45,13 @@ var audioExtensions = map[string]bool{
 	".opus": true,
 }
 
+// isRecording reports whether path is a recording transcribe takes, audio
+// or video, from its extension.
+func isRecording(path string) bool {
+	path = encrypt.Plain(path)
+	return audioExtensions[strings.ToLower(filepath.Ext(path))] || media.IsVideo(path)
+}
+
 func runTranscribeLatest(cmd *cobra.Command, args []string) error {
 	var cfg *config.Config
 	var err error
@@ -81,8 +90,8 @@ func runTranscribeLatest(cmd *cobra.Command, args []string) error {
 // truncating if the result would exceed maxFilenameLen.
 func renameWithLabel(path, label string) (string, error) {
 	dir := filepath.Dir(path)
-	ext := filepath.Ext(path)
-	base := strings.TrimSuffix(filepath.Base(path), ext)
+	ext := recordingExt(path)
+	base := filepath.Base(recordingBase(path))
 
 	// Sanitize: replace spaces/slashes with hyphens, collapse runs.
 	label = strings.Map(func(r rune) rune {
@@ -126,8 +135,8 @@ func findLatestAudio(dir string) (string, error) {
 		if e.IsDir() {
 			continue
 		}
-		ext := strings.ToLower(filepath.Ext(e.Name()))
-		if !audioExtensions[ext] {
+		ext := strings.ToLower(filepath.Ext(encrypt.Plain(e.Name())))
+		if !audioExtensions[ext] || strings.HasPrefix(e.Name(), ".") || isBleepedCopy(e.Name()) {
 			continue
 		}
 		info, err := e.Info()
@@ -32,8 +32,9 @@ func TestFindLatestAudioSkipsNonAudio(t *testing.T) {
 
 	os.WriteFile(filepath.Join(dir, "recording.ogg"), []byte("audio"), 0644)
 	time.Sleep(10 * time.Millisecond)
-	// This is newer but not an audio file.
+	// These are newer but not recordings: a text file, and the bleeped copy.
 	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("text"), 0644)
+	os.WriteFile(filepath.Join(dir, "recording-redacted.ogg"), []byte("audio"), 0644)
 
 	got, err := findLatestAudio(dir)
 	if err != nil {
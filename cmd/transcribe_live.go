@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/record"
+	"github.com/joegoldin/audiomemo/internal/stream"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+	"github.com/spf13/cobra"
+)
+
+// liveIdle is how long `transcribe --live` waits, once the input has ended,
+// for another commit before it settles on the transcript it has. The last
+// commit is answered some time after the audio that prompted it.
+const liveIdle = 1500 * time.Millisecond
+
+// liveInputFlags only describe the audio --live reads.
+var liveInputFlags = []string{"input-format", "input-rate", "input-channels"}
+
+// liveBatchFlags shape a batch result. The realtime API returns plain
+// committed text, so none of them has anything to act on.
+var liveBatchFlags = []string{"model", "diarize", "smart-format", "punctuate", "filler-words", "numerals", "speakers", "summarize", "translate-to", "export-note", "embed", "use-embedded", "audio-stream", "embed-subs"}
+
+// validateLiveFlags rejects what --live cannot honour rather than quietly
+// ignoring it: a user who asked for SRT or diarisation would otherwise get
+// plain text and no word of why.
+func validateLiveFlags(live bool, backend, format string, changed func(name string) bool) error {
+	if !live {
+		for _, name := range liveInputFlags {
+			if changed(name) {
+				return fmt.Errorf("--%s requires --live", name)
+			}
+		}
+		return nil
+	}
+	if backend != "" && backend != transcribe.RealtimeBackendName {
+		return fmt.Errorf("--live streams to the ElevenLabs realtime API and cannot use --backend %s", backend)
+	}
+	if transcribe.ParseFormat(format) != transcribe.FormatText {
+		return fmt.Errorf("--live writes text as it is committed; -f %s needs a batch transcription", format)
+	}
+	for _, name := range liveBatchFlags {
+		if changed(name) {
+			return fmt.Errorf("--%s cannot be combined with --live: it needs a batch transcription", name)
+		}
+	}
+	return nil
+}
+
+// runTranscribeLive is `transcribe --live`. ffmpeg decodes the input to the
+// PCM the realtime streamer takes, and each commit is printed, or emitted
+// with --stream, as it arrives. The session ends with the input, or on
+// Ctrl+C, which stops ffmpeg and still waits for what was left to commit.
+func runTranscribeLive(ctx context.Context, cmd *cobra.Command, cfg *config.Config, em *stream.Emitter, input string) error {
+	el := cfg.Transcribe.ElevenLabs
+	if el.APIKey == "" {
+		return fmt.Errorf("--live needs an ElevenLabs API key: live transcription uses the ElevenLabs realtime API")
+	}
+	if cmd.Flags().Changed("store-in-cloud") {
+		el.StoreInCloud = tStoreInCloud
+	}
+	post, err := resolvePostProcess(cfg.Transcribe.PostProcess, cmd.Flags())
+	if err != nil {
+		return err
+	}
+	decodeFrom := input
+	if input != "-" {
+		if _, err := os.Stat(input); err != nil {
+			return err
+		}
+		plain, cleanup, err := plainRecording(ctx, input)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		decodeFrom = plain
+	}
+
+	decodeCtx, stopDecoder := context.WithCancel(ctx)
+	defer stopDecoder()
+	pcm, wait, err := startLiveDecoder(decodeCtx, record.DecodeOpts{
+		Input:      decodeFrom,
+		Format:     tInputFormat,
+		SampleRate: tInputRate,
+		Channels:   tInputChans,
+		// A file is played as if it were being spoken. stdin arrives at
+		// whatever pace its producer has, which for a live source is right.
+		Realtime: input != "-",
+	})
+	if err != nil {
+		return err
+	}
+	defer pcm.Close()
+
+	streamer := transcribe.NewStreamer(el.APIKey, el.StoreInCloud)
+	streamer.SetPostProcess(post)
+	if tVoiceCmds {
+		streamer.SetVoiceCommands(transcribe.NewVoiceInterpreter(voiceCommandsFromConfig(cfg.Record.VoiceCommands)))
+	}
+	if err := streamer.Start(context.Background(), pcm, ""); err != nil {
+		stopDecoder()
+		wait()
+		return err
+	}
+	if em != nil {
+		em.TranscribeStart(stream.TranscribeStartEvent{
+			File:    input,
+			Backend: transcribe.RealtimeBackendName,
+			Mode:    stream.ModeLive,
+		})
+	}
+
+	onPartial := func(string) {}
+	onCommit := func(text string) {
+		if tOutput == "" && !tQuiet {
+			fmt.Println(text)
+		}
+	}
+	if em != nil {
+		onPartial, onCommit = em.Partial, em.Commit
+	}
+
+	// A spoken stop ends the input as Ctrl+C would. There is no recording to
+	// bookmark, so a mark is only reported.
+	go func() {
+		for action := range streamer.Voice {
+			if em != nil {
+				em.Voice(action)
+			}
+			if action == transcribe.VoiceStop {
+				stopDecoder()
+			}
+		}
+	}()
+
+	decoded := make(chan error, 1)
+	go func() { decoded <- wait() }()
+	err = followLive(streamer.Partial, streamer.Committed, streamer.Err, decoded, liveIdle, onPartial, onCommit)
+	streamer.Stop()
+	if err != nil {
+		return err
+	}
+
+	text := streamer.FullText()
+	if tOutput != "" {
+		if err := os.WriteFile(tOutput, []byte(text+"\n"), 0644); err != nil {
+			return err
+		}
+	}
+	if tCopy {
+		if err := copyToClipboard(text); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to copy to clipboard: %v\n", err)
+		}
+	}
+	if em != nil {
+		em.Final(stream.FinalEvent{
+			Text:           text,
+			Path:           input,
+			TranscriptPath: tOutput,
+			Backend:        transcribe.RealtimeBackendName,
+			Source:         stream.SourceLive,
+		})
+	}
+	return nil
+}
+
+// startLiveDecoder starts ffmpeg decoding opts.Input and returns the PCM it
+// writes, and a wait that reports how ffmpeg ended. The PCM comes through an
+// os.Pipe rather than StdoutPipe, whose Wait would close the read end under
+// the streamer before it had read the last of the audio.
+func startLiveDecoder(ctx context.Context, opts record.DecodeOpts) (pcm *os.File, wait func() error, err error) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create PCM pipe: %w", err)
+	}
+	dec := exec.CommandContext(ctx, "ffmpeg", record.BuildDecodeArgs(opts)...)
+	// Stopped as a terminal would stop it, so ffmpeg writes out what it has
+	// decoded instead of dying with it in a buffer.
+	dec.Cancel = func() error { return dec.Process.Signal(os.Interrupt) }
+	dec.WaitDelay = 5 * time.Second
+	if opts.Input == "-" {
+		dec.Stdin = os.Stdin
+	}
+	dec.Stdout = pw
+	var stderr bytes.Buffer
+	dec.Stderr = &stderr
+	if err := dec.Start(); err != nil {
+		pr.Close()
+		pw.Close()
+		return nil, nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+	// ffmpeg holds its own copy; the reader sees EOF once ffmpeg exits.
+	pw.Close()
+
+	return pr, func() error {
+		err := dec.Wait()
+		if err == nil || ctx.Err() != nil {
+			// Being stopped is how an interrupted session ends.
+			return nil
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("ffmpeg: %w\n%s", err, msg)
+		}
+		return fmt.Errorf("ffmpeg: %w", err)
+	}, nil
+}
+
+// followLive hands each partial and commit on until the input has ended
+// and no commit has followed for idle. It returns the decoder's failure, or
+// the streamer's if the session itself failed first.
+func followLive(partial, committed <-chan string, errs <-chan error, decoded <-chan error, idle time.Duration, onPartial, onCommit func(string)) error {
+	var timer *time.Timer
+	var settled <-chan time.Time
+	var decodeErr error
+	for {
+		select {
+		case text := <-partial:
+			onPartial(text)
+		case text := <-committed:
+			onCommit(text)
+			if timer != nil {
+				timer.Reset(idle)
+			}
+		case err := <-errs:
+			if err != nil {
+				return err
+			}
+		case decodeErr = <-decoded:
+			decoded = nil
+			timer = time.NewTimer(idle)
+			defer timer.Stop()
+			settled = timer.C
+		case <-settled:
+			return decodeErr
+		}
+	}
+}
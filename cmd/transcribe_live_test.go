@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateLiveFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		live    bool
+		backend string
+		format  string
+		changed []string
+		wantErr string
+	}{
+		{"batch", false, "deepgram", "srt", []string{"diarize"}, ""},
+		{"input format without --live", false, "", "text", []string{"input-format"}, "--input-format requires --live"},
+		{"live", true, "", "text", []string{"input-format", "input-rate"}, ""},
+		{"live naming its backend", true, "elevenlabs", "text", nil, ""},
+		{"another backend", true, "whisper-cpp", "text", nil, "--backend whisper-cpp"},
+		{"subtitles", true, "", "srt", nil, "-f srt"},
+		{"diarisation", true, "", "text", []string{"diarize"}, "--diarize"},
+		{"summary", true, "", "text", []string{"summarize"}, "--summarize"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := func(name string) bool { return slices.Contains(tt.changed, name) }
+			err := validateLiveFlags(tt.live, tt.backend, tt.format, changed)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFollowLiveWaitsForTheLastCommit(t *testing.T) {
+	partial := make(chan string)
+	committed := make(chan string)
+	decoded := make(chan error, 1)
+	var got []string
+	done := make(chan error)
+	go func() {
+		done <- followLive(partial, committed, nil, decoded, 50*time.Millisecond,
+			func(text string) { got = append(got, "partial:"+text) },
+			func(text string) { got = append(got, "commit:"+text) })
+	}()
+
+	partial <- "turn"
+	committed <- "Turn on"
+	decoded <- nil
+	// Answered after the input ended, but within idle of it: still kept.
+	time.Sleep(20 * time.Millisecond)
+	committed <- "the lights."
+
+	if err := <-done; err != nil {
+		t.Fatalf("followLive: %v", err)
+	}
+	want := []string{"partial:turn", "commit:Turn on", "commit:the lights."}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFollowLiveFailures(t *testing.T) {
+	ignore := func(string) {}
+
+	decoded := make(chan error, 1)
+	decoded <- errors.New("ffmpeg: exit status 1")
+	if err := followLive(nil, nil, nil, decoded, time.Millisecond, ignore, ignore); err == nil || !strings.Contains(err.Error(), "ffmpeg") {
+		t.Errorf("decoder failure: err = %v", err)
+	}
+
+	errs := make(chan error, 1)
+	errs <- errors.New("elevenlabs error (auth_error): invalid key")
+	if err := followLive(nil, nil, errs, make(chan error), time.Millisecond, ignore, ignore); err == nil || !strings.Contains(err.Error(), "auth_error") {
+		t.Errorf("session failure: err = %v", err)
+	}
+}
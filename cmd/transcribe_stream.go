@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"sync"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/stream"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+// configModel is the model a backend uses when -m is not given: the one the
+// config names for it, which is what NewDispatcher handed the backend.
+func configModel(cfg *config.Config, backend string) string {
+	switch backend {
+	case "elevenlabs":
+		return cfg.Transcribe.ElevenLabs.Model
+	case "deepgram":
+		return cfg.Transcribe.Deepgram.Model
+	case "openai":
+		return cfg.Transcribe.OpenAI.Model
+	case "mistral":
+		return cfg.Transcribe.Mistral.Model
+	case "whisper", "whisper-cpp", "whisperx", "ffmpeg-whisper":
+		return cfg.Transcribe.Whisper.Model
+	}
+	return ""
+}
+
+// streamProgress turns what a backend reports into events for `transcribe
+// --stream`. An upload reports every buffer net/http reads, and a consumer
+// drawing a bar wants percents, so uploads and progress are thinned to one
+// event per whole percent.
+type streamProgress struct {
+	em       *stream.Emitter
+	duration float64 // seconds; 0 when unknown
+
+	mu         sync.Mutex
+	uploadPct  int64
+	percent    float64
+	toolCounts bool // the tool prints its own percentage
+	segments   int
+}
+
+func newStreamProgress(em *stream.Emitter, duration float64) *streamProgress {
+	return &streamProgress{em: em, duration: duration, uploadPct: -1, percent: -1}
+}
+
+func (p *streamProgress) Uploaded(sent, total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pct := sent * 100 / total
+	if pct <= p.uploadPct {
+		return
+	}
+	p.uploadPct = pct
+	p.em.Upload(sent, total)
+}
+
+func (p *streamProgress) Processed(percent float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.toolCounts = true
+	p.progress(percent)
+}
+
+func (p *streamProgress) Segment(seg transcribe.Segment) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.segments++
+	p.em.Segment(segmentEvent(seg))
+	// Python whisper prints no percentage, but its segments arrive in order,
+	// so the latest one's end says how far it has got.
+	if !p.toolCounts && p.duration > 0 {
+		p.progress(min(seg.End/p.duration*100, 100))
+	}
+}
+
+// progress emits percent if it is a whole percent further on. Called with
+// mu held.
+func (p *streamProgress) progress(percent float64) {
+	if p.percent >= 0 && int(percent) <= int(p.percent) {
+		return
+	}
+	p.percent = percent
+	p.em.Progress(percent)
+}
+
+// finish emits the result's segments when the backend reported none while it
+// worked, so every run produces them.
+func (p *streamProgress) finish(result *transcribe.Result) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.segments > 0 {
+		return
+	}
+	for _, seg := range result.Segments {
+		p.em.Segment(segmentEvent(seg))
+	}
+}
+
+func segmentEvent(seg transcribe.Segment) stream.SegmentEvent {
+	return stream.SegmentEvent{Start: seg.Start, End: seg.End, Text: seg.Text, Speaker: seg.Speaker}
+}
+
+// endTranscribeStream closes the stream after the run and returns what the
+// run should. A failure is reported as a fatal error event before end. After
+// an interrupt the backend fails in whatever way being cancelled makes it, so
+// any failure then is the signal, which like record --stream is no failure.
+// A live session ends cleanly on the interrupt that stops it, which is a
+// signal all the same.
+func endTranscribeStream(ctx context.Context, em *stream.Emitter, path string, err error) error {
+	signalled := ctx.Err() != nil
+	if signalled {
+		err = nil
+	} else if err != nil {
+		em.Error(stream.ScopeTranscribe, true, err)
+	}
+	em.End(stream.EndEvent{
+		Reason:   endReason(signalled, false, err),
+		Path:     path,
+		ExitCode: endExitCode(signalled, err),
+	})
+	return err
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/stream"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+func eventTypes(lines []map[string]any) []string {
+	var types []string
+	for _, l := range lines {
+		types = append(types, l["type"].(string))
+	}
+	return types
+}
+
+func TestStreamProgressThinsUploadsToWholePercents(t *testing.T) {
+	buf := &bytes.Buffer{}
+	p := newStreamProgress(stream.NewEmitter(buf), 0)
+	for sent := int64(0); sent <= 10_000; sent += 10 {
+		p.Uploaded(sent, 10_000)
+	}
+	lines := decodeStreamLines(t, buf.String())
+	if len(lines) != 101 {
+		t.Fatalf("got %d upload events, want one per percent from 0 to 100", len(lines))
+	}
+	if last := lines[100]; last["sent"] != float64(10_000) || last["total"] != float64(10_000) {
+		t.Errorf("last upload = %v", last)
+	}
+}
+
+func TestStreamProgressFromSegmentsWhenTheToolDoesNotCount(t *testing.T) {
+	buf := &bytes.Buffer{}
+	p := newStreamProgress(stream.NewEmitter(buf), 200)
+	p.Segment(transcribe.Segment{Start: 0, End: 50, Text: "first"})
+	p.Segment(transcribe.Segment{Start: 50, End: 50.5, Text: "second"})
+	p.Segment(transcribe.Segment{Start: 50.5, End: 100, Text: "third"})
+
+	lines := decodeStreamLines(t, buf.String())
+	// The second segment is still at 25%, so it brings no progress event.
+	want := []string{"segment", "progress", "segment", "segment", "progress"}
+	if got := eventTypes(lines); !slices.Equal(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	if lines[1]["percent"] != float64(25) || lines[4]["percent"] != float64(50) {
+		t.Errorf("percents = %v, %v", lines[1]["percent"], lines[4]["percent"])
+	}
+}
+
+func TestStreamProgressPrefersTheToolsCount(t *testing.T) {
+	buf := &bytes.Buffer{}
+	p := newStreamProgress(stream.NewEmitter(buf), 200)
+	p.Processed(5)
+	p.Segment(transcribe.Segment{Start: 0, End: 100, Text: "a"})
+	p.Processed(10)
+	lines := decodeStreamLines(t, buf.String())
+	if got := eventTypes(lines); !slices.Equal(got, []string{"progress", "segment", "progress"}) {
+		t.Fatalf("events = %v", got)
+	}
+	if lines[2]["percent"] != float64(10) {
+		t.Errorf("percent = %v, want the tool's 10", lines[2]["percent"])
+	}
+}
+
+func TestStreamProgressFinishEmitsSegmentsOnlyOnce(t *testing.T) {
+	result := &transcribe.Result{Segments: []transcribe.Segment{
+		{Start: 0, End: 1, Text: "hello", Speaker: "Alice"},
+		{Start: 1, End: 2, Text: "world"},
+	}}
+
+	buf := &bytes.Buffer{}
+	newStreamProgress(stream.NewEmitter(buf), 0).finish(result)
+	lines := decodeStreamLines(t, buf.String())
+	if len(lines) != 2 || lines[0]["speaker"] != "Alice" || lines[1]["text"] != "world" {
+		t.Errorf("a cloud backend's segments = %v", lines)
+	}
+
+	buf.Reset()
+	p := newStreamProgress(stream.NewEmitter(buf), 0)
+	p.Segment(transcribe.Segment{Start: 0, End: 1, Text: "hello"})
+	p.finish(result)
+	if n := len(decodeStreamLines(t, buf.String())); n != 1 {
+		t.Errorf("segments seen while decoding were repeated: %d events", n)
+	}
+}
+
+func TestEndTranscribeStream(t *testing.T) {
+	live := context.Background()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		err      error
+		wantErr  bool
+		types    []string
+		reason   string
+		exitCode float64
+	}{
+		{"success", live, nil, false, []string{"end"}, "stopped", 0},
+		{"failure", live, errors.New("quota exceeded"), true, []string{"error", "end"}, "error", 1},
+		{"interrupt", cancelled, errors.New("signal: killed"), false, []string{"end"}, "signal", 0},
+		{"interrupted live session", cancelled, nil, false, []string{"end"}, "signal", 0},
+	}
+	for _, tt := range tests {
+		buf := &bytes.Buffer{}
+		err := endTranscribeStream(tt.ctx, stream.NewEmitter(buf), "memo.ogg", tt.err)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v", tt.name, err)
+		}
+		lines := decodeStreamLines(t, buf.String())
+		if got := eventTypes(lines); !slices.Equal(got, tt.types) {
+			t.Errorf("%s: events = %v, want %v", tt.name, got, tt.types)
+			continue
+		}
+		end := lines[len(lines)-1]
+		if end["reason"] != tt.reason || end["exit_code"] != tt.exitCode || end["path"] != "memo.ogg" {
+			t.Errorf("%s: end = %v", tt.name, end)
+		}
+	}
+}
+
+func TestConfigModel(t *testing.T) {
+	cfg := config.Default()
+	cfg.Transcribe.Deepgram.Model = "nova-3"
+	cfg.Transcribe.Whisper.Model = "large-v3"
+	if got := configModel(cfg, "deepgram"); got != "nova-3" {
+		t.Errorf("deepgram = %q", got)
+	}
+	if got := configModel(cfg, "whisper-cpp"); got != "large-v3" {
+		t.Errorf("whisper-cpp = %q", got)
+	}
+	if got := configModel(cfg, "nonesuch"); got != "" {
+		t.Errorf("unknown backend = %q", got)
+	}
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolveVocabularyMergesConfigAndFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vocab.txt")
+	os.WriteFile(path, []byte("# people\nJoe Goldin\n\n  AudioMemo  \naudiomemo\n"), 0644)
+
+	got, err := resolveVocabulary([]string{"Deepgram", "Joe Goldin"}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Deepgram", "Joe Goldin", "AudioMemo"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestResolveVocabularyWithoutFile(t *testing.T) {
+	got, err := resolveVocabulary(nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("expected no terms, got %v", got)
+	}
+}
+
+func TestResolveVocabularyMissingFile(t *testing.T) {
+	if _, err := resolveVocabulary(nil, "/nonexistent/vocab.txt"); err == nil {
+		t.Error("expected an error for a missing --vocab file")
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/summarize"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+// translateMode is how --translate-to produces its translation.
+type translateMode string
+
+const (
+	translateAuto   translateMode = "auto"   // native when possible, else the LLM
+	translateNative translateMode = "native" // the backend translates the audio
+	translateLLM    translateMode = "llm"    // the [summarize] endpoint translates the text
+)
+
+// resolveTranslateMode picks how to translate into lang, checked before any
+// audio is uploaded. Native translation re-reads the audio and only produces
+// English, so auto mode uses it for English and the LLM for everything else.
+func resolveTranslateMode(mode, lang string, backend transcribe.Transcriber) (translateMode, error) {
+	native := lang == "en" && transcribe.NativeTranslation(backend)
+	switch translateMode(mode) {
+	case translateAuto:
+		if native {
+			return translateNative, nil
+		}
+		return translateLLM, nil
+	case translateNative:
+		if lang != "en" {
+			return "", fmt.Errorf("--translate-with native only translates into en; use --translate-with llm for %s", lang)
+		}
+		if !native {
+			return "", fmt.Errorf("%s cannot translate natively; use --translate-with llm", backend.Name())
+		}
+		return translateNative, nil
+	case translateLLM:
+		return translateLLM, nil
+	default:
+		return "", fmt.Errorf("unknown --translate-with %q (use auto, native or llm)", mode)
+	}
+}
+
+// translationPathFor returns <base>.<lang>.<ext> beside the recording, so the
+// translated subtitles sort next to the originals.
+func translationPathFor(audioPath, lang string, format transcribe.OutputFormat) string {
+	return transcriptPathFor(recordingBase(audioPath)+"."+lang+recordingExt(audioPath), format)
+}
+
+// translateTranscript produces the translation of result into lang. The
+// source language is the one the backend detected, falling back to the
+// --language hint.
+func translateTranscript(ctx context.Context, cfg *config.Config, backend transcribe.Transcriber, mode translateMode, audioPath string, opts transcribe.TranscribeOpts, result *transcribe.Result, lang string, post *transcribe.PostProcessor) (*transcribe.Result, error) {
+	from := transcribe.NormalizeLanguage(result.Language)
+	if from == "" {
+		from = transcribe.NormalizeLanguage(opts.Language)
+	}
+	if from == lang {
+		return nil, nil
+	}
+
+	if mode == translateNative {
+		opts.Translate = true
+		translated, err := backend.Transcribe(ctx, audioPath, opts)
+		if err != nil {
+			return nil, err
+		}
+		// A second pass over the audio, so it gets the same cleanup as the first.
+		post.Apply(translated)
+		return translated, nil
+	}
+
+	client := summarize.NewClient(cfg.Summarize.BaseURL, cfg.Summarize.APIKey, cfg.Summarize.Model)
+	return transcribe.TranslateWithLLM(ctx, client.Chat, result, from, lang)
+}
+
+// writeTranslation saves a translation beside the recording. From stdin there
+// is no recording, so the translation goes to stderr after the transcript.
+func writeTranslation(translated *transcribe.Result, audioPath, lang string, format transcribe.OutputFormat, fromStdin bool) error {
+	output := translated.Format(format)
+	if fromStdin {
+		fmt.Fprintln(os.Stderr, output)
+		return nil
+	}
+	path := translationPathFor(audioPath, lang, format)
+	path, err := saveFile(path, []byte(output))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Saved %s translation to %s\n", lang, path)
+	return nil
+}
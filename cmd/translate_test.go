@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+func TestResolveTranslateMode(t *testing.T) {
+	openai := transcribe.NewOpenAI("k", "gpt-4o-transcribe")
+	deepgram := transcribe.NewDeepgram("k", "nova-3")
+	tests := []struct {
+		mode    string
+		lang    string
+		backend transcribe.Transcriber
+		want    translateMode
+		wantErr bool
+	}{
+		{"auto", "en", openai, translateNative, false},
+		{"auto", "de", openai, translateLLM, false},
+		{"auto", "en", deepgram, translateLLM, false},
+		{"native", "de", openai, "", true},
+		{"native", "en", deepgram, "", true},
+		{"llm", "en", openai, translateLLM, false},
+		{"bogus", "en", openai, "", true},
+	}
+	for _, tt := range tests {
+		got, err := resolveTranslateMode(tt.mode, tt.lang, tt.backend)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("resolveTranslateMode(%q, %q, %s) = %q, %v", tt.mode, tt.lang, tt.backend.Name(), got, err)
+		}
+	}
+}
+
+func TestTranslationPathFor(t *testing.T) {
+	got := translationPathFor("/rec/memo.ogg", "en", transcribe.FormatSRT)
+	if got != "/rec/memo.en.srt" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/typist"
+)
+
+const (
+	// defaultTypeInterval spaces typing runs when record.type_into leaves
+	// min_interval out. Commits arrive seconds apart, so it only matters for
+	// a burst, which is then typed as one run instead of several.
+	defaultTypeInterval = 100 * time.Millisecond
+	// typeIntoDrain is how long a finished recording waits for the typist to
+	// catch up before giving up on what is left.
+	typeIntoDrain = 5 * time.Second
+)
+
+// typeTarget is what --type-into resolved to: a typing program, or a named
+// pipe when pipe is set.
+type typeTarget struct {
+	program string // looked up on PATH before recording starts
+	tool    typist.Tool
+	pipe    string
+}
+
+// resolveTypeTarget works out where --type-into=value types. "auto" takes
+// the config's command or tool, or else guesses from the session: wtype
+// under Wayland, xdotool under X11, and ydotool otherwise. "command" insists
+// on the config's command. A value with a slash in it is a named pipe, which
+// must already exist so that its reader can be started first.
+func resolveTypeTarget(value string, cfg config.TypeIntoConfig, keyDelay time.Duration, getenv func(string) string) (typeTarget, error) {
+	if strings.Contains(value, "/") {
+		info, err := os.Stat(value)
+		if err != nil {
+			return typeTarget{}, fmt.Errorf("--type-into: %w (create a named pipe with mkfifo)", err)
+		}
+		if info.Mode()&os.ModeNamedPipe == 0 {
+			return typeTarget{}, fmt.Errorf("--type-into: %s is not a named pipe (create one with mkfifo)", value)
+		}
+		return typeTarget{pipe: value}, nil
+	}
+
+	name := value
+	switch value {
+	case "auto":
+		if len(cfg.Command) > 0 {
+			return customTarget(cfg), nil
+		}
+		name = cfg.Tool
+		if name == "" {
+			switch {
+			case getenv("WAYLAND_DISPLAY") != "":
+				name = "wtype"
+			case getenv("DISPLAY") != "":
+				name = "xdotool"
+			default:
+				name = "ydotool"
+			}
+		}
+	case "command":
+		if len(cfg.Command) == 0 {
+			return typeTarget{}, fmt.Errorf("--type-into=command needs record.type_into.command in the config")
+		}
+		return customTarget(cfg), nil
+	}
+	if !slices.Contains(typist.ToolNames, name) {
+		return typeTarget{}, fmt.Errorf("--type-into: unknown target %q (want auto, command, %s, or a named pipe path)", name, strings.Join(typist.ToolNames, ", "))
+	}
+	tool, err := typist.NamedTool(name, keyDelay)
+	if err != nil {
+		return typeTarget{}, err
+	}
+	return typeTarget{program: name, tool: tool}, nil
+}
+
+func customTarget(cfg config.TypeIntoConfig) typeTarget {
+	return typeTarget{program: cfg.Command[0], tool: typist.CustomTool(cfg.Command, cfg.EraseCommand)}
+}
+
+// validateTypeIntoFlags rejects what --type-into cannot honour. It types
+// live commits, so it needs a live transcript to take them from.
+func validateTypeIntoFlags(typeInto string, dryRun, liveDisabled, haveKey bool) error {
+	if typeInto == "" {
+		if dryRun {
+			return fmt.Errorf("--type-dry-run requires --type-into")
+		}
+		return nil
+	}
+	if liveDisabled {
+		return fmt.Errorf("--type-into types the live transcript, which is disabled")
+	}
+	if !haveKey {
+		return fmt.Errorf("--type-into types the live transcript, which needs an ElevenLabs API key")
+	}
+	return nil
+}
+
+// newRecordTypist starts the typist --type-into asks for, or returns nil
+// when it was not given. A dry run prints each typing command to dryRunOut
+// instead of running it, so a setup can be tried without a window to type
+// into.
+func newRecordTypist(typeInto string, dryRun bool, cfg config.TypeIntoConfig, dryRunOut io.Writer) (*typist.Typist, error) {
+	if typeInto == "" {
+		return nil, nil
+	}
+	keyDelay, err := parseFlagDuration("record.type_into.key_delay", cfg.KeyDelay)
+	if err != nil {
+		return nil, err
+	}
+	interval := defaultTypeInterval
+	if cfg.MinInterval != "" {
+		if interval, err = parseFlagDuration("record.type_into.min_interval", cfg.MinInterval); err != nil {
+			return nil, err
+		}
+	}
+	target, err := resolveTypeTarget(typeInto, cfg, keyDelay, os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.pipe != "" {
+		if dryRun {
+			return nil, fmt.Errorf("--type-dry-run has no typing commands to print for a named pipe; read the pipe instead")
+		}
+		return typist.New(typist.NewPipeSink(target.pipe), interval), nil
+	}
+	run := typist.Run
+	if dryRun {
+		run = typist.DryRun(dryRunOut)
+	} else if _, err := exec.LookPath(target.program); err != nil {
+		return nil, fmt.Errorf("--type-into: %s not found on PATH", target.program)
+	}
+	return typist.New(typist.NewCommandSink(target.tool, run), interval), nil
+}
+
+// closeTypist lets ty finish typing and reports whatever went wrong to warn.
+func closeTypist(ty *typist.Typist, warn func(error)) {
+	if ty == nil {
+		return
+	}
+	if err := ty.Close(typeIntoDrain); err != nil {
+		warn(fmt.Errorf("--type-into: %w", err))
+	}
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+)
+
+func TestResolveTypeTarget(t *testing.T) {
+	wayland := map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}
+	x11 := map[string]string{"DISPLAY": ":0"}
+	custom := config.TypeIntoConfig{Command: []string{"mytype", "-"}}
+	tests := []struct {
+		name  string
+		value string
+		cfg   config.TypeIntoConfig
+		env   map[string]string
+		want  string // program
+	}{
+		{"auto under Wayland", "auto", config.TypeIntoConfig{}, wayland, "wtype"},
+		{"auto under X11", "auto", config.TypeIntoConfig{}, x11, "xdotool"},
+		{"auto on a console", "auto", config.TypeIntoConfig{}, nil, "ydotool"},
+		{"auto takes the config tool", "auto", config.TypeIntoConfig{Tool: "ydotool"}, wayland, "ydotool"},
+		{"auto takes the config command", "auto", custom, wayland, "mytype"},
+		{"named tool beats config", "xdotool", custom, wayland, "xdotool"},
+		{"command", "command", custom, nil, "mytype"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := resolveTypeTarget(tt.value, tt.cfg, 0, func(k string) string { return tt.env[k] })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if target.program != tt.want {
+				t.Errorf("program = %q, want %q", target.program, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveTypeTargetRejects(t *testing.T) {
+	dir := t.TempDir()
+	notPipe := filepath.Join(dir, "file")
+	if err := os.WriteFile(notPipe, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"dotool", "unknown target"},
+		{"command", "record.type_into.command"},
+		{filepath.Join(dir, "missing"), "mkfifo"},
+		{notPipe, "not a named pipe"},
+	}
+	for _, tt := range tests {
+		_, err := resolveTypeTarget(tt.value, config.TypeIntoConfig{}, 0, func(string) string { return "" })
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want it to mention %q", tt.value, err, tt.want)
+		}
+	}
+}
+
+func TestValidateTypeIntoFlags(t *testing.T) {
+	if err := validateTypeIntoFlags("", true, false, true); err == nil {
+		t.Error("--type-dry-run alone should be rejected")
+	}
+	if err := validateTypeIntoFlags("auto", false, true, true); err == nil {
+		t.Error("--type-into without live transcription should be rejected")
+	}
+	if err := validateTypeIntoFlags("auto", false, false, false); err == nil {
+		t.Error("--type-into without an ElevenLabs key should be rejected")
+	}
+	if err := validateTypeIntoFlags("auto", true, false, true); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRecordTypistDryRunWithStubCommand(t *testing.T) {
+	cfg := config.TypeIntoConfig{
+		Command:      []string{"stub-type"},
+		EraseCommand: []string{"stub-erase"},
+		MinInterval:  "0s",
+	}
+	var out bytes.Buffer
+	ty, err := newRecordTypist("command", true, cfg, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ty.Commit("Dear team,")
+	time.Sleep(10 * time.Millisecond)
+	ty.Commit("sorry")
+	time.Sleep(10 * time.Millisecond)
+	ty.Scratch()
+	time.Sleep(10 * time.Millisecond)
+	ty.Commit("thanks.")
+	if err := ty.Close(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`stub-type < "Dear team,"`,
+		`stub-type < " sorry"`,
+		`stub-erase 6`,
+		`stub-type < " thanks."`,
+	}
+	if got := strings.Split(strings.TrimSpace(out.String()), "\n"); !slices.Equal(got, want) {
+		t.Errorf("printed %q, want %q", got, want)
+	}
+}
+
+func TestRecordTypistChecksConfig(t *testing.T) {
+	if _, err := newRecordTypist("xdotool", true, config.TypeIntoConfig{KeyDelay: "fast"}, nil); err == nil || !strings.Contains(err.Error(), "record.type_into.key_delay") {
+		t.Errorf("bad key_delay: err = %v", err)
+	}
+	if _, err := newRecordTypist("command", false, config.TypeIntoConfig{Command: []string{"no-such-typist-tool"}}, nil); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("missing tool: err = %v", err)
+	}
+	pipe := filepath.Join(t.TempDir(), "dictation")
+	if err := syscall.Mkfifo(pipe, 0600); err != nil {
+		t.Skipf("mkfifo: %v", err)
+	}
+	if _, err := newRecordTypist(pipe, true, config.TypeIntoConfig{}, nil); err == nil {
+		t.Error("a dry run into a named pipe should be rejected")
+	}
+}
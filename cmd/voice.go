@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/meta"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+	"github.com/spf13/pflag"
+)
+
+// rVoiceBatch asks record's batch pass to carry out the voice commands the
+// live transcript did, so the two agree. It is off when the config keeps
+// the phrases for the batch transcript. Set by runRecord.
+var rVoiceBatch bool
+
+// voiceCommandsFromConfig turns [record.voice_commands] into the phrases it
+// sets. Lists it leaves out stay nil, which keeps their defaults.
+func voiceCommandsFromConfig(cfg config.VoiceCommandsConfig) transcribe.VoiceCommands {
+	return transcribe.VoiceCommands{
+		NewParagraph: cfg.NewParagraph,
+		NewLine:      cfg.NewLine,
+		Scratch:      cfg.Scratch,
+		Mark:         cfg.Mark,
+		Stop:         cfg.Stop,
+		Punctuation:  cfg.Punctuation,
+	}
+}
+
+// recordVoice is the interpreter for record's live commits, or nil when
+// voice commands are off. --voice-commands overrides the config's enabled
+// when it is given.
+func recordVoice(cfg config.VoiceCommandsConfig, flags *pflag.FlagSet) *transcribe.VoiceInterpreter {
+	enabled := cfg.Enabled
+	if flags.Changed("voice-commands") {
+		enabled = rVoiceCmds
+	}
+	if !enabled {
+		return nil
+	}
+	return transcribe.NewVoiceInterpreter(voiceCommandsFromConfig(cfg))
+}
+
+// saveMark bookmarks the recording at audioPath elapsed in, in its sidecar,
+// under label if it has one. The sidecar is read afresh each time, so names
+// given to speakers since are kept.
+func saveMark(audioPath string, elapsed time.Duration, label string) error {
+	md, err := meta.Load(audioPath)
+	if err != nil {
+		return err
+	}
+	md.AddMark(elapsed.Round(time.Millisecond).Seconds(), label)
+	return md.Save(audioPath)
+}
+
+// followVoice carries out the actions spoken during a recording made without
+// the TUI, until the streamer is stopped. A mark goes into the sidecar and a
+// stop ends the recording; the streamer has already taken a scratch out of
+// the live transcript. heard, if set, is told of each action first.
+func followVoice(actions <-chan string, audioPath string, started time.Time, heard func(action string), stop func(), warn func(error)) {
+	for action := range actions {
+		if heard != nil {
+			heard(action)
+		}
+		switch action {
+		case transcribe.VoiceMark:
+			if err := saveMark(audioPath, time.Since(started), ""); err != nil {
+				warn(err)
+			}
+		case transcribe.VoiceStop:
+			stop()
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/meta"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+	"github.com/spf13/pflag"
+)
+
+func voiceFlags(t *testing.T, args ...string) *pflag.FlagSet {
+	t.Helper()
+	fs := pflag.NewFlagSet("record", pflag.ContinueOnError)
+	fs.BoolVar(&rVoiceCmds, "voice-commands", false, "")
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { rVoiceCmds = false })
+	return fs
+}
+
+func TestRecordVoiceFlagOverridesConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		args    []string
+		want    bool
+	}{
+		{"off by default", false, nil, false},
+		{"config turns on", true, nil, true},
+		{"flag turns on", false, []string{"--voice-commands"}, true},
+		{"flag turns off", true, []string{"--voice-commands=false"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := recordVoice(config.VoiceCommandsConfig{Enabled: tt.enabled}, voiceFlags(t, tt.args...))
+			if got := v != nil; got != tt.want {
+				t.Errorf("enabled = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordVoiceUsesConfigPhrases(t *testing.T) {
+	cfg := config.VoiceCommandsConfig{Enabled: true, Stop: []string{"over and out"}}
+	v := recordVoice(cfg, voiceFlags(t))
+	if _, action := v.Interpret("Over and out."); action != transcribe.VoiceStop {
+		t.Errorf("configured stop phrase: action = %q", action)
+	}
+	if _, action := v.Interpret("Mark this."); action != transcribe.VoiceMark {
+		t.Errorf("default mark phrase: action = %q", action)
+	}
+}
+
+func TestFollowVoice(t *testing.T) {
+	audio := filepath.Join(t.TempDir(), "memo.ogg")
+	actions := make(chan string, 4)
+	actions <- transcribe.VoiceScratch
+	actions <- transcribe.VoiceMark
+	actions <- transcribe.VoiceStop
+	close(actions)
+
+	var heard []string
+	stopped := false
+	started := time.Now().Add(-90 * time.Second)
+	followVoice(actions, audio, started, func(a string) { heard = append(heard, a) }, func() { stopped = true }, func(err error) {
+		t.Errorf("unexpected warning: %v", err)
+	})
+
+	if want := []string{transcribe.VoiceScratch, transcribe.VoiceMark, transcribe.VoiceStop}; !slices.Equal(heard, want) {
+		t.Errorf("heard %v, want %v", heard, want)
+	}
+	if !stopped {
+		t.Error("a spoken stop should stop the recording")
+	}
+	md, err := meta.Load(audio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(md.Marks) != 1 || md.Marks[0].At < 90 || md.Marks[0].At > 95 {
+		t.Errorf("marks = %v, want one about 90s in", md.Marks)
+	}
+}
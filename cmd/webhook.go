@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/summarize"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+	"github.com/joegoldin/audiomemo/internal/webhook"
+	"github.com/spf13/cobra"
+)
+
+var whConfig string
+
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Check the endpoints finished transcripts are POSTed to",
+	Long: `Every transcript transcribe finishes is POSTed, with its summary when
+--summarize made one, to each endpoint in [webhooks]. See WEBHOOKS in the
+README for the payload, its signature, and what happens when an endpoint is
+down.`,
+}
+
+var webhookTestCmd = &cobra.Command{
+	Use:   "test [url ...]",
+	Short: "Send a signed test payload to the configured endpoints",
+	Long: `Send one webhook.test payload to each endpoint in [webhooks], or only to
+the URLs given, and report how each answered. A URL that is not configured
+is sent the payload unsigned. There are no retries and nothing goes to the
+dead-letter file; the exit status is non-zero if any endpoint failed.
+
+Examples:
+  audiomemo webhook test
+  audiomemo webhook test http://127.0.0.1:9000/audiomemo`,
+	RunE: runWebhookTest,
+}
+
+func init() {
+	webhookCmd.PersistentFlags().StringVar(&whConfig, "config", "", "config file path")
+	webhookCmd.AddCommand(webhookTestCmd)
+}
+
+// newWebhookSender builds the sender for cfg's [webhooks] and the endpoints
+// it delivers to. It is checked before transcribing, so a bad duration fails
+// the run before the upload rather than after. cfg must have had ApplyEnv,
+// which reads the secret files, and useEncryption, so that with
+// [encryption] the dead letters are sealed like the transcripts they hold.
+func newWebhookSender(cfg *config.Config) (*webhook.Sender, []webhook.Endpoint, error) {
+	w := cfg.Webhooks
+	timeout, err := parseFlagDuration("webhooks.timeout", w.Timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	backoff, err := parseFlagDuration("webhooks.backoff", w.Backoff)
+	if err != nil {
+		return nil, nil, err
+	}
+	if w.Retries < 0 {
+		return nil, nil, fmt.Errorf("invalid webhooks.retries %d: must not be negative", w.Retries)
+	}
+	var endpoints []webhook.Endpoint
+	for _, ep := range w.Endpoints {
+		if ep.URL == "" {
+			return nil, nil, fmt.Errorf("a [[webhooks.endpoints]] entry has no url")
+		}
+		if ep.Secret == "" && ep.SecretFile != "" {
+			return nil, nil, fmt.Errorf("webhook %s: cannot read secret_file %s", ep.URL, ep.SecretFile)
+		}
+		endpoints = append(endpoints, webhook.Endpoint{URL: ep.URL, Secret: ep.Secret})
+	}
+	sender := &webhook.Sender{
+		Timeout:    timeout,
+		Retries:    w.Retries,
+		Backoff:    backoff,
+		DeadLetter: cfg.ResolveDeadLetterPath(),
+	}
+	if atRest.Encrypting() {
+		sender.Seal = atRest.Seal
+	}
+	return sender, endpoints, nil
+}
+
+// transcriptPayload is the webhook payload for a finished transcript.
+// audioPath is empty for audio read from stdin.
+func transcriptPayload(audioPath, transcriptPath, backend string, result *transcribe.Result, summary *summarize.Summary) webhook.Payload {
+	rec := webhook.Recording{
+		Path:       audioPath,
+		Transcript: transcriptPath,
+		Backend:    backend,
+		Language:   result.Language,
+		Duration:   result.Duration,
+	}
+	if audioPath != "" {
+		rec.Label = hookLabel(audioPath)
+	}
+	p := webhook.NewPayload(webhook.EventTranscript, rec, result, time.Now())
+	if summary != nil {
+		p.Summary = &webhook.Summary{Title: summary.Title, Summary: summary.Summary, ActionItems: summary.ActionItems}
+	}
+	return p
+}
+
+// deliverWebhooks sends p to every endpoint in turn, reporting each one that
+// never got it to warn. It never fails the run: the transcript is on disk,
+// and the payload is in the dead-letter file.
+func deliverWebhooks(ctx context.Context, s *webhook.Sender, endpoints []webhook.Endpoint, p webhook.Payload, warn func(error)) {
+	for _, ep := range endpoints {
+		if err := s.Deliver(ctx, ep, p); err != nil {
+			warn(fmt.Errorf("webhook %w", err))
+		}
+	}
+}
+
+func runWebhookTest(cmd *cobra.Command, args []string) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	var cfg *config.Config
+	var err error
+	if whConfig != "" {
+		cfg, err = config.LoadFrom(whConfig)
+	} else {
+		cfg, err = config.Load()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	cfg.ApplyEnv()
+
+	sender, endpoints, err := newWebhookSender(cfg)
+	if err != nil {
+		return err
+	}
+	targets, err := webhookTestTargets(endpoints, args)
+	if err != nil {
+		return err
+	}
+	return testWebhooks(ctx, sender, targets, os.Stdout)
+}
+
+// webhookTestTargets picks the endpoints `webhook test` sends to: the ones
+// named, with their secrets when they are configured, or else all of them.
+func webhookTestTargets(configured []webhook.Endpoint, urls []string) ([]webhook.Endpoint, error) {
+	if len(urls) == 0 {
+		if len(configured) == 0 {
+			return nil, fmt.Errorf("no webhook endpoints configured: add [[webhooks.endpoints]] to the config, or name a URL")
+		}
+		return configured, nil
+	}
+	var targets []webhook.Endpoint
+	for _, url := range urls {
+		i := slices.IndexFunc(configured, func(ep webhook.Endpoint) bool { return ep.URL == url })
+		if i >= 0 {
+			targets = append(targets, configured[i])
+		} else {
+			targets = append(targets, webhook.Endpoint{URL: url})
+		}
+	}
+	return targets, nil
+}
+
+// testWebhooks sends each target one test payload and prints a line for how
+// it went.
+func testWebhooks(ctx context.Context, s *webhook.Sender, targets []webhook.Endpoint, out io.Writer) error {
+	result := &transcribe.Result{
+		Text:     "This is a test delivery from audiomemo.",
+		Segments: []transcribe.Segment{{Start: 0, End: 2.5, Text: "This is a test delivery from audiomemo."}},
+		Language: "en",
+		Duration: 2.5,
+	}
+	rec := webhook.Recording{Path: "webhook-test.ogg", Label: "webhook-test", Backend: "test", Language: "en", Duration: 2.5}
+	var failed []error
+	for _, ep := range targets {
+		p := webhook.NewPayload(webhook.EventTest, rec, result, time.Now())
+		p.Summary = &webhook.Summary{Title: "Webhook test", Summary: "A test delivery.", ActionItems: "None."}
+		signed := "signed"
+		if ep.Secret == "" {
+			signed = "unsigned"
+		}
+		start := time.Now()
+		if err := s.Send(ctx, ep, p); err != nil {
+			fmt.Fprintf(out, "FAIL  %s (%s): %v\n", ep.URL, signed, err)
+			failed = append(failed, err)
+			continue
+		}
+		fmt.Fprintf(out, "ok    %s (%s, %s)\n", ep.URL, signed, time.Since(start).Round(time.Millisecond))
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d webhook endpoints failed", len(failed), len(targets))
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/encrypt"
+	"github.com/joegoldin/audiomemo/internal/summarize"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+	"github.com/joegoldin/audiomemo/internal/webhook"
+)
+
+func TestNewWebhookSender(t *testing.T) {
+	cfg := config.Default()
+	cfg.Webhooks.DeadLetter = "/tmp/dead.jsonl"
+	cfg.Webhooks.Backoff = "500ms"
+	cfg.Webhooks.Endpoints = []config.WebhookEndpoint{{URL: "https://example.com/hook", Secret: "k"}}
+	s, endpoints, err := newWebhookSender(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Retries != 4 || s.Backoff.String() != "500ms" || s.DeadLetter != "/tmp/dead.jsonl" {
+		t.Errorf("sender = %+v", s)
+	}
+	if len(endpoints) != 1 || endpoints[0] != (webhook.Endpoint{URL: "https://example.com/hook", Secret: "k"}) {
+		t.Errorf("endpoints = %+v", endpoints)
+	}
+	if s.Seal != nil {
+		t.Error("dead letters are sealed without [encryption]")
+	}
+
+	atRest = &encrypt.Keys{Recipients: []string{"age1example"}}
+	defer func() { atRest = nil }()
+	if s, _, _ := newWebhookSender(cfg); s.Seal == nil {
+		t.Error("dead letters are left in plaintext with [encryption]")
+	}
+
+	bad := []struct {
+		name string
+		edit func(*config.WebhooksConfig)
+		want string
+	}{
+		{"timeout", func(w *config.WebhooksConfig) { w.Timeout = "soon" }, "webhooks.timeout"},
+		{"retries", func(w *config.WebhooksConfig) { w.Retries = -1 }, "webhooks.retries"},
+		{"url", func(w *config.WebhooksConfig) { w.Endpoints = []config.WebhookEndpoint{{}} }, "no url"},
+		{"secret file", func(w *config.WebhooksConfig) {
+			w.Endpoints = []config.WebhookEndpoint{{URL: "https://example.com", SecretFile: "/nonexistent"}}
+		}, "secret_file"},
+	}
+	for _, tt := range bad {
+		cfg := config.Default()
+		tt.edit(&cfg.Webhooks)
+		cfg.ApplyEnv()
+		if _, _, err := newWebhookSender(cfg); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want it to mention %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestTranscriptPayload(t *testing.T) {
+	result := &transcribe.Result{Text: "Hi.", Language: "en", Duration: 3}
+	p := transcriptPayload("/r/2026-10-18_standup.ogg", "/r/2026-10-18_standup.txt", "whisper", result,
+		&summarize.Summary{Title: "Standup", Summary: "Short.", ActionItems: "- ship"})
+	if p.Event != webhook.EventTranscript || p.Recording.Label != "2026-10-18_standup" || p.Recording.Duration != 3 {
+		t.Errorf("payload = %+v", p)
+	}
+	if p.Summary == nil || p.Summary.Title != "Standup" || p.Summary.ActionItems != "- ship" {
+		t.Errorf("summary = %+v", p.Summary)
+	}
+
+	stdin := transcriptPayload("", "", "whisper", result, nil)
+	if stdin.Recording.Label != "" || stdin.Summary != nil {
+		t.Errorf("stdin payload = %+v", stdin)
+	}
+}
+
+func TestWebhookTestTargets(t *testing.T) {
+	configured := []webhook.Endpoint{{URL: "https://a.example", Secret: "a"}, {URL: "https://b.example"}}
+	if _, err := webhookTestTargets(nil, nil); err == nil {
+		t.Error("want an error with nothing to test")
+	}
+	if got, _ := webhookTestTargets(configured, nil); len(got) != 2 {
+		t.Errorf("all = %+v", got)
+	}
+	got, _ := webhookTestTargets(configured, []string{"https://a.example", "http://localhost:9000"})
+	want := []webhook.Endpoint{{URL: "https://a.example", Secret: "a"}, {URL: "http://localhost:9000"}}
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("named = %+v, want %+v", got, want)
+	}
+}
+
+func TestTestWebhooks(t *testing.T) {
+	var signature string
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get(webhook.HeaderSignature)
+	}))
+	defer ok.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	dead := filepath.Join(t.TempDir(), "dead.jsonl")
+	s := &webhook.Sender{Retries: 3, DeadLetter: dead}
+	var out bytes.Buffer
+	err := testWebhooks(context.Background(), s, []webhook.Endpoint{{URL: ok.URL, Secret: "k"}, {URL: down.URL}}, &out)
+	if err == nil || !strings.Contains(err.Error(), "1 of 2") {
+		t.Errorf("err = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "ok    "+ok.URL+" (signed, ") ||
+		!strings.HasPrefix(lines[1], "FAIL  "+down.URL+" (unsigned): 502") {
+		t.Errorf("output:\n%s", out.String())
+	}
+	if !strings.HasPrefix(signature, "sha256=") {
+		t.Errorf("signature = %q", signature)
+	}
+	if _, err := os.Stat(dead); !os.IsNotExist(err) {
+		t.Error("a test send should not be dead-lettered")
+	}
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/server"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+	"github.com/joegoldin/audiomemo/internal/wyoming"
+	"github.com/spf13/cobra"
+)
+
+var (
+	wyConfig   string
+	wyListen   string
+	wyBackend  string
+	wyRealtime bool
+)
+
+var wyomingCmd = &cobra.Command{
+	Use:   "wyoming",
+	Short: "Serve speech-to-text to Home Assistant over the Wyoming protocol",
+	Long: `Run a Wyoming protocol speech-to-text server, so a Home Assistant voice
+pipeline can use audiomemo's backends, local whisper.cpp included.
+
+Each utterance is buffered to a WAV file and transcribed when the speaker
+stops. With --realtime (or wyoming.realtime), 16 kHz audio is instead
+streamed to ElevenLabs as it arrives, so the transcript is ready almost as
+soon as the utterance ends.
+
+In Home Assistant, add the Wyoming Protocol integration with this machine's
+host and port. The protocol has no authentication: listen on a network
+address only on a network you trust.
+
+Examples:
+  audiomemo wyoming --backend whisper-cpp
+  audiomemo wyoming --listen 0.0.0.0:10300 --realtime`,
+	Args: cobra.NoArgs,
+	RunE: runWyoming,
+}
+
+func init() {
+	wyomingCmd.Flags().StringVar(&wyConfig, "config", "", "config file path")
+	wyomingCmd.Flags().StringVar(&wyListen, "listen", "", "address to listen on (wyoming.listen, default 127.0.0.1:10300)")
+	wyomingCmd.Flags().StringVarP(&wyBackend, "backend", "b", "", "batch backend (wyoming.backend, default as for transcribe)")
+	wyomingCmd.Flags().BoolVar(&wyRealtime, "realtime", false, "stream 16 kHz audio to ElevenLabs as it arrives (wyoming.realtime)")
+}
+
+// wyomingLanguages are offered to Home Assistant when neither
+// wyoming.languages nor transcribe.language narrows them. Home Assistant only
+// offers an engine for pipelines in a language it lists; these are the ones
+// every cloud backend and whisper handle well.
+var wyomingLanguages = []string{
+	"ar", "bg", "ca", "cs", "da", "de", "el", "en", "es", "fi", "fr", "he",
+	"hi", "hr", "hu", "id", "it", "ja", "ko", "ms", "nl", "no", "pl", "pt",
+	"ro", "ru", "sk", "sl", "sr", "sv", "th", "tr", "uk", "vi", "zh",
+}
+
+// wyomingInfo describes the server to Home Assistant: one program with one
+// model, named after the backend so a client echoing it back selects it.
+func wyomingInfo(backendName string, languages []string) wyoming.Info {
+	attribution := wyoming.Attribution{Name: "audiomemo", URL: "https://github.com/joegoldin/audiomemo"}
+	return wyoming.Info{ASR: []wyoming.Program{{
+		Name:        "audiomemo",
+		Description: "audiomemo speech-to-text",
+		Attribution: attribution,
+		Installed:   true,
+		Models: []wyoming.Model{{
+			Name:        backendName,
+			Description: backendName + " via audiomemo",
+			Attribution: attribution,
+			Installed:   true,
+			Languages:   languages,
+		}},
+	}}}
+}
+
+// wyomingSessions routes each utterance. In realtime mode, audio the
+// streamer can take goes to ElevenLabs' realtime endpoint unless the client
+// asked for another backend by name; everything else is transcribed in one
+// batch at the end.
+func wyomingSessions(cfg *config.Config, resolve server.Resolver, post *transcribe.PostProcessor, realtime bool) wyoming.SessionFunc {
+	return func(ctx context.Context, req wyoming.Request) (wyoming.Session, error) {
+		name, _ := serveModel(req.Model)
+		if realtime && req.Format == wyoming.StreamFormat && (name == "" || name == transcribe.RealtimeBackendName) {
+			streamer := transcribe.NewStreamer(cfg.Transcribe.ElevenLabs.APIKey, cfg.Transcribe.ElevenLabs.StoreInCloud)
+			streamer.SetPostProcess(post)
+			return wyoming.NewStreamSession(ctx, streamer, req.Format)
+		}
+		backend, opts, err := resolve(req.Model)
+		if err != nil {
+			return nil, err
+		}
+		if req.Language != "" {
+			opts.Language = req.Language
+		}
+		return wyoming.NewBatchSession(backend, opts, post, req.Format)
+	}
+}
+
+func runWyoming(cmd *cobra.Command, args []string) error {
+	var cfg *config.Config
+	var err error
+	if wyConfig != "" {
+		cfg, err = config.LoadFrom(wyConfig)
+	} else {
+		cfg, err = config.Load()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	cfg.ApplyEnv()
+
+	listen := strings.TrimPrefix(cfg.Wyoming.Listen, "tcp://")
+	if wyListen != "" {
+		listen = strings.TrimPrefix(wyListen, "tcp://")
+	}
+	backendName := cfg.Wyoming.Backend
+	if wyBackend != "" {
+		backendName = wyBackend
+	}
+	realtime := cfg.Wyoming.Realtime
+	if cmd.Flags().Changed("realtime") {
+		realtime = wyRealtime
+	}
+	if realtime && cfg.Transcribe.ElevenLabs.APIKey == "" {
+		return fmt.Errorf("--realtime requires an ElevenLabs API key")
+	}
+	languages := cfg.Wyoming.Languages
+	if len(languages) == 0 && cfg.Transcribe.Language != "" {
+		languages = []string{cfg.Transcribe.Language}
+	}
+	if len(languages) == 0 {
+		languages = wyomingLanguages
+	}
+
+	vocabulary, err := resolveVocabulary(cfg.Transcribe.Vocabulary, "")
+	if err != nil {
+		return err
+	}
+	post, err := newPostProcessor(cfg.Transcribe.PostProcess)
+	if err != nil {
+		return err
+	}
+	resolve := serveResolver(cfg, backendName, vocabulary)
+	backend, _, err := resolve("")
+	if err != nil {
+		return err
+	}
+	modelName := backend.Name()
+	if realtime {
+		modelName = transcribe.RealtimeBackendName
+	}
+
+	ln, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+	if !isLoopback(listen) {
+		fmt.Fprintf(os.Stderr, "Warning: the Wyoming protocol has no authentication and %s is reachable from other machines\n", listen)
+	}
+	mode := "batch"
+	if realtime {
+		mode = "realtime"
+	}
+	fmt.Fprintf(os.Stderr, "Serving %s (%s) over Wyoming on tcp://%s\n", modelName, mode, ln.Addr())
+
+	srv := &wyoming.Server{
+		Info:       wyomingInfo(modelName, languages),
+		NewSession: wyomingSessions(cfg, resolve, post, realtime),
+		ErrorLog: func(err error) {
+			fmt.Fprintf(os.Stderr, "wyoming: %v\n", err)
+		},
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return srv.Serve(ctx, ln)
+}
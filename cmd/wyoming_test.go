@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/joegoldin/audiomemo/internal/config"
+	"github.com/joegoldin/audiomemo/internal/wyoming"
+)
+
+func TestWyomingInfo(t *testing.T) {
+	info := wyomingInfo("whisper-cpp", []string{"en"})
+	if len(info.ASR) != 1 || len(info.ASR[0].Models) != 1 {
+		t.Fatalf("info = %+v", info)
+	}
+	m := info.ASR[0].Models[0]
+	if m.Name != "whisper-cpp" || !m.Installed || !slices.Equal(m.Languages, []string{"en"}) {
+		t.Errorf("model = %+v", m)
+	}
+	// A client echoing the model name back must select the same backend.
+	if backend, _ := serveModel(m.Name); backend != "whisper-cpp" {
+		t.Errorf("serveModel(%q) = %q", m.Name, backend)
+	}
+}
+
+func TestWyomingSessionsBatch(t *testing.T) {
+	cfg := config.Default()
+	cfg.Transcribe.OpenAI.APIKey = "oa"
+	cfg.Transcribe.ElevenLabs.APIKey = "el"
+	resolve := serveResolver(cfg, "openai", nil)
+
+	// Realtime only takes 16 kHz mono, and only for ElevenLabs; anything
+	// else falls back to a batch session, which needs no network to start.
+	for _, tt := range []struct {
+		name     string
+		realtime bool
+		req      wyoming.Request
+	}{
+		{"batch mode", false, wyoming.Request{Format: wyoming.StreamFormat}},
+		{"other rate", true, wyoming.Request{Format: wyoming.AudioFormat{Rate: 22050, Width: 2, Channels: 1}}},
+		{"named backend", true, wyoming.Request{Model: "openai", Format: wyoming.StreamFormat}},
+	} {
+		session, err := wyomingSessions(cfg, resolve, nil, tt.realtime)(t.Context(), tt.req)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		session.Close()
+	}
+
+	if _, err := wyomingSessions(cfg, resolve, nil, false)(t.Context(), wyoming.Request{Model: "mistral", Format: wyoming.StreamFormat}); err == nil {
+		t.Error("expected an error for a backend without a key")
+	}
+}
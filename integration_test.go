@@ -225,6 +225,23 @@ func TestTranscribeMistralNoKey(t *testing.T) {
 	}
 }
 
+func TestTranscribeLiveNoKey(t *testing.T) {
+	t.Setenv("ELEVENLABS_API_KEY", "")
+	t.Setenv("ELEVENLABS_API_KEY_FILE", "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	_, stderr, err := run(t, "transcribe", "--live", "--input-format", "s16le", "-")
+	if err == nil || !strings.Contains(stderr, "ElevenLabs API key") {
+		t.Errorf("err = %v, stderr %q", err, stderr)
+	}
+}
+
+func TestTranscribeInputFormatRequiresLive(t *testing.T) {
+	_, stderr, err := run(t, "transcribe", "--input-format", "s16le", "-")
+	if err == nil || !strings.Contains(stderr, "--input-format requires --live") {
+		t.Errorf("err = %v, stderr %q", err, stderr)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Transcribe: whisper-cpp end-to-end (requires whisper-cli + model)
 // ---------------------------------------------------------------------------
@@ -1095,3 +1112,173 @@ func TestRecordPipedStdoutOmitsThePath(t *testing.T) {
 		t.Errorf("the path belongs on stderr in headless mode, got %q", stderr)
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Input file
+// ---------------------------------------------------------------------------
+
+func TestRecordInputFileStreamsLikeADevice(t *testing.T) {
+	configPath, outputDir := stubRecordConfig(t)
+	input, err := filepath.Abs("testdata/test.ogg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, err := runWithStubFFmpeg(t, "0.5",
+		"record", "--stream", "--input-file", input, "--realtime",
+		"--no-live-transcription", "--config", configPath)
+	if err != nil {
+		t.Fatalf("record --input-file failed: %v\n%s", err, stderr)
+	}
+
+	types := map[string]int{}
+	var start, end map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		var ev map[string]any
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("not an event: %q: %v", line, err)
+		}
+		typ, _ := ev["type"].(string)
+		types[typ]++
+		switch typ {
+		case "start":
+			start = ev
+		case "end":
+			end = ev
+		}
+	}
+	if start == nil || end == nil || types["level"] == 0 {
+		t.Fatalf("want start, levels and end, got %v:\n%s", types, stdout)
+	}
+	if start["input_file"] != input || start["device_label"] != "test.ogg" {
+		t.Errorf("start should name the file: %v", start)
+	}
+	// The file ending is the recording ending, not a failure.
+	if end["reason"] != "stopped" || end["exit_code"] != float64(0) {
+		t.Errorf("end = %v", end)
+	}
+	// With no name given, the recording is named after the file.
+	path, _ := end["path"].(string)
+	if filepath.Dir(path) != outputDir || !strings.HasPrefix(filepath.Base(path), "test-") {
+		t.Errorf("recording saved as %q, want test-*.ogg in %s", path, outputDir)
+	}
+}
+
+func TestRecordInputFileRejections(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.ogg")
+	cases := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"realtime alone", []string{"--realtime", "--no-tui"}, "--realtime requires --input-file"},
+		{"with a device", []string{"--input-file", "testdata/test.ogg", "-D", "default"}, "cannot be combined with --device"},
+		{"missing file", []string{"--input-file", missing}, "missing.ogg"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, stderr, err := run(t, append([]string{"record"}, c.args...)...)
+			if err == nil || !strings.Contains(stderr, c.want) {
+				t.Errorf("err = %v, stderr %q, want %q", err, stderr, c.want)
+			}
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Stream tee and replay
+// ---------------------------------------------------------------------------
+
+func TestRecordStreamTeeReplaysIdentically(t *testing.T) {
+	configPath, _ := stubRecordConfig(t)
+	teePath := filepath.Join(t.TempDir(), "session.ndjson")
+
+	live, stderr, err := runWithStubFFmpeg(t, "1.0",
+		"record", "--stream", "--stream-tee", teePath, "-D", "default", "--no-live-transcription",
+		"--max-duration", "1s", "--config", configPath, "-n", "tee")
+	if err != nil {
+		t.Fatalf("record --stream failed: %v\n%s", err, stderr)
+	}
+	saved, err := os.ReadFile(teePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(saved) != live || !strings.Contains(live, `"type":"end"`) {
+		t.Fatalf("the tee differs from stdout:\n%s\nvs\n%s", saved, live)
+	}
+
+	replayed, stderr, err := run(t, "replay", "--speed", "max", teePath)
+	if err != nil {
+		t.Fatalf("replay failed: %v\n%s", err, stderr)
+	}
+	if replayed != live {
+		t.Errorf("replay differs from the live stream:\n%s\nvs\n%s", replayed, live)
+	}
+}
+
+func TestStreamTeeRequiresStream(t *testing.T) {
+	_, stderr, err := run(t, "record", "--stream-tee", filepath.Join(t.TempDir(), "x.ndjson"))
+	if err == nil || !strings.Contains(stderr, "--stream-tee requires --stream") {
+		t.Errorf("err = %v, stderr %q", err, stderr)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Encryption at rest
+// ---------------------------------------------------------------------------
+
+// stubAge puts a stand-in age ahead of PATH that "encrypts" a file to
+// --output by copying it behind a header, which is all record needs.
+func stubAge(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	script := `#!/bin/sh
+out=
+while [ $# -gt 1 ]; do
+	case "$1" in
+	--output) out="$2"; shift ;;
+	esac
+	shift
+done
+{ echo "age-stub"; cat "$1"; } > "$out"
+`
+	if err := os.WriteFile(filepath.Join(dir, "age"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestRecordStreamEncryptedGivesHooksTheSealedPath(t *testing.T) {
+	stubAge(t)
+	configPath, outputDir := stubRecordConfig(t)
+	seen := filepath.Join(t.TempDir(), "audio")
+	extra := "\n[encryption]\nrecipients = [\"age1example\"]\n\n[hooks]\npost_record = 'echo \"$AUDIOMEMO_AUDIO\" > " + seen + "'\n"
+	f, err := os.OpenFile(configPath, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(extra)
+	f.Close()
+
+	stdout, stderr, err := runWithStubFFmpeg(t, "1.0",
+		"record", "--stream", "-D", "default", "--no-live-transcription",
+		"--max-duration", "1s", "--config", configPath, "-n", "sealed")
+	if err != nil {
+		t.Fatalf("record --stream failed: %v\n%s", err, stderr)
+	}
+	if strings.Contains(stdout, `"type":"error"`) {
+		t.Errorf("the stream reported an error:\n%s", stdout)
+	}
+
+	data, err := os.ReadFile(seen)
+	if err != nil {
+		t.Fatalf("post_record did not run: %v\n%s", err, stdout)
+	}
+	audio := strings.TrimSpace(string(data))
+	if !strings.HasSuffix(audio, ".age") || filepath.Dir(audio) != outputDir {
+		t.Errorf("post_record was given %q, want the sealed recording in %s", audio, outputDir)
+	}
+	if _, err := os.Stat(audio); err != nil {
+		t.Errorf("the path post_record was given does not exist: %v", err)
+	}
+}
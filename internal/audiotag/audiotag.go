@@ -0,0 +1,365 @@
+// Package audiotag writes a transcript into a recording's own metadata, so
+// the words travel with the audio when the files beside it are left behind,
+// and reads it back.
+//
+// ffmpeg remuxes the recording with the new tags and chapters; the audio is
+// copied, not re-encoded. Ogg, Opus and FLAC get Vorbis comments, M4A gets
+// iTunes atoms, and MP3 gets ID3v2.4 frames, among them the unsynchronised
+// (USLT) and synchronised (SYLT) lyrics frames, which ffmpeg cannot write and
+// are spliced into the tag it leaves. Besides the plain lyrics every format
+// carries the JSON transcript, which is what Read prefers: it keeps the
+// segments and speakers that plain lyrics lose.
+package audiotag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/joegoldin/audiomemo/internal/encrypt"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+// KeyTranscript holds the JSON transcript, as `transcribe -f json` writes it.
+const KeyTranscript = "AUDIOMEMO_TRANSCRIPT"
+
+// ErrNoTranscript is Read's answer for a file with no transcript in it.
+var ErrNoTranscript = errors.New("no transcript embedded")
+
+// Kind is the family of tags a container holds.
+type Kind int
+
+const (
+	Vorbis Kind = iota + 1 // Ogg, Opus, FLAC
+	ID3                    // MP3
+	MP4                    // M4A
+)
+
+// KindOf says which tags the file at path takes, from its extension.
+func KindOf(path string) (Kind, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ogg", ".oga", ".opus", ".flac":
+		return Vorbis, nil
+	case ".mp3":
+		return ID3, nil
+	case ".m4a":
+		return MP4, nil
+	}
+	return 0, fmt.Errorf("cannot tag %s files: use Ogg, Opus, FLAC, MP3 or M4A", filepath.Ext(path))
+}
+
+// Tags is what Write embeds. Fields left empty are left alone.
+type Tags struct {
+	Title    string
+	Date     time.Time
+	Result   *transcribe.Result
+	Chapters []Chapter
+}
+
+// Chapter is a named span of the recording, in seconds.
+type Chapter struct {
+	Start, End float64
+	Title      string
+}
+
+// Write embeds t in the recording at path. The new file is written beside
+// the old and renamed over it, so a failure leaves the recording as it was.
+// Tags already in the file that t does not replace are kept.
+func Write(ctx context.Context, path string, t Tags) error {
+	kind, err := KindOf(path)
+	if err != nil {
+		return err
+	}
+	existing, err := probeTags(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	// The metadata holds the transcript, so it goes with the other
+	// plaintext temp files rather than beside the recording.
+	dir, err := encrypt.TempDir()
+	if err != nil {
+		return err
+	}
+	metaFile, err := os.CreateTemp(dir, "tags-*.txt")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(metaFile.Name())
+	_, err = metaFile.WriteString(ffmetadata(kind, existing, t))
+	if err := errors.Join(err, metaFile.Close()); err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(filepath.Dir(path), ".tagging-"+filepath.Base(path))
+	defer os.Remove(tmp)
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ffmpeg", writeArgs(kind, path, metaFile.Name(), tmp, len(t.Chapters) > 0)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	if kind == ID3 && t.Result != nil {
+		data, err := os.ReadFile(tmp)
+		if err != nil {
+			return err
+		}
+		lang := transcribe.LanguageCode3(t.Result.Language)
+		frames := [][]byte{usltFrame(lang, t.Result.Format(transcribe.FormatText))}
+		if len(t.Result.Segments) > 0 {
+			frames = append(frames, syltFrame(lang, t.Result.Segments))
+		}
+		if data, err = spliceID3(data, frames...); err != nil {
+			return err
+		}
+		if err := os.WriteFile(tmp, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		os.Chmod(tmp, info.Mode().Perm())
+	}
+	return os.Rename(tmp, path)
+}
+
+// writeArgs remuxes in to out with the tags in metaFile, and its chapters
+// when there are any, or else the ones already in the file. The tags go to
+// the audio stream as well, since that is where an Ogg file keeps its
+// comments.
+func writeArgs(kind Kind, in, metaFile, out string, chapters bool) []string {
+	chapterSource := "0"
+	if chapters {
+		chapterSource = "1"
+	}
+	args := []string{
+		"-y", "-v", "error",
+		"-i", in,
+		"-f", "ffmetadata", "-i", metaFile,
+		"-map", "0", "-c", "copy",
+		"-map_metadata", "1", "-map_metadata:s:a", "1:g",
+		"-map_chapters", chapterSource,
+	}
+	switch kind {
+	case ID3:
+		args = append(args, "-id3v2_version", "4")
+	case MP4:
+		// Without it the mov muxer drops keys it has no atom for, such as
+		// the JSON transcript.
+		args = append(args, "-movflags", "use_metadata_tags")
+	}
+	return append(args, out)
+}
+
+// ourKeys are replaced on every write, whatever case they were written in.
+var ourKeys = []string{"title", "date", "lyrics", strings.ToLower(KeyTranscript)}
+
+// ffmetadata renders ffmpeg's metadata file: the tags already in the file
+// that are not ours, then ours, then the chapters.
+func ffmetadata(kind Kind, existing map[string]string, t Tags) string {
+	var b strings.Builder
+	b.WriteString(";FFMETADATA1\n")
+	put := func(key, value string) {
+		fmt.Fprintf(&b, "%s=%s\n", escapeMeta(key), escapeMeta(value))
+	}
+
+	keys := make([]string, 0, len(existing))
+	for k := range existing {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		lower := strings.ToLower(k)
+		if slices.Contains(ourKeys, lower) || strings.HasPrefix(lower, "lyrics-") || lower == "encoder" {
+			continue
+		}
+		put(k, existing[k])
+	}
+
+	key := strings.ToLower
+	if kind == Vorbis {
+		key = strings.ToUpper
+	}
+	if t.Title != "" {
+		put(key("title"), t.Title)
+	}
+	if !t.Date.IsZero() {
+		put(key("date"), t.Date.Format("2006-01-02"))
+	}
+	if t.Result != nil {
+		// MP3 lyrics go into USLT and SYLT frames instead, which ffmpeg
+		// would otherwise write as a TXXX frame nothing reads.
+		if kind != ID3 {
+			put(key("lyrics"), t.Result.Format(transcribe.FormatText))
+		}
+		put(KeyTranscript, compactJSON(t.Result))
+	}
+
+	for _, c := range t.Chapters {
+		b.WriteString("[CHAPTER]\nTIMEBASE=1/1000\n")
+		fmt.Fprintf(&b, "START=%d\nEND=%d\n", int64(c.Start*1000), int64(c.End*1000))
+		put("title", c.Title)
+	}
+	return b.String()
+}
+
+func compactJSON(r *transcribe.Result) string {
+	out := *r
+	out.SchemaVersion = transcribe.SchemaVersion
+	data, _ := json.Marshal(out)
+	return string(data)
+}
+
+// escapeMeta escapes what the ffmetadata format gives meaning to.
+func escapeMeta(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", "\\\n").Replace(s)
+}
+
+// Read returns the transcript embedded in the recording at path: the JSON
+// transcript when it is there, or else the lyrics as plain text. A file
+// with neither is ErrNoTranscript.
+func Read(ctx context.Context, path string) (*transcribe.Result, error) {
+	tags, err := probeTags(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return fromTags(tags)
+}
+
+func fromTags(tags map[string]string) (*transcribe.Result, error) {
+	for k, v := range tags {
+		if strings.EqualFold(k, KeyTranscript) {
+			var r transcribe.Result
+			if err := json.Unmarshal([]byte(v), &r); err != nil {
+				return nil, fmt.Errorf("embedded transcript: %w", err)
+			}
+			r.SchemaVersion = ""
+			return &r, nil
+		}
+	}
+	for k, v := range tags {
+		lower := strings.ToLower(k)
+		if (lower == "lyrics" || strings.HasPrefix(lower, "lyrics-")) && strings.TrimSpace(v) != "" {
+			return &transcribe.Result{Text: v}, nil
+		}
+	}
+	return nil, ErrNoTranscript
+}
+
+// probeTags asks ffprobe for the tags on the file and on its first audio
+// stream, where Ogg keeps them, as one map.
+func probeTags(ctx context.Context, path string) (map[string]string, error) {
+	out, err := exec.CommandContext(ctx, "ffprobe",
+		"-v", "error",
+		"-select_streams", "a:0",
+		"-show_entries", "format_tags:stream_tags",
+		"-of", "json",
+		path,
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe: %w", err)
+	}
+	return parseProbe(out)
+}
+
+func parseProbe(out []byte) (map[string]string, error) {
+	var probe struct {
+		Streams []struct {
+			Tags map[string]string `json:"tags"`
+		} `json:"streams"`
+		Format struct {
+			Tags map[string]string `json:"tags"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return nil, fmt.Errorf("ffprobe: %w", err)
+	}
+	tags := map[string]string{}
+	for _, s := range probe.Streams {
+		for k, v := range s.Tags {
+			tags[k] = v
+		}
+	}
+	for k, v := range probe.Format.Tags {
+		tags[k] = v
+	}
+	return tags, nil
+}
+
+// ChaptersFromMarks makes a chapter of each bookmark, running to the next
+// one or the end, and one for the start when the first mark comes later.
+func ChaptersFromMarks(marks []float64, duration float64) []Chapter {
+	marks = slices.Clone(marks)
+	slices.Sort(marks)
+	var out []Chapter
+	if len(marks) > 0 && marks[0] >= 1 {
+		out = append(out, Chapter{Start: 0, End: marks[0], Title: "Start"})
+	}
+	for i, at := range marks {
+		end := duration
+		if i+1 < len(marks) {
+			end = marks[i+1]
+		}
+		if end <= at {
+			continue
+		}
+		out = append(out, Chapter{Start: at, End: end, Title: fmt.Sprintf("Mark %d", i+1)})
+	}
+	return out
+}
+
+// minChapter is how long a chapter of an undiarised transcript runs before
+// the next segment starts another.
+const minChapter = 60.0
+
+// ChaptersFromSegments makes a chapter of each speaker's turn or, without
+// speakers, of each minute or so, named after its first words.
+func ChaptersFromSegments(segs []transcribe.Segment, duration float64) []Chapter {
+	var out []Chapter
+	speaker := ""
+	for _, seg := range segs {
+		text := strings.TrimSpace(seg.Text)
+		if text == "" {
+			continue
+		}
+		if n := len(out); n > 0 {
+			same := seg.Speaker == speaker
+			if same && (speaker != "" || seg.Start-out[n-1].Start < minChapter) {
+				out[n-1].End = seg.End
+				continue
+			}
+			out[n-1].End = seg.Start
+		}
+		speaker = seg.Speaker
+		title := firstWords(text, 40)
+		if speaker != "" {
+			title = speaker + ": " + title
+		}
+		out = append(out, Chapter{Start: seg.Start, End: seg.End, Title: title})
+	}
+	if n := len(out); n > 0 && duration > out[n-1].End {
+		out[n-1].End = duration
+	}
+	return out
+}
+
+func firstWords(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)[:n]
+	if i := strings.LastIndex(string(r), " "); i > 0 {
+		return string(r)[:i] + "…"
+	}
+	return string(r) + "…"
+}
@@ -0,0 +1,212 @@
+package audiotag
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+var testResult = &transcribe.Result{
+	Text:     "Morning. Hi.",
+	Language: "en",
+	Segments: []transcribe.Segment{
+		{Start: 0, End: 1.5, Text: "Morning.", Speaker: "Alice"},
+		{Start: 2.25, End: 3, Text: "Hi.", Speaker: "Bob"},
+	},
+}
+
+func TestKindOf(t *testing.T) {
+	for path, want := range map[string]Kind{"a.ogg": Vorbis, "a.OPUS": Vorbis, "a.flac": Vorbis, "a.mp3": ID3, "a.m4a": MP4} {
+		if got, err := KindOf(path); err != nil || got != want {
+			t.Errorf("KindOf(%q) = %v, %v", path, got, err)
+		}
+	}
+	if _, err := KindOf("a.wav"); err == nil {
+		t.Error("WAV has nowhere to keep a transcript")
+	}
+}
+
+func TestFFMetadata(t *testing.T) {
+	existing := map[string]string{"ARTIST": "Me", "TITLE": "old", "lyrics-eng": "old words", "encoder": "Lavf", "comment": "a=b;c"}
+	tags := Tags{
+		Title:    "Standup #4",
+		Date:     time.Date(2026, 10, 18, 9, 30, 0, 0, time.Local),
+		Result:   testResult,
+		Chapters: []Chapter{{Start: 0, End: 2.25, Title: "Alice: Morning."}},
+	}
+	got := ffmetadata(Vorbis, existing, tags)
+	want := ";FFMETADATA1\n" +
+		"ARTIST=Me\n" +
+		"comment=a\\=b\\;c\n" +
+		"TITLE=Standup \\#4\n" +
+		"DATE=2026-10-18\n" +
+		"LYRICS=Alice: Morning.\\\nBob: Hi.\n" +
+		"AUDIOMEMO_TRANSCRIPT=" + escapeMeta(compactJSON(testResult)) + "\n" +
+		"[CHAPTER]\nTIMEBASE=1/1000\nSTART=0\nEND=2250\ntitle=Alice: Morning.\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	mp3 := ffmetadata(ID3, nil, tags)
+	if strings.Contains(mp3, "lyrics=") || !strings.Contains(mp3, "title=Standup") {
+		t.Errorf("MP3 metadata:\n%s", mp3)
+	}
+}
+
+func TestWriteArgs(t *testing.T) {
+	args := writeArgs(ID3, "in.mp3", "meta.txt", "out.mp3", false)
+	if !slices.Contains(args, "-id3v2_version") || args[len(args)-1] != "out.mp3" {
+		t.Errorf("args = %v", args)
+	}
+	// With no chapters of its own, the file keeps the ones it has.
+	if i := slices.Index(args, "-map_chapters"); args[i+1] != "0" {
+		t.Errorf("args = %v", args)
+	}
+	if args := writeArgs(Vorbis, "in.ogg", "meta.txt", "out.ogg", true); !slices.Contains(args, "1:g") || slices.Contains(args, "-id3v2_version") {
+		t.Errorf("args = %v", args)
+	}
+}
+
+func TestReadBack(t *testing.T) {
+	probe := `{"streams":[{"tags":{"LYRICS":"Morning. Hi.","AUDIOMEMO_TRANSCRIPT":` +
+		`"{\"schema_version\":\"1.0\",\"text\":\"Morning. Hi.\",\"segments\":[{\"start\":0,\"end\":1.5,\"text\":\"Morning.\",\"speaker\":\"Alice\"}]}"}}],` +
+		`"format":{"tags":{"encoder":"Lavf"}}}`
+	tags, err := parseProbe([]byte(probe))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := fromTags(tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.SchemaVersion != "" || len(r.Segments) != 1 || r.Segments[0].Speaker != "Alice" {
+		t.Errorf("result = %+v", r)
+	}
+
+	// An MP3 from elsewhere: ffprobe names USLT after its language.
+	if r, err := fromTags(map[string]string{"lyrics-eng": "Just words."}); err != nil || r.Text != "Just words." {
+		t.Errorf("lyrics = %+v, %v", r, err)
+	}
+	if _, err := fromTags(map[string]string{"title": "x"}); err != ErrNoTranscript {
+		t.Errorf("no transcript: %v", err)
+	}
+}
+
+func TestChaptersFromMarks(t *testing.T) {
+	got := ChaptersFromMarks([]float64{90, 30}, 120)
+	want := []Chapter{{0, 30, "Start"}, {30, 90, "Mark 1"}, {90, 120, "Mark 2"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestChaptersFromSegments(t *testing.T) {
+	segs := []transcribe.Segment{
+		{Start: 0, End: 4, Text: "Morning, everyone, and welcome to the weekly planning meeting.", Speaker: "Alice"},
+		{Start: 4, End: 6, Text: "Agenda first.", Speaker: "Alice"},
+		{Start: 7, End: 9, Text: "Sure.", Speaker: "Bob"},
+	}
+	got := ChaptersFromSegments(segs, 20)
+	want := []Chapter{
+		{0, 7, "Alice: Morning, everyone, and welcome to the…"},
+		{7, 20, "Bob: Sure."},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// Without speakers, a chapter runs for a minute or so.
+	plain := []transcribe.Segment{{Start: 0, End: 30, Text: "One."}, {Start: 30, End: 65, Text: "Two."}, {Start: 65, End: 70, Text: "Three."}}
+	if got := ChaptersFromSegments(plain, 70); len(got) != 2 || got[1].Start != 65 || got[1].Title != "Three." {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestSpliceID3(t *testing.T) {
+	title := id3Frame("TIT2", append([]byte{id3UTF8}, "Standup"...))
+	old := id3Frame("USLT", []byte{id3UTF8, 'e', 'n', 'g', 0, 'o', 'l', 'd'})
+	var tag bytes.Buffer
+	tag.WriteString("ID3\x04\x00\x00")
+	tag.Write(putSyncsafe(len(title) + len(old) + 20))
+	tag.Write(title)
+	tag.Write(old)
+	tag.Write(make([]byte, 20)) // padding, as ffmpeg leaves
+	mp3 := append(tag.Bytes(), "\xff\xfbAUDIO"...)
+
+	out, err := spliceID3(mp3, usltFrame("eng", "Morning. Hi."), syltFrame("eng", testResult.Segments))
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := syncsafe(out[6:10])
+	if !bytes.Equal(out[10+size:], []byte("\xff\xfbAUDIO")) {
+		t.Fatalf("audio after the tag = %q", out[10+size:])
+	}
+	var ids []string
+	var sylt []byte
+	for body := out[10 : 10+size]; len(body) > 0; {
+		n := syncsafe(body[4:8])
+		ids = append(ids, string(body[:4]))
+		if string(body[:4]) == "SYLT" {
+			sylt = body[10 : 10+n]
+		}
+		body = body[10+n:]
+	}
+	if !slices.Equal(ids, []string{"TIT2", "USLT", "SYLT"}) {
+		t.Errorf("frames = %v", ids)
+	}
+	// encoding, language, ms timestamps, lyrics, empty descriptor, then
+	// each line and when it starts.
+	want := []byte{id3UTF8, 'e', 'n', 'g', 2, 1, 0}
+	want = append(want, "Alice: Morning.\x00"...)
+	want = binary.BigEndian.AppendUint32(want, 0)
+	want = append(want, "Bob: Hi.\x00"...)
+	want = binary.BigEndian.AppendUint32(want, 2250)
+	if !bytes.Equal(sylt, want) {
+		t.Errorf("SYLT = %q, want %q", sylt, want)
+	}
+
+	if _, err := spliceID3([]byte("ID3\x03\x00\x00\x00\x00\x00\x00"), nil); err == nil {
+		t.Error("want an error for an ID3v2.3 tag")
+	}
+	bare, err := spliceID3([]byte("\xff\xfb"), usltFrame("und", "x"))
+	if err != nil || string(bare[:3]) != "ID3" || !bytes.HasSuffix(bare, []byte("\xff\xfb")) {
+		t.Errorf("untagged file = %q, %v", bare, err)
+	}
+}
+
+func TestWriteAndRead(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not on PATH")
+	}
+	ctx := context.Background()
+	for _, ext := range []string{".ogg", ".flac", ".mp3"} {
+		path := filepath.Join(t.TempDir(), "memo"+ext)
+		if out, err := exec.Command("ffmpeg", "-v", "error", "-f", "lavfi", "-i", "sine=d=4", path).CombinedOutput(); err != nil {
+			t.Skipf("ffmpeg cannot write %s: %v: %s", ext, err, out)
+		}
+		err := Write(ctx, path, Tags{Title: "Memo", Result: testResult, Chapters: ChaptersFromSegments(testResult.Segments, 4)})
+		if err != nil {
+			t.Fatalf("%s: %v", ext, err)
+		}
+		got, err := Read(ctx, path)
+		if err != nil {
+			t.Fatalf("%s: %v", ext, err)
+		}
+		if !reflect.DeepEqual(got, testResult) {
+			t.Errorf("%s: read back %+v", ext, got)
+		}
+		if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+			t.Errorf("%s: left behind %v", ext, entries)
+		}
+	}
+}
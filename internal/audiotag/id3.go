@@ -0,0 +1,100 @@
+package audiotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+// ID3v2.4 text encoding byte for UTF-8.
+const id3UTF8 = 3
+
+// spliceID3 adds frames to the ID3v2.4 tag at the start of an MP3, in place
+// of any lyrics frames it already has. A file without a tag gets one.
+func spliceID3(data []byte, frames ...[]byte) ([]byte, error) {
+	var kept [][]byte
+	audio := data
+	if len(data) >= 10 && string(data[:3]) == "ID3" {
+		if data[3] != 4 {
+			return nil, fmt.Errorf("ID3v2.%d tag: only ID3v2.4 can be extended", data[3])
+		}
+		if data[5]&0xc0 != 0 {
+			return nil, fmt.Errorf("ID3 tag uses unsynchronisation or an extended header, which are not supported")
+		}
+		size := syncsafe(data[6:10])
+		if 10+size > len(data) {
+			return nil, fmt.Errorf("ID3 tag is truncated")
+		}
+		body := data[10 : 10+size]
+		audio = data[10+size:]
+		for len(body) >= 10 && body[0] != 0 {
+			n := syncsafe(body[4:8])
+			if 10+n > len(body) {
+				return nil, fmt.Errorf("ID3 frame %q is truncated", body[:4])
+			}
+			if id := string(body[:4]); id != "USLT" && id != "SYLT" {
+				kept = append(kept, body[:10+n])
+			}
+			body = body[10+n:]
+		}
+	}
+	kept = append(kept, frames...)
+
+	var b bytes.Buffer
+	b.WriteString("ID3\x04\x00\x00")
+	b.Write(putSyncsafe(len(bytes.Join(kept, nil))))
+	for _, f := range kept {
+		b.Write(f)
+	}
+	b.Write(audio)
+	return b.Bytes(), nil
+}
+
+// usltFrame is the unsynchronised lyrics frame: the whole transcript.
+func usltFrame(lang, text string) []byte {
+	var body bytes.Buffer
+	body.WriteByte(id3UTF8)
+	body.WriteString(lang)
+	body.WriteByte(0) // empty content descriptor
+	body.WriteString(text)
+	return id3Frame("USLT", body.Bytes())
+}
+
+// syltFrame is the synchronised lyrics frame: each segment with the
+// millisecond it starts at, which players show in step with the audio.
+func syltFrame(lang string, segs []transcribe.Segment) []byte {
+	var body bytes.Buffer
+	body.WriteByte(id3UTF8)
+	body.WriteString(lang)
+	body.WriteByte(2) // timestamps in milliseconds
+	body.WriteByte(1) // content type: lyrics
+	body.WriteByte(0) // empty content descriptor
+	for _, seg := range segs {
+		text := strings.TrimSpace(seg.Text)
+		if seg.Speaker != "" {
+			text = seg.Speaker + ": " + text
+		}
+		body.WriteString(text)
+		body.WriteByte(0)
+		binary.Write(&body, binary.BigEndian, uint32(seg.Start*1000))
+	}
+	return id3Frame("SYLT", body.Bytes())
+}
+
+func id3Frame(id string, body []byte) []byte {
+	f := append([]byte(id), putSyncsafe(len(body))...)
+	f = append(f, 0, 0) // no flags
+	return append(f, body...)
+}
+
+// syncsafe reads ID3's 28-bit sizes, stored seven bits to a byte.
+func syncsafe(b []byte) int {
+	return int(b[0])<<21 | int(b[1])<<14 | int(b[2])<<7 | int(b[3])
+}
+
+func putSyncsafe(n int) []byte {
+	return []byte{byte(n >> 21 & 0x7f), byte(n >> 14 & 0x7f), byte(n >> 7 & 0x7f), byte(n & 0x7f)}
+}
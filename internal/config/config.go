@@ -21,25 +21,227 @@ type Config struct {
 	Devices        map[string]string   `toml:"devices"`
 	DeviceGroups   map[string][]string `toml:"device_groups"`
 	Transcribe     TranscribeConfig    `toml:"transcribe"`
+	Summarize      SummarizeConfig     `toml:"summarize"`
+	Serve          ServeConfig         `toml:"serve"`
+	Wyoming        WyomingConfig       `toml:"wyoming"`
+	Hooks          HooksConfig         `toml:"hooks"`
+	Webhooks       WebhooksConfig      `toml:"webhooks"`
+	Export         ExportConfig        `toml:"export"`
+	Retention      RetentionConfig     `toml:"retention"`
+	Encryption     EncryptionConfig    `toml:"encryption"`
+	Sync           SyncConfig          `toml:"sync"`
 }
 
 type RecordConfig struct {
-	Format     string `toml:"format"`
-	SampleRate int    `toml:"sample_rate"`
-	Channels   int    `toml:"channels"`
-	OutputDir  string `toml:"output_dir"`
-	Device     string `toml:"device"`
+	Format        string              `toml:"format"`
+	SampleRate    int                 `toml:"sample_rate"`
+	Channels      int                 `toml:"channels"`
+	OutputDir     string              `toml:"output_dir"`
+	Device        string              `toml:"device"`
+	VoiceCommands VoiceCommandsConfig `toml:"voice_commands"`
+	TypeInto      TypeIntoConfig      `toml:"type_into"`
+}
+
+// VoiceCommandsConfig is [record.voice_commands]: phrases that, said while
+// dictating, shape the live transcript instead of appearing in it. A phrase
+// list left out keeps its default; an empty one turns that command off.
+// Punctuation maps a spoken word to its mark, for a backend that does not
+// punctuate.
+type VoiceCommandsConfig struct {
+	Enabled      bool              `toml:"enabled"`
+	Keep         bool              `toml:"keep"` // leave the phrases in the batch transcript
+	NewParagraph []string          `toml:"new_paragraph,omitempty"`
+	NewLine      []string          `toml:"new_line,omitempty"`
+	Scratch      []string          `toml:"scratch,omitempty"`
+	Mark         []string          `toml:"mark,omitempty"`
+	Stop         []string          `toml:"stop,omitempty"`
+	Punctuation  map[string]string `toml:"punctuation,omitempty"`
+}
+
+// TypeIntoConfig is [record.type_into]: how `record --type-into` types live
+// commits into the focused window. Tool names a built-in typing program;
+// Command replaces it with any program that reads the text on stdin, and
+// EraseCommand, given the number of characters as its last argument, lets a
+// scratch take typed text back. Durations are Go durations such as "10ms".
+type TypeIntoConfig struct {
+	Tool         string   `toml:"tool,omitempty"` // wtype, xdotool, or ydotool
+	Command      []string `toml:"command,omitempty"`
+	EraseCommand []string `toml:"erase_command,omitempty"`
+	KeyDelay     string   `toml:"key_delay,omitempty"`    // between keystrokes
+	MinInterval  string   `toml:"min_interval,omitempty"` // between typing runs
 }
 
 type TranscribeConfig struct {
-	DefaultBackend string           `toml:"default_backend"`
-	Language       string           `toml:"language"`
-	OutputFormat   string           `toml:"output_format"`
-	Whisper        WhisperConfig    `toml:"whisper"`
-	Deepgram       DeepgramConfig   `toml:"deepgram"`
-	OpenAI         OpenAIConfig     `toml:"openai"`
-	Mistral        MistralConfig    `toml:"mistral"`
-	ElevenLabs     ElevenLabsConfig `toml:"elevenlabs"`
+	DefaultBackend string            `toml:"default_backend"`
+	Language       string            `toml:"language"`
+	OutputFormat   string            `toml:"output_format"`
+	Vocabulary     []string          `toml:"vocabulary,omitempty"`
+	Embed          bool              `toml:"embed"` // write each transcript into the recording's tags
+	PostProcess    PostProcessConfig `toml:"postprocess"`
+	Redact         RedactConfig      `toml:"redact"`
+	Whisper        WhisperConfig     `toml:"whisper"`
+	Deepgram       DeepgramConfig    `toml:"deepgram"`
+	OpenAI         OpenAIConfig      `toml:"openai"`
+	Mistral        MistralConfig     `toml:"mistral"`
+	ElevenLabs     ElevenLabsConfig  `toml:"elevenlabs"`
+}
+
+// PostProcessConfig is the cleanup run on every transcript before it is
+// written, and on live commits as they arrive. Each step can be switched per
+// invocation with the matching transcribe flag.
+type PostProcessConfig struct {
+	RemoveFillers     bool                `toml:"remove_fillers"`
+	Fillers           []string            `toml:"fillers,omitempty"`
+	FixCapitalization bool                `toml:"fix_capitalization"`
+	Paragraphs        bool                `toml:"paragraphs"`
+	ParagraphGap      float64             `toml:"paragraph_gap"`
+	Replace           []ReplacementConfig `toml:"replace,omitempty"`
+}
+
+// RedactConfig is [transcribe.redact]: what every transcript has masked
+// before it is written, as `transcribe --redact` would. Categories are
+// "email", "phone", "card" and "terms", the last masking Terms. With Bleep,
+// a copy of the recording is also saved with the masked words bleeped.
+type RedactConfig struct {
+	Categories []string `toml:"categories,omitempty"`
+	Terms      []string `toml:"terms,omitempty"`
+	Bleep      bool     `toml:"bleep"`
+}
+
+// ReplacementConfig is one [[transcribe.postprocess.replace]] entry.
+type ReplacementConfig struct {
+	From  string `toml:"from"`
+	To    string `toml:"to"`
+	Regex bool   `toml:"regex"`
+}
+
+// SummarizeConfig points summaries at an OpenAI-compatible chat endpoint.
+// BaseURL may be a local server (llama.cpp, Ollama); the API key is optional
+// for those.
+type SummarizeConfig struct {
+	BaseURL    string           `toml:"base_url"`
+	APIKey     string           `toml:"api_key"`
+	APIKeyFile string           `toml:"api_key_file"`
+	Model      string           `toml:"model"`
+	AutoLabel  bool             `toml:"auto_label"`
+	Prompts    SummarizePrompts `toml:"prompts"`
+}
+
+// SummarizePrompts override the built-in prompt templates. Each is a Go
+// text/template with {{.Transcript}} and {{.Language}}.
+type SummarizePrompts struct {
+	Summary     string `toml:"summary,omitempty"`
+	ActionItems string `toml:"action_items,omitempty"`
+	Title       string `toml:"title,omitempty"`
+}
+
+// ServeConfig is the transcription server run by `audiomemo serve`. With a
+// token set, every request must carry it as a bearer token.
+type ServeConfig struct {
+	Listen        string `toml:"listen"`
+	Token         string `toml:"token"`
+	TokenFile     string `toml:"token_file"`
+	MaxConcurrent int    `toml:"max_concurrent"`
+}
+
+// WyomingConfig is the Wyoming speech-to-text server run by
+// `audiomemo wyoming` for Home Assistant. Realtime streams 16 kHz audio to
+// ElevenLabs as it arrives instead of transcribing each utterance after it
+// ends. Languages are the ones offered to Home Assistant; empty means the
+// transcribe.language, or a broad default list.
+type WyomingConfig struct {
+	Listen    string   `toml:"listen"`
+	Backend   string   `toml:"backend"`
+	Realtime  bool     `toml:"realtime"`
+	Languages []string `toml:"languages,omitempty"`
+}
+
+// HooksConfig is [hooks]: shell commands run after a recording is saved,
+// after a transcript is written, and when record or transcribe fails. An
+// executable named after the hook in Dir runs as well. Timeout is a Go
+// duration bounding each one.
+type HooksConfig struct {
+	PostRecord     string `toml:"post_record,omitempty"`
+	PostTranscribe string `toml:"post_transcribe,omitempty"`
+	OnError        string `toml:"on_error,omitempty"`
+	Dir            string `toml:"dir,omitempty"` // default: hooks/ next to config.toml
+	Timeout        string `toml:"timeout,omitempty"`
+}
+
+// WebhooksConfig is [webhooks]: endpoints that each finished transcript is
+// POSTed to. Retries are attempts after the first, Backoff the wait before
+// the first retry, doubling after each, and Timeout the limit on one attempt;
+// the durations are Go durations. Deliveries that never get through are
+// appended to DeadLetter.
+type WebhooksConfig struct {
+	Endpoints  []WebhookEndpoint `toml:"endpoints,omitempty"`
+	Retries    int               `toml:"retries"`
+	Backoff    string            `toml:"backoff,omitempty"`
+	Timeout    string            `toml:"timeout,omitempty"`
+	DeadLetter string            `toml:"dead_letter,omitempty"`
+}
+
+// WebhookEndpoint is one [[webhooks.endpoints]]. Secret, or the contents of
+// SecretFile, signs each delivery; without either it is sent unsigned.
+type WebhookEndpoint struct {
+	URL        string `toml:"url"`
+	Secret     string `toml:"secret,omitempty"`
+	SecretFile string `toml:"secret_file,omitempty"`
+}
+
+// ExportConfig is [export]: places recordings are written out to besides
+// the output directory.
+type ExportConfig struct {
+	Notes NotesConfig `toml:"notes"`
+}
+
+// NotesConfig is [export.notes]: a Markdown vault, such as Obsidian's or
+// Logseq's, that gets a note for each recording. Tags are added to every
+// note's front matter, alongside any the note already has.
+type NotesConfig struct {
+	Dir  string   `toml:"dir,omitempty"`
+	Tags []string `toml:"tags,omitempty"`
+}
+
+// RetentionConfig is [retention]: what `audiomemo prune` clears out of the
+// output directory. Ages are in days from when a recording was made, and 0
+// turns a rule off, as every rule is by default. MaxSize caps the directory,
+// as "20GB" or "500MB", by deleting the oldest transcribed audio first.
+type RetentionConfig struct {
+	DeleteAudioAfterDays int    `toml:"delete_audio_after_days"`
+	TranscodeAfterDays   int    `toml:"transcode_after_days"`
+	DeleteLive           bool   `toml:"delete_live"`
+	MaxSize              string `toml:"max_size,omitempty"`
+}
+
+// EncryptionConfig is [encryption]: the age recipients new recordings and
+// transcripts are encrypted to, and the identity that decrypts them again.
+// With no recipients nothing is encrypted; the identity alone still lets
+// files encrypted elsewhere be read.
+type EncryptionConfig struct {
+	Recipients     []string `toml:"recipients,omitempty"`
+	RecipientsFile string   `toml:"recipients_file,omitempty"`
+	IdentityFile   string   `toml:"identity_file,omitempty"`
+}
+
+// SyncConfig is [sync]: where `audiomemo sync` backs recordings up to. With
+// Auto, record and transcribe sync each recording as they finish with it.
+type SyncConfig struct {
+	Auto bool     `toml:"auto"`
+	S3   S3Config `toml:"s3"`
+}
+
+// S3Config is [sync.s3]: a bucket on an S3-compatible server, such as MinIO.
+// Objects are keyed by Prefix and the file's name. The access key comes from
+// Profile, or [default], in CredentialsFile, an AWS-style credentials file;
+// without one, from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.
+type S3Config struct {
+	Endpoint        string `toml:"endpoint,omitempty"`
+	Region          string `toml:"region,omitempty"`
+	Bucket          string `toml:"bucket,omitempty"`
+	Prefix          string `toml:"prefix,omitempty"`
+	CredentialsFile string `toml:"credentials_file,omitempty"`
+	Profile         string `toml:"profile,omitempty"`
 }
 
 type WhisperConfig struct {
@@ -93,12 +295,27 @@ func Default() *Config {
 		DeviceGroups: map[string][]string{},
 		Transcribe: TranscribeConfig{
 			OutputFormat: "text",
+			PostProcess:  PostProcessConfig{ParagraphGap: 2.0},
 			Whisper:      WhisperConfig{Model: "base", Binary: "whisper"},
 			Deepgram:     DeepgramConfig{Model: "nova-3", SmartFormat: true, Diarize: true, Punctuate: true, FillerWords: true, Numerals: true},
 			OpenAI:       OpenAIConfig{Model: "gpt-4o-transcribe"},
 			Mistral:      MistralConfig{Model: "voxtral-mini-latest"},
 			ElevenLabs:   ElevenLabsConfig{Model: "scribe_v2", Diarize: true},
 		},
+		Summarize: SummarizeConfig{
+			BaseURL: "https://api.openai.com/v1",
+			Model:   "gpt-4o-mini",
+		},
+		Serve: ServeConfig{
+			Listen:        "127.0.0.1:8765",
+			MaxConcurrent: 2,
+		},
+		Wyoming: WyomingConfig{
+			Listen: "127.0.0.1:10300",
+		},
+		Webhooks: WebhooksConfig{
+			Retries: 4,
+		},
 	}
 }
 
@@ -143,6 +360,9 @@ func (c *Config) ApplyEnv() {
 	if v := os.Getenv("ELEVENLABS_API_KEY"); v != "" {
 		c.Transcribe.ElevenLabs.APIKey = v
 	}
+	if v := os.Getenv("AUDIOMEMO_SERVE_TOKEN"); v != "" {
+		c.Serve.Token = v
+	}
 	if v := os.Getenv("HF_TOKEN"); v != "" && c.Transcribe.Whisper.HFToken == "" {
 		c.Transcribe.Whisper.HFToken = v
 	}
@@ -190,6 +410,23 @@ func (c *Config) ApplyEnv() {
 	if c.Transcribe.Whisper.HFToken == "" && c.Transcribe.Whisper.HFTokenFile != "" {
 		c.Transcribe.Whisper.HFToken = readKeyFile(c.Transcribe.Whisper.HFTokenFile)
 	}
+	if c.Summarize.APIKey == "" && c.Summarize.APIKeyFile != "" {
+		c.Summarize.APIKey = readKeyFile(c.Summarize.APIKeyFile)
+	}
+	if c.Serve.Token == "" && c.Serve.TokenFile != "" {
+		c.Serve.Token = readKeyFile(c.Serve.TokenFile)
+	}
+	for i, ep := range c.Webhooks.Endpoints {
+		if ep.Secret == "" && ep.SecretFile != "" {
+			c.Webhooks.Endpoints[i].Secret = readKeyFile(ep.SecretFile)
+		}
+	}
+
+	// Summaries against OpenAI itself reuse the transcription key rather than
+	// asking for the same key twice. Any other endpoint gets only its own key.
+	if c.Summarize.APIKey == "" && strings.Contains(c.Summarize.BaseURL, "api.openai.com") {
+		c.Summarize.APIKey = c.Transcribe.OpenAI.APIKey
+	}
 }
 
 // readKeyFile reads a file and returns its trimmed contents, or empty string on error.
@@ -226,6 +463,90 @@ func (c *Config) ResolveOutputDir() string {
 	return dir
 }
 
+// ResolveNotesDir returns export.notes.dir with ~ expanded. It is empty when
+// no vault is configured.
+func (c *Config) ResolveNotesDir() string {
+	dir := c.Export.Notes.Dir
+	if strings.HasPrefix(dir, "~/") {
+		home, err := os.UserHomeDir()
+		if err == nil {
+			dir = filepath.Join(home, dir[2:])
+		}
+	}
+	return dir
+}
+
+// ResolveHooksDir returns hooks.dir with ~ expanded, or the hooks directory
+// beside the default config file. It is empty when neither can be found.
+func (c *Config) ResolveHooksDir() string {
+	dir := c.Hooks.Dir
+	if strings.HasPrefix(dir, "~/") {
+		home, err := os.UserHomeDir()
+		if err == nil {
+			dir = filepath.Join(home, dir[2:])
+		}
+	}
+	if dir != "" {
+		return dir
+	}
+	path, err := defaultConfigPath()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(path), "hooks")
+}
+
+// ResolveDeadLetterPath returns webhooks.dead_letter with ~ expanded, or
+// webhooks-failed.jsonl in the XDG state directory. It is empty when neither
+// can be found.
+func (c *Config) ResolveDeadLetterPath() string {
+	path := c.Webhooks.DeadLetter
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	if path != "" {
+		return path
+	}
+	stateDir := os.Getenv("XDG_STATE_HOME")
+	if stateDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		stateDir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(stateDir, "audiomemo", "webhooks-failed.jsonl")
+}
+
+// ResolveEncryption returns [encryption] with ~ expanded in its file paths.
+func (c *Config) ResolveEncryption() EncryptionConfig {
+	e := c.Encryption
+	for _, path := range []*string{&e.RecipientsFile, &e.IdentityFile} {
+		if strings.HasPrefix(*path, "~/") {
+			home, err := os.UserHomeDir()
+			if err == nil {
+				*path = filepath.Join(home, (*path)[2:])
+			}
+		}
+	}
+	return e
+}
+
+// ResolveS3 returns [sync.s3] with ~ expanded in credentials_file.
+func (c *Config) ResolveS3() S3Config {
+	s := c.Sync.S3
+	if strings.HasPrefix(s.CredentialsFile, "~/") {
+		home, err := os.UserHomeDir()
+		if err == nil {
+			s.CredentialsFile = filepath.Join(home, s.CredentialsFile[2:])
+		}
+	}
+	return s
+}
+
 // defaultConfigPath returns the default XDG config path for the config file.
 func defaultConfigPath() (string, error) {
 	configDir := os.Getenv("XDG_CONFIG_HOME")
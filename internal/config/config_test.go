@@ -474,3 +474,379 @@ zoom = ["mic", "desktop"]
 		t.Errorf("expected zoom group, got %v", cfg.DeviceGroups["zoom"])
 	}
 }
+
+func TestLoadVocabulary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte(`
+[transcribe]
+vocabulary = ["AudioMemo", "Joe Goldin"]
+`), 0644)
+
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"AudioMemo", "Joe Goldin"}
+	if !reflect.DeepEqual(cfg.Transcribe.Vocabulary, want) {
+		t.Errorf("vocabulary = %v, want %v", cfg.Transcribe.Vocabulary, want)
+	}
+}
+
+func TestLoadPostProcess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte(`
+[transcribe.postprocess]
+remove_fillers = true
+paragraphs = true
+
+[[transcribe.postprocess.replace]]
+from = "cooper netties"
+to = "Kubernetes"
+
+[[transcribe.postprocess.replace]]
+from = '(\d+) percent'
+to = "$1%"
+regex = true
+`), 0644)
+
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pp := cfg.Transcribe.PostProcess
+	if !pp.RemoveFillers || !pp.Paragraphs || pp.FixCapitalization {
+		t.Errorf("steps = %+v", pp)
+	}
+	if pp.ParagraphGap != 2.0 {
+		t.Errorf("paragraph_gap = %v, want the 2.0 default", pp.ParagraphGap)
+	}
+	want := []ReplacementConfig{
+		{From: "cooper netties", To: "Kubernetes"},
+		{From: `(\d+) percent`, To: "$1%", Regex: true},
+	}
+	if !reflect.DeepEqual(pp.Replace, want) {
+		t.Errorf("replace = %+v, want %+v", pp.Replace, want)
+	}
+}
+
+func TestLoadVoiceCommands(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte(`
+[record.voice_commands]
+enabled = true
+scratch = ["scratch that", "delete that"]
+stop = []
+
+[record.voice_commands.punctuation]
+comma = ","
+`), 0644)
+
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vc := cfg.Record.VoiceCommands
+	if !vc.Enabled || vc.Keep {
+		t.Errorf("voice_commands = %+v", vc)
+	}
+	if !reflect.DeepEqual(vc.Scratch, []string{"scratch that", "delete that"}) {
+		t.Errorf("scratch = %q", vc.Scratch)
+	}
+	// Left out keeps the default; empty turns the command off.
+	if vc.Mark != nil {
+		t.Errorf("mark = %q, want nil", vc.Mark)
+	}
+	if vc.Stop == nil || len(vc.Stop) != 0 {
+		t.Errorf("stop = %#v, want empty and non-nil", vc.Stop)
+	}
+	if vc.Punctuation["comma"] != "," {
+		t.Errorf("punctuation = %v", vc.Punctuation)
+	}
+}
+
+func TestLoadTypeInto(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte(`
+[record.type_into]
+command = ["dotool-type"]
+erase_command = ["dotool-erase", "--count"]
+min_interval = "150ms"
+`), 0644)
+
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := TypeIntoConfig{
+		Command:      []string{"dotool-type"},
+		EraseCommand: []string{"dotool-erase", "--count"},
+		MinInterval:  "150ms",
+	}
+	if !reflect.DeepEqual(cfg.Record.TypeInto, want) {
+		t.Errorf("type_into = %+v, want %+v", cfg.Record.TypeInto, want)
+	}
+}
+
+func TestLoadHooks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte(`
+[hooks]
+post_record = "notify-send 'Saved' \"$AUDIOMEMO_LABEL\""
+on_error = "notify-send -u critical audiomemo \"$AUDIOMEMO_ERROR\""
+timeout = "1m"
+`), 0644)
+
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := HooksConfig{
+		PostRecord: `notify-send 'Saved' "$AUDIOMEMO_LABEL"`,
+		OnError:    `notify-send -u critical audiomemo "$AUDIOMEMO_ERROR"`,
+		Timeout:    "1m",
+	}
+	if cfg.Hooks != want {
+		t.Errorf("hooks = %+v, want %+v", cfg.Hooks, want)
+	}
+}
+
+func TestResolveHooksDir(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	cfg := Default()
+	if got := cfg.ResolveHooksDir(); got != "/xdg/audiomemo/hooks" {
+		t.Errorf("default = %q", got)
+	}
+	cfg.Hooks.Dir = "/srv/hooks"
+	if got := cfg.ResolveHooksDir(); got != "/srv/hooks" {
+		t.Errorf("configured = %q", got)
+	}
+}
+
+func TestLoadWebhooks(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "n8n-secret")
+	os.WriteFile(secretFile, []byte("from-file\n"), 0600)
+	path := filepath.Join(dir, "config.toml")
+	os.WriteFile(path, []byte(`
+[webhooks]
+retries = 2
+backoff = "5s"
+
+[[webhooks.endpoints]]
+url = "https://hooks.example.com/audiomemo"
+secret = "inline"
+
+[[webhooks.endpoints]]
+url = "http://127.0.0.1:5678/webhook/notes"
+secret_file = "`+secretFile+`"
+`), 0644)
+
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ApplyEnv()
+	w := cfg.Webhooks
+	if w.Retries != 2 || w.Backoff != "5s" || len(w.Endpoints) != 2 {
+		t.Fatalf("webhooks = %+v", w)
+	}
+	if w.Endpoints[0].Secret != "inline" || w.Endpoints[1].Secret != "from-file" {
+		t.Errorf("secrets = %q, %q", w.Endpoints[0].Secret, w.Endpoints[1].Secret)
+	}
+	if Default().Webhooks.Retries != 4 {
+		t.Errorf("default retries = %d, want 4", Default().Webhooks.Retries)
+	}
+}
+
+func TestResolveDeadLetterPath(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/state")
+	cfg := Default()
+	if got := cfg.ResolveDeadLetterPath(); got != "/state/audiomemo/webhooks-failed.jsonl" {
+		t.Errorf("default = %q", got)
+	}
+	cfg.Webhooks.DeadLetter = "/var/spool/audiomemo.jsonl"
+	if got := cfg.ResolveDeadLetterPath(); got != "/var/spool/audiomemo.jsonl" {
+		t.Errorf("configured = %q", got)
+	}
+}
+
+func TestLoadExportNotes(t *testing.T) {
+	t.Setenv("HOME", "/home/alice")
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte(`
+[export.notes]
+dir = "~/vault/Meetings"
+tags = ["meeting", "audiomemo"]
+`), 0644)
+
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.ResolveNotesDir(); got != "/home/alice/vault/Meetings" {
+		t.Errorf("dir = %q", got)
+	}
+	if len(cfg.Export.Notes.Tags) != 2 || cfg.Export.Notes.Tags[1] != "audiomemo" {
+		t.Errorf("tags = %v", cfg.Export.Notes.Tags)
+	}
+	if Default().ResolveNotesDir() != "" {
+		t.Error("no vault should be configured by default")
+	}
+}
+
+func TestLoadTranscribeEmbed(t *testing.T) {
+	if Default().Transcribe.Embed {
+		t.Error("recordings should not be retagged unless asked")
+	}
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte("[transcribe]\nembed = true\n"), 0644)
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Transcribe.Embed {
+		t.Error("embed = false, want true")
+	}
+}
+
+func TestLoadRetention(t *testing.T) {
+	if Default().Retention != (RetentionConfig{}) {
+		t.Error("nothing should be pruned unless asked")
+	}
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte(`
+[retention]
+delete_audio_after_days = 90
+transcode_after_days = 30
+delete_live = true
+max_size = "20GB"
+`), 0644)
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := RetentionConfig{DeleteAudioAfterDays: 90, TranscodeAfterDays: 30, DeleteLive: true, MaxSize: "20GB"}
+	if cfg.Retention != want {
+		t.Errorf("retention = %+v", cfg.Retention)
+	}
+}
+
+func TestLoadEncryption(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte(`
+[encryption]
+recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+recipients_file = "~/.config/audiomemo/recipients.txt"
+identity_file = "~/.config/audiomemo/key.txt"
+`), 0644)
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := cfg.ResolveEncryption()
+	if len(e.Recipients) != 1 || !strings.HasPrefix(e.Recipients[0], "age1") {
+		t.Errorf("recipients = %q", e.Recipients)
+	}
+	if e.RecipientsFile != filepath.Join(home, ".config/audiomemo/recipients.txt") {
+		t.Errorf("recipients_file = %q", e.RecipientsFile)
+	}
+	if e.IdentityFile != filepath.Join(home, ".config/audiomemo/key.txt") {
+		t.Errorf("identity_file = %q", e.IdentityFile)
+	}
+	if cfg.Encryption.IdentityFile != "~/.config/audiomemo/key.txt" {
+		t.Error("resolving should not change the config")
+	}
+}
+
+func TestLoadRedact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte(`
+[transcribe.redact]
+categories = ["email", "phone", "terms"]
+terms = ["Project Falcon"]
+bleep = true
+`), 0644)
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := cfg.Transcribe.Redact
+	if len(r.Categories) != 3 || r.Categories[2] != "terms" || len(r.Terms) != 1 || !r.Bleep {
+		t.Errorf("redact = %+v", r)
+	}
+}
+
+func TestLoadSync(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte(`
+[sync]
+auto = true
+
+[sync.s3]
+endpoint = "https://minio.example.com:9000"
+bucket = "team-recordings"
+prefix = "joe/"
+credentials_file = "~/.config/audiomemo/s3-credentials"
+`), 0644)
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Sync.Auto {
+		t.Error("auto should be on")
+	}
+	s := cfg.ResolveS3()
+	if s.Endpoint != "https://minio.example.com:9000" || s.Bucket != "team-recordings" || s.Prefix != "joe/" {
+		t.Errorf("s3 = %+v", s)
+	}
+	if s.CredentialsFile != filepath.Join(home, ".config/audiomemo/s3-credentials") {
+		t.Errorf("credentials_file = %q", s.CredentialsFile)
+	}
+	if cfg.Sync.S3.CredentialsFile != "~/.config/audiomemo/s3-credentials" {
+		t.Error("resolving should not change the config")
+	}
+}
+
+func TestSummarizeDefaultsAndKeyFallback(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "sk-openai")
+	cfg := Default()
+	cfg.ApplyEnv()
+	if cfg.Summarize.BaseURL != "https://api.openai.com/v1" || cfg.Summarize.Model == "" {
+		t.Errorf("defaults = %+v", cfg.Summarize)
+	}
+	if cfg.Summarize.APIKey != "sk-openai" {
+		t.Errorf("expected the OpenAI key to be reused for OpenAI summaries, got %q", cfg.Summarize.APIKey)
+	}
+
+	local := Default()
+	local.Summarize.BaseURL = "http://localhost:8080"
+	local.ApplyEnv()
+	if local.Summarize.APIKey != "" {
+		t.Errorf("OpenAI key leaked to a local endpoint: %q", local.Summarize.APIKey)
+	}
+}
+
+func TestServeTokenSources(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	os.WriteFile(tokenFile, []byte("from-file\n"), 0600)
+
+	cfg := Default()
+	cfg.Serve.TokenFile = tokenFile
+	cfg.ApplyEnv()
+	if cfg.Serve.Token != "from-file" {
+		t.Errorf("token = %q, want the token file's contents", cfg.Serve.Token)
+	}
+
+	t.Setenv("AUDIOMEMO_SERVE_TOKEN", "from-env")
+	cfg = Default()
+	cfg.Serve.TokenFile = tokenFile
+	cfg.ApplyEnv()
+	if cfg.Serve.Token != "from-env" {
+		t.Errorf("token = %q, want the environment to win", cfg.Serve.Token)
+	}
+}
@@ -0,0 +1,227 @@
+// Package encrypt keeps recordings and transcripts encrypted at rest with
+// age (https://age-encryption.org), by running the age command as audiomemo
+// runs ffmpeg.
+//
+// An encrypted file keeps its name with .age added: standup.ogg becomes
+// standup.ogg.age and standup.txt standup.txt.age. Text is encrypted on its
+// way to the disk and decrypted on its way back, through pipes, so it is
+// never written out in plaintext. Audio is different: ffmpeg and the
+// backends read it from a path, so it is decrypted into a private directory,
+// on tmpfs where there is one, for as long as it is needed and no longer.
+package encrypt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Ext is what an encrypted file's name ends in.
+const Ext = ".age"
+
+// IsEncrypted reports whether path names an encrypted file.
+func IsEncrypted(path string) bool {
+	return strings.HasSuffix(path, Ext)
+}
+
+// Plain is the name path had before it was encrypted.
+func Plain(path string) string {
+	return strings.TrimSuffix(path, Ext)
+}
+
+// Keys are the age recipients files are encrypted to and the identity they
+// are decrypted with. Either half may be missing: a machine that only
+// records needs no identity, and one that only reads needs no recipients.
+type Keys struct {
+	Recipients     []string // age1… or SSH public keys
+	RecipientsFile string   // one recipient a line, as age -R reads it
+	IdentityFile   string
+}
+
+// Encrypting reports whether new files are to be encrypted.
+func (k *Keys) Encrypting() bool {
+	return k != nil && (len(k.Recipients) > 0 || k.RecipientsFile != "")
+}
+
+func (k *Keys) encryptArgs() []string {
+	args := []string{"--encrypt"}
+	for _, r := range k.Recipients {
+		args = append(args, "--recipient", r)
+	}
+	if k.RecipientsFile != "" {
+		args = append(args, "--recipients-file", k.RecipientsFile)
+	}
+	return args
+}
+
+func (k *Keys) decryptArgs() ([]string, error) {
+	if k == nil || k.IdentityFile == "" {
+		return nil, errors.New("no identity to decrypt with: set identity_file under [encryption]")
+	}
+	return []string{"--decrypt", "--identity", k.IdentityFile}, nil
+}
+
+// Seal encrypts plain.
+func (k *Keys) Seal(ctx context.Context, plain []byte) ([]byte, error) {
+	if !k.Encrypting() {
+		return nil, errors.New("no recipients to encrypt to: set recipients under [encryption]")
+	}
+	return run(ctx, k.encryptArgs(), plain)
+}
+
+// Open decrypts what Seal encrypted.
+func (k *Keys) Open(ctx context.Context, sealed []byte) ([]byte, error) {
+	args, err := k.decryptArgs()
+	if err != nil {
+		return nil, err
+	}
+	return run(ctx, args, sealed)
+}
+
+// WriteFile encrypts plain into the file at path, which should end in Ext.
+// It is written beside itself and renamed into place, so that a failure
+// leaves whatever was there before.
+func (k *Keys) WriteFile(ctx context.Context, path string, plain []byte) error {
+	sealed, err := k.Seal(ctx, plain)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(filepath.Dir(path), ".sealing-"+filepath.Base(path))
+	defer os.Remove(tmp)
+	if err := os.WriteFile(tmp, sealed, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ReadFile decrypts the file at path.
+func (k *Keys) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	sealed, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := k.Open(ctx, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return plain, nil
+}
+
+// EncryptFile encrypts the file at path to path+Ext, deletes the plaintext,
+// and returns the new path.
+func (k *Keys) EncryptFile(ctx context.Context, path string) (string, error) {
+	if !k.Encrypting() {
+		return "", errors.New("no recipients to encrypt to: set recipients under [encryption]")
+	}
+	out := path + Ext
+	tmp := filepath.Join(filepath.Dir(out), ".sealing-"+filepath.Base(out))
+	defer os.Remove(tmp)
+	args := append(k.encryptArgs(), "--output", tmp, path)
+	if _, err := run(ctx, args, nil); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, out); err != nil {
+		return "", err
+	}
+	return out, os.Remove(path)
+}
+
+// DecryptToTemp decrypts the file at path into TempDir, under its plain name
+// so that tools which go by the extension still can, and returns the
+// plaintext's path with a func that deletes it. The caller must call it,
+// however it returns.
+func (k *Keys) DecryptToTemp(ctx context.Context, path string) (string, func(), error) {
+	args, err := k.decryptArgs()
+	if err != nil {
+		return "", nil, err
+	}
+	tmp, err := TempDir()
+	if err != nil {
+		return "", nil, err
+	}
+	dir, err := os.MkdirTemp(tmp, "plain-*")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	out := filepath.Join(dir, filepath.Base(Plain(path)))
+	if _, err := run(ctx, append(args, "--output", out, path), nil); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return out, cleanup, nil
+}
+
+var tempDir struct {
+	sync.Mutex
+	path string
+}
+
+// TempDir returns a directory only the user can enter, for plaintext that
+// has to be a file: decrypted audio and whatever is made from it. It is made
+// on first use in runtimeDir and shared for the rest of the run; call
+// RemoveTempDir on the way out so nothing is left behind.
+func TempDir() (string, error) {
+	tempDir.Lock()
+	defer tempDir.Unlock()
+	if tempDir.path != "" {
+		return tempDir.path, nil
+	}
+	dir, err := os.MkdirTemp(runtimeDir(), "audiomemo-*")
+	if err != nil {
+		return "", err
+	}
+	// MkdirTemp asks for 0700, but say so rather than trust the umask.
+	if err := os.Chmod(dir, 0700); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	tempDir.path = dir
+	return dir, nil
+}
+
+// RemoveTempDir deletes TempDir and everything still in it. A later TempDir
+// makes a new one.
+func RemoveTempDir() {
+	tempDir.Lock()
+	defer tempDir.Unlock()
+	if tempDir.path != "" {
+		os.RemoveAll(tempDir.path)
+		tempDir.path = ""
+	}
+}
+
+// runtimeDir is where TempDir is made: $XDG_RUNTIME_DIR, which is
+// memory-backed and private to the user on most Linux systems, or else the
+// system's temp directory, where TempDir's own permissions keep others out.
+func runtimeDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return os.TempDir()
+}
+
+func run(ctx context.Context, args []string, stdin []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "age", args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, errors.New("age is not installed: see https://age-encryption.org")
+		}
+		return nil, fmt.Errorf("age: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,157 @@
+package encrypt
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPlain(t *testing.T) {
+	if !IsEncrypted("standup.ogg.age") || IsEncrypted("standup.ogg") {
+		t.Error("IsEncrypted goes by the .age extension")
+	}
+	if got := Plain("/rec/standup.ogg.age"); got != "/rec/standup.ogg" {
+		t.Errorf("Plain = %q", got)
+	}
+	if got := Plain("/rec/standup.ogg"); got != "/rec/standup.ogg" {
+		t.Errorf("Plain of a plain name = %q", got)
+	}
+}
+
+func TestKeysArgs(t *testing.T) {
+	var none *Keys
+	if none.Encrypting() {
+		t.Error("no keys should encrypt nothing")
+	}
+	if (&Keys{IdentityFile: "key.txt"}).Encrypting() {
+		t.Error("an identity alone should encrypt nothing")
+	}
+
+	k := &Keys{Recipients: []string{"age1a", "age1b"}, RecipientsFile: "team.txt"}
+	want := []string{"--encrypt", "--recipient", "age1a", "--recipient", "age1b", "--recipients-file", "team.txt"}
+	if got := k.encryptArgs(); !slices.Equal(got, want) {
+		t.Errorf("encryptArgs = %q", got)
+	}
+	if _, err := k.decryptArgs(); err == nil || !strings.Contains(err.Error(), "identity_file") {
+		t.Errorf("decrypting without an identity should say how to set one, got %v", err)
+	}
+	k.IdentityFile = "key.txt"
+	args, err := k.decryptArgs()
+	if err != nil || !slices.Equal(args, []string{"--decrypt", "--identity", "key.txt"}) {
+		t.Errorf("decryptArgs = %q, %v", args, err)
+	}
+}
+
+func TestRuntimeDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+	if got := runtimeDir(); got != dir {
+		t.Errorf("runtimeDir = %q, want $XDG_RUNTIME_DIR", got)
+	}
+	t.Setenv("XDG_RUNTIME_DIR", filepath.Join(dir, "gone"))
+	if got := runtimeDir(); got != os.TempDir() {
+		t.Errorf("runtimeDir with a missing runtime dir = %q", got)
+	}
+}
+
+func TestTempDirIsPrivateAndRemoved(t *testing.T) {
+	runtime := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", runtime)
+	t.Cleanup(RemoveTempDir)
+
+	dir, err := TempDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(dir) != runtime {
+		t.Errorf("TempDir = %q, want it in $XDG_RUNTIME_DIR", dir)
+	}
+	if info, _ := os.Stat(dir); info.Mode().Perm() != 0700 {
+		t.Errorf("mode = %v, want 0700", info.Mode().Perm())
+	}
+	if again, _ := TempDir(); again != dir {
+		t.Errorf("second TempDir = %q, want the same %q", again, dir)
+	}
+
+	os.WriteFile(filepath.Join(dir, "left.ogg"), []byte("audio"), 0600)
+	RemoveTempDir()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("RemoveTempDir left the directory behind")
+	}
+}
+
+// testKeys makes a fresh identity with age-keygen, or skips.
+func testKeys(t *testing.T) *Keys {
+	t.Helper()
+	for _, tool := range []string{"age", "age-keygen"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skip(tool + " not on PATH")
+		}
+	}
+	identity := filepath.Join(t.TempDir(), "key.txt")
+	if out, err := exec.Command("age-keygen", "-o", identity).CombinedOutput(); err != nil {
+		t.Fatalf("age-keygen: %v: %s", err, out)
+	}
+	recipient, err := exec.Command("age-keygen", "-y", identity).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Keys{Recipients: []string{strings.TrimSpace(string(recipient))}, IdentityFile: identity}
+}
+
+func TestWriteAndReadFile(t *testing.T) {
+	k := testKeys(t)
+	path := filepath.Join(t.TempDir(), "standup.txt.age")
+	if err := k.WriteFile(t.Context(), path, []byte("Confidential.\n")); err != nil {
+		t.Fatal(err)
+	}
+	sealed, _ := os.ReadFile(path)
+	if strings.Contains(string(sealed), "Confidential") {
+		t.Error("the file holds the plaintext")
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+	got, err := k.ReadFile(t.Context(), path)
+	if err != nil || string(got) != "Confidential.\n" {
+		t.Errorf("ReadFile = %q, %v", got, err)
+	}
+}
+
+func TestEncryptFileAndDecryptToTemp(t *testing.T) {
+	k := testKeys(t)
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	t.Cleanup(RemoveTempDir)
+	path := filepath.Join(t.TempDir(), "standup.ogg")
+	os.WriteFile(path, []byte("OggS audio"), 0644)
+
+	sealed, err := k.EncryptFile(t.Context(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sealed != path+Ext {
+		t.Errorf("EncryptFile = %q", sealed)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("the plaintext recording was left behind")
+	}
+
+	plain, cleanup, err := k.DecryptToTemp(t.Context(), sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp, _ := TempDir()
+	if filepath.Base(plain) != "standup.ogg" || !strings.HasPrefix(plain, tmp) {
+		t.Errorf("decrypted to %q", plain)
+	}
+	if data, _ := os.ReadFile(plain); string(data) != "OggS audio" {
+		t.Errorf("decrypted = %q", data)
+	}
+	cleanup()
+	if _, err := os.Stat(filepath.Dir(plain)); !os.IsNotExist(err) {
+		t.Error("cleanup left the plaintext behind")
+	}
+}
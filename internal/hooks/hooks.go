@@ -0,0 +1,162 @@
+// Package hooks runs the user's scripts at the points where record and
+// transcribe hand their work on: a recording saved, a transcript written, or
+// a run that failed.
+//
+// A hook is told what happened twice over: as AUDIOMEMO_* environment
+// variables, which suit a one-line shell command, and as one JSON object on
+// stdin, which carries the full transcript for a script that wants it. A
+// hook's own output goes to stderr, since stdout may be carrying a transcript
+// or an event stream, and its failure is the caller's to report: by the time
+// a hook runs the recording is already safe.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+// Hook names, as keys of [hooks] and names of scripts in the hooks directory.
+const (
+	PostRecord     = "post_record"
+	PostTranscribe = "post_transcribe"
+	OnError        = "on_error"
+)
+
+// DefaultTimeout bounds a hook when the config sets no timeout.
+const DefaultTimeout = 30 * time.Second
+
+// Event is what a hook is told. Fields that do not apply are left empty.
+type Event struct {
+	Hook    string `json:"hook"`
+	Command string `json:"command"` // record or transcribe
+
+	Audio          string  `json:"audio,omitempty"`
+	Transcript     string  `json:"transcript,omitempty"`
+	LiveTranscript string  `json:"live_transcript,omitempty"`
+	Label          string  `json:"label,omitempty"`
+	Duration       float64 `json:"duration,omitempty"` // seconds of audio
+	Backend        string  `json:"backend,omitempty"`
+	Reason         string  `json:"reason,omitempty"` // why a recording ended
+	Error          string  `json:"error,omitempty"`
+
+	Result *transcribe.Result `json:"result,omitempty"`
+}
+
+// Env returns the event as AUDIOMEMO_* variables. The transcript itself is
+// left to stdin, where its size does not matter.
+func (e Event) Env() []string {
+	vars := []struct{ name, value string }{
+		{"HOOK", e.Hook},
+		{"COMMAND", e.Command},
+		{"AUDIO", e.Audio},
+		{"TRANSCRIPT", e.Transcript},
+		{"LIVE_TRANSCRIPT", e.LiveTranscript},
+		{"LABEL", e.Label},
+		{"BACKEND", e.Backend},
+		{"REASON", e.Reason},
+		{"ERROR", e.Error},
+	}
+	var env []string
+	for _, v := range vars {
+		if v.value != "" {
+			env = append(env, "AUDIOMEMO_"+v.name+"="+v.value)
+		}
+	}
+	if e.Duration > 0 {
+		env = append(env, "AUDIOMEMO_DURATION="+strconv.FormatFloat(e.Duration, 'f', 3, 64))
+	}
+	return env
+}
+
+// Runner runs the hooks configured for each event: the command set in the
+// config, then the executable of the same name in Dir, whichever exist.
+type Runner struct {
+	Commands map[string]string // hook name to shell command
+	Dir      string
+	Timeout  time.Duration
+	Stderr   io.Writer // where hooks' output goes; nil for os.Stderr
+}
+
+// Run runs the hooks for ev.Hook and reports each one that failed, timed out
+// or could not be started. Having no hook for an event is not an error.
+func (r *Runner) Run(ctx context.Context, ev Event) error {
+	if r == nil {
+		return nil
+	}
+	stdin, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if command := r.Commands[ev.Hook]; command != "" {
+		if err := r.run(ctx, shell(command), ev, stdin); err != nil {
+			errs = append(errs, fmt.Errorf("hook %s: %w", ev.Hook, err))
+		}
+	}
+	if r.Dir != "" {
+		script := filepath.Join(r.Dir, ev.Hook)
+		info, err := os.Stat(script)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			errs = append(errs, fmt.Errorf("hook %s: %w", script, err))
+		case info.IsDir():
+		case info.Mode()&0111 == 0 && runtime.GOOS != "windows":
+			errs = append(errs, fmt.Errorf("hook %s is not executable (chmod +x it to run it)", script))
+		default:
+			if err := r.run(ctx, []string{script}, ev, stdin); err != nil {
+				errs = append(errs, fmt.Errorf("hook %s: %w", script, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Runner) run(ctx context.Context, args []string, ev Event, stdin []byte) error {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	out := r.Stderr
+	if out == nil {
+		out = os.Stderr
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), ev.Env()...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	killGroup(cmd)
+	// A hook that backgrounds a child holding its output open would
+	// otherwise keep Wait waiting after the hook itself was killed.
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	return err
+}
+
+func shell(command string) []string {
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/C", command}
+	}
+	return []string{"sh", "-c", command}
+}
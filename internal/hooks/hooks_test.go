@@ -0,0 +1,142 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+func skipOnWindows(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are run with sh in these tests")
+	}
+}
+
+func TestEventEnv(t *testing.T) {
+	ev := Event{Hook: PostRecord, Command: "record", Audio: "/r/memo.ogg", Label: "memo", Duration: 61.5, Reason: "stopped"}
+	want := []string{
+		"AUDIOMEMO_HOOK=post_record",
+		"AUDIOMEMO_COMMAND=record",
+		"AUDIOMEMO_AUDIO=/r/memo.ogg",
+		"AUDIOMEMO_LABEL=memo",
+		"AUDIOMEMO_REASON=stopped",
+		"AUDIOMEMO_DURATION=61.500",
+	}
+	if got := ev.Env(); !slices.Equal(got, want) {
+		t.Errorf("Env = %q, want %q", got, want)
+	}
+}
+
+func TestRunPassesEnvAndStdin(t *testing.T) {
+	skipOnWindows(t)
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	r := &Runner{Commands: map[string]string{
+		PostTranscribe: `echo "$AUDIOMEMO_LABEL $AUDIOMEMO_BACKEND" > ` + out + `; cat >> ` + out,
+	}}
+	ev := Event{
+		Hook:    PostTranscribe,
+		Command: "transcribe",
+		Label:   "standup",
+		Backend: "deepgram",
+		Result:  &transcribe.Result{Text: "Hello."},
+	}
+	if err := r.Run(context.Background(), ev); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line, payload, _ := strings.Cut(string(data), "\n")
+	if line != "standup deepgram" {
+		t.Errorf("env line = %q", line)
+	}
+	var got Event
+	if err := json.Unmarshal([]byte(payload), &got); err != nil {
+		t.Fatalf("stdin is not JSON: %v\n%s", err, payload)
+	}
+	if got.Hook != PostTranscribe || got.Result == nil || got.Result.Text != "Hello." {
+		t.Errorf("stdin = %+v", got)
+	}
+}
+
+func TestRunScriptInDir(t *testing.T) {
+	skipOnWindows(t)
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	script := "#!/bin/sh\necho \"$AUDIOMEMO_HOOK\" >> " + out + "\n"
+	os.WriteFile(filepath.Join(dir, PostRecord), []byte(script), 0755)
+	r := &Runner{
+		Commands: map[string]string{PostRecord: "echo command >> " + out},
+		Dir:      dir,
+	}
+	if err := r.Run(context.Background(), Event{Hook: PostRecord}); err != nil {
+		t.Fatal(err)
+	}
+	// No hook for this one: nothing runs and nothing fails.
+	if err := r.Run(context.Background(), Event{Hook: OnError}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(out)
+	if string(data) != "command\npost_record\n" {
+		t.Errorf("ran %q, want the command and then the script", data)
+	}
+}
+
+func TestRunReportsFailures(t *testing.T) {
+	skipOnWindows(t)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, OnError), []byte("#!/bin/sh\n"), 0644)
+	var stderr bytes.Buffer
+	r := &Runner{
+		Commands: map[string]string{OnError: "echo upload failed >&2; exit 3"},
+		Dir:      dir,
+		Stderr:   &stderr,
+	}
+	err := r.Run(context.Background(), Event{Hook: OnError})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{"hook on_error: exit status 3", "not executable"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+	if stderr.String() != "upload failed\n" {
+		t.Errorf("hook output = %q, want it passed through", stderr.String())
+	}
+}
+
+func TestRunTimesOut(t *testing.T) {
+	skipOnWindows(t)
+	r := &Runner{
+		Commands: map[string]string{PostRecord: "sleep 5"},
+		Timeout:  50 * time.Millisecond,
+	}
+	start := time.Now()
+	err := r.Run(context.Background(), Event{Hook: PostRecord})
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Errorf("Run = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("a timed-out hook held the run for %v", elapsed)
+	}
+}
+
+func TestNilRunnerRunsNothing(t *testing.T) {
+	var r *Runner
+	if err := r.Run(context.Background(), Event{Hook: PostRecord}); err != nil {
+		t.Fatal(err)
+	}
+}
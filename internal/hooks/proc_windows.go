@@ -0,0 +1,6 @@
+package hooks
+
+import "os/exec"
+
+// killGroup leaves the default on Windows, which kills the hook alone.
+func killGroup(cmd *exec.Cmd) {}
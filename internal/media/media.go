@@ -0,0 +1,283 @@
+// Package media deals with the video containers screen recordings come in:
+// taking out the audio track to transcribe, and putting the subtitles made
+// from it back as a soft subtitle track.
+//
+// Both are ffmpeg remuxes. Only the extracted audio is encoded, to Opus,
+// which every backend reads and which keeps an hour of speech to a size the
+// cloud APIs accept; the video and its other streams are copied.
+//
+// It also makes the bleeped copy of a recording that redaction asks for.
+package media
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// VideoExtensions are the containers IsVideo recognises, in sorted order.
+// WebM is not among them: record writes audio-only WebM, and backends read
+// it as it is.
+var VideoExtensions = []string{".avi", ".m4v", ".mkv", ".mov", ".mp4"}
+
+// IsVideo reports whether path names a video container, from its extension.
+func IsVideo(path string) bool {
+	return slices.Contains(VideoExtensions, strings.ToLower(filepath.Ext(path)))
+}
+
+// Stream is one stream of a kind in a file, numbered as ffmpeg's stream
+// specifiers number them: the second audio stream is Index 1 of "a".
+type Stream struct {
+	Index    int
+	Codec    string
+	Language string // as tagged, usually ISO 639-2; empty when untagged
+	Title    string
+}
+
+// Streams lists the file's streams of kind: "a" for audio, "s" for
+// subtitles, "v" for video.
+func Streams(ctx context.Context, path, kind string) ([]Stream, error) {
+	out, err := exec.CommandContext(ctx, "ffprobe",
+		"-v", "error",
+		"-select_streams", kind,
+		"-show_entries", "stream=codec_name:stream_tags=language,title",
+		"-of", "json",
+		path,
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe: %w", err)
+	}
+	return parseStreams(out)
+}
+
+func parseStreams(out []byte) ([]Stream, error) {
+	var probe struct {
+		Streams []struct {
+			Codec string            `json:"codec_name"`
+			Tags  map[string]string `json:"tags"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return nil, fmt.Errorf("ffprobe: %w", err)
+	}
+	streams := make([]Stream, len(probe.Streams))
+	for i, s := range probe.Streams {
+		streams[i] = Stream{Index: i, Codec: s.Codec, Language: s.Tags["language"], Title: s.Tags["title"]}
+	}
+	return streams, nil
+}
+
+// ExtractAudio writes audio stream n of the file at path to out, an Ogg
+// file, as mono Opus.
+func ExtractAudio(ctx context.Context, path string, n int, out string) error {
+	streams, err := Streams(ctx, path, "a")
+	if err != nil {
+		return err
+	}
+	if err := checkStream(path, streams, n); err != nil {
+		return err
+	}
+	return run(ctx, extractArgs(path, n, out))
+}
+
+// checkStream says what is wrong with asking for audio stream n, in terms
+// of what the file has.
+func checkStream(path string, streams []Stream, n int) error {
+	switch {
+	case len(streams) == 0:
+		return fmt.Errorf("%s has no audio to transcribe", filepath.Base(path))
+	case n < 0 || n >= len(streams):
+		return fmt.Errorf("%s has no audio stream %d: it has %d, numbered from 0", filepath.Base(path), n, len(streams))
+	}
+	return nil
+}
+
+func extractArgs(path string, n int, out string) []string {
+	return []string{
+		"-y", "-v", "error",
+		"-i", path,
+		"-map", "0:a:" + strconv.Itoa(n),
+		"-vn", "-sn", "-dn",
+		"-ac", "1",
+		"-c:a", "libopus", "-b:a", "64k",
+		"-f", "ogg",
+		out,
+	}
+}
+
+// SubtitleFormat is the subtitle format to render for the video at path:
+// WebVTT for WebM, which takes nothing else, and otherwise the one asked
+// for, "srt" or "vtt".
+func SubtitleFormat(path, want string) string {
+	if strings.EqualFold(filepath.Ext(path), ".webm") {
+		return "vtt"
+	}
+	return want
+}
+
+// subtitleCodec is the codec a subtitle track takes in the container at
+// path, given subtitles in format.
+func subtitleCodec(path, format string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp4", ".m4v", ".mov":
+		return "mov_text", nil
+	case ".webm":
+		return "webvtt", nil
+	case ".mkv":
+		if format == "vtt" {
+			return "webvtt", nil
+		}
+		return "srt", nil
+	}
+	return "", fmt.Errorf("cannot put subtitles into %s files: use MKV, MP4, MOV or WebM", filepath.Ext(path))
+}
+
+// SubtitledPath is where MuxSubtitles' copy of the video at path goes:
+// beside it, with -subtitled before the extension.
+func SubtitledPath(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-subtitled" + ext
+}
+
+// CheckSubtitles reports whether the video at path can carry a subtitle
+// track, before the work of making one.
+func CheckSubtitles(path string) error {
+	_, err := subtitleCodec(path, "srt")
+	return err
+}
+
+// MuxSubtitles copies the video at path to out with the subtitles in subs,
+// in format ("srt" or "vtt"), as an extra track tagged with lang, an ISO
+// 639-2 code. The streams already in the video, its own subtitle tracks
+// among them, are copied as they are. out is written beside itself and
+// renamed into place, so a failure leaves no half-written copy.
+func MuxSubtitles(ctx context.Context, path, subs, format, lang, out string) error {
+	codec, err := subtitleCodec(path, format)
+	if err != nil {
+		return err
+	}
+	existing, err := Streams(ctx, path, "s")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(filepath.Dir(out), ".muxing-"+filepath.Base(out))
+	defer os.Remove(tmp)
+	if err := run(ctx, muxArgs(path, subs, codec, lang, len(existing), tmp)); err != nil {
+		return err
+	}
+	return os.Rename(tmp, out)
+}
+
+// muxArgs adds subs as subtitle track n, after the n the video has.
+func muxArgs(path, subs, codec, lang string, n int, out string) []string {
+	track := strconv.Itoa(n)
+	return []string{
+		"-y", "-v", "error",
+		"-i", path,
+		"-i", subs,
+		"-map", "0", "-map", "1",
+		"-c", "copy",
+		"-c:s:" + track, codec,
+		"-metadata:s:s:" + track, "language=" + lang,
+		out,
+	}
+}
+
+// Span is a stretch of a recording, in seconds.
+type Span struct {
+	Start, End float64
+}
+
+// bleepPad widens each bleeped span, so that the edges of a word the
+// timestamps cut close stay covered.
+const bleepPad = 0.05
+
+// bleepCodecs are the encoders a bleeped copy is written with, by its
+// extension, where ffmpeg's own default is not the one the recording used.
+var bleepCodecs = map[string]string{
+	".ogg":  "libopus",
+	".opus": "libopus",
+	".webm": "libopus",
+	".m4a":  "aac",
+	".mp3":  "libmp3lame",
+}
+
+// BleepedPath is where Bleep's copy of the recording at path goes: beside
+// it, with -redacted before the extension.
+func BleepedPath(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-redacted" + ext
+}
+
+// Bleep copies the audio at path to out with each span silenced and a
+// 1 kHz tone in its place. Tags and chapters are not copied, since they may
+// hold the very transcript being redacted. out is written beside itself and
+// renamed into place.
+func Bleep(ctx context.Context, path string, spans []Span, out string) error {
+	tmp := filepath.Join(filepath.Dir(out), ".bleeping-"+filepath.Base(out))
+	defer os.Remove(tmp)
+	if err := run(ctx, bleepArgs(path, spans, tmp)); err != nil {
+		return err
+	}
+	return os.Rename(tmp, out)
+}
+
+func bleepArgs(path string, spans []Span, out string) []string {
+	var during []string
+	for _, s := range mergeSpans(spans) {
+		during = append(during, fmt.Sprintf("between(t,%.3f,%.3f)", s.Start, s.End))
+	}
+	when := "0"
+	if len(during) > 0 {
+		when = strings.Join(during, "+")
+	}
+	graph := "[0:a]volume=0:enable='" + when + "'[muted];" +
+		"sine=frequency=1000:sample_rate=48000,volume=0.3,volume=0:enable='not(" + when + ")'[tone];" +
+		"[muted][tone]amix=inputs=2:duration=first:normalize=0[out]"
+	args := []string{
+		"-y", "-v", "error",
+		"-i", path,
+		"-filter_complex", graph,
+		"-map", "[out]",
+		"-map_metadata", "-1", "-map_chapters", "-1",
+	}
+	if codec, ok := bleepCodecs[strings.ToLower(filepath.Ext(out))]; ok {
+		args = append(args, "-c:a", codec)
+	}
+	return append(args, out)
+}
+
+// mergeSpans pads spans by bleepPad and joins those that then overlap, in
+// order.
+func mergeSpans(spans []Span) []Span {
+	sorted := slices.Clone(spans)
+	slices.SortFunc(sorted, func(a, b Span) int { return cmp.Compare(a.Start, b.Start) })
+	var out []Span
+	for _, s := range sorted {
+		s.Start, s.End = max(s.Start-bleepPad, 0), s.End+bleepPad
+		if n := len(out); n > 0 && s.Start <= out[n-1].End {
+			out[n-1].End = max(out[n-1].End, s.End)
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func run(ctx context.Context, args []string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
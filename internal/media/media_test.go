@@ -0,0 +1,159 @@
+package media
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestIsVideo(t *testing.T) {
+	for path, want := range map[string]bool{"a.mkv": true, "a.MP4": true, "a.mov": true, "a.webm": false, "a.ogg": false} {
+		if got := IsVideo(path); got != want {
+			t.Errorf("IsVideo(%q) = %v", path, got)
+		}
+	}
+	if !slices.IsSorted(VideoExtensions) {
+		t.Errorf("VideoExtensions is not sorted: %v", VideoExtensions)
+	}
+}
+
+func TestParseStreams(t *testing.T) {
+	out := `{"streams":[{"codec_name":"aac","tags":{"language":"eng","title":"Mic"}},{"codec_name":"opus"}]}`
+	streams, err := parseStreams([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Stream{{Index: 0, Codec: "aac", Language: "eng", Title: "Mic"}, {Index: 1, Codec: "opus"}}
+	if !slices.Equal(streams, want) {
+		t.Errorf("got %+v", streams)
+	}
+
+	if err := checkStream("talk.mkv", streams, 1); err != nil {
+		t.Error(err)
+	}
+	if err := checkStream("talk.mkv", streams, 2); err == nil || !strings.Contains(err.Error(), "it has 2") {
+		t.Errorf("stream 2 of 2: %v", err)
+	}
+	if err := checkStream("silent.mp4", nil, 0); err == nil || !strings.Contains(err.Error(), "no audio") {
+		t.Errorf("no audio: %v", err)
+	}
+}
+
+func TestExtractArgs(t *testing.T) {
+	args := extractArgs("talk.mkv", 1, "out.ogg")
+	if i := slices.Index(args, "-map"); args[i+1] != "0:a:1" {
+		t.Errorf("args = %v", args)
+	}
+	if !slices.Contains(args, "-vn") || args[len(args)-1] != "out.ogg" {
+		t.Errorf("args = %v", args)
+	}
+}
+
+func TestSubtitleCodec(t *testing.T) {
+	for _, c := range []struct{ path, format, want string }{
+		{"a.mp4", "srt", "mov_text"},
+		{"a.MOV", "vtt", "mov_text"},
+		{"a.mkv", "srt", "srt"},
+		{"a.mkv", "vtt", "webvtt"},
+		{"a.webm", "vtt", "webvtt"},
+	} {
+		if got, err := subtitleCodec(c.path, c.format); err != nil || got != c.want {
+			t.Errorf("subtitleCodec(%q, %q) = %q, %v", c.path, c.format, got, err)
+		}
+	}
+	if err := CheckSubtitles("a.avi"); err == nil {
+		t.Error("want an error for AVI")
+	}
+	if got := SubtitleFormat("a.webm", "srt"); got != "vtt" {
+		t.Errorf("WebM takes WebVTT, got %q", got)
+	}
+	if got := SubtitledPath("/v/talk.final.mkv"); got != "/v/talk.final-subtitled.mkv" {
+		t.Errorf("SubtitledPath = %q", got)
+	}
+}
+
+func TestMuxArgs(t *testing.T) {
+	args := strings.Join(muxArgs("talk.mkv", "subs.srt", "srt", "deu", 2, "out.mkv"), " ")
+	for _, want := range []string{"-map 0 -map 1", "-c copy", "-c:s:2 srt", "-metadata:s:s:2 language=deu"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("args lack %q: %s", want, args)
+		}
+	}
+}
+
+func TestExtractAndMux(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not on PATH")
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+	video := filepath.Join(dir, "talk.mkv")
+	if out, err := exec.Command("ffmpeg", "-v", "error",
+		"-f", "lavfi", "-i", "color=d=2:s=64x64",
+		"-f", "lavfi", "-i", "sine=d=2",
+		"-f", "lavfi", "-i", "sine=d=2:f=880",
+		"-map", "0", "-map", "1", "-map", "2", "-shortest", video).CombinedOutput(); err != nil {
+		t.Skipf("ffmpeg cannot write MKV: %v: %s", err, out)
+	}
+
+	audio := filepath.Join(dir, "audio.ogg")
+	if err := ExtractAudio(ctx, video, 1, audio); err != nil {
+		t.Fatal(err)
+	}
+	if streams, err := Streams(ctx, audio, "a"); err != nil || len(streams) != 1 || streams[0].Codec != "opus" {
+		t.Errorf("extracted %+v, %v", streams, err)
+	}
+	if err := ExtractAudio(ctx, video, 2, audio); err == nil {
+		t.Error("want an error for a third audio stream")
+	}
+
+	subs := filepath.Join(dir, "talk.srt")
+	os.WriteFile(subs, []byte("1\n00:00:00,000 --> 00:00:01,000\nHello.\n"), 0644)
+	out := SubtitledPath(video)
+	if err := MuxSubtitles(ctx, video, subs, "srt", "eng", out); err != nil {
+		t.Fatal(err)
+	}
+	streams, err := Streams(ctx, out, "s")
+	if err != nil || len(streams) != 1 || streams[0].Language != "eng" {
+		t.Errorf("subtitles %+v, %v", streams, err)
+	}
+}
+
+func TestBleepArgs(t *testing.T) {
+	args := bleepArgs("talk.ogg", []Span{{3, 4}, {1, 2}, {2.05, 2.5}}, "out.ogg")
+	graph := args[slices.Index(args, "-filter_complex")+1]
+	if want := "between(t,0.950,2.550)+between(t,2.950,4.050)"; !strings.Contains(graph, want) {
+		t.Errorf("graph lacks %q: %s", want, graph)
+	}
+	joined := strings.Join(args, " ")
+	for _, want := range []string{"-map_metadata -1", "-map_chapters -1", "-c:a libopus"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("args lack %q: %s", want, joined)
+		}
+	}
+	if got := BleepedPath("/r/standup.ogg"); got != "/r/standup-redacted.ogg" {
+		t.Errorf("BleepedPath = %q", got)
+	}
+}
+
+func TestBleep(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not on PATH")
+	}
+	dir := t.TempDir()
+	in := filepath.Join(dir, "talk.wav")
+	if out, err := exec.Command("ffmpeg", "-v", "error", "-f", "lavfi", "-i", "sine=d=2:f=440", in).CombinedOutput(); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	out := BleepedPath(in)
+	if err := Bleep(context.Background(), in, []Span{{0.5, 1}}, out); err != nil {
+		t.Fatal(err)
+	}
+	if streams, err := Streams(context.Background(), out, "a"); err != nil || len(streams) != 1 {
+		t.Errorf("bleeped copy has %+v, %v", streams, err)
+	}
+}
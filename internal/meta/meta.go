@@ -0,0 +1,145 @@
+// Package meta reads and writes the metadata sidecar kept next to each
+// recording at <base>.meta.json.
+//
+// The sidecar holds what the user told audiomemo about a recording, as
+// opposed to what a backend produced. Transcripts are derived output and get
+// overwritten by the next batch run; the sidecar is the part that must
+// survive it.
+package meta
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/joegoldin/audiomemo/internal/encrypt"
+)
+
+// Metadata is the sidecar's content. Every field is optional, so a recording
+// nobody has annotated, and that was not made by record, has no sidecar at
+// all.
+type Metadata struct {
+	// Speakers maps the label a backend assigned ("Speaker 0", "SPEAKER_01")
+	// to the name a person gave it.
+	Speakers map[string]string `json:"speakers,omitempty"`
+	// Marks are the moments bookmarked while recording, in the order they
+	// were made.
+	Marks []Mark `json:"marks,omitempty"`
+	// Device is the input the recording was made from, as the user named
+	// it: a device, an alias or a group.
+	Device string `json:"device,omitempty"`
+	// Tags are words the user put on the recording. "keep" protects it
+	// from prune.
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Mark is one bookmark in a recording.
+type Mark struct {
+	// At is how far into the recording the mark was made, in seconds.
+	At float64 `json:"at"`
+	// Label is what a mark command on stdin called it; spoken marks and the
+	// TUI's have none.
+	Label string `json:"label,omitempty"`
+}
+
+// PathFor returns the sidecar path for an audio file or any transcript
+// derived from it, encrypted or not: all share the base name, so any finds
+// the sidecar.
+func PathFor(path string) string {
+	path = encrypt.Plain(path)
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".meta.json"
+}
+
+// Load reads the sidecar for path. A missing sidecar is an empty Metadata
+// rather than an error.
+func Load(path string) (*Metadata, error) {
+	m := &Metadata{}
+	data, err := os.ReadFile(PathFor(path))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return m, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", PathFor(path), err)
+	}
+	return m, nil
+}
+
+// Save writes the sidecar for path.
+func (m *Metadata) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(PathFor(path), append(data, '\n'), 0644)
+}
+
+// SetSpeakerNames records renames keyed by the label a transcript currently
+// shows. After a first naming the transcript shows names rather than backend
+// labels, so a rename of "Alice" updates whichever backend label already maps
+// to Alice instead of adding "Alice" as a label of its own.
+func (m *Metadata) SetSpeakerNames(renames map[string]string) {
+	if len(renames) == 0 {
+		return
+	}
+	if m.Speakers == nil {
+		m.Speakers = map[string]string{}
+	}
+	handled := map[string]bool{}
+	for label, name := range m.Speakers {
+		if next, ok := renames[name]; ok {
+			m.Speakers[label] = next
+			handled[name] = true
+		}
+	}
+	for current, next := range renames {
+		if !handled[current] {
+			m.Speakers[current] = next
+		}
+	}
+}
+
+// SpeakerName returns the name recorded for a label as a transcript shows it:
+// either a backend label, or a name given earlier.
+func (m *Metadata) SpeakerName(label string) string {
+	if name, ok := m.Speakers[label]; ok {
+		return name
+	}
+	for _, name := range m.Speakers {
+		if name == label {
+			return name
+		}
+	}
+	return ""
+}
+
+// HasTag reports whether the recording carries tag.
+func (m *Metadata) HasTag(tag string) bool {
+	return slices.Contains(m.Tags, tag)
+}
+
+// SetTag puts tag on the recording, or with on false takes it off, and
+// reports whether that changed anything.
+func (m *Metadata) SetTag(tag string, on bool) bool {
+	if m.HasTag(tag) == on {
+		return false
+	}
+	if on {
+		m.Tags = append(m.Tags, tag)
+	} else {
+		m.Tags = slices.DeleteFunc(m.Tags, func(t string) bool { return t == tag })
+	}
+	return true
+}
+
+// AddMark bookmarks the recording at seconds in, with an optional label.
+func (m *Metadata) AddMark(at float64, label string) {
+	m.Marks = append(m.Marks, Mark{At: at, Label: label})
+}
@@ -0,0 +1,99 @@
+package meta
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPathFor(t *testing.T) {
+	for _, in := range []string{"/rec/memo.ogg", "/rec/memo.json", "/rec/memo.srt"} {
+		if got := PathFor(in); got != "/rec/memo.meta.json" {
+			t.Errorf("PathFor(%q) = %q", in, got)
+		}
+	}
+}
+
+func TestLoadMissingIsEmpty(t *testing.T) {
+	m, err := Load(filepath.Join(t.TempDir(), "memo.ogg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Speakers) != 0 {
+		t.Errorf("expected no speakers, got %v", m.Speakers)
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	audio := filepath.Join(t.TempDir(), "memo.ogg")
+	m := &Metadata{}
+	m.SetSpeakerNames(map[string]string{"Speaker 0": "Alice"})
+	if err := m.Save(audio); err != nil {
+		t.Fatal(err)
+	}
+	// The transcript finds the same sidecar as the audio.
+	got, err := Load(filepath.Join(filepath.Dir(audio), "memo.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Speakers, m.Speakers) {
+		t.Errorf("got %v, want %v", got.Speakers, m.Speakers)
+	}
+}
+
+func TestSetSpeakerNamesRenamesExistingName(t *testing.T) {
+	m := &Metadata{Speakers: map[string]string{"Speaker 0": "Alice", "Speaker 1": "Bob"}}
+	// The transcript now shows "Alice", so that is the label being renamed.
+	m.SetSpeakerNames(map[string]string{"Alice": "Alicia", "Speaker 2": "Carol"})
+	want := map[string]string{"Speaker 0": "Alicia", "Speaker 1": "Bob", "Speaker 2": "Carol"}
+	if !reflect.DeepEqual(m.Speakers, want) {
+		t.Errorf("got %v, want %v", m.Speakers, want)
+	}
+	if got := m.SpeakerName("Alicia"); got != "Alicia" {
+		t.Errorf("SpeakerName(Alicia) = %q", got)
+	}
+	if got := m.SpeakerName("Speaker 1"); got != "Bob" {
+		t.Errorf("SpeakerName(Speaker 1) = %q", got)
+	}
+}
+
+func TestAddMarkKeepsSpeakers(t *testing.T) {
+	audio := filepath.Join(t.TempDir(), "memo.ogg")
+	m := &Metadata{Speakers: map[string]string{"Speaker 0": "Alice"}}
+	m.Save(audio)
+
+	// As record does for each mark: load, add, save.
+	for i, at := range []float64{12.5, 61} {
+		m, err := Load(audio)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.AddMark(at, []string{"", "intro"}[i])
+		if err := m.Save(audio); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := Load(audio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Mark{{At: 12.5}, {At: 61, Label: "intro"}}; !reflect.DeepEqual(got.Marks, want) {
+		t.Errorf("marks = %v, want %v", got.Marks, want)
+	}
+	if got.Speakers["Speaker 0"] != "Alice" {
+		t.Errorf("speakers lost: %v", got.Speakers)
+	}
+}
+
+func TestSetTag(t *testing.T) {
+	m := &Metadata{}
+	if !m.SetTag("keep", true) || m.SetTag("keep", true) {
+		t.Error("tagging twice should change the sidecar once")
+	}
+	if !m.HasTag("keep") || len(m.Tags) != 1 {
+		t.Errorf("tags = %v", m.Tags)
+	}
+	if !m.SetTag("keep", false) || m.HasTag("keep") || m.SetTag("keep", false) {
+		t.Errorf("untagging: tags = %v", m.Tags)
+	}
+}
@@ -0,0 +1,351 @@
+// Package notes writes a recording out as a Markdown note for a vault such
+// as Obsidian's or Logseq's.
+//
+// A note is YAML front matter followed by a block audiomemo owns, fenced by
+// HTML comments that neither app shows. Exporting again rewrites that block
+// and the front matter keys audiomemo sets, and leaves the rest as it was:
+// whatever the user wrote above or below the block, keys of their own, and
+// tags they added. That makes exporting the same recording twice harmless.
+package notes
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+// The comments fencing the block audiomemo rewrites.
+const (
+	BeginMarker = "<!-- audiomemo:begin -->"
+	EndMarker   = "<!-- audiomemo:end -->"
+)
+
+// Note is what goes into a recording's note. Fields left empty are left out.
+type Note struct {
+	Title       string
+	Date        time.Time // when the recording was made
+	Duration    float64   // seconds
+	Device      string
+	Tags        []string
+	Audio       string // the recording's absolute path, linked from the note
+	Summary     string
+	ActionItems string
+	Result      *transcribe.Result // with speaker names applied
+}
+
+// Write creates the note at path, or updates the one already there, and
+// reports whether the file changed.
+func (n *Note) Write(path string) (bool, error) {
+	old, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+	var text string
+	if err != nil {
+		text = n.Render()
+	} else if text, err = n.Update(string(old)); err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+	if text == string(old) {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(path, []byte(text), 0644)
+}
+
+// Render returns the note as a new file.
+func (n *Note) Render() string {
+	return renderFrontMatter(n.fields(nil)) + "\n" + n.block() + "\n"
+}
+
+// Update returns existing with the block and audiomemo's front matter keys
+// brought up to date. A note whose block has been deleted is an error rather
+// than a guess at where the block should go back.
+func (n *Note) Update(existing string) (string, error) {
+	head, body := splitFrontMatter(existing)
+	start := strings.Index(body, BeginMarker)
+	end := strings.Index(body, EndMarker)
+	if start < 0 || end < start {
+		return "", fmt.Errorf("no %s … %s block to update: put the two lines back, or delete the note to start it again", BeginMarker, EndMarker)
+	}
+	body = body[:start] + n.block() + body[end+len(EndMarker):]
+
+	old := parseFrontMatter(head)
+	var tags []string
+	for _, f := range old {
+		if f.key == "tags" {
+			tags = f.list()
+		}
+	}
+	fresh := n.fields(tags)
+	var out []field
+	used := map[string]bool{}
+	for _, f := range old {
+		if i := slices.IndexFunc(fresh, func(g field) bool { return g.key == f.key }); i >= 0 {
+			f = fresh[i]
+			used[f.key] = true
+		}
+		out = append(out, f)
+	}
+	for _, f := range fresh {
+		if !used[f.key] {
+			out = append(out, f)
+		}
+	}
+	if head == "" {
+		body = "\n" + body
+	}
+	return renderFrontMatter(out) + body, nil
+}
+
+// field is one top-level front matter key, with the lines that hold its
+// value: the key's own line and any indented or list lines after it.
+type field struct {
+	key   string
+	lines []string
+}
+
+// fields are the front matter keys audiomemo sets, in the order a new note
+// has them. The tags already in the note come first, then the new ones.
+func (n *Note) fields(tags []string) []field {
+	var fs []field
+	scalar := func(key, value string) {
+		if value != "" {
+			fs = append(fs, field{key, []string{key + ": " + value}})
+		}
+	}
+	list := func(key string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		f := field{key: key, lines: []string{key + ":"}}
+		for _, v := range values {
+			f.lines = append(f.lines, "  - "+quote(v))
+		}
+		fs = append(fs, f)
+	}
+
+	if n.Title != "" {
+		scalar("title", quote(n.Title))
+	}
+	if !n.Date.IsZero() {
+		scalar("date", n.Date.Format("2006-01-02T15:04:05"))
+	}
+	if n.Duration > 0 {
+		scalar("duration", quote(Clock(n.Duration)))
+	}
+	if n.Device != "" {
+		scalar("device", quote(n.Device))
+	}
+	if n.Result != nil {
+		list("speakers", n.Result.Speakers())
+	}
+	for _, t := range n.Tags {
+		if !slices.Contains(tags, t) {
+			tags = append(tags, t)
+		}
+	}
+	list("tags", tags)
+	if n.Audio != "" {
+		scalar("audio", quote(FileURL(n.Audio)))
+	}
+	return fs
+}
+
+// block renders the part of the note audiomemo owns, markers included.
+func (n *Note) block() string {
+	var b strings.Builder
+	b.WriteString(BeginMarker + "\n")
+	if n.Title != "" {
+		fmt.Fprintf(&b, "# %s\n\n", n.Title)
+	}
+	if n.Audio != "" {
+		fmt.Fprintf(&b, "Recording: [%s](%s)\n\n", filepath.Base(n.Audio), FileURL(n.Audio))
+	}
+	if s := strings.TrimSpace(n.Summary); s != "" {
+		fmt.Fprintf(&b, "## Summary\n\n%s\n\n", s)
+	}
+	if s := strings.TrimSpace(n.ActionItems); s != "" {
+		fmt.Fprintf(&b, "## Action items\n\n%s\n\n", s)
+	}
+	if n.Result != nil {
+		fmt.Fprintf(&b, "## Transcript\n\n%s\n\n", n.transcript())
+	}
+	b.WriteString(EndMarker)
+	return b.String()
+}
+
+// transcript renders the result one paragraph per turn, each opening with
+// the speaker and a link into the recording where the turn starts. Without
+// speakers each segment is its own paragraph; without segments there is
+// only the text.
+func (n *Note) transcript() string {
+	r := n.Result
+	if len(r.Segments) == 0 {
+		return strings.TrimSpace(r.Text)
+	}
+	var paras []string
+	var cur strings.Builder
+	speaker := ""
+	for i, seg := range r.Segments {
+		text := strings.TrimSpace(seg.Text)
+		if text == "" {
+			continue
+		}
+		if i > 0 && seg.Speaker != "" && seg.Speaker == speaker {
+			cur.WriteString(" " + text)
+			continue
+		}
+		if cur.Len() > 0 {
+			paras = append(paras, cur.String())
+			cur.Reset()
+		}
+		speaker = seg.Speaker
+		if speaker != "" {
+			fmt.Fprintf(&cur, "**%s** ", speaker)
+		}
+		fmt.Fprintf(&cur, "%s %s", n.timestamp(seg.Start), text)
+	}
+	if cur.Len() > 0 {
+		paras = append(paras, cur.String())
+	}
+	return strings.Join(paras, "\n\n")
+}
+
+// timestamp links to the recording at seconds in, with a media fragment
+// that browsers and Obsidian's media plugins seek to.
+func (n *Note) timestamp(seconds float64) string {
+	if n.Audio == "" {
+		return "`" + Clock(seconds) + "`"
+	}
+	return fmt.Sprintf("[%s](%s#t=%d)", Clock(seconds), FileURL(n.Audio), int(seconds))
+}
+
+// Clock formats seconds as m:ss, or h:mm:ss from an hour.
+func Clock(seconds float64) string {
+	s := int(seconds)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s%3600/60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+// FileURL is the file:// URL of path, with the parentheses that would end a
+// Markdown link early escaped too.
+func FileURL(path string) string {
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p // a Windows drive letter
+	}
+	u := (&url.URL{Scheme: "file", Path: p}).String()
+	return strings.NewReplacer("(", "%28", ")", "%29").Replace(u)
+}
+
+// quote writes s as a double-quoted YAML scalar, which is what a JSON string
+// is, so that a title with a colon or a leading # stays a string.
+func quote(s string) string {
+	return strconv.Quote(s)
+}
+
+// splitFrontMatter separates a note's front matter, without its --- lines,
+// from the rest. A note without front matter has an empty head.
+func splitFrontMatter(text string) (head, body string) {
+	if !strings.HasPrefix(text, "---\n") {
+		return "", text
+	}
+	rest := text[len("---\n"):]
+	if strings.HasPrefix(rest, "---\n") {
+		return "", rest[len("---\n"):]
+	}
+	end := strings.Index(rest, "\n---\n")
+	if end < 0 {
+		return "", text
+	}
+	return rest[:end+1], rest[end+len("\n---\n"):]
+}
+
+var keyLine = regexp.MustCompile(`^([^\s#:-][^:]*):`)
+
+// parseFrontMatter splits front matter into its top-level keys. It reads no
+// more YAML than it needs to: lines belong to the key above them, and are
+// kept exactly as written.
+func parseFrontMatter(head string) []field {
+	if head == "" {
+		return nil
+	}
+	var fs []field
+	for _, line := range strings.Split(strings.TrimSuffix(head, "\n"), "\n") {
+		if m := keyLine.FindStringSubmatch(line); m != nil {
+			fs = append(fs, field{key: m[1], lines: []string{line}})
+			continue
+		}
+		if len(fs) == 0 {
+			// A comment before the first key.
+			fs = append(fs, field{})
+		}
+		fs[len(fs)-1].lines = append(fs[len(fs)-1].lines, line)
+	}
+	return fs
+}
+
+// list reads the field as a list of strings, written as a block list, a
+// flow list or a single value.
+func (f field) list() []string {
+	var out []string
+	add := func(v string) {
+		if v = unquote(strings.TrimSpace(v)); v != "" {
+			out = append(out, v)
+		}
+	}
+	_, first, _ := strings.Cut(f.lines[0], ":")
+	first = strings.TrimSpace(first)
+	if strings.HasPrefix(first, "[") && strings.HasSuffix(first, "]") {
+		for _, v := range strings.Split(first[1:len(first)-1], ",") {
+			add(v)
+		}
+	} else {
+		add(first)
+	}
+	for _, line := range f.lines[1:] {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(line), "-"); ok {
+			add(v)
+		}
+	}
+	return out
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	return s
+}
+
+func renderFrontMatter(fs []field) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	for _, f := range fs {
+		for _, line := range f.lines {
+			b.WriteString(line + "\n")
+		}
+	}
+	b.WriteString("---\n")
+	return b.String()
+}
@@ -0,0 +1,151 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+func testNote() *Note {
+	return &Note{
+		Title:       "Sprint planning",
+		Date:        time.Date(2026, 10, 18, 9, 30, 0, 0, time.Local),
+		Duration:    312.4,
+		Device:      "zoom (mic + monitor)",
+		Tags:        []string{"meeting"},
+		Audio:       "/home/alice/Recordings/planning (v2).ogg",
+		Summary:     "We planned.",
+		ActionItems: "- Bob: tickets",
+		Result: &transcribe.Result{
+			Text: "Hello. Shall we? Yes.",
+			Segments: []transcribe.Segment{
+				{Start: 0, End: 1, Text: "Hello.", Speaker: "Alice"},
+				{Start: 1, End: 2, Text: " Shall we?", Speaker: "Alice"},
+				{Start: 65.7, End: 67, Text: "Yes.", Speaker: "Bob"},
+			},
+		},
+	}
+}
+
+func TestRender(t *testing.T) {
+	want := `---
+title: "Sprint planning"
+date: 2026-10-18T09:30:00
+duration: "5:12"
+device: "zoom (mic + monitor)"
+speakers:
+  - "Alice"
+  - "Bob"
+tags:
+  - "meeting"
+audio: "file:///home/alice/Recordings/planning%20%28v2%29.ogg"
+---
+
+<!-- audiomemo:begin -->
+# Sprint planning
+
+Recording: [planning (v2).ogg](file:///home/alice/Recordings/planning%20%28v2%29.ogg)
+
+## Summary
+
+We planned.
+
+## Action items
+
+- Bob: tickets
+
+## Transcript
+
+**Alice** [0:00](file:///home/alice/Recordings/planning%20%28v2%29.ogg#t=0) Hello. Shall we?
+
+**Bob** [1:05](file:///home/alice/Recordings/planning%20%28v2%29.ogg#t=65) Yes.
+
+<!-- audiomemo:end -->
+`
+	if got := testNote().Render(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderWithoutSpeakersOrSegments(t *testing.T) {
+	n := &Note{Result: &transcribe.Result{
+		Segments: []transcribe.Segment{{Start: 3, Text: "One."}, {Start: 3725, Text: "Two."}},
+	}}
+	got := n.Render()
+	if !strings.Contains(got, "`0:03` One.\n\n`1:02:05` Two.") {
+		t.Errorf("segments without speakers or audio:\n%s", got)
+	}
+	n = &Note{Result: &transcribe.Result{Text: "Just text."}}
+	if got := n.Render(); !strings.Contains(got, "## Transcript\n\nJust text.\n") {
+		t.Errorf("text only:\n%s", got)
+	}
+}
+
+func TestUpdateKeepsUserEdits(t *testing.T) {
+	n := testNote()
+	edited := strings.Replace(n.Render(), "tags:\n  - \"meeting\"\n", "tags: [project-x, meeting]\nstatus: done\n", 1)
+	edited = strings.Replace(edited, "<!-- audiomemo:begin -->", "My thoughts first.\n\n<!-- audiomemo:begin -->", 1)
+	edited += "\n## Follow-up\n\nCall Bob.\n"
+
+	n.Summary = "We planned, again."
+	n.Tags = []string{"meeting", "audiomemo"}
+	got, err := n.Update(edited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"tags:\n  - \"project-x\"\n  - \"meeting\"\n  - \"audiomemo\"\nstatus: done\n",
+		"My thoughts first.\n\n<!-- audiomemo:begin -->",
+		"We planned, again.",
+		"<!-- audiomemo:end -->\n\n## Follow-up\n\nCall Bob.\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("update lost %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "We planned.\n") {
+		t.Errorf("the old summary is still there:\n%s", got)
+	}
+
+	again, err := n.Update(got)
+	if err != nil || again != got {
+		t.Errorf("a second update changed the note:\n%s", again)
+	}
+}
+
+func TestUpdateWithoutBlock(t *testing.T) {
+	if _, err := testNote().Update("---\ntitle: mine\n---\nNo block here.\n"); err == nil {
+		t.Error("want an error when the block has been deleted")
+	}
+}
+
+func TestUpdateWithoutFrontMatter(t *testing.T) {
+	n := testNote()
+	_, body := splitFrontMatter(n.Render())
+	got, err := n.Update(strings.TrimPrefix(body, "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != n.Render() {
+		t.Errorf("front matter was not put back:\n%s", got)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Meetings", "planning.md")
+	n := testNote()
+	if changed, err := n.Write(path); err != nil || !changed {
+		t.Fatalf("first write: changed %v, %v", changed, err)
+	}
+	if changed, err := n.Write(path); err != nil || changed {
+		t.Errorf("second write: changed %v, %v", changed, err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != n.Render() {
+		t.Errorf("written:\n%s", data)
+	}
+}
@@ -28,6 +28,16 @@ type RecordOpts struct {
 	OutputPath  string
 	LivePCM     bool
 
+	// InputFile, when set, is read in place of a capture device, so a saved
+	// recording can be played through the live pipeline: the PCM pipe, the
+	// level readings and the output file all come out as they would from a
+	// microphone. Device and Devices are then only labels.
+	InputFile string
+	// Realtime reads InputFile at its own pace (ffmpeg's -re) rather than as
+	// fast as it decodes, which is what a live transcription session or a
+	// watcher of the meter expects.
+	Realtime bool
+
 	// MaxDuration caps the capture length. It becomes ffmpeg's own -t, so
 	// ffmpeg finalises the file and exits on its own; every run loop already
 	// watches Recorder.Done and so needs no further handling.
@@ -114,8 +124,14 @@ func BuildFFmpegArgs(opts RecordOpts) []string {
 
 	codec := CodecForFormat(opts.Format)
 
-	args := []string{
-		"-f", inputFmt,
+	var args []string
+	if opts.InputFile != "" {
+		if opts.Realtime {
+			args = append(args, "-re")
+		}
+		inputDevice = opts.InputFile
+	} else {
+		args = append(args, "-f", inputFmt)
 	}
 	args = append(args, ffmpegDurationArgs(opts.MaxDuration)...)
 	args = append(args,
@@ -234,9 +250,48 @@ func appendPCMPipeArgs(args []string, pipeFd int, mapLabel string) []string {
 	return append(args, "-f", "s16le", "-ar", "16000", "-ac", "1", fmt.Sprintf("pipe:%d", pipeFd))
 }
 
+// DecodeOpts describes audio for live transcription that comes from neither
+// a device nor a recording: a file, or "-" for stdin.
+type DecodeOpts struct {
+	Input  string
+	Format string // ffmpeg's -f for Input; empty lets ffmpeg probe it
+	// SampleRate and Channels describe raw Input formats such as s16le,
+	// which have no header to say. They are ignored when Format is empty.
+	SampleRate int
+	Channels   int
+	Realtime   bool // read Input at its own pace (-re)
+}
+
+// BuildDecodeArgs returns ffmpeg args that decode opts.Input to the PCM
+// stream appendPCMPipeArgs gives the live transcriber, written to stdout.
+func BuildDecodeArgs(opts DecodeOpts) []string {
+	args := []string{"-hide_banner", "-loglevel", "error"}
+	input := opts.Input
+	if input == "-" {
+		input = "pipe:0"
+	} else {
+		// stdin is not the input, so ffmpeg must not take it for keystrokes.
+		args = append(args, "-nostdin")
+	}
+	if opts.Realtime {
+		args = append(args, "-re")
+	}
+	if opts.Format != "" {
+		args = append(args, "-f", opts.Format)
+		if opts.SampleRate > 0 {
+			args = append(args, "-ar", strconv.Itoa(opts.SampleRate))
+		}
+		if opts.Channels > 0 {
+			args = append(args, "-ac", strconv.Itoa(opts.Channels))
+		}
+	}
+	args = append(args, "-i", input)
+	return appendPCMPipeArgs(args, 1, "")
+}
+
 func Start(opts RecordOpts) (*Recorder, error) {
 	var args []string
-	if len(opts.Devices) > 1 {
+	if len(opts.Devices) > 1 && opts.InputFile == "" {
 		var err error
 		args, err = BuildFFmpegArgsMulti(opts)
 		if err != nil {
@@ -261,7 +316,7 @@ func Start(opts RecordOpts) (*Recorder, error) {
 		// the filter_complex exposes the mix as label [b] via asplit; pass that
 		// so the pipe receives mixed audio rather than just input 0.
 		mapLabel := ""
-		if len(opts.Devices) > 1 {
+		if len(opts.Devices) > 1 && opts.InputFile == "" {
 			mapLabel = "[b]"
 		}
 		args = appendPCMPipeArgs(args, 3, mapLabel)
@@ -333,7 +388,10 @@ func Start(opts RecordOpts) (*Recorder, error) {
 		r.PCMReader = pcmReadEnd
 	}
 
-	go r.discoverSourceOutputs()
+	// A file has no PulseAudio source-output to find, let alone mute.
+	if opts.InputFile == "" {
+		go r.discoverSourceOutputs()
+	}
 	go func() {
 		exitErr := cmd.Wait()
 		close(r.Level)
@@ -374,6 +374,37 @@ func TestAppendPCMPipeArgs(t *testing.T) {
 // TestBuildFFmpegArgsMultiLivePCMSplitsMix verifies that with LivePCM and
 // multiple devices the filter graph uses asplit so [b] is available to map
 // to the PCM pipe output, otherwise the pipe would receive only input 0.
+func TestBuildDecodeArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		opts DecodeOpts
+		want []string
+	}{
+		{
+			"stdin, probed",
+			DecodeOpts{Input: "-"},
+			[]string{"-hide_banner", "-loglevel", "error", "-i", "pipe:0", "-f", "s16le", "-ar", "16000", "-ac", "1", "pipe:1"},
+		},
+		{
+			"raw stdin",
+			DecodeOpts{Input: "-", Format: "s16le", SampleRate: 44100, Channels: 2},
+			[]string{"-hide_banner", "-loglevel", "error", "-f", "s16le", "-ar", "44100", "-ac", "2", "-i", "pipe:0", "-f", "s16le", "-ar", "16000", "-ac", "1", "pipe:1"},
+		},
+		{
+			"a file at real time",
+			DecodeOpts{Input: "talk.ogg", SampleRate: 44100, Channels: 2, Realtime: true},
+			[]string{"-hide_banner", "-loglevel", "error", "-nostdin", "-re", "-i", "talk.ogg", "-f", "s16le", "-ar", "16000", "-ac", "1", "pipe:1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BuildDecodeArgs(tt.opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildDecodeArgs(%+v)\n got %v\nwant %v", tt.opts, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestBuildFFmpegArgsMultiLivePCMSplitsMix(t *testing.T) {
 	opts := RecordOpts{
 		Devices:    []string{"mic", "system_monitor"},
@@ -525,6 +556,47 @@ func TestBuildFFmpegArgsNoMaxDuration(t *testing.T) {
 	}
 }
 
+func TestBuildFFmpegArgsInputFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		realtime bool
+	}{
+		{"as fast as it decodes", false},
+		{"at real time", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := BuildFFmpegArgs(RecordOpts{
+				Device:     "meeting.ogg",
+				InputFile:  "/tmp/meeting.ogg",
+				Realtime:   tt.realtime,
+				Format:     "ogg",
+				OutputPath: "/tmp/out.ogg",
+			})
+			if got := argAfter(args, "-i"); got != "/tmp/meeting.ogg" {
+				t.Errorf("-i %q, want the input file: %v", got, args)
+			}
+			// The device's demuxer would try to open the file as a
+			// PulseAudio source.
+			if got := argAfter(args, "-f"); got == InputFormat() {
+				t.Errorf("input file should not be read with -f %s: %v", got, args)
+			}
+			// -re paces the input it precedes, so it has to come first.
+			idx := indexOf(args, "-re")
+			if tt.realtime && (idx < 0 || idx > indexOf(args, "-i")) {
+				t.Errorf("expected -re before -i: %v", args)
+			}
+			if !tt.realtime && idx >= 0 {
+				t.Errorf("unexpected -re: %v", args)
+			}
+			// The meter and silence detection read the same astats chain.
+			if !strings.Contains(argAfter(args, "-af"), "astats=metadata=1") {
+				t.Errorf("expected the astats filter: %v", args)
+			}
+		})
+	}
+}
+
 func TestBuildFFmpegArgsMultiMaxDurationPerInput(t *testing.T) {
 	opts := RecordOpts{
 		Devices:     []string{"mic", "desktop"},
@@ -0,0 +1,300 @@
+// Package retention decides what to clear out of the recordings directory
+// under a [retention] policy, and clears it.
+//
+// Planning is separate from doing, and pure, so that `prune --dry-run`
+// reports exactly what a real run would do. Nothing a plan removes costs
+// words: audio goes only once a batch transcript of it exists, a live
+// transcript only once the batch one does, and a recording tagged "keep" is
+// left alone whatever its age and however full the directory.
+package retention
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/encrypt"
+)
+
+// KeepTag is the sidecar tag that protects a recording.
+const KeepTag = "keep"
+
+// Policy is what to clear. A zero field turns its rule off.
+type Policy struct {
+	// DeleteAudioAfter deletes transcribed audio this old.
+	DeleteAudioAfter time.Duration
+	// TranscodeAfter re-encodes WAV and FLAC this old to Opus.
+	TranscodeAfter time.Duration
+	// DeleteLive deletes a -live.txt once the batch transcript exists.
+	DeleteLive bool
+	// MaxSize caps the directory, in bytes, by deleting the oldest
+	// transcribed audio until it fits.
+	MaxSize int64
+}
+
+// IsZero reports whether the policy clears nothing.
+func (p Policy) IsZero() bool {
+	return p == Policy{}
+}
+
+// Recording is one recording in the directory, as the plan needs to know it.
+// Its audio may already be gone, leaving transcripts.
+type Recording struct {
+	Audio       string // or empty
+	Size        int64
+	Made        time.Time
+	Duration    float64 // seconds, or 0 when unknown; sizes a transcode
+	Transcribed bool    // a batch transcript sits beside it
+	Live        string  // its -live.txt, or empty
+	LiveSize    int64
+	Kept        bool // tagged KeepTag
+}
+
+// Op is what an Action does to its file.
+type Op int
+
+const (
+	Delete    Op = iota + 1
+	Transcode    // to Opus, in an .ogg beside it, replacing it
+)
+
+// Action is one change to the directory.
+type Action struct {
+	Op     Op
+	Path   string
+	Freed  int64 // bytes; an estimate for a transcode
+	Reason string
+}
+
+// Plan is what a policy does to a directory.
+type Plan struct {
+	Actions []Action
+	Kept    int   // recordings the policy would have touched but for KeepTag
+	Before  int64 // the directory's size now
+	After   int64 // and once the plan is carried out, estimated
+	// Over is how far the directory stays above MaxSize when nothing left
+	// may go: what remains is untranscribed or kept.
+	Over int64
+	// Skipped are the transcodes TranscodeAfter asks for that are not made,
+	// and why: the recording is encrypted, and transcoding it would mean
+	// decrypting it. Nothing in them is carried out.
+	Skipped []Action
+}
+
+// opusBytesPerSecond is the 64 kbit/s Transcode encodes at.
+const opusBytesPerSecond = 64000 / 8
+
+// Lossless reports whether the audio at path, encrypted or not, is in a
+// format Transcode shrinks.
+func Lossless(path string) bool {
+	switch strings.ToLower(filepath.Ext(encrypt.Plain(path))) {
+	case ".wav", ".flac":
+		return true
+	}
+	return false
+}
+
+// MakePlan works out what p does to the recordings in a directory of size
+// total, as of now. The oldest recordings are dealt with first, which is
+// also the order the size cap deletes in.
+func MakePlan(recs []Recording, total int64, p Policy, now time.Time) *Plan {
+	recs = slices.Clone(recs)
+	slices.SortStableFunc(recs, func(a, b Recording) int { return a.Made.Compare(b.Made) })
+	plan := &Plan{Before: total, After: total}
+	add := func(a Action) int {
+		plan.Actions = append(plan.Actions, a)
+		plan.After -= a.Freed
+		return len(plan.Actions) - 1
+	}
+	older := func(r Recording, d time.Duration) bool {
+		return d > 0 && now.Sub(r.Made) >= d
+	}
+	expired := func(r Recording) bool {
+		return r.Audio != "" && r.Transcribed && older(r, p.DeleteAudioAfter)
+	}
+
+	// What is left of each recording's audio for the size cap: its index
+	// in recs, and the transcode planned for it, if any.
+	type remaining struct {
+		rec       int
+		transcode int
+	}
+	var left []remaining
+	for i, r := range recs {
+		if r.Kept {
+			if (p.DeleteLive && r.Live != "" && r.Transcribed) ||
+				expired(r) ||
+				(Lossless(r.Audio) && older(r, p.TranscodeAfter)) {
+				plan.Kept++
+			}
+			continue
+		}
+		if p.DeleteLive && r.Live != "" && r.Transcribed {
+			add(Action{Op: Delete, Path: r.Live, Freed: r.LiveSize, Reason: "the batch transcript exists"})
+		}
+		if expired(r) {
+			add(Action{Op: Delete, Path: r.Audio, Freed: r.Size, Reason: "transcribed, older than " + days(p.DeleteAudioAfter)})
+			continue
+		}
+		transcode := -1
+		if Lossless(r.Audio) && older(r, p.TranscodeAfter) {
+			if encrypt.IsEncrypted(r.Audio) {
+				plan.Skipped = append(plan.Skipped, Action{Op: Transcode, Path: r.Audio, Reason: "lossless, older than " + days(p.TranscodeAfter) + ", but encrypted"})
+			} else {
+				transcode = add(Action{Op: Transcode, Path: r.Audio, Freed: transcodeSaving(r), Reason: "lossless, older than " + days(p.TranscodeAfter)})
+			}
+		}
+		if r.Audio != "" && r.Transcribed {
+			left = append(left, remaining{i, transcode})
+		}
+	}
+
+	if p.MaxSize <= 0 {
+		return plan
+	}
+	for _, l := range left {
+		if plan.After <= p.MaxSize {
+			break
+		}
+		r := recs[l.rec]
+		if l.transcode >= 0 {
+			// Deleting the original instead frees what the transcode
+			// would have kept.
+			plan.After += plan.Actions[l.transcode].Freed
+			plan.Actions[l.transcode] = Action{Op: Delete, Path: r.Audio, Freed: r.Size, Reason: "transcribed, over max_size"}
+			plan.After -= r.Size
+			continue
+		}
+		add(Action{Op: Delete, Path: r.Audio, Freed: r.Size, Reason: "transcribed, over max_size"})
+	}
+	if plan.After > p.MaxSize {
+		plan.Over = plan.After - p.MaxSize
+	}
+	return plan
+}
+
+// transcodeSaving estimates what re-encoding r to Opus frees. Without a
+// duration it claims nothing, which keeps the size cap from counting on it.
+func transcodeSaving(r Recording) int64 {
+	saved := r.Size - int64(math.Ceil(r.Duration*opusBytesPerSecond))
+	if r.Duration <= 0 || saved < 0 {
+		return 0
+	}
+	return saved
+}
+
+func days(d time.Duration) string {
+	n := int(d.Hours() / 24)
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
+
+// Do carries out the action.
+func (a Action) Do(ctx context.Context) error {
+	switch a.Op {
+	case Delete:
+		return os.Remove(a.Path)
+	case Transcode:
+		_, err := TranscodeToOpus(ctx, a.Path)
+		return err
+	}
+	return fmt.Errorf("unknown action %d", a.Op)
+}
+
+// TranscodeToOpus re-encodes the recording at path to Opus in an .ogg beside
+// it, keeping its tags, chapters and modification time, then deletes the
+// original. It returns the new file's path. An .ogg already there is an
+// error rather than something to overwrite.
+func TranscodeToOpus(ctx context.Context, path string) (string, error) {
+	out := strings.TrimSuffix(path, filepath.Ext(path)) + ".ogg"
+	if _, err := os.Stat(out); err == nil {
+		return "", fmt.Errorf("%s already exists", out)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	tmp := filepath.Join(filepath.Dir(out), ".transcoding-"+filepath.Base(out))
+	defer os.Remove(tmp)
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ffmpeg", transcodeArgs(path, tmp)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("ffmpeg: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	os.Chmod(tmp, info.Mode().Perm())
+	// The name's timestamp says when it was made, but a recording with
+	// none in its name is aged by its modification time.
+	if err := os.Chtimes(tmp, time.Time{}, info.ModTime()); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, out); err != nil {
+		return "", err
+	}
+	return out, os.Remove(path)
+}
+
+func transcodeArgs(in, out string) []string {
+	return []string{
+		"-y", "-v", "error",
+		"-i", in,
+		"-map", "0:a",
+		"-c:a", "libopus", "-b:a", "64k",
+		"-map_metadata", "0", "-map_metadata:s:a", "0:g",
+		"-map_chapters", "0",
+		"-f", "ogg",
+		out,
+	}
+}
+
+// ParseSize reads a size such as "20GB", "500 MB", "1.5G" or "2GiB". Units
+// without an i are decimal; a bare number is bytes.
+func ParseSize(s string) (int64, error) {
+	t := strings.ToUpper(strings.TrimSpace(s))
+	units := []struct {
+		suffix string
+		mult   float64
+	}{
+		{"TIB", 1 << 40}, {"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+		{"TB", 1e12}, {"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3},
+		{"T", 1e12}, {"G", 1e9}, {"M", 1e6}, {"K", 1e3}, {"B", 1},
+	}
+	mult := 1.0
+	for _, u := range units {
+		if rest, ok := strings.CutSuffix(t, u.suffix); ok {
+			t, mult = strings.TrimSpace(rest), u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(t, 64)
+	if err != nil || n < 0 {
+		return 0, errors.New("invalid size " + strconv.Quote(s) + ": use a number and a unit, such as 20GB or 500MB")
+	}
+	return int64(n * mult), nil
+}
+
+// FormatSize writes n bytes in decimal units, as ParseSize reads them.
+func FormatSize(n int64) string {
+	switch {
+	case n >= 1e12:
+		return fmt.Sprintf("%.1f TB", float64(n)/1e12)
+	case n >= 1e9:
+		return fmt.Sprintf("%.1f GB", float64(n)/1e9)
+	case n >= 1e6:
+		return fmt.Sprintf("%.1f MB", float64(n)/1e6)
+	case n >= 1e3:
+		return fmt.Sprintf("%.1f kB", float64(n)/1e3)
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,156 @@
+package retention
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+func daysAgo(n int) time.Time { return now.AddDate(0, 0, -n) }
+
+func paths(plan *Plan, op Op) []string {
+	var out []string
+	for _, a := range plan.Actions {
+		if a.Op == op {
+			out = append(out, a.Path)
+		}
+	}
+	return out
+}
+
+func TestMakePlanAgeRules(t *testing.T) {
+	recs := []Recording{
+		{Audio: "old.ogg", Size: 100, Made: daysAgo(100), Transcribed: true, Live: "old-live.txt", LiveSize: 5},
+		{Audio: "untranscribed.ogg", Size: 100, Made: daysAgo(200), Live: "untranscribed-live.txt", LiveSize: 5},
+		{Audio: "kept.ogg", Size: 100, Made: daysAgo(300), Transcribed: true, Kept: true},
+		{Audio: "lecture.wav", Size: 1000, Duration: 10, Made: daysAgo(40), Transcribed: true},
+		{Audio: "new.flac", Size: 1000, Made: daysAgo(1), Transcribed: true},
+		{Live: "gone-live.txt", LiveSize: 5, Made: daysAgo(400), Transcribed: true},
+	}
+	p := Policy{DeleteAudioAfter: 90 * 24 * time.Hour, TranscodeAfter: 30 * 24 * time.Hour, DeleteLive: true}
+	plan := MakePlan(recs, 5000, p, now)
+
+	if got, want := paths(plan, Delete), []string{"gone-live.txt", "old-live.txt", "old.ogg"}; !slices.Equal(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+	if got := paths(plan, Transcode); !slices.Equal(got, []string{"lecture.wav"}) {
+		t.Errorf("transcoded %v", got)
+	}
+	// Ten seconds of Opus is more than the tiny WAV, so the transcode is
+	// counted as saving nothing.
+	if plan.After != 5000-5-5-100 {
+		t.Errorf("after = %d", plan.After)
+	}
+	if plan.Kept != 1 {
+		t.Errorf("kept = %d", plan.Kept)
+	}
+	if plan.Actions[2].Reason != "transcribed, older than 90 days" {
+		t.Errorf("reason = %q", plan.Actions[2].Reason)
+	}
+}
+
+func TestMakePlanSkipsEncryptedTranscodes(t *testing.T) {
+	if !Lossless("lecture.wav.age") || !Lossless("talk.FLAC.age") || Lossless("memo.ogg.age") {
+		t.Error("Lossless should go by the name under .age")
+	}
+	recs := []Recording{
+		{Audio: "lecture.wav.age", Size: 1000, Made: daysAgo(40), Transcribed: true},
+		{Audio: "talk.flac", Size: 1000, Duration: 1, Made: daysAgo(40), Transcribed: true},
+	}
+	plan := MakePlan(recs, 2000, Policy{TranscodeAfter: 30 * 24 * time.Hour}, now)
+	if got := paths(plan, Transcode); !slices.Equal(got, []string{"talk.flac"}) {
+		t.Errorf("transcoded %v", got)
+	}
+	if len(plan.Skipped) != 1 || plan.Skipped[0].Path != "lecture.wav.age" ||
+		plan.Skipped[0].Reason != "lossless, older than 30 days, but encrypted" {
+		t.Errorf("skipped = %+v", plan.Skipped)
+	}
+}
+
+func TestMakePlanSizeCap(t *testing.T) {
+	recs := []Recording{
+		{Audio: "c.ogg", Size: 300, Made: daysAgo(3), Transcribed: true},
+		{Audio: "a.wav", Size: 800_000, Duration: 10, Made: daysAgo(50), Transcribed: true},
+		{Audio: "b.ogg", Size: 300, Made: daysAgo(10)},
+		{Audio: "d.ogg", Size: 300, Made: daysAgo(20), Transcribed: true, Kept: true},
+	}
+	p := Policy{TranscodeAfter: 30 * 24 * time.Hour, MaxSize: 500}
+	plan := MakePlan(recs, 801_000, p, now)
+
+	// The transcode of the oldest is not enough, so it goes instead, then
+	// the next oldest transcribed recording that is not kept.
+	if got, want := paths(plan, Delete), []string{"a.wav", "c.ogg"}; !slices.Equal(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+	if len(paths(plan, Transcode)) != 0 {
+		t.Errorf("transcoded %v", paths(plan, Transcode))
+	}
+	if plan.After != 801_000-800_000-300 || plan.Over != 200 {
+		t.Errorf("after = %d, over = %d", plan.After, plan.Over)
+	}
+
+	if plan := MakePlan(recs, 400, p, now); len(paths(plan, Delete)) != 0 {
+		t.Errorf("under the cap, deleted %v", paths(plan, Delete))
+	}
+}
+
+func TestTranscodeSaving(t *testing.T) {
+	if got := transcodeSaving(Recording{Size: 10_000_000, Duration: 60}); got != 10_000_000-480_000 {
+		t.Errorf("saving = %d", got)
+	}
+	if got := transcodeSaving(Recording{Size: 10_000_000}); got != 0 {
+		t.Errorf("without a duration, saving = %d", got)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for in, want := range map[string]int64{"20GB": 20e9, "500 mb": 500e6, "1.5G": 1.5e9, "2GiB": 2 << 30, "1024": 1024, "3k": 3000} {
+		if got, err := ParseSize(in); err != nil || got != want {
+			t.Errorf("ParseSize(%q) = %d, %v", in, got, err)
+		}
+	}
+	for _, in := range []string{"", "GB", "-1GB", "lots"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Errorf("ParseSize(%q): want an error", in)
+		}
+	}
+	if got := FormatSize(1_530_000_000); got != "1.5 GB" {
+		t.Errorf("FormatSize = %q", got)
+	}
+	if got := FormatSize(512); got != "512 B" {
+		t.Errorf("FormatSize = %q", got)
+	}
+}
+
+func TestTranscodeToOpus(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not on PATH")
+	}
+	dir := t.TempDir()
+	wav := filepath.Join(dir, "memo.wav")
+	if out, err := exec.Command("ffmpeg", "-v", "error", "-f", "lavfi", "-i", "sine=d=2", wav).CombinedOutput(); err != nil {
+		t.Skipf("ffmpeg: %v: %s", err, out)
+	}
+	mtime := daysAgo(60)
+	os.Chtimes(wav, mtime, mtime)
+
+	out, err := TranscodeToOpus(context.Background(), wav)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != filepath.Join(dir, "memo.ogg") {
+		t.Errorf("out = %s", out)
+	}
+	if _, err := os.Stat(wav); !os.IsNotExist(err) {
+		t.Error("the WAV is still there")
+	}
+	if info, err := os.Stat(out); err != nil || !info.ModTime().Equal(mtime) {
+		t.Errorf("the Opus file lost its age: %v, %v", info, err)
+	}
+}
@@ -0,0 +1,541 @@
+// Package s3 backs recordings up to an S3-compatible bucket, such as MinIO's
+// or AWS's. It speaks only the part of the S3 API that needs: objects, their
+// listing, and multipart uploads, signed with Signature Version 4.
+//
+// Buckets are addressed by path (https://minio.example.com/bucket/key),
+// which every S3-compatible server takes, rather than by virtual host.
+package s3
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRegion signs requests when none is configured. MinIO and most other
+// stand-ins accept it whatever they call themselves.
+const DefaultRegion = "us-east-1"
+
+// ErrNotFound is returned for an object, or an upload, the bucket does not
+// have.
+var ErrNotFound = errors.New("not found")
+
+// Credentials are an access key pair, and the session token that comes with
+// temporary ones.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// LoadCredentials reads profile from an AWS-style shared credentials file:
+//
+//	[default]
+//	aws_access_key_id = ...
+//	aws_secret_access_key = ...
+//
+// An empty profile is "default".
+func LoadCredentials(path, profile string) (Credentials, error) {
+	if profile == "" {
+		profile = "default"
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return Credentials{}, err
+	}
+	defer f.Close()
+	var creds Credentials
+	section := ""
+	found := false
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+			continue
+		case line[0] == '[':
+			section = strings.TrimSpace(strings.Trim(line, "[]"))
+			continue
+		case section != profile:
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		found = true
+		switch strings.TrimSpace(k) {
+		case "aws_access_key_id":
+			creds.AccessKeyID = strings.TrimSpace(v)
+		case "aws_secret_access_key":
+			creds.SecretAccessKey = strings.TrimSpace(v)
+		case "aws_session_token":
+			creds.SessionToken = strings.TrimSpace(v)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return Credentials{}, err
+	}
+	if !found || creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return Credentials{}, fmt.Errorf("%s has no aws_access_key_id and aws_secret_access_key under [%s]", path, profile)
+	}
+	return creds, nil
+}
+
+// CredentialsFromEnv reads AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN.
+func CredentialsFromEnv() Credentials {
+	return Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// Client is a bucket on an S3-compatible server.
+type Client struct {
+	Endpoint    string // scheme and host, such as https://minio.example.com:9000
+	Region      string // DefaultRegion when empty
+	Bucket      string
+	Credentials Credentials
+	// PartSize is the size of each part of a multipart upload, and the size
+	// above which one is used. DefaultPartSize when zero.
+	PartSize int64
+	HTTP     *http.Client // http.DefaultClient when nil
+
+	now func() time.Time // for signing; time.Now when nil
+}
+
+// Object is an object in the bucket. Meta holds its user metadata, from the
+// x-amz-meta- headers, by lower-case name; only Head fills it in.
+type Object struct {
+	Key      string
+	Size     int64
+	ETag     string
+	Modified time.Time
+	Meta     map[string]string
+}
+
+// emptyHash is the SHA-256 of no payload.
+const emptyHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// request is one S3 API call.
+type request struct {
+	method string
+	key    string
+	query  url.Values
+	header http.Header
+	body   io.Reader
+	size   int64
+	hash   string // hex SHA-256 of body; emptyHash when empty
+}
+
+// send signs and makes r, and turns an S3 error into a Go one. The caller
+// closes the response's body.
+func (c *Client) send(ctx context.Context, r request) (*http.Response, error) {
+	endpoint, err := url.Parse(strings.TrimRight(c.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q: use a URL such as https://minio.example.com", c.Endpoint)
+	}
+	path := "/" + c.Bucket
+	if r.key != "" {
+		path += "/" + r.key
+	}
+	endpoint.Path = endpoint.Path + path
+	endpoint.RawPath = escapePath(endpoint.Path)
+	endpoint.RawQuery = canonicalQuery(r.query)
+
+	body := r.body
+	if body == nil {
+		body = http.NoBody
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, endpoint.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.URL.Path, req.URL.RawPath = endpoint.Path, endpoint.RawPath
+	req.ContentLength = r.size
+	for k, vs := range r.header {
+		req.Header[k] = vs
+	}
+	hash := r.hash
+	if hash == "" {
+		hash = emptyHash
+	}
+	now := time.Now
+	if c.now != nil {
+		now = c.now
+	}
+	c.sign(req, hash, now())
+
+	hc := c.HTTP
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	return nil, responseError(r, resp)
+}
+
+// responseError reads the S3 error in resp.
+func responseError(r request, resp *http.Response) error {
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	xml.Unmarshal(data, &e)
+	if resp.StatusCode == http.StatusNotFound && e.Code != "NoSuchBucket" {
+		return fmt.Errorf("%s: %w", r.key, ErrNotFound)
+	}
+	if e.Code == "" {
+		e.Code = resp.Status
+	}
+	if e.Message == "" {
+		return fmt.Errorf("s3: %s %s: %s", r.method, r.key, e.Code)
+	}
+	return fmt.Errorf("s3: %s %s: %s: %s", r.method, r.key, e.Code, e.Message)
+}
+
+// sign adds a Signature Version 4 Authorization header to req, whose body
+// hashes to hash, as of t. Every x-amz- header is signed, with the host and
+// any range.
+func (c *Client) sign(req *http.Request, hash string, t time.Time) {
+	stamp := t.UTC().Format("20060102T150405Z")
+	day := stamp[:8]
+	region := c.Region
+	if region == "" {
+		region = DefaultRegion
+	}
+	req.Header.Set("X-Amz-Date", stamp)
+	req.Header.Set("X-Amz-Content-Sha256", hash)
+	if c.Credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.Credentials.SessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for k, vs := range req.Header {
+		k = strings.ToLower(k)
+		if strings.HasPrefix(k, "x-amz-") || k == "range" || k == "content-md5" {
+			headers[k] = strings.Join(strings.Fields(strings.Join(vs, ",")), " ")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonical strings.Builder
+	for _, k := range names {
+		canonical.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	request := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonical.String(),
+		signed,
+		hash,
+	}, "\n")
+	scope := day + "/" + region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + hexSHA256([]byte(request))
+
+	key := hmacSHA256([]byte("AWS4"+c.Credentials.SecretAccessKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.Credentials.AccessKeyID, scope, signed, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// escape percent-encodes s as SigV4 does: everything but letters, digits
+// and -_.~, and '/' too unless keepSlash.
+func escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func escapePath(path string) string {
+	return escape(path, true)
+}
+
+// canonicalQuery encodes q sorted by name, as it is both sent and signed.
+func canonicalQuery(q url.Values) string {
+	var pairs []string
+	for k, vs := range q {
+		for _, v := range vs {
+			pairs = append(pairs, escape(k, false)+"="+escape(v, false))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// List returns every object whose key starts with prefix.
+func (c *Client) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	token := ""
+	for {
+		q := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		var page struct {
+			Contents []struct {
+				Key          string    `xml:"Key"`
+				Size         int64     `xml:"Size"`
+				ETag         string    `xml:"ETag"`
+				LastModified time.Time `xml:"LastModified"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		if err := c.sendXML(ctx, request{method: http.MethodGet, query: q}, &page); err != nil {
+			return nil, err
+		}
+		for _, o := range page.Contents {
+			objects = append(objects, Object{Key: o.Key, Size: o.Size, ETag: o.ETag, Modified: o.LastModified})
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// sendXML makes r and decodes its XML response into v.
+func (c *Client) sendXML(ctx context.Context, r request, v any) error {
+	resp, err := c.send(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	// CompleteMultipartUpload can fail after its 200 has been sent, and
+	// says so in the body.
+	if bytes.Contains(data, []byte("<Error>")) {
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+		return responseError(r, resp)
+	}
+	if v == nil {
+		return nil
+	}
+	return xml.Unmarshal(data, v)
+}
+
+// Head returns the object at key, with its metadata.
+func (c *Client) Head(ctx context.Context, key string) (*Object, error) {
+	resp, err := c.send(ctx, request{method: http.MethodHead, key: key})
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	o := &Object{Key: key, Size: resp.ContentLength, ETag: resp.Header.Get("ETag"), Meta: map[string]string{}}
+	o.Modified, _ = http.ParseTime(resp.Header.Get("Last-Modified"))
+	for k, vs := range resp.Header {
+		if name, ok := strings.CutPrefix(strings.ToLower(k), "x-amz-meta-"); ok && len(vs) > 0 {
+			o.Meta[name] = vs[0]
+		}
+	}
+	return o, nil
+}
+
+// Get opens the object at key from offset on, and returns where what it
+// reads starts: offset, or 0 if the server sent the whole object.
+func (c *Client) Get(ctx context.Context, key string, offset int64) (io.ReadCloser, int64, error) {
+	r := request{method: http.MethodGet, key: key}
+	if offset > 0 {
+		r.header = http.Header{"Range": {"bytes=" + strconv.FormatInt(offset, 10) + "-"}}
+	}
+	resp, err := c.send(ctx, r)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		offset = 0
+	}
+	return resp.Body, offset, nil
+}
+
+// Put writes size bytes from body to key, with meta as its metadata. hash is
+// the hex SHA-256 of what body holds.
+func (c *Client) Put(ctx context.Context, key string, body io.Reader, size int64, hash string, meta map[string]string) error {
+	resp, err := c.send(ctx, request{method: http.MethodPut, key: key, header: metaHeader(meta), body: body, size: size, hash: hash})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Delete removes the object at key.
+func (c *Client) Delete(ctx context.Context, key string) error {
+	resp, err := c.send(ctx, request{method: http.MethodDelete, key: key})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func metaHeader(meta map[string]string) http.Header {
+	h := http.Header{}
+	for k, v := range meta {
+		h.Set("X-Amz-Meta-"+k, v)
+	}
+	return h
+}
+
+// upload is a multipart upload in progress.
+type upload struct {
+	ID        string
+	Initiated time.Time
+}
+
+// part is one part of a multipart upload.
+type part struct {
+	Number int    `xml:"PartNumber"`
+	ETag   string `xml:"ETag"`
+	Size   int64  `xml:"Size,omitempty"`
+}
+
+func (c *Client) createMultipart(ctx context.Context, key string, meta map[string]string) (string, error) {
+	var res struct {
+		UploadID string `xml:"UploadId"`
+	}
+	r := request{method: http.MethodPost, key: key, query: url.Values{"uploads": {""}}, header: metaHeader(meta)}
+	if err := c.sendXML(ctx, r, &res); err != nil {
+		return "", err
+	}
+	return res.UploadID, nil
+}
+
+func (c *Client) uploadPart(ctx context.Context, key, id string, n int, data []byte) (string, error) {
+	q := url.Values{"partNumber": {strconv.Itoa(n)}, "uploadId": {id}}
+	resp, err := c.send(ctx, request{method: http.MethodPut, key: key, query: q, body: bytes.NewReader(data), size: int64(len(data)), hash: hexSHA256(data)})
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return resp.Header.Get("ETag"), nil
+}
+
+func (c *Client) completeMultipart(ctx context.Context, key, id string, parts []part) error {
+	type completed struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []part   `xml:"Part"`
+	}
+	body, err := xml.Marshal(completed{Parts: parts})
+	if err != nil {
+		return err
+	}
+	r := request{method: http.MethodPost, key: key, query: url.Values{"uploadId": {id}}, body: bytes.NewReader(body), size: int64(len(body)), hash: hexSHA256(body)}
+	return c.sendXML(ctx, r, nil)
+}
+
+func (c *Client) abortMultipart(ctx context.Context, key, id string) error {
+	resp, err := c.send(ctx, request{method: http.MethodDelete, key: key, query: url.Values{"uploadId": {id}}})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// uploads lists the multipart uploads in progress to key.
+func (c *Client) uploads(ctx context.Context, key string) ([]upload, error) {
+	var res struct {
+		Uploads []struct {
+			Key       string    `xml:"Key"`
+			UploadID  string    `xml:"UploadId"`
+			Initiated time.Time `xml:"Initiated"`
+		} `xml:"Upload"`
+	}
+	r := request{method: http.MethodGet, query: url.Values{"uploads": {""}, "prefix": {key}}}
+	if err := c.sendXML(ctx, r, &res); err != nil {
+		return nil, err
+	}
+	var ups []upload
+	for _, u := range res.Uploads {
+		if u.Key == key {
+			ups = append(ups, upload{ID: u.UploadID, Initiated: u.Initiated})
+		}
+	}
+	return ups, nil
+}
+
+// parts lists the parts an upload to key already has.
+func (c *Client) parts(ctx context.Context, key, id string) ([]part, error) {
+	var all []part
+	marker := ""
+	for {
+		q := url.Values{"uploadId": {id}}
+		if marker != "" {
+			q.Set("part-number-marker", marker)
+		}
+		var res struct {
+			Parts                []part `xml:"Part"`
+			IsTruncated          bool   `xml:"IsTruncated"`
+			NextPartNumberMarker string `xml:"NextPartNumberMarker"`
+		}
+		if err := c.sendXML(ctx, request{method: http.MethodGet, key: key, query: q}, &res); err != nil {
+			return nil, err
+		}
+		all = append(all, res.Parts...)
+		if !res.IsTruncated || res.NextPartNumberMarker == "" {
+			return all, nil
+		}
+		marker = res.NextPartNumberMarker
+	}
+}
@@ -0,0 +1,335 @@
+// Package s3test is an in-memory stand-in for an S3-compatible server, for
+// testing what talks to one without MinIO, in the manner of httptest. It
+// serves one bucket and the calls package s3 makes, and checks that each is
+// signed with the right access key and carries the payload hash it claims.
+package s3test
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is the stand-in. Fail, when set, is asked about each request, and
+// one it returns true for gets a 500 instead of being served.
+type Server struct {
+	*httptest.Server
+	Bucket      string
+	AccessKeyID string
+	Fail        func(r *http.Request) bool
+
+	mu       sync.Mutex
+	objects  map[string]*object
+	uploads  map[string]*upload
+	nextID   int
+	requests []string
+}
+
+type object struct {
+	data     []byte
+	meta     http.Header
+	modified time.Time
+}
+
+type upload struct {
+	key       string
+	meta      http.Header
+	initiated time.Time
+	parts     map[int][]byte
+}
+
+// NewServer starts a stand-in serving bucket to requests signed with
+// accessKeyID. Close it when done.
+func NewServer(bucket, accessKeyID string) *Server {
+	s := &Server{
+		Bucket:      bucket,
+		AccessKeyID: accessKeyID,
+		objects:     map[string]*object{},
+		uploads:     map[string]*upload{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Put stores an object as if it had been uploaded, with meta as its
+// x-amz-meta- metadata.
+func (s *Server) Put(key string, data []byte, meta map[string]string) {
+	h := http.Header{}
+	for k, v := range meta {
+		h.Set("X-Amz-Meta-"+k, v)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = &object{data: data, meta: h, modified: time.Now()}
+}
+
+// Object returns the object at key, and whether there is one.
+func (s *Server) Object(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.objects[key]
+	if !ok {
+		return nil, false
+	}
+	return o.data, true
+}
+
+// Requests lists the requests served so far, as "METHOD key", with
+// ?partNumber=N for a part.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// Uploads is the number of multipart uploads begun and not completed.
+func (s *Server) Uploads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.uploads)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	q := r.URL.Query()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	logged := r.Method + " " + key
+	if n := q.Get("partNumber"); n != "" {
+		logged += "?partNumber=" + n
+	}
+	s.requests = append(s.requests, logged)
+
+	if !strings.Contains(r.Header.Get("Authorization"), "Credential="+s.AccessKeyID+"/") {
+		writeError(w, http.StatusForbidden, "InvalidAccessKeyId", "unknown access key")
+		return
+	}
+	if bucket != s.Bucket {
+		writeError(w, http.StatusNotFound, "NoSuchBucket", "no bucket "+bucket)
+		return
+	}
+	if s.Fail != nil && s.Fail(r) {
+		writeError(w, http.StatusInternalServerError, "InternalError", "failing as asked")
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+	sum := sha256.Sum256(body)
+	if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+		writeError(w, http.StatusBadRequest, "XAmzContentSHA256Mismatch", "the payload does not match its hash")
+		return
+	}
+
+	switch {
+	case key == "" && q.Has("uploads"):
+		s.listUploads(w, q.Get("prefix"))
+	case key == "" && r.Method == http.MethodGet:
+		s.list(w, q.Get("prefix"))
+	case r.Method == http.MethodPost && q.Has("uploads"):
+		s.nextID++
+		id := "upload-" + strconv.Itoa(s.nextID)
+		s.uploads[id] = &upload{key: key, meta: metaOf(r.Header), initiated: time.Now(), parts: map[int][]byte{}}
+		writeXML(w, struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			UploadID string   `xml:"UploadId"`
+		}{UploadID: id})
+	case r.Method == http.MethodPut && q.Has("uploadId"):
+		u, ok := s.uploads[q.Get("uploadId")]
+		n, err := strconv.Atoi(q.Get("partNumber"))
+		if !ok || err != nil {
+			writeError(w, http.StatusNotFound, "NoSuchUpload", "no such upload")
+			return
+		}
+		u.parts[n] = body
+		w.Header().Set("ETag", etag(body))
+	case r.Method == http.MethodPost && q.Has("uploadId"):
+		s.complete(w, key, q.Get("uploadId"), body)
+	case r.Method == http.MethodGet && q.Has("uploadId"):
+		s.listParts(w, q.Get("uploadId"))
+	case r.Method == http.MethodDelete && q.Has("uploadId"):
+		delete(s.uploads, q.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		s.objects[key] = &object{data: body, meta: metaOf(r.Header), modified: time.Now()}
+		w.Header().Set("ETag", etag(body))
+	case r.Method == http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodHead || r.Method == http.MethodGet:
+		s.get(w, r, key)
+	default:
+		writeError(w, http.StatusNotImplemented, "NotImplemented", r.Method+" is not supported here")
+	}
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request, key string) {
+	o, ok := s.objects[key]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchKey", "no such key")
+		return
+	}
+	for k, vs := range o.meta {
+		w.Header()[k] = vs
+	}
+	w.Header().Set("ETag", etag(o.data))
+	w.Header().Set("Last-Modified", o.modified.UTC().Format(http.TimeFormat))
+	data := o.data
+	status := http.StatusOK
+	if rng, ok := strings.CutPrefix(r.Header.Get("Range"), "bytes="); ok {
+		from, err := strconv.Atoi(strings.TrimSuffix(rng, "-"))
+		if err != nil || from >= len(data) {
+			writeError(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "bad range")
+			return
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", from, len(data)-1, len(data)))
+		data, status = data[from:], http.StatusPartialContent
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	if r.Method == http.MethodGet {
+		w.Write(data)
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter, prefix string) {
+	type content struct {
+		Key          string `xml:"Key"`
+		Size         int    `xml:"Size"`
+		ETag         string `xml:"ETag"`
+		LastModified string `xml:"LastModified"`
+	}
+	res := struct {
+		XMLName  xml.Name  `xml:"ListBucketResult"`
+		Contents []content `xml:"Contents"`
+	}{}
+	for _, k := range s.keys(prefix) {
+		o := s.objects[k]
+		res.Contents = append(res.Contents, content{k, len(o.data), etag(o.data), o.modified.UTC().Format(time.RFC3339)})
+	}
+	writeXML(w, res)
+}
+
+func (s *Server) keys(prefix string) []string {
+	var keys []string
+	for k := range s.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Server) listUploads(w http.ResponseWriter, prefix string) {
+	type entry struct {
+		Key       string `xml:"Key"`
+		UploadID  string `xml:"UploadId"`
+		Initiated string `xml:"Initiated"`
+	}
+	res := struct {
+		XMLName xml.Name `xml:"ListMultipartUploadsResult"`
+		Uploads []entry  `xml:"Upload"`
+	}{}
+	for id, u := range s.uploads {
+		if strings.HasPrefix(u.key, prefix) {
+			res.Uploads = append(res.Uploads, entry{u.key, id, u.initiated.UTC().Format(time.RFC3339Nano)})
+		}
+	}
+	writeXML(w, res)
+}
+
+func (s *Server) listParts(w http.ResponseWriter, id string) {
+	u, ok := s.uploads[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchUpload", "no such upload")
+		return
+	}
+	type entry struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+		Size       int    `xml:"Size"`
+	}
+	res := struct {
+		XMLName xml.Name `xml:"ListPartsResult"`
+		Parts   []entry  `xml:"Part"`
+	}{}
+	for n, data := range u.parts {
+		res.Parts = append(res.Parts, entry{n, etag(data), len(data)})
+	}
+	sort.Slice(res.Parts, func(i, j int) bool { return res.Parts[i].PartNumber < res.Parts[j].PartNumber })
+	writeXML(w, res)
+}
+
+func (s *Server) complete(w http.ResponseWriter, key, id string, body []byte) {
+	u, ok := s.uploads[id]
+	if !ok || u.key != key {
+		writeError(w, http.StatusNotFound, "NoSuchUpload", "no such upload")
+		return
+	}
+	var req struct {
+		Parts []struct {
+			PartNumber int    `xml:"PartNumber"`
+			ETag       string `xml:"ETag"`
+		} `xml:"Part"`
+	}
+	if err := xml.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "MalformedXML", err.Error())
+		return
+	}
+	var data bytes.Buffer
+	for i, p := range req.Parts {
+		part, ok := u.parts[p.PartNumber]
+		if !ok || p.PartNumber != i+1 || etag(part) != p.ETag {
+			writeError(w, http.StatusBadRequest, "InvalidPart", fmt.Sprintf("part %d is missing or changed", p.PartNumber))
+			return
+		}
+		data.Write(part)
+	}
+	s.objects[key] = &object{data: data.Bytes(), meta: u.meta, modified: time.Now()}
+	delete(s.uploads, id)
+	writeXML(w, struct {
+		XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+		Key     string   `xml:"Key"`
+	}{Key: key})
+}
+
+func metaOf(h http.Header) http.Header {
+	meta := http.Header{}
+	for k, vs := range h {
+		if strings.HasPrefix(strings.ToLower(k), "x-amz-meta-") {
+			meta[k] = vs
+		}
+	}
+	return meta
+}
+
+func etag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func writeXML(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/xml")
+	data, _ := xml.Marshal(v)
+	w.Write(append([]byte(xml.Header), data...))
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "%s<Error><Code>%s</Code><Message>%s</Message></Error>", xml.Header, code, message)
+}
@@ -0,0 +1,272 @@
+package s3
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultPartSize is the part size of a multipart upload when the Client
+// sets none: big enough to keep an hour of WAV to a few dozen parts, small
+// enough that an interrupted upload loses little.
+const DefaultPartSize = 16 << 20
+
+// Metadata Upload writes on every object, and Download checks.
+const (
+	MetaSHA256 = "sha256" // hex SHA-256 of the content
+	MetaMtime  = "mtime"  // the file's modification time, RFC 3339
+)
+
+// Outcome is what Upload or Download did with a file.
+type Outcome int
+
+const (
+	Unchanged Outcome = iota // the same at both ends already
+	Copied                   // copied whole
+	Resumed                  // finished from where an earlier attempt stopped
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Copied:
+		return "copied"
+	case Resumed:
+		return "resumed"
+	}
+	return "unchanged"
+}
+
+func (c *Client) partSize() int64 {
+	if c.PartSize > 0 {
+		return c.PartSize
+	}
+	return DefaultPartSize
+}
+
+// HashFile returns the hex SHA-256 of the file at path.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Upload copies the file at path to key, unless the object there already
+// has its content. An object with the file's size and modification time is
+// taken to have it without reading the file; otherwise their SHA-256 hashes
+// are compared.
+//
+// A file over the part size goes up in parts. When an earlier upload of it
+// was interrupted, the parts that made it are kept and only the rest sent.
+func (c *Client) Upload(ctx context.Context, key, path string) (Outcome, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Unchanged, err
+	}
+	mtime := info.ModTime().UTC().Format(time.RFC3339Nano)
+	remote, err := c.Head(ctx, key)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return Unchanged, err
+	}
+	sum := ""
+	if remote != nil && remote.Size == info.Size() && remote.Meta[MetaSHA256] != "" {
+		if remote.Meta[MetaMtime] == mtime {
+			return Unchanged, nil
+		}
+		if sum, err = HashFile(path); err != nil {
+			return Unchanged, err
+		}
+		if sum == remote.Meta[MetaSHA256] {
+			return Unchanged, nil
+		}
+	}
+	if sum == "" {
+		if sum, err = HashFile(path); err != nil {
+			return Unchanged, err
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return Unchanged, err
+	}
+	defer f.Close()
+	meta := map[string]string{MetaSHA256: sum, MetaMtime: mtime}
+	if info.Size() <= c.partSize() {
+		return Copied, c.Put(ctx, key, f, info.Size(), sum, meta)
+	}
+	return c.putParts(ctx, key, f, info.ModTime(), meta)
+}
+
+// putParts uploads f to key in parts, carrying on with an upload of it
+// already in progress.
+func (c *Client) putParts(ctx context.Context, key string, f io.Reader, modified time.Time, meta map[string]string) (Outcome, error) {
+	id, done, err := c.resumable(ctx, key, modified)
+	if err != nil {
+		return Unchanged, err
+	}
+	if id == "" {
+		if id, err = c.createMultipart(ctx, key, meta); err != nil {
+			return Unchanged, err
+		}
+	}
+
+	outcome := Copied
+	var parts []part
+	buf := make([]byte, c.partSize())
+	for n := 1; ; n++ {
+		size, err := io.ReadFull(f, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return outcome, err
+		}
+		data := buf[:size]
+		sum := md5.Sum(data)
+		if p, ok := done[n]; ok && p.Size == int64(size) && strings.Trim(p.ETag, `"`) == hex.EncodeToString(sum[:]) {
+			parts = append(parts, part{Number: n, ETag: p.ETag})
+			outcome = Resumed
+			continue
+		}
+		etag, err := c.uploadPart(ctx, key, id, n, data)
+		if err != nil {
+			return outcome, fmt.Errorf("part %d: %w", n, err)
+		}
+		parts = append(parts, part{Number: n, ETag: etag})
+		if size < len(buf) {
+			break
+		}
+	}
+	return outcome, c.completeMultipart(ctx, key, id, parts)
+}
+
+// resumable finds the latest upload to key begun since the file was last
+// modified, which is therefore of the same content, and the parts it has by
+// number. The id is empty when there is none. Uploads begun before then are
+// of content that has since changed, and are aborted so the server can drop
+// their parts.
+func (c *Client) resumable(ctx context.Context, key string, modified time.Time) (string, map[int]part, error) {
+	ups, err := c.uploads(ctx, key)
+	if err != nil {
+		return "", nil, err
+	}
+	var latest *upload
+	for i, u := range ups {
+		if !u.Initiated.After(modified) {
+			c.abortMultipart(ctx, key, u.ID)
+			continue
+		}
+		if latest == nil || u.Initiated.After(latest.Initiated) {
+			latest = &ups[i]
+		}
+	}
+	if latest == nil {
+		return "", nil, nil
+	}
+	parts, err := c.parts(ctx, key, latest.ID)
+	if errors.Is(err, ErrNotFound) {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	done := make(map[int]part, len(parts))
+	for _, p := range parts {
+		done[p.Number] = p
+	}
+	return latest.ID, done, nil
+}
+
+// Download copies the object at key to path, unless the file there already
+// has its content. It is written to a hidden file beside path first, and an
+// interrupted download carries on from where that file ends. The content is
+// checked against the SHA-256 Upload recorded before it replaces path, and
+// the file takes the modification time the original had.
+func (c *Client) Download(ctx context.Context, key, path string) (Outcome, error) {
+	remote, err := c.Head(ctx, key)
+	if err != nil {
+		return Unchanged, err
+	}
+	want := remote.Meta[MetaSHA256]
+	if want != "" {
+		if sum, err := HashFile(path); err == nil && sum == want {
+			return Unchanged, nil
+		}
+	}
+
+	partial := filepath.Join(filepath.Dir(path), ".pulling-"+filepath.Base(path))
+	outcome, err := c.fetch(ctx, key, partial, remote.Size)
+	if err != nil {
+		return outcome, err
+	}
+	if want != "" {
+		sum, err := HashFile(partial)
+		if err != nil {
+			return outcome, err
+		}
+		if sum != want {
+			os.Remove(partial)
+			if outcome == Resumed {
+				// What was there was of an older version: start again.
+				return c.Download(ctx, key, path)
+			}
+			return outcome, fmt.Errorf("%s does not match the SHA-256 it was uploaded with", key)
+		}
+	}
+	if t, err := time.Parse(time.RFC3339Nano, remote.Meta[MetaMtime]); err == nil {
+		os.Chtimes(partial, time.Time{}, t)
+	}
+	return outcome, os.Rename(partial, path)
+}
+
+// fetch appends the object at key, of size bytes, to what partial already
+// has of it.
+func (c *Client) fetch(ctx context.Context, key, partial string, size int64) (Outcome, error) {
+	f, err := os.OpenFile(partial, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return Unchanged, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return Unchanged, err
+	}
+	offset := info.Size()
+	if offset >= size {
+		offset = 0
+	}
+	body, start, err := c.Get(ctx, key, offset)
+	if err != nil {
+		return Unchanged, err
+	}
+	defer body.Close()
+	if err := f.Truncate(start); err != nil {
+		return Unchanged, err
+	}
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		return Unchanged, err
+	}
+	outcome := Copied
+	if start > 0 {
+		outcome = Resumed
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		return outcome, err
+	}
+	return outcome, f.Close()
+}
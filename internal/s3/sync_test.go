@@ -0,0 +1,203 @@
+package s3
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/s3/s3test"
+)
+
+func testClient(t *testing.T) (*Client, *s3test.Server) {
+	t.Helper()
+	srv := s3test.NewServer("recordings", "minioadmin")
+	t.Cleanup(srv.Close)
+	c := &Client{
+		Endpoint:    srv.URL,
+		Bucket:      "recordings",
+		Credentials: Credentials{AccessKeyID: "minioadmin", SecretAccessKey: "minio-secret"},
+		PartSize:    1024,
+	}
+	return c, srv
+}
+
+func puts(reqs []string) []string {
+	var out []string
+	for _, r := range reqs {
+		if strings.HasPrefix(r, "PUT ") {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func TestUploadSkipsUnchanged(t *testing.T) {
+	c, srv := testClient(t)
+	path := filepath.Join(t.TempDir(), "standup.txt")
+	os.WriteFile(path, []byte("Hello.\n"), 0644)
+
+	if got, err := c.Upload(t.Context(), "team/standup.txt", path); err != nil || got != Copied {
+		t.Fatalf("first upload = %v, %v", got, err)
+	}
+	if data, _ := srv.Object("team/standup.txt"); string(data) != "Hello.\n" {
+		t.Errorf("uploaded %q", data)
+	}
+	if got, err := c.Upload(t.Context(), "team/standup.txt", path); err != nil || got != Unchanged {
+		t.Errorf("second upload = %v, %v", got, err)
+	}
+
+	// Touched but the same: hashed, and still skipped.
+	os.Chtimes(path, time.Time{}, time.Now().Add(time.Hour))
+	if got, err := c.Upload(t.Context(), "team/standup.txt", path); err != nil || got != Unchanged {
+		t.Errorf("upload after touch = %v, %v", got, err)
+	}
+	os.WriteFile(path, []byte("Hullo.\n"), 0644)
+	if got, err := c.Upload(t.Context(), "team/standup.txt", path); err != nil || got != Copied {
+		t.Errorf("upload after edit = %v, %v", got, err)
+	}
+	if n := len(puts(srv.Requests())); n != 2 {
+		t.Errorf("%d PUTs, want 2: %q", n, srv.Requests())
+	}
+}
+
+func TestUploadResumesParts(t *testing.T) {
+	c, srv := testClient(t)
+	path := filepath.Join(t.TempDir(), "standup.wav")
+	data := bytes.Repeat([]byte("0123456789abcdef"), 200) // 3200 bytes: 4 parts
+	os.WriteFile(path, data, 0644)
+	os.Chtimes(path, time.Time{}, time.Now().Add(-time.Minute))
+
+	srv.Fail = func(r *http.Request) bool { return r.URL.Query().Get("partNumber") == "3" }
+	if _, err := c.Upload(t.Context(), "standup.wav", path); err == nil || !strings.Contains(err.Error(), "part 3") {
+		t.Fatalf("interrupted upload = %v", err)
+	}
+	if srv.Uploads() != 1 {
+		t.Fatalf("uploads in progress = %d", srv.Uploads())
+	}
+
+	srv.Fail = nil
+	before := len(srv.Requests())
+	got, err := c.Upload(t.Context(), "standup.wav", path)
+	if err != nil || got != Resumed {
+		t.Fatalf("resumed upload = %v, %v", got, err)
+	}
+	sent := puts(srv.Requests()[before:])
+	if want := []string{"PUT standup.wav?partNumber=3", "PUT standup.wav?partNumber=4"}; !slices.Equal(sent, want) {
+		t.Errorf("resumed with %q, want %q", sent, want)
+	}
+	if stored, _ := srv.Object("standup.wav"); !bytes.Equal(stored, data) {
+		t.Error("the object is not the file")
+	}
+	if got, _ := c.Upload(t.Context(), "standup.wav", path); got != Unchanged {
+		t.Errorf("upload after completion = %v", got)
+	}
+}
+
+func TestUploadIgnoresUploadOfOlderContent(t *testing.T) {
+	c, srv := testClient(t)
+	path := filepath.Join(t.TempDir(), "standup.wav")
+	os.WriteFile(path, bytes.Repeat([]byte("a"), 3000), 0644)
+	srv.Fail = func(r *http.Request) bool { return r.URL.Query().Get("partNumber") == "2" }
+	c.Upload(t.Context(), "standup.wav", path)
+
+	// Rewritten after that upload began: its parts cannot be trusted.
+	srv.Fail = nil
+	data := bytes.Repeat([]byte("b"), 3000)
+	os.WriteFile(path, data, 0644)
+	os.Chtimes(path, time.Time{}, time.Now().Add(time.Minute))
+	if got, err := c.Upload(t.Context(), "standup.wav", path); err != nil || got != Copied {
+		t.Fatalf("upload = %v, %v", got, err)
+	}
+	if stored, _ := srv.Object("standup.wav"); !bytes.Equal(stored, data) {
+		t.Error("the object is not the new file")
+	}
+	if srv.Uploads() != 0 {
+		t.Error("the stale upload was not aborted")
+	}
+}
+
+func TestDownload(t *testing.T) {
+	c, _ := testClient(t)
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.ogg")
+	data := bytes.Repeat([]byte("OggS"), 100)
+	os.WriteFile(src, data, 0644)
+	made := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	os.Chtimes(src, time.Time{}, made)
+	if _, err := c.Upload(t.Context(), "standup.ogg", src); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "standup.ogg")
+	if got, err := c.Download(t.Context(), "standup.ogg", dst); err != nil || got != Copied {
+		t.Fatalf("download = %v, %v", got, err)
+	}
+	if got, _ := os.ReadFile(dst); !bytes.Equal(got, data) {
+		t.Error("downloaded content differs")
+	}
+	if info, _ := os.Stat(dst); !info.ModTime().Equal(made) {
+		t.Errorf("mtime = %v, want %v", info.ModTime(), made)
+	}
+	if got, err := c.Download(t.Context(), "standup.ogg", dst); err != nil || got != Unchanged {
+		t.Errorf("second download = %v, %v", got, err)
+	}
+}
+
+func TestDownloadResumes(t *testing.T) {
+	c, srv := testClient(t)
+	data := bytes.Repeat([]byte("0123456789"), 50)
+	srv.Put("standup.ogg", data, map[string]string{MetaSHA256: hexSHA256(data)})
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".pulling-standup.ogg"), data[:200], 0644)
+
+	dst := filepath.Join(dir, "standup.ogg")
+	if got, err := c.Download(t.Context(), "standup.ogg", dst); err != nil || got != Resumed {
+		t.Fatalf("download = %v, %v", got, err)
+	}
+	if got, _ := os.ReadFile(dst); !bytes.Equal(got, data) {
+		t.Error("resumed content differs")
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".pulling-standup.ogg")); !os.IsNotExist(err) {
+		t.Error("the partial file was left behind")
+	}
+}
+
+func TestDownloadRestartsStalePartial(t *testing.T) {
+	c, srv := testClient(t)
+	data := bytes.Repeat([]byte("new "), 50)
+	srv.Put("standup.ogg", data, map[string]string{MetaSHA256: hexSHA256(data)})
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".pulling-standup.ogg"), []byte("old old old "), 0644)
+
+	dst := filepath.Join(dir, "standup.ogg")
+	if got, err := c.Download(t.Context(), "standup.ogg", dst); err != nil || got != Copied {
+		t.Fatalf("download = %v, %v", got, err)
+	}
+	if got, _ := os.ReadFile(dst); !bytes.Equal(got, data) {
+		t.Errorf("content = %q", got)
+	}
+}
+
+func TestListAndHeadMissing(t *testing.T) {
+	c, srv := testClient(t)
+	srv.Put("team/a.ogg", []byte("a"), nil)
+	srv.Put("team/a.txt", []byte("aa"), nil)
+	srv.Put("other/b.ogg", []byte("b"), nil)
+	objs, err := c.List(t.Context(), "team/")
+	if err != nil || len(objs) != 2 || objs[0].Key != "team/a.ogg" || objs[1].Size != 2 {
+		t.Errorf("List = %+v, %v", objs, err)
+	}
+	if _, err := c.Head(t.Context(), "team/missing.ogg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Head of a missing key = %v", err)
+	}
+	c.Credentials.AccessKeyID = "someone-else"
+	if _, err := c.List(t.Context(), ""); err == nil || !strings.Contains(err.Error(), "InvalidAccessKeyId") {
+		t.Errorf("List with the wrong key = %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+// Package schema generates the JSON Schema published for audiomemo's
+// machine-readable outputs: the NDJSON events of `--stream`, the JSON
+// transcript, and the webhook payload. It is derived from the Go types by
+// reflection, so the schema cannot describe a field the code does not
+// write, and the committed copies under docs/schema are checked against it
+// by the tests.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/joegoldin/audiomemo/internal/stream"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+	"github.com/joegoldin/audiomemo/internal/webhook"
+)
+
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Names are the schemas `audiomemo schema` can print.
+var Names = []string{"stream", "result", "webhook"}
+
+// Generate returns the named schema as indented JSON with a trailing newline,
+// byte for byte what is committed under docs/schema.
+func Generate(name string) ([]byte, error) {
+	var s object
+	switch name {
+	case "stream":
+		s = Stream()
+	case "result":
+		s = Result()
+	case "webhook":
+		s = Webhook()
+	default:
+		return nil, fmt.Errorf("unknown schema %q: want %s", name, strings.Join(Names, ", "))
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// object is one schema node. encoding/json sorts map keys, which keeps the
+// output stable from run to run.
+type object map[string]any
+
+// Stream describes one line of `record --stream`: exactly one of the events,
+// told apart by "type".
+func Stream() object {
+	g := newGenerator()
+	// TextEvent carries both partial and commit, so the types are gathered
+	// per struct before each definition's "type" is pinned.
+	var order []reflect.Type
+	types := map[reflect.Type][]any{}
+	for _, e := range stream.Events {
+		t := reflect.TypeOf(e.Event)
+		if _, seen := types[t]; !seen {
+			order = append(order, t)
+		}
+		types[t] = append(types[t], e.Type)
+	}
+	var oneOf []object
+	for _, t := range order {
+		ref := g.ref(t)
+		props := g.defs[t.Name()]["properties"].(object)
+		if values := types[t]; len(values) == 1 {
+			props["type"] = object{"const": values[0]}
+		} else {
+			props["type"] = object{"enum": values}
+		}
+		oneOf = append(oneOf, ref)
+	}
+	return object{
+		"$schema":  draft,
+		"$comment": "schema_version " + stream.SchemaVersion,
+		"title":    "audiomemo stream event",
+		"description": "One line of the newline-delimited JSON written by `record --stream`. " +
+			"Consumers must ignore unknown event types and unknown fields.",
+		"oneOf": oneOf,
+		"$defs": g.defs,
+	}
+}
+
+// Result describes the transcript written by `transcribe -f json`.
+func Result() object {
+	g := newGenerator()
+	root := g.object(reflect.TypeOf(transcribe.Result{}))
+	root["$schema"] = draft
+	root["$comment"] = "schema_version " + transcribe.SchemaVersion
+	root["title"] = "audiomemo transcript"
+	root["description"] = "The JSON transcript written by `transcribe -f json`. " +
+		"Consumers must ignore unknown fields."
+	if len(g.defs) > 0 {
+		root["$defs"] = g.defs
+	}
+	return root
+}
+
+// Webhook describes the body POSTed to a [webhooks] endpoint.
+func Webhook() object {
+	g := newGenerator()
+	root := g.object(reflect.TypeOf(webhook.Payload{}))
+	root["$schema"] = draft
+	root["$comment"] = "schema_version " + webhook.SchemaVersion
+	root["title"] = "audiomemo webhook payload"
+	root["description"] = "The JSON body POSTed to each webhook endpoint when a transcript is finished. " +
+		"Consumers must ignore unknown fields."
+	root["$defs"] = g.defs
+	return root
+}
+
+type generator struct {
+	defs map[string]object
+}
+
+func newGenerator() *generator {
+	return &generator{defs: map[string]object{}}
+}
+
+// ref defines a named struct once under $defs and points at it.
+func (g *generator) ref(t reflect.Type) object {
+	if _, ok := g.defs[t.Name()]; !ok {
+		// Claimed before recursing, so a type that refers to itself ends.
+		g.defs[t.Name()] = nil
+		g.defs[t.Name()] = g.object(t)
+	}
+	return object{"$ref": "#/$defs/" + t.Name()}
+}
+
+// schemaFor maps a Go type onto the JSON encoding/json gives it.
+func (g *generator) schemaFor(t reflect.Type) object {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schemaFor(t.Elem())
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return object{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return object{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return object{"type": "array", "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return object{"type": "object", "additionalProperties": g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return g.ref(t)
+	}
+	// Interfaces and the like are whatever was put in them.
+	return object{}
+}
+
+// object lists a struct's fields as encoding/json names them. A field is
+// required unless omitempty can leave it out. Untagged embedded structs are
+// promoted into the parent, as encoding/json does with the events' header.
+func (g *generator) object(t reflect.Type) object {
+	props := object{}
+	required := []string{}
+	g.fields(t, props, &required)
+	return object{"type": "object", "properties": props, "required": required}
+}
+
+func (g *generator) fields(t reflect.Type, props object, required *[]string) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			g.fields(f.Type, props, required)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = g.schemaFor(f.Type)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/joegoldin/audiomemo/internal/stream"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+	"github.com/joegoldin/audiomemo/internal/webhook"
+)
+
+// TestCommittedSchemasMatchTypes fails when a Go type changes without the
+// published schema: regenerate it, and bump the schema version if the change
+// is one a consumer would notice.
+func TestCommittedSchemasMatchTypes(t *testing.T) {
+	for _, name := range Names {
+		got, err := Generate(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join("..", "..", "docs", "schema", name+".schema.json")
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is out of date; run: go run . schema %s > docs/schema/%s.schema.json", path, name, name)
+		}
+	}
+}
+
+func TestGenerateRejectsUnknownName(t *testing.T) {
+	if _, err := Generate("config"); err == nil {
+		t.Error("want an error")
+	}
+}
+
+// properties returns the property names of a $defs entry.
+func properties(t *testing.T, s object, def string) object {
+	t.Helper()
+	d, ok := s["$defs"].(map[string]object)[def]
+	if !ok {
+		t.Fatalf("no $defs/%s", def)
+	}
+	return d["properties"].(object)
+}
+
+// TestEmittedEventsAreDescribed emits one of every event with every field
+// set and checks each key on the wire is in the schema, so a field written
+// outside the struct, or an event left out of stream.Events, shows up here.
+func TestEmittedEventsAreDescribed(t *testing.T) {
+	var buf bytes.Buffer
+	em := stream.NewEmitter(&buf)
+	muted := true
+	em.Start(stream.StartEvent{Device: "d", DeviceLabel: "l", Devices: []string{"d"}, Path: "p", Format: "ogg", SampleRate: 1, Channels: 1, Mode: stream.ModeLive, Backend: "b", EventsURL: "u"})
+	em.Level(0.5, -20)
+	em.Partial("a")
+	em.Commit("a")
+	em.Final(stream.FinalEvent{Text: "a", Path: "p", TranscriptPath: "p", Backend: "b", Source: stream.SourceLive})
+	em.Error(stream.ScopeStream, false, nil)
+	em.Ack(stream.AckEvent{Cmd: stream.CmdMute, ID: "1", OK: true, Error: "e", Muted: &muted, Label: "l"})
+	em.TranscribeStart(stream.TranscribeStartEvent{File: "f", Backend: "b", Model: "m", Duration: 1})
+	em.Upload(1, 2)
+	em.Progress(50)
+	em.Segment(stream.SegmentEvent{Start: 0, End: 1, Text: "a", Speaker: "s"})
+	em.Voice(transcribe.VoiceMark)
+	em.End(stream.EndEvent{Reason: stream.ReasonStopped, Path: "p"})
+
+	s := Stream()
+	defFor := map[string]string{
+		"start": "StartEvent", "level": "LevelEvent", "partial": "TextEvent", "commit": "TextEvent",
+		"final": "FinalEvent", "error": "ErrorEvent", "ack": "AckEvent", "end": "EndEvent",
+		"upload": "UploadEvent", "progress": "ProgressEvent", "segment": "SegmentEvent",
+		"voice": "VoiceEvent",
+	}
+	var seen []string
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var ev map[string]any
+		if err := json.Unmarshal(line, &ev); err != nil {
+			t.Fatal(err)
+		}
+		typ := ev["type"].(string)
+		seen = append(seen, typ)
+		def := defFor[typ]
+		if _, ok := ev["file"]; ok && typ == stream.TypeStart {
+			def = "TranscribeStartEvent"
+		}
+		props := properties(t, s, def)
+		for key := range ev {
+			if _, ok := props[key]; !ok {
+				t.Errorf("%s event has %q, which the schema does not describe", typ, key)
+			}
+		}
+	}
+	for _, e := range stream.Events {
+		if !slices.Contains(seen, e.Type) {
+			t.Errorf("the test does not emit a %s event", e.Type)
+		}
+	}
+}
+
+func TestTypeIsPinnedPerEvent(t *testing.T) {
+	s := Stream()
+	if got := properties(t, s, "StartEvent")["type"]; got.(object)["const"] != stream.TypeStart {
+		t.Errorf("StartEvent type = %v", got)
+	}
+	enum := properties(t, s, "TextEvent")["type"].(object)["enum"].([]any)
+	if !slices.Equal(enum, []any{stream.TypePartial, stream.TypeCommit}) {
+		t.Errorf("TextEvent type enum = %v", enum)
+	}
+	if got := properties(t, s, "TranscribeStartEvent")["type"]; got.(object)["const"] != stream.TypeStart {
+		t.Errorf("TranscribeStartEvent type = %v", got)
+	}
+	if n := len(s["oneOf"].([]object)); n != 12 {
+		t.Errorf("oneOf has %d events, want 12 distinct structs", n)
+	}
+}
+
+func TestWebhookNestsTheResult(t *testing.T) {
+	s := Webhook()
+	if req := s["required"].([]string); !slices.Equal(req, []string{"schema_version", "event", "id", "created", "recording", "result"}) {
+		t.Errorf("required = %v", req)
+	}
+	// The transcript inside is described as the result schema describes it.
+	if got := properties(t, s, "Result")["segments"]; got == nil {
+		t.Error("no $defs/Result.segments")
+	}
+	if s["$comment"] != "schema_version "+webhook.SchemaVersion {
+		t.Errorf("$comment = %v", s["$comment"])
+	}
+}
+
+func TestResultRequiredFieldsFollowOmitempty(t *testing.T) {
+	s := Result()
+	if req := s["required"].([]string); !slices.Equal(req, []string{"text"}) {
+		t.Errorf("required = %v", req)
+	}
+	seg := s["$defs"].(map[string]object)["Segment"]
+	if req := seg["required"].([]string); !slices.Equal(req, []string{"start", "end", "text"}) {
+		t.Errorf("Segment required = %v", req)
+	}
+	// The version written into transcripts is the one the schema announces.
+	if s["$comment"] != "schema_version "+transcribe.SchemaVersion {
+		t.Errorf("$comment = %v", s["$comment"])
+	}
+}
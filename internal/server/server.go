@@ -0,0 +1,241 @@
+// Package server exposes the transcription backends over HTTP as an
+// OpenAI-compatible /v1/audio/transcriptions endpoint, so tools that already
+// speak to OpenAI can use whichever backend audiomemo is configured with.
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joegoldin/audiomemo/internal/encrypt"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+// DefaultMaxUpload is the largest upload accepted when Options.MaxUpload is
+// unset. Far above OpenAI's own 25 MB, since local backends take long files.
+const DefaultMaxUpload = 1 << 30
+
+// uploadMemory is how much of a multipart form is held in memory before the
+// rest spills to disk.
+const uploadMemory = 32 << 20
+
+// Resolver picks the backend and options for a request. model is the
+// request's model field as the client sent it, possibly empty.
+type Resolver func(model string) (transcribe.Transcriber, transcribe.TranscribeOpts, error)
+
+// Options configure a Server.
+type Options struct {
+	Resolve Resolver
+	// Post cleans every transcript before it is returned; nil leaves them
+	// as the backend produced them.
+	Post *transcribe.PostProcessor
+	// Token, when set, must be sent as "Authorization: Bearer <token>".
+	Token string
+	// MaxConcurrent caps the transcriptions running at once. Further
+	// requests wait for a slot. Values below 1 mean 1.
+	MaxConcurrent int
+	MaxUpload     int64
+}
+
+// Server handles the OpenAI transcription API.
+type Server struct {
+	opts  Options
+	slots chan struct{}
+	mux   *http.ServeMux
+}
+
+func New(opts Options) *Server {
+	if opts.MaxConcurrent < 1 {
+		opts.MaxConcurrent = 1
+	}
+	if opts.MaxUpload <= 0 {
+		opts.MaxUpload = DefaultMaxUpload
+	}
+	s := &Server{
+		opts:  opts,
+		slots: make(chan struct{}, opts.MaxConcurrent),
+		mux:   http.NewServeMux(),
+	}
+	s.mux.HandleFunc("POST /v1/audio/transcriptions", s.handleTranscription)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "invalid_request_error", "missing or invalid bearer token")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.opts.Token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) == 1
+}
+
+// responseFormats are the response_format values OpenAI accepts.
+var responseFormats = map[string]bool{"json": true, "text": true, "srt": true, "vtt": true, "verbose_json": true}
+
+func (s *Server) handleTranscription(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, s.opts.MaxUpload)
+	if err := r.ParseMultipartForm(uploadMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "invalid_request_error", fmt.Sprintf("upload exceeds %d bytes", tooLarge.Limit))
+			return
+		}
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "expected a multipart/form-data body: "+err.Error())
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "missing file field")
+		return
+	}
+	defer file.Close()
+
+	format := r.FormValue("response_format")
+	if format == "" {
+		format = "json"
+	}
+	if !responseFormats[format] {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("unsupported response_format %q (use json, text, srt, vtt or verbose_json)", format))
+		return
+	}
+
+	backend, opts, err := s.opts.Resolve(r.FormValue("model"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	if lang := r.FormValue("language"); lang != "" {
+		opts.Language = lang
+	}
+	opts.Vocabulary = append(opts.Vocabulary, promptTerms(r.FormValue("prompt"))...)
+
+	path, err := saveUpload(file, header)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", "failed to store upload: "+err.Error())
+		return
+	}
+	defer os.Remove(path)
+
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-r.Context().Done():
+		return
+	}
+
+	result, err := backend.Transcribe(r.Context(), path, opts)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "server_error", fmt.Sprintf("%s: %v", backend.Name(), err))
+		return
+	}
+	s.opts.Post.Apply(result)
+	writeResult(w, result, format)
+}
+
+// promptTerms reads the prompt field as a comma-separated vocabulary, which
+// is how clients mostly use it: a list of names the model should spell right.
+// Backends that take a free-text prompt get the terms joined back together.
+func promptTerms(prompt string) []string {
+	var terms []string
+	for _, term := range strings.Split(prompt, ",") {
+		if term = strings.TrimSpace(term); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// saveUpload copies the upload to a temporary file in encrypt.TempDir,
+// keeping its extension because the local backends decide how to decode by
+// it.
+func saveUpload(file multipart.File, header *multipart.FileHeader) (string, error) {
+	ext := strings.ToLower(filepath.Ext(header.Filename))
+	dir, err := encrypt.TempDir()
+	if err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(dir, "serve-*"+ext)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(tmp, file); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// verboseJSON is OpenAI's verbose_json response, plus each segment's speaker
+// when the backend diarised.
+type verboseJSON struct {
+	Task     string           `json:"task"`
+	Language string           `json:"language"`
+	Duration float64          `json:"duration"`
+	Text     string           `json:"text"`
+	Segments []verboseSegment `json:"segments"`
+}
+
+type verboseSegment struct {
+	ID      int     `json:"id"`
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Text    string  `json:"text"`
+	Speaker string  `json:"speaker,omitempty"`
+}
+
+func writeResult(w http.ResponseWriter, r *transcribe.Result, format string) {
+	switch format {
+	case "json":
+		writeJSON(w, http.StatusOK, map[string]string{"text": r.Text})
+	case "verbose_json":
+		v := verboseJSON{Task: "transcribe", Language: r.Language, Duration: r.Duration, Text: r.Text, Segments: []verboseSegment{}}
+		for i, seg := range r.Segments {
+			v.Segments = append(v.Segments, verboseSegment{ID: i, Start: seg.Start, End: seg.End, Text: seg.Text, Speaker: seg.Speaker})
+		}
+		writeJSON(w, http.StatusOK, v)
+	case "vtt":
+		w.Header().Set("Content-Type", "text/vtt; charset=utf-8")
+		io.WriteString(w, r.Format(transcribe.FormatVTT))
+	default:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, r.Format(transcribe.ParseFormat(format)))
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError sends an error in OpenAI's shape, which client libraries parse
+// into their own error types.
+func writeError(w http.ResponseWriter, status int, kind, message string) {
+	type apiError struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	}
+	writeJSON(w, status, map[string]apiError{"error": {Message: message, Type: kind}})
+}
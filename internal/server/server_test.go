@@ -0,0 +1,321 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/encrypt"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+// TestMain removes the private temp directory the tests' temp files went in,
+// as execute does for a real run.
+func TestMain(m *testing.M) {
+	code := m.Run()
+	encrypt.RemoveTempDir()
+	os.Exit(code)
+}
+
+type fakeBackend struct {
+	result *transcribe.Result
+	err    error
+	gotOps transcribe.TranscribeOpts
+	gotExt string
+	gotLen int
+	block  chan struct{}
+	active atomic.Int32
+	peak   atomic.Int32
+	mu     sync.Mutex
+}
+
+func (f *fakeBackend) Name() string { return "fake" }
+
+func (f *fakeBackend) Transcribe(ctx context.Context, path string, opts transcribe.TranscribeOpts) (*transcribe.Result, error) {
+	n := f.active.Add(1)
+	defer f.active.Add(-1)
+	for {
+		peak := f.peak.Load()
+		if n <= peak || f.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	data, _ := os.ReadFile(path)
+	f.mu.Lock()
+	f.gotOps = opts
+	f.gotExt = filepath.Ext(path)
+	f.gotLen = len(data)
+	f.mu.Unlock()
+	if f.block != nil {
+		<-f.block
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	r := *f.result
+	return &r, nil
+}
+
+func newTestServer(t *testing.T, backend *fakeBackend, opts Options) *httptest.Server {
+	t.Helper()
+	if opts.Resolve == nil {
+		opts.Resolve = func(model string) (transcribe.Transcriber, transcribe.TranscribeOpts, error) {
+			if model == "missing" {
+				return nil, transcribe.TranscribeOpts{}, fmt.Errorf("unknown backend: missing")
+			}
+			return backend, transcribe.TranscribeOpts{Model: model, Vocabulary: []string{"Kubernetes"}}, nil
+		}
+	}
+	srv := httptest.NewServer(New(opts))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func upload(t *testing.T, url string, fields map[string]string, token string) *http.Response {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "memo.OGG")
+	fw.Write([]byte("OggS fake audio"))
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	mw.Close()
+
+	req, _ := http.NewRequestWithContext(t.Context(), http.MethodPost, url+"/v1/audio/transcriptions", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+var testResult = &transcribe.Result{
+	Text:     "Hello there. General Kenobi.",
+	Language: "en",
+	Duration: 3.5,
+	Segments: []transcribe.Segment{
+		{Start: 0, End: 1.5, Text: "Hello there.", Speaker: "A"},
+		{Start: 1.5, End: 3.5, Text: "General Kenobi.", Speaker: "B"},
+	},
+}
+
+func TestResponseFormats(t *testing.T) {
+	backend := &fakeBackend{result: testResult}
+	srv := newTestServer(t, backend, Options{})
+
+	tests := []struct {
+		format      string
+		contentType string
+		want        string
+	}{
+		{"", "application/json", `{"text":"Hello there. General Kenobi."}`},
+		{"json", "application/json", `{"text":"Hello there. General Kenobi."}`},
+		{"text", "text/plain; charset=utf-8", "A: Hello there.\nB: General Kenobi."},
+		{"srt", "text/plain; charset=utf-8", "1\n00:00:00,000 --> 00:00:01,500\n"},
+		{"vtt", "text/vtt; charset=utf-8", "WEBVTT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			fields := map[string]string{"model": "whisper-1"}
+			if tt.format != "" {
+				fields["response_format"] = tt.format
+			}
+			resp := upload(t, srv.URL, fields, "")
+			body := readBody(t, resp)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, body %s", resp.StatusCode, body)
+			}
+			if got := resp.Header.Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if !strings.Contains(body, tt.want) {
+				t.Errorf("body = %q, want it to contain %q", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerboseJSON(t *testing.T) {
+	srv := newTestServer(t, &fakeBackend{result: testResult}, Options{})
+	resp := upload(t, srv.URL, map[string]string{"response_format": "verbose_json"}, "")
+	var got verboseJSON
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := verboseJSON{
+		Task:     "transcribe",
+		Language: "en",
+		Duration: 3.5,
+		Text:     "Hello there. General Kenobi.",
+		Segments: []verboseSegment{
+			{ID: 0, Start: 0, End: 1.5, Text: "Hello there.", Speaker: "A"},
+			{ID: 1, Start: 1.5, End: 3.5, Text: "General Kenobi.", Speaker: "B"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestRequestFieldsReachBackend(t *testing.T) {
+	backend := &fakeBackend{result: testResult}
+	srv := newTestServer(t, backend, Options{})
+	resp := upload(t, srv.URL, map[string]string{
+		"model":    "deepgram/nova-3",
+		"language": "de",
+		"prompt":   "Joe Goldin, audiomemo , ",
+	}, "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, body %s", resp.StatusCode, readBody(t, resp))
+	}
+	if backend.gotOps.Model != "deepgram/nova-3" || backend.gotOps.Language != "de" {
+		t.Errorf("opts = %+v", backend.gotOps)
+	}
+	if want := []string{"Kubernetes", "Joe Goldin", "audiomemo"}; !reflect.DeepEqual(backend.gotOps.Vocabulary, want) {
+		t.Errorf("vocabulary = %q, want %q", backend.gotOps.Vocabulary, want)
+	}
+	if backend.gotExt != ".ogg" || backend.gotLen != len("OggS fake audio") {
+		t.Errorf("upload stored as %q with %d bytes", backend.gotExt, backend.gotLen)
+	}
+}
+
+func TestPostProcessing(t *testing.T) {
+	post, err := transcribe.NewPostProcessor(transcribe.PostProcess{
+		Replacements: []transcribe.Replacement{{From: "Kenobi", To: "Obi-Wan"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newTestServer(t, &fakeBackend{result: testResult}, Options{Post: post})
+	resp := upload(t, srv.URL, nil, "")
+	if body := readBody(t, resp); !strings.Contains(body, "General Obi-Wan.") {
+		t.Errorf("body = %s, want the replacement applied", body)
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	srv := newTestServer(t, &fakeBackend{result: testResult}, Options{Token: "s3cret"})
+	for _, tt := range []struct {
+		token string
+		want  int
+	}{
+		{"", http.StatusUnauthorized},
+		{"wrong", http.StatusUnauthorized},
+		{"s3cret", http.StatusOK},
+	} {
+		resp := upload(t, srv.URL, nil, tt.token)
+		if resp.StatusCode != tt.want {
+			t.Errorf("token %q: status = %d, want %d", tt.token, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	srv := newTestServer(t, &fakeBackend{err: errors.New("boom")}, Options{MaxUpload: 1 << 20})
+
+	decode := func(resp *http.Response) string {
+		var body struct {
+			Error struct {
+				Message string `json:"message"`
+				Type    string `json:"type"`
+			} `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("error body is not OpenAI-shaped JSON: %v", err)
+		}
+		return body.Error.Message
+	}
+
+	resp := upload(t, srv.URL, map[string]string{"response_format": "xml"}, "")
+	if msg := decode(resp); resp.StatusCode != http.StatusBadRequest || !strings.Contains(msg, "response_format") {
+		t.Errorf("bad format: %d %q", resp.StatusCode, msg)
+	}
+	resp = upload(t, srv.URL, map[string]string{"model": "missing"}, "")
+	if msg := decode(resp); resp.StatusCode != http.StatusBadRequest || !strings.Contains(msg, "unknown backend") {
+		t.Errorf("bad model: %d %q", resp.StatusCode, msg)
+	}
+	resp = upload(t, srv.URL, nil, "")
+	if msg := decode(resp); resp.StatusCode != http.StatusBadGateway || msg != "fake: boom" {
+		t.Errorf("backend failure: %d %q", resp.StatusCode, msg)
+	}
+
+	big := bytes.Repeat([]byte("x"), 2<<20)
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "big.wav")
+	fw.Write(big)
+	mw.Close()
+	resp, err := http.Post(srv.URL+"/v1/audio/transcriptions", mw.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized upload: status = %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(srv.URL + "/v1/audio/transcriptions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status = %d", resp.StatusCode)
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	backend := &fakeBackend{result: testResult, block: make(chan struct{})}
+	srv := newTestServer(t, backend, Options{MaxConcurrent: 2})
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			upload(t, srv.URL, nil, "")
+		}()
+	}
+	// Let every request reach the semaphore before releasing them one by one.
+	deadline := time.Now().Add(2 * time.Second)
+	for backend.active.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	for range 5 {
+		backend.block <- struct{}{}
+	}
+	wg.Wait()
+	if peak := backend.peak.Load(); peak != 2 {
+		t.Errorf("peak concurrency = %d, want 2", peak)
+	}
+}
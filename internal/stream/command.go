@@ -0,0 +1,66 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Command names a wrapper may write to stdin, one JSON object per line.
+const (
+	CmdStop   = "stop"   // end the recording; "transcribe" overrides --transcribe
+	CmdMute   = "mute"   // silence the input until unmute
+	CmdUnmute = "unmute" // undo mute
+	CmdPause  = "pause"  // toggle mute, as the TUI's pause key does
+	CmdMark   = "mark"   // note a moment, with an optional label
+)
+
+// Command is one line of stdin. Transcribe is a pointer because leaving it
+// out keeps whatever --transcribe said, which false would not.
+type Command struct {
+	Cmd        string `json:"cmd"`
+	ID         string `json:"id,omitempty"`
+	Transcribe *bool  `json:"transcribe,omitempty"`
+	Label      string `json:"label,omitempty"`
+}
+
+// maxCommandBytes bounds one line. Commands are a few dozen bytes; a line
+// longer than this is not a command.
+const maxCommandBytes = 64 << 10
+
+// ParseCommand decodes one line. An unknown cmd is an error rather than
+// ignored: the sender is waiting for an ack that says whether it worked.
+func ParseCommand(line []byte) (Command, error) {
+	var c Command
+	if err := json.Unmarshal(line, &c); err != nil {
+		return Command{}, fmt.Errorf("invalid command: %w", err)
+	}
+	switch c.Cmd {
+	case CmdStop, CmdMute, CmdUnmute, CmdPause, CmdMark:
+		return c, nil
+	case "":
+		return c, fmt.Errorf("command has no cmd")
+	default:
+		return c, fmt.Errorf("unknown command %q", c.Cmd)
+	}
+}
+
+// ReadCommands parses r line by line, passing each command, or the reason a
+// line is not one, to handle. Blank lines are skipped. It returns at EOF, on
+// a read error, or when handle returns false.
+func ReadCommands(r io.Reader, handle func(Command, error) bool) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 1024), maxCommandBytes)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if !handle(ParseCommand(line)) {
+			return nil
+		}
+	}
+	return sc.Err()
+}
@@ -0,0 +1,75 @@
+package stream
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    Command
+		wantErr string
+	}{
+		{line: `{"cmd":"mute"}`, want: Command{Cmd: CmdMute}},
+		{line: `{"cmd":"mark","id":"a1","label":"intro"}`, want: Command{Cmd: CmdMark, ID: "a1", Label: "intro"}},
+		{line: `{"cmd":"rewind","id":"a2"}`, want: Command{Cmd: "rewind", ID: "a2"}, wantErr: "unknown command"},
+		{line: `{"id":"a3"}`, want: Command{ID: "a3"}, wantErr: "no cmd"},
+		{line: `stop`, wantErr: "invalid command"},
+	}
+	for _, tt := range tests {
+		got, err := ParseCommand([]byte(tt.line))
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: err = %v, want %q", tt.line, err, tt.wantErr)
+			}
+		} else if err != nil {
+			t.Errorf("%s: %v", tt.line, err)
+		}
+		// The id survives a bad cmd, so the failure can still be acked.
+		if got.Cmd != tt.want.Cmd || got.ID != tt.want.ID || got.Label != tt.want.Label {
+			t.Errorf("%s: got %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseCommandTranscribeIsTriState(t *testing.T) {
+	for line, want := range map[string]string{
+		`{"cmd":"stop"}`:                    "unset",
+		`{"cmd":"stop","transcribe":true}`:  "true",
+		`{"cmd":"stop","transcribe":false}`: "false",
+	} {
+		c, err := ParseCommand([]byte(line))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := "unset"
+		if c.Transcribe != nil {
+			got = map[bool]string{true: "true", false: "false"}[*c.Transcribe]
+		}
+		if got != want {
+			t.Errorf("%s: transcribe %s, want %s", line, got, want)
+		}
+	}
+}
+
+func TestReadCommands(t *testing.T) {
+	in := "{\"cmd\":\"mute\"}\n\n  \nnot json\n{\"cmd\":\"stop\"}\n{\"cmd\":\"mark\"}\n"
+	var cmds []string
+	var errs int
+	err := ReadCommands(strings.NewReader(in), func(c Command, err error) bool {
+		if err != nil {
+			errs++
+			return true
+		}
+		cmds = append(cmds, c.Cmd)
+		return c.Cmd != CmdStop
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Blank lines are skipped and nothing after stop is read.
+	if strings.Join(cmds, ",") != "mute,stop" || errs != 1 {
+		t.Errorf("commands %v with %d errors", cmds, errs)
+	}
+}
@@ -1,6 +1,7 @@
 package stream
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
 	"math"
@@ -13,7 +14,10 @@ import (
 // keeps lines whole.
 type Emitter struct {
 	mu  sync.Mutex
+	w   io.Writer
+	buf bytes.Buffer
 	enc *json.Encoder
+	hub *Hub // also receives every line when set
 
 	start time.Time
 	now   func() time.Time
@@ -23,11 +27,18 @@ type Emitter struct {
 func NewEmitter(w io.Writer) *Emitter { return newEmitter(w, time.Now) }
 
 func newEmitter(w io.Writer, now func() time.Time) *Emitter {
-	enc := json.NewEncoder(w)
+	e := &Emitter{w: w, start: now(), now: now}
+	e.enc = json.NewEncoder(&e.buf)
 	// Transcripts contain apostrophes and angle brackets; escaping them would
 	// be visible in the consumer's UI.
-	enc.SetEscapeHTML(false)
-	return &Emitter{enc: enc, start: now(), now: now}
+	e.enc.SetEscapeHTML(false)
+	return e
+}
+
+// SetHub publishes every event to h as well as the writer. Call it before
+// the first event.
+func (e *Emitter) SetHub(h *Hub) {
+	e.hub = h
 }
 
 func (e *Emitter) header(t string) header {
@@ -37,14 +48,23 @@ func (e *Emitter) header(t string) header {
 // emit writes one line. json.Encoder.Encode appends the newline itself, which
 // is exactly the NDJSON framing. A write error means the consumer is gone;
 // there is nowhere useful to report that, so it is dropped.
-func (e *Emitter) emit(v any) {
+func (e *Emitter) emit(v event) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	_ = e.enc.Encode(v)
+	e.buf.Reset()
+	if err := e.enc.Encode(v); err != nil {
+		return
+	}
+	_, _ = e.w.Write(e.buf.Bytes())
+	if e.hub != nil {
+		e.hub.Publish(v.eventType(), bytes.Clone(e.buf.Bytes()))
+	}
 }
 
 func (e *Emitter) Start(ev StartEvent) {
 	ev.header = e.header(TypeStart)
+	ev.Protocol = ProtocolVersion
+	ev.SchemaVersion = SchemaVersion
 	if ev.Devices == nil {
 		ev.Devices = []string{}
 	}
@@ -79,6 +99,34 @@ func (e *Emitter) Error(scope string, fatal bool, err error) {
 	e.emit(ErrorEvent{header: e.header(TypeError), Scope: scope, Fatal: fatal, Message: msg})
 }
 
+func (e *Emitter) TranscribeStart(ev TranscribeStartEvent) {
+	ev.header = e.header(TypeStart)
+	ev.SchemaVersion = SchemaVersion
+	e.emit(ev)
+}
+
+func (e *Emitter) Upload(sent, total int64) {
+	e.emit(UploadEvent{header: e.header(TypeUpload), Sent: sent, Total: total})
+}
+
+func (e *Emitter) Progress(percent float64) {
+	e.emit(ProgressEvent{header: e.header(TypeProgress), Percent: finite(percent, 0, 100)})
+}
+
+func (e *Emitter) Segment(ev SegmentEvent) {
+	ev.header = e.header(TypeSegment)
+	e.emit(ev)
+}
+
+func (e *Emitter) Ack(ev AckEvent) {
+	ev.header = e.header(TypeAck)
+	e.emit(ev)
+}
+
+func (e *Emitter) Voice(command string) {
+	e.emit(VoiceEvent{header: e.header(TypeVoice), Command: command})
+}
+
 func (e *Emitter) End(ev EndEvent) {
 	ev.header = e.header(TypeEnd)
 	e.emit(ev)
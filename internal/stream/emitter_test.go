@@ -77,6 +77,12 @@ func TestStartEvent(t *testing.T) {
 	if got["t"] != float64(0) {
 		t.Errorf("t = %v, want 0 on the first event", got["t"])
 	}
+	if got["protocol"] != float64(ProtocolVersion) {
+		t.Errorf("protocol = %v, want %d", got["protocol"], ProtocolVersion)
+	}
+	if got["schema_version"] != SchemaVersion {
+		t.Errorf("schema_version = %v, want %s", got["schema_version"], SchemaVersion)
+	}
 }
 
 func TestLevelEventCarriesBothScales(t *testing.T) {
@@ -204,6 +210,21 @@ func TestEndEvent(t *testing.T) {
 
 // Levels come off ffmpeg's stderr goroutine while partials come off the
 // websocket goroutine. Interleaved half-lines would be unparseable.
+func TestAckEvent(t *testing.T) {
+	em, buf, _ := newTestEmitter()
+	muted := true
+	em.Ack(AckEvent{Cmd: CmdMute, ID: "7", OK: true, Muted: &muted})
+	em.Ack(AckEvent{Cmd: "rewind", Error: `unknown command "rewind"`})
+	lines := decodeLines(t, buf.String())
+	if got := lines[0]; got["type"] != "ack" || got["cmd"] != "mute" || got["id"] != "7" || got["ok"] != true || got["muted"] != true {
+		t.Errorf("mute ack = %v", got)
+	}
+	// ok is always present, and muted only where it means something.
+	if got := lines[1]; got["ok"] != false || got["error"] == nil || got["muted"] != nil {
+		t.Errorf("failed ack = %v", got)
+	}
+}
+
 func TestConcurrentEmitsProduceWholeLines(t *testing.T) {
 	em, buf, _ := newTestEmitter()
 	var wg sync.WaitGroup
@@ -1,20 +1,38 @@
 // Package stream defines the newline-delimited JSON that `record --stream`
-// writes to stdout: one JSON object per line, emitted as it happens.
+// and `transcribe --stream` write to stdout: one JSON object per line,
+// emitted as it happens, and the commands record reads back from stdin.
 //
 // The wire carries measurements, not a rendering. Levels are raw readings on
 // two scales and text is exactly what the backend produced; smoothing, colour,
 // and layout belong to the consumer, which knows its own terminal width.
 package stream
 
+// ProtocolVersion is announced in every start event and versions the
+// conversation on stdin: it goes up when a command changes meaning or an ack
+// changes what it promises. New commands do not change it.
+const ProtocolVersion = 1
+
+// SchemaVersion is announced in every start event and versions the shape of
+// the events, as published by `audiomemo schema stream`. The minor number
+// goes up when an event type or an optional field is added, which a consumer
+// that ignores what it does not know survives. The major number goes up when
+// a field is removed, renamed, retyped or made optional.
+const SchemaVersion = "1.4"
+
 // Event type discriminators.
 const (
-	TypeStart   = "start"
-	TypeLevel   = "level"
-	TypePartial = "partial"
-	TypeCommit  = "commit"
-	TypeFinal   = "final"
-	TypeError   = "error"
-	TypeEnd     = "end"
+	TypeStart    = "start"
+	TypeLevel    = "level"
+	TypePartial  = "partial"
+	TypeCommit   = "commit"
+	TypeFinal    = "final"
+	TypeError    = "error"
+	TypeAck      = "ack"
+	TypeUpload   = "upload"
+	TypeProgress = "progress"
+	TypeSegment  = "segment"
+	TypeVoice    = "voice"
+	TypeEnd      = "end"
 )
 
 // StartEvent.Mode values. Mode answers one question: will partials arrive?
@@ -42,9 +60,32 @@ const (
 const (
 	ReasonStopped = "stopped" // ffmpeg exited on its own (duration elapsed, device gone)
 	ReasonSignal  = "signal"  // SIGINT or SIGTERM; a deliberate stop
+	ReasonCommand = "command" // a stop command on stdin, or spoken
 	ReasonError   = "error"   // the run failed
 )
 
+// Events pairs every event type with the struct that carries it. The
+// published schema is generated from it, so an event missing here is missing
+// from the schema.
+var Events = []struct {
+	Type  string
+	Event any
+}{
+	{TypeStart, StartEvent{}},
+	{TypeStart, TranscribeStartEvent{}},
+	{TypeLevel, LevelEvent{}},
+	{TypePartial, TextEvent{}},
+	{TypeCommit, TextEvent{}},
+	{TypeFinal, FinalEvent{}},
+	{TypeError, ErrorEvent{}},
+	{TypeAck, AckEvent{}},
+	{TypeUpload, UploadEvent{}},
+	{TypeProgress, ProgressEvent{}},
+	{TypeSegment, SegmentEvent{}},
+	{TypeVoice, VoiceEvent{}},
+	{TypeEnd, EndEvent{}},
+}
+
 // header is embedded in every event. Anonymous with no tag of its own, so its
 // fields are promoted into the top-level JSON object.
 type header struct {
@@ -52,19 +93,44 @@ type header struct {
 	T    int64  `json:"t"` // milliseconds since the stream opened
 }
 
+func (h header) eventType() string { return h.Type }
+
+// event is any of the events below, through their embedded header.
+type event interface{ eventType() string }
+
 // StartEvent is emitted once, after the recorder is running and the realtime
 // backend has either connected or failed. Mode is therefore a fact.
 type StartEvent struct {
 	header
-	Device      string   `json:"device"`
-	DeviceLabel string   `json:"device_label"`
-	Devices     []string `json:"devices"`
-	Path        string   `json:"path"`
-	Format      string   `json:"format"`
-	SampleRate  int      `json:"sample_rate"`
-	Channels    int      `json:"channels"`
-	Mode        string   `json:"mode"`
-	Backend     string   `json:"backend,omitempty"`
+	Protocol      int      `json:"protocol"`       // ProtocolVersion
+	SchemaVersion string   `json:"schema_version"` // SchemaVersion
+	Device        string   `json:"device"`
+	DeviceLabel   string   `json:"device_label"`
+	Devices       []string `json:"devices"`
+	Path          string   `json:"path"`
+	Format        string   `json:"format"`
+	SampleRate    int      `json:"sample_rate"`
+	Channels      int      `json:"channels"`
+	Mode          string   `json:"mode"`
+	Backend       string   `json:"backend,omitempty"`
+	EventsURL     string   `json:"events_url,omitempty"` // where --serve-events serves the stream
+	InputFile     string   `json:"input_file,omitempty"` // set when record --input-file stands in for a device
+}
+
+// TranscribeStartEvent is the start event of `transcribe --stream`. It
+// shares the type with StartEvent, and a consumer of both tells them apart
+// by File. Duration is in seconds, and is left out when ffprobe cannot tell.
+// Mode is ModeLive for `transcribe --live`, whose partials and commits
+// replace the progress and segments of a batch run, and is left out
+// otherwise.
+type TranscribeStartEvent struct {
+	header
+	SchemaVersion string  `json:"schema_version"` // SchemaVersion
+	File          string  `json:"file"`
+	Backend       string  `json:"backend"`
+	Model         string  `json:"model,omitempty"`
+	Duration      float64 `json:"duration,omitempty"`
+	Mode          string  `json:"mode,omitempty"`
 }
 
 // LevelEvent carries one mic reading on both scales: RMS normalised onto
@@ -102,6 +168,58 @@ type ErrorEvent struct {
 	Message string `json:"message"`
 }
 
+// AckEvent answers one command from stdin, in the order they were read. ID
+// echoes the command's id, so a consumer with several commands in flight can
+// match them up. Muted is the state after mute, unmute or pause; a mark's T
+// is the moment it was placed.
+type AckEvent struct {
+	header
+	Cmd   string `json:"cmd"`
+	ID    string `json:"id,omitempty"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+	Muted *bool  `json:"muted,omitempty"`
+	Label string `json:"label,omitempty"`
+}
+
+// UploadEvent reports how much of the file has gone to a cloud backend.
+// Reaching Total means the upload is done, not the transcription: the
+// backend works on it before it answers.
+type UploadEvent struct {
+	header
+	Sent  int64 `json:"sent"`
+	Total int64 `json:"total"`
+}
+
+// ProgressEvent reports how far a local model has got through the audio, as
+// a percentage. It comes from the tool's own count where it prints one, and
+// otherwise from the end of the latest segment against the duration.
+type ProgressEvent struct {
+	header
+	Percent float64 `json:"percent"`
+}
+
+// SegmentEvent is one segment as soon as it is known: while a local model
+// decodes, or all at once when a cloud backend answers. The text is the
+// backend's; final has the post-processed transcript.
+type SegmentEvent struct {
+	header
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Text    string  `json:"text"`
+	Speaker string  `json:"speaker,omitempty"`
+}
+
+// VoiceEvent reports a command spoken while dictating, once it has been
+// carried out. After a scratch the last commit is no longer in the
+// transcript, and a consumer keeping one should drop it too; a mark's T is
+// the moment it was heard; a stop leads to final and end like a stop on
+// stdin. Breaks and punctuation are not reported: they are in the commit.
+type VoiceEvent struct {
+	header
+	Command string `json:"command"` // scratch, mark or stop
+}
+
 // EndEvent is always the last line. Reaching EOF without one means the
 // producer died rather than finished.
 type EndEvent struct {
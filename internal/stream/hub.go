@@ -0,0 +1,119 @@
+package stream
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// subscriberBuffer is how many lines a subscriber may fall behind before it
+// is dropped. Levels arrive twenty times a second, so this is several
+// seconds of slack; a client further behind than that is not keeping up and
+// must not hold up the recording.
+const subscriberBuffer = 256
+
+// voiceScratch is the VoiceEvent command for a spoken "scratch that", as
+// transcribe.VoiceScratch names it.
+const voiceScratch = "scratch"
+
+// Hub fans the event lines out to any number of subscribers, such as the
+// browsers watching `record --serve-events`. A subscriber that joins late
+// first gets a backlog: the start event and every commit so far, less any
+// scratched by voice, which is enough to draw the transcript as it stands.
+type Hub struct {
+	mu      sync.Mutex
+	backlog []backlogLine
+	subs    map[chan []byte]struct{}
+	closed  bool
+	joined  chan struct{}
+}
+
+type backlogLine struct {
+	eventType string
+	line      []byte
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[chan []byte]struct{}), joined: make(chan struct{})}
+}
+
+// Joined is closed when the first subscriber arrives, for a producer such as
+// replay that has no reason to start before someone is watching.
+func (h *Hub) Joined() <-chan struct{} { return h.joined }
+
+// Publish sends one NDJSON line, newline included, to every subscriber.
+func (h *Hub) Publish(eventType string, line []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	switch eventType {
+	case TypeStart, TypeCommit:
+		h.backlog = append(h.backlog, backlogLine{eventType, line})
+	case TypeVoice:
+		// A scratch takes back the last commit, so a late joiner must not
+		// be shown it.
+		var ev VoiceEvent
+		if json.Unmarshal(line, &ev) == nil && ev.Command == voiceScratch {
+			if n := len(h.backlog); n > 0 && h.backlog[n-1].eventType == TypeCommit {
+				h.backlog = h.backlog[:n-1]
+			}
+		}
+	}
+	for ch := range h.subs {
+		select {
+		case ch <- line:
+		default:
+			// Too far behind: drop it rather than block the emitter.
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe returns a channel of lines that starts with the backlog. The
+// channel is closed by Close, when the subscriber falls too far behind, or
+// by calling cancel.
+func (h *Hub) Subscribe() (lines <-chan []byte, cancel func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ch := make(chan []byte, len(h.backlog)+subscriberBuffer)
+	for _, b := range h.backlog {
+		ch <- b.line
+	}
+	if h.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if len(h.subs) == 0 {
+		select {
+		case <-h.joined:
+		default:
+			close(h.joined)
+		}
+	}
+	h.subs[ch] = struct{}{}
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subs[ch]; ok {
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Close ends every subscription once the lines already sent are read. Call
+// it after the end event.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for ch := range h.subs {
+		close(ch)
+	}
+	h.subs = nil
+}
@@ -0,0 +1,124 @@
+package stream
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func drain(lines <-chan []byte) []string {
+	var out []string
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return out
+			}
+			out = append(out, strings.TrimSpace(string(line)))
+		default:
+			return out
+		}
+	}
+}
+
+func TestHubBacklogForLateJoiners(t *testing.T) {
+	h := NewHub()
+	h.Publish(TypeStart, []byte("start\n"))
+	h.Publish(TypeLevel, []byte("level\n"))
+	h.Publish(TypePartial, []byte("partial\n"))
+	h.Publish(TypeCommit, []byte("commit 1\n"))
+	h.Publish(TypeCommit, []byte("commit 2\n"))
+
+	lines, cancel := h.Subscribe()
+	defer cancel()
+	h.Publish(TypeLevel, []byte("level 2\n"))
+
+	got := strings.Join(drain(lines), "|")
+	if want := "start|commit 1|commit 2|level 2"; got != want {
+		t.Errorf("late joiner got %q, want %q", got, want)
+	}
+}
+
+func TestHubBacklogForgetsScratchedCommit(t *testing.T) {
+	h := NewHub()
+	h.Publish(TypeStart, []byte("start\n"))
+	h.Publish(TypeCommit, []byte("commit 1\n"))
+	h.Publish(TypeCommit, []byte("commit 2\n"))
+	h.Publish(TypeVoice, []byte(`{"type":"voice","t":3,"command":"scratch"}`+"\n"))
+	h.Publish(TypeVoice, []byte(`{"type":"voice","t":4,"command":"mark"}`+"\n"))
+	h.Publish(TypeCommit, []byte("commit 3\n"))
+
+	lines, cancel := h.Subscribe()
+	defer cancel()
+
+	got := strings.Join(drain(lines), "|")
+	if want := "start|commit 1|commit 3"; got != want {
+		t.Errorf("late joiner got %q, want %q", got, want)
+	}
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	h := NewHub()
+	slow, cancelSlow := h.Subscribe()
+	defer cancelSlow()
+	fast, cancelFast := h.Subscribe()
+	defer cancelFast()
+
+	for i := range subscriberBuffer + 10 {
+		h.Publish(TypeLevel, []byte(fmt.Sprintf("level %d\n", i)))
+		drain(fast)
+	}
+	got := drain(slow)
+	if len(got) != subscriberBuffer {
+		t.Errorf("slow subscriber got %d lines before being dropped, want %d", len(got), subscriberBuffer)
+	}
+	if _, ok := <-slow; ok {
+		t.Error("slow subscriber's channel should be closed")
+	}
+
+	h.Publish(TypeLevel, []byte("still here\n"))
+	if got := drain(fast); len(got) != 1 || got[0] != "still here" {
+		t.Errorf("fast subscriber got %q", got)
+	}
+}
+
+func TestHubCloseEndsSubscriptions(t *testing.T) {
+	h := NewHub()
+	h.Publish(TypeStart, []byte("start\n"))
+	lines, cancel := h.Subscribe()
+	h.Publish(TypeEnd, []byte("end\n"))
+	h.Close()
+	cancel() // after Close, must not double-close
+
+	if got := drain(lines); strings.Join(got, "|") != "start|end" {
+		t.Errorf("got %q", got)
+	}
+	if _, ok := <-lines; ok {
+		t.Error("channel should be closed")
+	}
+
+	// Joining after the end still gets the backlog, then EOF.
+	late, _ := h.Subscribe()
+	if got := drain(late); strings.Join(got, "|") != "start" {
+		t.Errorf("late joiner after close got %q", got)
+	}
+}
+
+func TestHubJoinedOnFirstSubscriber(t *testing.T) {
+	h := NewHub()
+	select {
+	case <-h.Joined():
+		t.Fatal("joined before anyone subscribed")
+	default:
+	}
+	_, cancel := h.Subscribe()
+	cancel()
+	// A second subscriber after the first left must not close it twice.
+	_, cancel = h.Subscribe()
+	defer cancel()
+	select {
+	case <-h.Joined():
+	default:
+		t.Error("not joined after a subscriber arrived")
+	}
+}
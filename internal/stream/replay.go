@@ -0,0 +1,79 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLineBytes bounds one saved event. A final with an hour's transcript is
+// well under this.
+const maxLineBytes = 16 << 20
+
+// ParseSpeed reads a replay speed such as "2x", "0.5" or "max". Max is zero:
+// no waiting at all, for tests that only care about the events.
+func ParseSpeed(s string) (float64, error) {
+	if strings.EqualFold(s, "max") {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(s), "x"), 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("invalid speed %q: want a positive multiple like 2x or 0.5x, or max", s)
+	}
+	return v, nil
+}
+
+// Replay reads a saved stream from r and hands each line to emit, newline
+// included, waiting between lines as long as their t values were apart,
+// divided by speed. A speed of zero does not wait. The lines are passed on
+// as they were saved, t included, so a consumer sees exactly what it would
+// have seen live.
+func Replay(ctx context.Context, r io.Reader, speed float64, emit func(eventType string, line []byte)) error {
+	return replay(ctx, r, speed, sleepCtx, emit)
+}
+
+func replay(ctx context.Context, r io.Reader, speed float64, sleep func(context.Context, time.Duration) error, emit func(string, []byte)) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), maxLineBytes)
+	var prev int64
+	first := true
+	for n := 1; sc.Scan(); n++ {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var h header
+		if err := json.Unmarshal(line, &h); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		if h.Type == "" {
+			return fmt.Errorf("line %d: not an event: no type", n)
+		}
+		if !first && speed > 0 && h.T > prev {
+			wait := time.Duration(float64(time.Duration(h.T-prev)*time.Millisecond) / speed)
+			if err := sleep(ctx, wait); err != nil {
+				return err
+			}
+		}
+		first, prev = false, h.T
+		emit(h.Type, append(bytes.Clone(line), '\n'))
+	}
+	return sc.Err()
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
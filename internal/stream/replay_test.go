@@ -0,0 +1,87 @@
+package stream
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const saved = `{"type":"start","t":0,"mode":"live"}
+{"type":"level","t":50,"rms":0.2,"db":-40}
+
+{"type":"commit","t":1050,"text":"hello"}
+{"type":"end","t":1050,"reason":"stopped","exit_code":0}
+`
+
+func TestReplayKeepsSpacingAndLines(t *testing.T) {
+	var waits []time.Duration
+	sleep := func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	var out bytes.Buffer
+	var types []string
+	err := replay(t.Context(), strings.NewReader(saved), 2, sleep, func(typ string, line []byte) {
+		types = append(types, typ)
+		out.Write(line)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Twice as fast, and no wait between events at the same t.
+	if want := []time.Duration{25 * time.Millisecond, 500 * time.Millisecond}; !slices.Equal(waits, want) {
+		t.Errorf("waits = %v, want %v", waits, want)
+	}
+	if want := []string{TypeStart, TypeLevel, TypeCommit, TypeEnd}; !slices.Equal(types, want) {
+		t.Errorf("types = %v", types)
+	}
+	if got, want := out.String(), strings.Replace(saved, "\n\n", "\n", 1); got != want {
+		t.Errorf("replayed\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestReplayAtMaxSpeedDoesNotWait(t *testing.T) {
+	sleep := func(context.Context, time.Duration) error {
+		t.Error("slept at max speed")
+		return nil
+	}
+	if err := replay(t.Context(), strings.NewReader(saved), 0, sleep, func(string, []byte) {}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplayRejectsWhatIsNotAStream(t *testing.T) {
+	for _, in := range []string{"{\"type\":\"start\",\"t\":0}\nnot json\n", `{"t":5}`} {
+		if err := Replay(t.Context(), strings.NewReader(in), 0, func(string, []byte) {}); err == nil {
+			t.Errorf("%q: want an error", in)
+		}
+	}
+}
+
+func TestReplayStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	var n int
+	err := Replay(ctx, strings.NewReader(saved), 1, func(string, []byte) {
+		n++
+		cancel()
+	})
+	if err != context.Canceled || n != 1 {
+		t.Errorf("err = %v after %d events", err, n)
+	}
+}
+
+func TestParseSpeed(t *testing.T) {
+	for in, want := range map[string]float64{"2x": 2, "0.5": 0.5, "1X": 1, "max": 0} {
+		if got, err := ParseSpeed(in); err != nil || got != want {
+			t.Errorf("ParseSpeed(%q) = %v, %v", in, got, err)
+		}
+	}
+	for _, in := range []string{"", "fast", "0x", "-1"} {
+		if _, err := ParseSpeed(in); err == nil {
+			t.Errorf("ParseSpeed(%q): want an error", in)
+		}
+	}
+}
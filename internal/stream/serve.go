@@ -0,0 +1,101 @@
+package stream
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+//go:embed captions.html
+var captionsPage []byte
+
+// The default origin check stays on: a page on another site has no business
+// reading the microphone's transcript, and neither endpoint sends CORS
+// headers for the same reason.
+var upgrader = websocket.Upgrader{}
+
+// NewHandler serves the hub's events to browsers: Server-Sent Events at
+// /events, one text message per event on a WebSocket at /ws, and at / a
+// caption page that an OBS browser source or a second screen can show. Every
+// event is the same JSON object the NDJSON stream carries.
+func NewHandler(h *Hub) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(captionsPage)
+	})
+	mux.HandleFunc("GET /events", func(w http.ResponseWriter, r *http.Request) { serveSSE(h, w, r) })
+	mux.HandleFunc("GET /ws", func(w http.ResponseWriter, r *http.Request) { serveWebSocket(h, w, r) })
+	return mux
+}
+
+func serveSSE(h *Hub, w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher.Flush()
+
+	lines, cancel := h.Subscribe()
+	defer cancel()
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			// Unnamed events, so EventSource.onmessage sees all of them; the
+			// type is in the JSON.
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", bytes.TrimRight(line, "\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func serveWebSocket(h *Hub, w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // Upgrade has already replied
+	}
+	defer conn.Close()
+
+	lines, cancel := h.Subscribe()
+	defer cancel()
+
+	// Clients send nothing; reading is how a close or a dropped connection
+	// is noticed.
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "stream ended"))
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, bytes.TrimRight(line, "\n")); err != nil {
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
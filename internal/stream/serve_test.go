@@ -0,0 +1,120 @@
+package stream
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestServeEvents(t *testing.T) {
+	hub := NewHub()
+	em, _, _ := newTestEmitter()
+	em.SetHub(hub)
+	srv := httptest.NewServer(NewHandler(hub))
+	defer srv.Close()
+
+	em.Start(StartEvent{Path: "/tmp/memo.ogg", Mode: ModeLive})
+	em.Level(0.5, -20)
+	em.Commit("Hello <world>.")
+
+	// Both clients join late and must catch up from the backlog.
+	resp, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	sse := bufio.NewReader(resp.Body)
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	// Wait for both subscriptions before publishing more.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		hub.mu.Lock()
+		n := len(hub.subs)
+		hub.mu.Unlock()
+		if n == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	em.Partial("next")
+	em.End(EndEvent{Reason: ReasonStopped})
+	hub.Close()
+
+	readSSE := func() string {
+		for {
+			line, err := sse.ReadString('\n')
+			if err != nil {
+				return ""
+			}
+			if data, ok := strings.CutPrefix(line, "data: "); ok {
+				return strings.TrimSpace(data)
+			}
+		}
+	}
+	readWS := func() string {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+
+	for name, read := range map[string]func() string{"sse": readSSE, "ws": readWS} {
+		var types []string
+		for range 4 {
+			var ev map[string]any
+			data := read()
+			if err := json.Unmarshal([]byte(data), &ev); err != nil {
+				t.Fatalf("%s: %q is not JSON: %v", name, data, err)
+			}
+			types = append(types, ev["type"].(string))
+			if ev["type"] == TypeCommit && ev["text"] != "Hello <world>." {
+				t.Errorf("%s: commit text = %q", name, ev["text"])
+			}
+		}
+		if got := strings.Join(types, ","); got != "start,commit,partial,end" {
+			t.Errorf("%s: got %s, want the backlog then the live events", name, got)
+		}
+		if extra := read(); extra != "" {
+			t.Errorf("%s: expected the stream to end, got %q", name, extra)
+		}
+	}
+}
+
+func TestServeCaptionsPage(t *testing.T) {
+	srv := httptest.NewServer(NewHandler(NewHub()))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("status %d, Content-Type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	resp, err = http.Get(srv.URL + "/nope")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown path: status %d", resp.StatusCode)
+	}
+}
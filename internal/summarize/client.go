@@ -0,0 +1,99 @@
+// Package summarize turns a transcript into a summary, a list of action items
+// and a suggested title by asking an OpenAI-compatible chat endpoint.
+//
+// Only the chat completions API is used, so anything that speaks it works:
+// OpenAI itself, a local llama.cpp or Ollama server, or a proxy in front of
+// another provider.
+package summarize
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Client calls the chat completions endpoint under baseURL.
+type Client struct {
+	baseURL string
+	apiKey  string
+	model   string
+}
+
+// NewClient returns a client for the endpoint at baseURL. The base URL may be
+// given with or without its /v1 suffix, since both spellings are common in
+// the servers' own docs. An empty apiKey sends no Authorization header, which
+// is what local servers expect.
+func NewClient(baseURL, apiKey, model string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/v1"),
+		apiKey:  apiKey,
+		model:   model,
+	}
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// Chat sends one user message and returns the reply.
+func (c *Client) Chat(ctx context.Context, prompt string) (string, error) {
+	if c.model == "" {
+		return "", fmt.Errorf("no model configured (set summarize.model)")
+	}
+	body, err := json.Marshal(chatRequest{
+		Model:    c.model,
+		Messages: []chatMessage{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	reqURL := c.baseURL + "/v1/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("chat request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("chat API error (%d): %s", resp.StatusCode, string(respBody))
+	}
+
+	var out chatResponse
+	if err := json.Unmarshal(respBody, &out); err != nil {
+		return "", fmt.Errorf("failed to parse chat response: %w", err)
+	}
+	if len(out.Choices) == 0 {
+		return "", fmt.Errorf("chat response has no choices")
+	}
+	return strings.TrimSpace(out.Choices[0].Message.Content), nil
+}
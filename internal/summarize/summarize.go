@@ -0,0 +1,192 @@
+package summarize
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// Prompts are the templates sent for each part of a summary. Each is a Go
+// text/template executed with a Data; an empty template means the default.
+type Prompts struct {
+	Summary     string
+	ActionItems string
+	Title       string
+}
+
+// DefaultPrompts ask for plain replies with no preamble, because the replies
+// are pasted straight into the summary file.
+var DefaultPrompts = Prompts{
+	Summary: `Summarise the following transcript in a few short paragraphs. Cover what was decided and what is still open. Reply with the summary only.
+
+{{.Transcript}}`,
+	ActionItems: `List the action items in the following transcript as a Markdown bullet list, naming the owner when the transcript does. If there are none, reply with "None." only.
+
+{{.Transcript}}`,
+	Title: `Suggest a short title, at most six words, for the following transcript. Reply with the title only, without quotes or a full stop.
+
+{{.Transcript}}`,
+}
+
+// Data is what a prompt template can refer to.
+type Data struct {
+	Transcript string
+	Language   string // ISO 639-1 code, when the backend reported one
+}
+
+// Summary is the model's answer to each prompt.
+type Summary struct {
+	Title       string
+	Summary     string
+	ActionItems string
+}
+
+type compiledPrompts struct {
+	summary, actionItems, title *template.Template
+}
+
+func (p Prompts) compile() (*compiledPrompts, error) {
+	parse := func(name, text, fallback string) (*template.Template, error) {
+		if strings.TrimSpace(text) == "" {
+			text = fallback
+		}
+		t, err := template.New(name).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("summarize.prompts.%s: %w", name, err)
+		}
+		return t, nil
+	}
+	var c compiledPrompts
+	var err error
+	if c.summary, err = parse("summary", p.Summary, DefaultPrompts.Summary); err != nil {
+		return nil, err
+	}
+	if c.actionItems, err = parse("action_items", p.ActionItems, DefaultPrompts.ActionItems); err != nil {
+		return nil, err
+	}
+	if c.title, err = parse("title", p.Title, DefaultPrompts.Title); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Validate reports a template that does not parse or refers to a field Data
+// lacks, so a typo in the config fails before a long transcription rather
+// than after it.
+func (p Prompts) Validate() error {
+	c, err := p.compile()
+	if err != nil {
+		return err
+	}
+	for _, t := range []*template.Template{c.summary, c.actionItems, c.title} {
+		if err := t.Execute(io.Discard, Data{}); err != nil {
+			return fmt.Errorf("summarize.prompts.%s: %w", t.Name(), err)
+		}
+	}
+	return nil
+}
+
+// Summarize asks for the summary, action items and title in turn. They are
+// separate requests so each template stays a simple question with a simple
+// answer, rather than one prompt whose reply has to be parsed apart.
+func Summarize(ctx context.Context, c *Client, prompts Prompts, data Data) (*Summary, error) {
+	if strings.TrimSpace(data.Transcript) == "" {
+		return nil, fmt.Errorf("transcript is empty")
+	}
+	tmpl, err := prompts.compile()
+	if err != nil {
+		return nil, err
+	}
+	ask := func(t *template.Template) (string, error) {
+		var b strings.Builder
+		if err := t.Execute(&b, data); err != nil {
+			return "", fmt.Errorf("summarize.prompts.%s: %w", t.Name(), err)
+		}
+		reply, err := c.Chat(ctx, b.String())
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", t.Name(), err)
+		}
+		return reply, nil
+	}
+
+	var s Summary
+	if s.Summary, err = ask(tmpl.summary); err != nil {
+		return nil, err
+	}
+	if s.ActionItems, err = ask(tmpl.actionItems); err != nil {
+		return nil, err
+	}
+	title, err := ask(tmpl.title)
+	if err != nil {
+		return nil, err
+	}
+	s.Title = cleanTitle(title)
+	return &s, nil
+}
+
+// cleanTitle keeps the first line of a title reply and strips the quotes,
+// Markdown heading marks and full stop models add despite being asked not to.
+func cleanTitle(s string) string {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+	s = strings.TrimLeft(s, "# ")
+	s = strings.Trim(s, "\"'“”‘’*` ")
+	s = strings.TrimPrefix(s, "Title:")
+	return strings.TrimRight(strings.TrimSpace(s), ".")
+}
+
+// Markdown renders the summary file.
+func (s *Summary) Markdown() string {
+	var b strings.Builder
+	title := s.Title
+	if title == "" {
+		title = "Summary"
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	fmt.Fprintf(&b, "## Summary\n\n%s\n\n", strings.TrimSpace(s.Summary))
+	fmt.Fprintf(&b, "## Action items\n\n%s\n", strings.TrimSpace(s.ActionItems))
+	return b.String()
+}
+
+// ParseMarkdown reads back a summary file written by Markdown, so it can be
+// reused without asking the model again. Text under a heading it does not
+// know stays with the section before it.
+func ParseMarkdown(md string) *Summary {
+	s := &Summary{}
+	var summary, actionItems strings.Builder
+	var section *strings.Builder
+	for _, line := range strings.Split(md, "\n") {
+		switch {
+		case section == nil && s.Title == "" && strings.HasPrefix(line, "# "):
+			s.Title = strings.TrimSpace(line[2:])
+		case line == "## Summary":
+			section = &summary
+		case line == "## Action items":
+			section = &actionItems
+		case section != nil:
+			section.WriteString(line + "\n")
+		}
+	}
+	s.Summary = strings.TrimSpace(summary.String())
+	s.ActionItems = strings.TrimSpace(actionItems.String())
+	return s
+}
+
+// Label turns the title into a filename label: lowercase words joined by
+// hyphens, without the punctuation that makes paths awkward to type.
+func (s *Summary) Label() string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s.Title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	return strings.TrimRight(b.String(), "-")
+}
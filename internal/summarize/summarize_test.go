@@ -0,0 +1,142 @@
+package summarize
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newChatServer answers each prompt according to reply, and records the
+// prompts and auth headers it saw.
+func newChatServer(t *testing.T, reply func(prompt string) string) (*httptest.Server, *[]string, *[]string) {
+	var prompts, auths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("bad request body: %v", err)
+		}
+		if req.Model != "test-model" {
+			t.Errorf("model = %q", req.Model)
+		}
+		prompt := req.Messages[len(req.Messages)-1].Content
+		prompts = append(prompts, prompt)
+		auths = append(auths, r.Header.Get("Authorization"))
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": reply(prompt)}}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &prompts, &auths
+}
+
+func TestSummarize(t *testing.T) {
+	srv, prompts, auths := newChatServer(t, func(prompt string) string {
+		switch {
+		case strings.Contains(prompt, "action items"):
+			return "- Alice: ship it"
+		case strings.Contains(prompt, "title"):
+			return `"Release Planning."`
+		default:
+			return "We agreed to ship on Friday."
+		}
+	})
+
+	c := NewClient(srv.URL+"/v1/", "sk-test", "test-model")
+	s, err := Summarize(t.Context(), c, Prompts{}, Data{Transcript: "Alice: let's ship Friday"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Summary{Title: "Release Planning", Summary: "We agreed to ship on Friday.", ActionItems: "- Alice: ship it"}
+	if *s != want {
+		t.Errorf("got %+v, want %+v", *s, want)
+	}
+	if len(*prompts) != 3 || !strings.Contains((*prompts)[0], "Alice: let's ship Friday") {
+		t.Errorf("prompts = %q", *prompts)
+	}
+	if (*auths)[0] != "Bearer sk-test" {
+		t.Errorf("Authorization = %q", (*auths)[0])
+	}
+}
+
+func TestSummarizeCustomPromptAndNoKey(t *testing.T) {
+	srv, prompts, auths := newChatServer(t, func(string) string { return "ok" })
+	c := NewClient(srv.URL, "", "test-model")
+	p := Prompts{Summary: "Résume en {{.Language}}: {{.Transcript}}"}
+	if _, err := Summarize(t.Context(), c, p, Data{Transcript: "bonjour", Language: "fr"}); err != nil {
+		t.Fatal(err)
+	}
+	if (*prompts)[0] != "Résume en fr: bonjour" {
+		t.Errorf("summary prompt = %q", (*prompts)[0])
+	}
+	if (*auths)[0] != "" {
+		t.Errorf("expected no Authorization header for a keyless server, got %q", (*auths)[0])
+	}
+}
+
+func TestSummarizeAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+	}))
+	defer srv.Close()
+	_, err := Summarize(t.Context(), NewClient(srv.URL, "", "test-model"), Prompts{}, Data{Transcript: "hi"})
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected a 404 error, got %v", err)
+	}
+}
+
+func TestPromptsValidate(t *testing.T) {
+	if err := (Prompts{}).Validate(); err != nil {
+		t.Errorf("default prompts: %v", err)
+	}
+	if err := (Prompts{Title: "{{.Transcript"}).Validate(); err == nil {
+		t.Error("expected a parse error")
+	}
+	if err := (Prompts{Summary: "{{.Speakers}}"}).Validate(); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
+
+func TestCleanTitle(t *testing.T) {
+	tests := map[string]string{
+		`"Q3 Planning Review."`:       "Q3 Planning Review",
+		"# Budget sync\n\nSome notes": "Budget sync",
+		"Title: Standup":              "Standup",
+		"**Hiring plan**":             "Hiring plan",
+	}
+	for in, want := range tests {
+		if got := cleanTitle(in); got != want {
+			t.Errorf("cleanTitle(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestLabel(t *testing.T) {
+	s := &Summary{Title: "Q3 Planning: Budget & Hiring!"}
+	if got := s.Label(); got != "q3-planning-budget-hiring" {
+		t.Errorf("Label() = %q", got)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	s := &Summary{Title: "Standup", Summary: "All fine.\n", ActionItems: "None."}
+	want := "# Standup\n\n## Summary\n\nAll fine.\n\n## Action items\n\nNone.\n"
+	if got := s.Markdown(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestParseMarkdownRoundTrip(t *testing.T) {
+	s := &Summary{Title: "Standup", Summary: "All fine.\n\n## Risks\n\nNone yet.", ActionItems: "- Alice: ship it"}
+	got := ParseMarkdown(s.Markdown())
+	if *got != *s {
+		t.Errorf("got %+v, want %+v", got, s)
+	}
+	if got := ParseMarkdown("just some notes"); *got != (Summary{}) {
+		t.Errorf("a file that is not a summary = %+v", got)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 )
 
 type Deepgram struct {
@@ -48,6 +49,11 @@ func (d *Deepgram) Transcribe(ctx context.Context, audioPath string, opts Transc
 	if err != nil {
 		return nil, err
 	}
+	if opts.Progress != nil {
+		if fi, err := f.Stat(); err == nil {
+			trackUpload(req, fi.Size(), opts.Progress)
+		}
+	}
 	req.Header.Set("Authorization", "Token "+d.apiKey)
 	req.Header.Set("Content-Type", "application/octet-stream")
 
@@ -94,6 +100,22 @@ func (d *Deepgram) buildQuery(opts TranscribeOpts) url.Values {
 		q.Set("numerals", "true")
 	}
 
+	// Nova-3 replaced keyword boosting with keyterm prompting; older models
+	// still only understand keywords.
+	vocabParam := "keywords"
+	if strings.HasPrefix(model, "nova-3") {
+		vocabParam = "keyterm"
+	}
+	for _, term := range opts.Vocabulary {
+		q.Add(vocabParam, term)
+	}
+
+	for _, category := range opts.Redact {
+		if entity, ok := deepgramRedact[category]; ok {
+			q.Add("redact", entity)
+		}
+	}
+
 	if opts.Language != "" {
 		q.Set("language", opts.Language)
 	} else {
@@ -102,6 +124,14 @@ func (d *Deepgram) buildQuery(opts TranscribeOpts) url.Values {
 	return q
 }
 
+// deepgramRedact is what Deepgram's redact parameter calls each category it
+// can mask. Configured terms are not among them: only Redactor knows those.
+var deepgramRedact = map[string]string{
+	RedactEmail: "email_address",
+	RedactPhone: "phone_number",
+	RedactCard:  "pci",
+}
+
 type deepgramResponse struct {
 	Metadata struct {
 		Duration float64 `json:"duration"`
@@ -110,6 +140,12 @@ type deepgramResponse struct {
 		Channels []struct {
 			Alternatives []struct {
 				Transcript string `json:"transcript"`
+				Words      []struct {
+					Word           string  `json:"word"`
+					PunctuatedWord string  `json:"punctuated_word"`
+					Start          float64 `json:"start"`
+					End            float64 `json:"end"`
+				} `json:"words"`
 			} `json:"alternatives"`
 		} `json:"channels"`
 		Utterances []struct {
@@ -130,7 +166,15 @@ func (d *Deepgram) parseResponse(data []byte, diarize bool) (*Result, error) {
 	result := &Result{Duration: resp.Metadata.Duration}
 
 	if len(resp.Results.Channels) > 0 && len(resp.Results.Channels[0].Alternatives) > 0 {
-		result.Text = resp.Results.Channels[0].Alternatives[0].Transcript
+		alt := resp.Results.Channels[0].Alternatives[0]
+		result.Text = alt.Transcript
+		for _, w := range alt.Words {
+			text := w.PunctuatedWord
+			if text == "" {
+				text = w.Word
+			}
+			result.Words = append(result.Words, Word{Start: w.Start, End: w.End, Text: text})
+		}
 	}
 
 	for _, u := range resp.Results.Utterances {
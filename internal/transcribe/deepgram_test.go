@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -31,7 +32,11 @@ func TestDeepgramParseResponse(t *testing.T) {
 			"channels": [{
 				"alternatives": [{
 					"transcript": "Hello world",
-					"confidence": 0.99
+					"confidence": 0.99,
+					"words": [
+						{"word": "hello", "punctuated_word": "Hello", "start": 0.1, "end": 0.6},
+						{"word": "world", "start": 2.6, "end": 3.1}
+					]
 				}]
 			}],
 			"utterances": [
@@ -55,6 +60,10 @@ func TestDeepgramParseResponse(t *testing.T) {
 	if result.Duration != 5.0 {
 		t.Errorf("expected duration 5.0, got %f", result.Duration)
 	}
+	want := []Word{{Start: 0.1, End: 0.6, Text: "Hello"}, {Start: 2.6, End: 3.1, Text: "world"}}
+	if !slices.Equal(result.Words, want) {
+		t.Errorf("words = %+v, want %+v", result.Words, want)
+	}
 }
 
 func TestDeepgramBuildQueryParams(t *testing.T) {
@@ -120,3 +129,35 @@ func TestDeepgramRoundTrip(t *testing.T) {
 		t.Errorf("expected 'test', got %q", result.Text)
 	}
 }
+
+func TestDeepgramBuildQueryRedact(t *testing.T) {
+	d := NewDeepgram("key", "nova-3")
+	params := d.buildQuery(TranscribeOpts{Redact: []string{RedactEmail, RedactCard, RedactTerms}})
+	if got := params["redact"]; !slices.Equal(got, []string{"email_address", "pci"}) {
+		t.Errorf("redact = %q", got)
+	}
+	if params := d.buildQuery(TranscribeOpts{}); params.Has("redact") {
+		t.Errorf("redact sent unasked: %q", params["redact"])
+	}
+}
+
+func TestDeepgramBuildQueryVocabulary(t *testing.T) {
+	d := NewDeepgram("key", "nova-3")
+	terms := []string{"AudioMemo", "Joe Goldin"}
+
+	params := d.buildQuery(TranscribeOpts{Vocabulary: terms})
+	if got := params["keyterm"]; len(got) != 2 || got[0] != "AudioMemo" || got[1] != "Joe Goldin" {
+		t.Errorf("nova-3 keyterm = %v, want %v", got, terms)
+	}
+	if params.Has("keywords") {
+		t.Errorf("nova-3 should not send keywords: %v", params["keywords"])
+	}
+
+	params = d.buildQuery(TranscribeOpts{Model: "nova-2", Vocabulary: terms})
+	if got := params["keywords"]; len(got) != 2 {
+		t.Errorf("nova-2 keywords = %v, want %v", got, terms)
+	}
+	if params.Has("keyterm") {
+		t.Errorf("nova-2 should not send keyterm: %v", params["keyterm"])
+	}
+}
@@ -3,10 +3,14 @@ package transcribe
 import (
 	"fmt"
 	"os/exec"
+	"strings"
 
 	"github.com/joegoldin/audiomemo/internal/config"
 )
 
+// BackendNames are the backends NewDispatcher accepts by name.
+var BackendNames = []string{"elevenlabs", "whisper", "whisper-cpp", "whisperx", "ffmpeg-whisper", "deepgram", "openai", "mistral"}
+
 func NewDispatcher(cfg *config.Config, backendOverride string) (Transcriber, error) {
 	backend := backendOverride
 	if backend == "" {
@@ -92,6 +96,6 @@ func newBackend(cfg *config.Config, name string) (Transcriber, error) {
 		}
 		return NewMistral(cfg.Transcribe.Mistral.APIKey, cfg.Transcribe.Mistral.Model), nil
 	default:
-		return nil, fmt.Errorf("unknown backend: %s (available: elevenlabs, whisper, whisper-cpp, whisperx, ffmpeg-whisper, deepgram, openai, mistral)", name)
+		return nil, fmt.Errorf("unknown backend: %s (available: %s)", name, strings.Join(BackendNames, ", "))
 	}
 }
@@ -57,6 +57,7 @@ func (e *ElevenLabs) Transcribe(ctx context.Context, audioPath string, opts Tran
 	if err != nil {
 		return nil, err
 	}
+	trackUpload(req, req.ContentLength, opts.Progress)
 	req.Header.Set("xi-api-key", e.apiKey)
 	req.Header.Set("Content-Type", contentType)
 
@@ -111,6 +112,9 @@ func (e *ElevenLabs) buildMultipart(audioPath string, opts TranscribeOpts) (*byt
 	if opts.Diarize {
 		w.WriteField("diarize", "true")
 	}
+	for _, term := range opts.Vocabulary {
+		w.WriteField("keyterms", term)
+	}
 
 	w.WriteField("timestamps_granularity", "word")
 
@@ -160,6 +164,12 @@ func (e *ElevenLabs) parseResponse(data []byte, diarize bool) (*Result, string,
 		result.Duration = *resp.AudioDurationSecs
 	}
 
+	for _, w := range resp.Words {
+		if w.Type == "word" && w.Start != nil && w.End != nil {
+			result.Words = append(result.Words, Word{Start: *w.Start, End: *w.End, Text: w.Text})
+		}
+	}
+
 	if diarize {
 		result.Segments = groupWordsBySpeaker(resp.Words)
 	} else if len(resp.Words) > 0 {
@@ -89,6 +89,9 @@ func TestElevenLabsParseResponse(t *testing.T) {
 	if transcriptionID != "txn_123" {
 		t.Errorf("expected 'txn_123', got %q", transcriptionID)
 	}
+	if len(result.Words) != 2 || result.Words[1] != (Word{Start: 0.6, End: 1.0, Text: "world"}) {
+		t.Errorf("words = %+v", result.Words)
+	}
 }
 
 func TestElevenLabsParseResponseDiarize(t *testing.T) {
@@ -156,6 +159,25 @@ func TestElevenLabsRoundTrip(t *testing.T) {
 	}
 }
 
+func TestElevenLabsMultipartKeyterms(t *testing.T) {
+	e := NewElevenLabs("key", "scribe_v2", false)
+	tmp := filepath.Join(t.TempDir(), "test.ogg")
+	os.WriteFile(tmp, []byte("fake audio"), 0644)
+
+	body, contentType, err := e.buildMultipart(tmp, TranscribeOpts{Vocabulary: []string{"AudioMemo", "Joe Goldin"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("POST", "/", body)
+	req.Header.Set("Content-Type", contentType)
+	if err := req.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+	if got := req.MultipartForm.Value["keyterms"]; len(got) != 2 || got[0] != "AudioMemo" || got[1] != "Joe Goldin" {
+		t.Errorf("keyterms = %v", got)
+	}
+}
+
 func TestElevenLabsDeleteAfterTranscribe(t *testing.T) {
 	var deleteCalled atomic.Int32
 	tid := "txn_delete_me"
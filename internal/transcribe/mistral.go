@@ -47,6 +47,7 @@ func (m *Mistral) Transcribe(ctx context.Context, audioPath string, opts Transcr
 	if err != nil {
 		return nil, err
 	}
+	trackUpload(req, req.ContentLength, opts.Progress)
 	req.Header.Set("Authorization", "Bearer "+m.apiKey)
 	req.Header.Set("Content-Type", contentType)
 
@@ -95,6 +96,9 @@ func (m *Mistral) buildMultipart(audioPath string, opts TranscribeOpts) (*bytes.
 	if opts.Language != "" {
 		w.WriteField("language", opts.Language)
 	}
+	for _, term := range opts.Vocabulary {
+		w.WriteField("context_bias", term)
+	}
 
 	if err := w.Close(); err != nil {
 		return nil, "", err
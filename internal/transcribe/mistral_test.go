@@ -79,3 +79,22 @@ func TestMistralRoundTrip(t *testing.T) {
 		t.Errorf("expected 'bonjour', got %q", result.Text)
 	}
 }
+
+func TestMistralMultipartContextBias(t *testing.T) {
+	m := NewMistral("key", "voxtral-mini-latest")
+	tmp := filepath.Join(t.TempDir(), "test.ogg")
+	os.WriteFile(tmp, []byte("fake"), 0644)
+
+	body, contentType, err := m.buildMultipart(tmp, TranscribeOpts{Vocabulary: []string{"AudioMemo", "Joe Goldin"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("POST", "/", body)
+	req.Header.Set("Content-Type", contentType)
+	if err := req.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+	if got := req.MultipartForm.Value["context_bias"]; len(got) != 2 || got[0] != "AudioMemo" || got[1] != "Joe Goldin" {
+		t.Errorf("context_bias = %v", got)
+	}
+}
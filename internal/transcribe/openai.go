@@ -12,6 +12,8 @@ import (
 	"path/filepath"
 )
 
+const openaiTranslationModel = "whisper-1"
+
 type OpenAI struct {
 	apiKey       string
 	defaultModel string
@@ -42,11 +44,16 @@ func (o *OpenAI) Transcribe(ctx context.Context, audioPath string, opts Transcri
 		return nil, err
 	}
 
-	reqURL := fmt.Sprintf("%s/v1/audio/transcriptions", o.baseURL)
+	endpoint := "transcriptions"
+	if opts.Translate {
+		endpoint = "translations"
+	}
+	reqURL := fmt.Sprintf("%s/v1/audio/%s", o.baseURL, endpoint)
 	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, body)
 	if err != nil {
 		return nil, err
 	}
+	trackUpload(req, req.ContentLength, opts.Progress)
 	req.Header.Set("Authorization", "Bearer "+o.apiKey)
 	req.Header.Set("Content-Type", contentType)
 
@@ -65,7 +72,15 @@ func (o *OpenAI) Transcribe(ctx context.Context, audioPath string, opts Transcri
 		return nil, fmt.Errorf("openai API error (%d): %s", resp.StatusCode, string(respBody))
 	}
 
-	return o.parseVerboseResponse(respBody)
+	result, err := o.parseVerboseResponse(respBody)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Translate {
+		// Translations are always English, whatever language is reported.
+		result.Language = "en"
+	}
+	return result, nil
 }
 
 func (o *OpenAI) buildMultipart(audioPath string, opts TranscribeOpts) (*bytes.Buffer, string, error) {
@@ -90,12 +105,20 @@ func (o *OpenAI) buildMultipart(audioPath string, opts TranscribeOpts) (*bytes.B
 	if model == "" {
 		model = o.defaultModel
 	}
+	if opts.Translate {
+		// The translations endpoint only serves whisper-1.
+		model = openaiTranslationModel
+	}
 	w.WriteField("model", model)
 	w.WriteField("response_format", "verbose_json")
 
-	if opts.Language != "" {
+	// The translations endpoint takes no language: its output is English.
+	if opts.Language != "" && !opts.Translate {
 		w.WriteField("language", opts.Language)
 	}
+	if len(opts.Vocabulary) > 0 {
+		w.WriteField("prompt", vocabularyPrompt(opts.Vocabulary))
+	}
 
 	if err := w.Close(); err != nil {
 		return nil, "", err
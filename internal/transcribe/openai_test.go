@@ -79,3 +79,59 @@ func TestOpenAIRoundTrip(t *testing.T) {
 		t.Errorf("expected 'hello', got %q", result.Text)
 	}
 }
+
+func TestOpenAIMultipartVocabularyPrompt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatal(err)
+		}
+		if got := r.FormValue("prompt"); got != "AudioMemo, Joe Goldin" {
+			t.Errorf("prompt = %q", got)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"text": "ok"})
+	}))
+	defer server.Close()
+
+	o := NewOpenAI("test-key", "gpt-4o-transcribe")
+	o.baseURL = server.URL
+
+	tmp := filepath.Join(t.TempDir(), "test.ogg")
+	os.WriteFile(tmp, []byte("fake"), 0644)
+
+	if _, err := o.Transcribe(t.Context(), tmp, TranscribeOpts{Vocabulary: []string{"AudioMemo", "Joe Goldin"}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenAITranslateUsesTranslationsEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/translations" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatal(err)
+		}
+		if got := r.FormValue("model"); got != "whisper-1" {
+			t.Errorf("model = %q, want whisper-1", got)
+		}
+		if got := r.FormValue("language"); got != "" {
+			t.Errorf("language sent to translations: %q", got)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"text": "hello", "language": "german"})
+	}))
+	defer server.Close()
+
+	o := NewOpenAI("test-key", "gpt-4o-transcribe")
+	o.baseURL = server.URL
+
+	tmp := filepath.Join(t.TempDir(), "test.ogg")
+	os.WriteFile(tmp, []byte("fake"), 0644)
+
+	result, err := o.Transcribe(t.Context(), tmp, TranscribeOpts{Language: "de", Translate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Language != "en" {
+		t.Errorf("language = %q, want en", result.Language)
+	}
+}
@@ -0,0 +1,272 @@
+package transcribe
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// DefaultFillers are the hesitation sounds removed when filler removal is on
+// and no list is configured. Words that are also real words ("like", "so",
+// "well") are left out: telling the two uses apart needs more judgement than
+// a word list has.
+var DefaultFillers = []string{"um", "umm", "uh", "uhh", "er", "erm", "ah", "hmm", "mm"}
+
+// Replacement is one entry of the find/replace dictionary. A literal From
+// matches whole words regardless of case, which is what a misheard name
+// needs; a regex From is used exactly as written, and To may refer to its
+// groups as $1.
+type Replacement struct {
+	From  string
+	To    string
+	Regex bool
+}
+
+// PostProcess selects the cleanup steps run on a transcript before it is
+// formatted. The zero value changes nothing.
+type PostProcess struct {
+	RemoveFillers     bool
+	Fillers           []string // nil means DefaultFillers
+	Replacements      []Replacement
+	FixCapitalization bool
+	// ParagraphGap merges consecutive segments from the same speaker into
+	// paragraphs, starting a new one at any pause at least this long, in
+	// seconds. Zero leaves the backend's segments alone.
+	ParagraphGap float64
+}
+
+// PostProcessor applies a PostProcess. Build one with NewPostProcessor so a
+// bad pattern in the config is reported before any audio is sent anywhere.
+// A nil *PostProcessor is valid and changes nothing.
+type PostProcessor struct {
+	fillers map[string]bool
+	replace []compiledReplacement
+	caps    bool
+	gap     float64
+}
+
+type compiledReplacement struct {
+	re      *regexp.Regexp
+	to      string
+	literal bool
+}
+
+// NewPostProcessor validates p and compiles its replacement patterns.
+func NewPostProcessor(p PostProcess) (*PostProcessor, error) {
+	pp := &PostProcessor{caps: p.FixCapitalization, gap: p.ParagraphGap}
+	if p.RemoveFillers {
+		fillers := p.Fillers
+		if fillers == nil {
+			fillers = DefaultFillers
+		}
+		pp.fillers = make(map[string]bool, len(fillers))
+		for _, f := range fillers {
+			pp.fillers[strings.ToLower(strings.TrimSpace(f))] = true
+		}
+	}
+	for _, r := range p.Replacements {
+		if r.From == "" {
+			return nil, fmt.Errorf("replacement for %q has an empty pattern", r.To)
+		}
+		pattern := r.From
+		if !r.Regex {
+			pattern = literalPattern(r.From)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("replacement pattern %q: %w", r.From, err)
+		}
+		pp.replace = append(pp.replace, compiledReplacement{re: re, to: r.To, literal: !r.Regex})
+	}
+	if p.ParagraphGap < 0 {
+		return nil, fmt.Errorf("paragraph gap must not be negative, got %v", p.ParagraphGap)
+	}
+	return pp, nil
+}
+
+// literalPattern matches s case-insensitively as whole words. The word
+// boundary is only asserted at an end that is a word character, so an entry
+// like "C++" still matches.
+func literalPattern(s string) string {
+	pattern := "(?i)" + regexp.QuoteMeta(s)
+	if isWordByte(s[0]) {
+		pattern = `(?i)\b` + regexp.QuoteMeta(s)
+	}
+	if isWordByte(s[len(s)-1]) {
+		pattern += `\b`
+	}
+	return pattern
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// Apply cleans r in place: its text, each segment, and, when a paragraph gap
+// is set, the segmentation itself. Segments left empty, such as a lone "Um.",
+// are dropped.
+func (p *PostProcessor) Apply(r *Result) {
+	if p == nil || r == nil {
+		return
+	}
+	r.Text = p.cleanText(r.Text, true)
+
+	segs := r.Segments[:0]
+	sentenceStart := true
+	for _, seg := range r.Segments {
+		seg.Text = p.cleanText(seg.Text, sentenceStart)
+		if strings.TrimSpace(seg.Text) == "" {
+			continue
+		}
+		sentenceStart = endsSentence(seg.Text)
+		segs = append(segs, seg)
+	}
+	r.Segments = segs
+
+	if p.gap > 0 && len(r.Segments) > 0 {
+		r.Segments = mergeParagraphs(r.Segments, p.gap)
+		paras := make([]string, len(r.Segments))
+		for i, seg := range r.Segments {
+			paras[i] = seg.Text
+		}
+		r.Text = strings.Join(paras, "\n\n")
+	}
+}
+
+// ApplyText cleans one piece of live text, such as a realtime commit. Each
+// commit is treated as starting a sentence; paragraphing needs timestamps,
+// so it only applies to whole results.
+func (p *PostProcessor) ApplyText(text string) string {
+	if p == nil {
+		return text
+	}
+	return p.cleanText(text, true)
+}
+
+func (p *PostProcessor) cleanText(text string, sentenceStart bool) string {
+	if len(p.fillers) > 0 {
+		lines := strings.Split(text, "\n")
+		for i, line := range lines {
+			lines[i] = p.removeFillers(line)
+		}
+		text = strings.Join(lines, "\n")
+	}
+	for _, r := range p.replace {
+		if r.literal {
+			text = r.re.ReplaceAllLiteralString(text, r.to)
+		} else {
+			text = r.re.ReplaceAllString(text, r.to)
+		}
+	}
+	if p.caps {
+		text = fixCapitalization(text, sentenceStart)
+	}
+	return text
+}
+
+// removeFillers drops filler words from one line. A filler that closed a
+// sentence ("I think, um.") hands its full stop to the word before it, so the
+// sentence still ends.
+func (p *PostProcessor) removeFillers(line string) string {
+	words := strings.Fields(line)
+	var out []string
+	changed := false
+	for _, w := range words {
+		lead, trail := splitVocabPunct(w)
+		if !p.fillers[strings.ToLower(w[len(lead):len(w)-len(trail)])] {
+			out = append(out, w)
+			continue
+		}
+		changed = true
+		if i := strings.IndexAny(trail, ".?!"); i >= 0 && len(out) > 0 {
+			prev := strings.TrimRight(out[len(out)-1], ",;:")
+			if !endsSentence(prev) {
+				prev += trail[i : i+1]
+			}
+			out[len(out)-1] = prev
+		}
+	}
+	if !changed {
+		return line
+	}
+	return strings.Join(out, " ")
+}
+
+var (
+	multiSpace       = regexp.MustCompile(` {2,}`)
+	spaceBeforePunct = regexp.MustCompile(` +([,.!?;:])`)
+	loneI            = regexp.MustCompile(`\bi\b`)
+)
+
+// notSentenceEnds are abbreviations whose full stop does not end a sentence.
+var notSentenceEnds = map[string]bool{"e.g": true, "i.e": true, "vs": true, "cf": true, "approx": true}
+
+// fixCapitalization tidies the casing and spacing whisper tends to get wrong
+// in long recordings: a lowercase start to a sentence, a lowercase "i", and
+// stray spaces around punctuation. It never lowercases anything, so names the
+// backend capitalised are safe.
+func fixCapitalization(text string, sentenceStart bool) string {
+	text = multiSpace.ReplaceAllString(text, " ")
+	text = spaceBeforePunct.ReplaceAllString(text, "$1")
+
+	// "i" alone or before an apostrophe is the pronoun; "i.e." is not.
+	var b strings.Builder
+	last := 0
+	for _, loc := range loneI.FindAllStringIndex(text, -1) {
+		if strings.HasPrefix(text[loc[1]:], ".e") {
+			continue
+		}
+		b.WriteString(text[last:loc[0]])
+		b.WriteString("I")
+		last = loc[1]
+	}
+	b.WriteString(text[last:])
+	text = b.String()
+
+	runes := []rune(text)
+	capNext := sentenceStart
+	wordStart := 0
+	for i, r := range runes {
+		switch {
+		case unicode.IsLetter(r):
+			if capNext {
+				runes[i] = unicode.ToUpper(r)
+				capNext = false
+			}
+		case unicode.IsDigit(r):
+			capNext = false
+		case r == '.' || r == '!' || r == '?':
+			word := strings.ToLower(string(runes[wordStart:i]))
+			if r != '.' || !notSentenceEnds[word] {
+				capNext = i+1 == len(runes) || unicode.IsSpace(runes[i+1])
+			}
+		case unicode.IsSpace(r):
+			wordStart = i + 1
+		}
+	}
+	return string(runes)
+}
+
+// endsSentence reports whether text ends with sentence-closing punctuation,
+// looking past closing quotes and brackets.
+func endsSentence(text string) bool {
+	text = strings.TrimRight(strings.TrimSpace(text), `"')]”’`)
+	return strings.HasSuffix(text, ".") || strings.HasSuffix(text, "!") || strings.HasSuffix(text, "?")
+}
+
+// mergeParagraphs joins consecutive segments from the same speaker whose
+// pause is shorter than gap. A change of speaker always starts a paragraph.
+func mergeParagraphs(segs []Segment, gap float64) []Segment {
+	var out []Segment
+	for _, seg := range segs {
+		seg.Text = strings.TrimSpace(seg.Text)
+		if n := len(out); n > 0 && seg.Speaker == out[n-1].Speaker && seg.Start-out[n-1].End < gap {
+			out[n-1].End = seg.End
+			out[n-1].Text += " " + seg.Text
+			continue
+		}
+		out = append(out, seg)
+	}
+	return out
+}
@@ -0,0 +1,135 @@
+package transcribe
+
+import (
+	"reflect"
+	"testing"
+)
+
+func mustPostProcessor(t *testing.T, p PostProcess) *PostProcessor {
+	t.Helper()
+	pp, err := NewPostProcessor(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pp
+}
+
+func TestRemoveFillers(t *testing.T) {
+	p := mustPostProcessor(t, PostProcess{RemoveFillers: true})
+	tests := []struct {
+		in, want string
+	}{
+		{"um so we start", "so we start"},
+		{"So, um, we start", "So, we start"},
+		{"I think, uh.", "I think."},
+		{"Really? Hmm?", "Really?"},
+		{"Umm. Uhh.", ""},
+		{"uh-huh, the umbrella", "uh-huh, the umbrella"},
+		{"first line um\nsecond uh line", "first line\nsecond line"},
+	}
+	for _, tt := range tests {
+		if got := p.ApplyText(tt.in); got != tt.want {
+			t.Errorf("ApplyText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRemoveFillersCustomList(t *testing.T) {
+	p := mustPostProcessor(t, PostProcess{RemoveFillers: true, Fillers: []string{"like"}})
+	if got := p.ApplyText("it was, like, um, fine"); got != "it was, um, fine" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestReplacements(t *testing.T) {
+	p := mustPostProcessor(t, PostProcess{Replacements: []Replacement{
+		{From: "cooper netties", To: "Kubernetes"},
+		{From: "C++", To: "C plus plus"},
+		{From: `(\d+) percent`, To: "$1%", Regex: true},
+		{From: "cost", To: "$cost"},
+	}})
+	got := p.ApplyText("Cooper Netties costs 50 percent of the cost in C++")
+	want := "Kubernetes costs 50% of the $cost in C plus plus"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestNewPostProcessorRejectsBadPattern(t *testing.T) {
+	if _, err := NewPostProcessor(PostProcess{Replacements: []Replacement{{From: "(", Regex: true}}}); err == nil {
+		t.Error("expected an error for an invalid regex")
+	}
+	if _, err := NewPostProcessor(PostProcess{Replacements: []Replacement{{To: "x"}}}); err == nil {
+		t.Error("expected an error for an empty pattern")
+	}
+}
+
+func TestFixCapitalization(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"hello there. how are you? i'm fine", "Hello there. How are you? I'm fine"},
+		{"so i said  no , i.e. never", "So I said no, i.e. never"},
+		{"pi is 3.14 exactly", "Pi is 3.14 exactly"},
+		{"e.g. this one", "E.g. this one"},
+		{"keep iPhone and NASA", "Keep iPhone and NASA"},
+	}
+	for _, tt := range tests {
+		if got := fixCapitalization(tt.in, true); got != tt.want {
+			t.Errorf("fixCapitalization(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestApplyCapitalizesSegmentsAtSentenceStarts(t *testing.T) {
+	p := mustPostProcessor(t, PostProcess{FixCapitalization: true})
+	r := &Result{Segments: []Segment{
+		{Text: "we started late"},
+		{Text: "because of traffic."},
+		{Text: "then it rained"},
+	}}
+	p.Apply(r)
+	var got []string
+	for _, seg := range r.Segments {
+		got = append(got, seg.Text)
+	}
+	want := []string{"We started late", "because of traffic.", "Then it rained"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestApplyMergesParagraphsByPause(t *testing.T) {
+	p := mustPostProcessor(t, PostProcess{RemoveFillers: true, ParagraphGap: 1.5})
+	r := &Result{
+		Text: "One. Two. Um. Three. Four.",
+		Segments: []Segment{
+			{Start: 0, End: 1, Text: "One."},
+			{Start: 1.2, End: 2, Text: " Two."},
+			{Start: 2.1, End: 2.4, Text: "Um."},
+			{Start: 5, End: 6, Text: "Three."},
+			{Start: 6.5, End: 7, Text: "Four.", Speaker: "Bob"},
+		},
+	}
+	p.Apply(r)
+	want := []Segment{
+		{Start: 0, End: 2, Text: "One. Two."},
+		{Start: 5, End: 6, Text: "Three."},
+		{Start: 6.5, End: 7, Text: "Four.", Speaker: "Bob"},
+	}
+	if !reflect.DeepEqual(r.Segments, want) {
+		t.Errorf("segments = %+v, want %+v", r.Segments, want)
+	}
+	if r.Text != "One. Two.\n\nThree.\n\nFour." {
+		t.Errorf("text = %q", r.Text)
+	}
+}
+
+func TestNilPostProcessorChangesNothing(t *testing.T) {
+	var p *PostProcessor
+	r := &Result{Text: "um hello"}
+	p.Apply(r)
+	if r.Text != "um hello" || p.ApplyText("uh") != "uh" {
+		t.Error("nil processor changed the text")
+	}
+}
@@ -0,0 +1,181 @@
+package transcribe
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Progress hears what a backend learns while it works, so `transcribe
+// --stream` can show more than a spinner for a long file. Backends call it
+// from whichever goroutine reads their output; a nil Progress in
+// TranscribeOpts means nobody is listening and nothing is parsed.
+type Progress interface {
+	// Uploaded reports that sent of total bytes have gone to the API.
+	Uploaded(sent, total int64)
+	// Processed reports how much of the audio a local model has got
+	// through, as a percentage, when the tool prints one.
+	Processed(percent float64)
+	// Segment reports a segment as soon as the backend has it, before any
+	// post-processing. Backends that only answer at the end never call it.
+	Segment(seg Segment)
+}
+
+// trackUpload makes req report its body's progress to p. The wrapped body
+// no longer tells net/http its length, so size is set explicitly; without it
+// the upload would go out chunked.
+func trackUpload(req *http.Request, size int64, p Progress) {
+	if p == nil || req.Body == nil || size <= 0 {
+		return
+	}
+	req.Body = &uploadReader{r: req.Body, total: size, p: p}
+	req.ContentLength = size
+	// A retry from GetBody would bypass the count; there are none here.
+	req.GetBody = nil
+}
+
+type uploadReader struct {
+	r     io.ReadCloser
+	sent  int64
+	total int64
+	p     Progress
+}
+
+func (u *uploadReader) Read(b []byte) (int, error) {
+	n, err := u.r.Read(b)
+	if n > 0 {
+		u.sent += int64(n)
+		u.p.Uploaded(u.sent, u.total)
+	}
+	return n, err
+}
+
+func (u *uploadReader) Close() error { return u.r.Close() }
+
+var (
+	// whisper.cpp with -pp: "whisper_print_progress_callback: progress =  35%"
+	// whisperx with --print_progress True: "Progress: 35.00%..."
+	whisperProgressRe = regexp.MustCompile(`(?:progress =|Progress:)\s*([0-9]+(?:\.[0-9]+)?)%`)
+	// Both whisper.cpp and Python whisper print each segment as it is
+	// decoded: "[00:01:02.500 --> 00:01:05.000]  text", with the hours left
+	// out by Python whisper for audio under an hour.
+	whisperSegmentRe = regexp.MustCompile(`^\[((?:\d+:)?\d+:\d+\.\d+) --> ((?:\d+:)?\d+:\d+\.\d+)\]\s*(.*)$`)
+)
+
+// parseWhisperProgress reads a percentage from a whisper.cpp or whisperx
+// progress line.
+func parseWhisperProgress(line string) (float64, bool) {
+	m := whisperProgressRe.FindStringSubmatch(line)
+	if m == nil {
+		return 0, false
+	}
+	pct, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	return pct, true
+}
+
+// parseWhisperSegment reads one segment line as whisper.cpp and Python
+// whisper print them while decoding.
+func parseWhisperSegment(line string) (Segment, bool) {
+	m := whisperSegmentRe.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return Segment{}, false
+	}
+	return Segment{Start: segmentTimestamp(m[1]), End: segmentTimestamp(m[2]), Text: strings.TrimSpace(m[3])}, true
+}
+
+// segmentTimestamp is parseTimestamp for both "HH:MM:SS.mmm" and the
+// "MM:SS.mmm" Python whisper prints under an hour.
+func segmentTimestamp(ts string) float64 {
+	if strings.Count(ts, ":") == 1 {
+		ts = "00:" + ts
+	}
+	return parseTimestamp(ts)
+}
+
+// lineWriter calls fn for each line written to it. Progress bars redraw with
+// a carriage return, so that ends a line too.
+type lineWriter struct {
+	mu  sync.Mutex
+	buf []byte
+	fn  func(line string)
+	tee io.Writer
+}
+
+func (lw *lineWriter) Write(b []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if lw.tee != nil {
+		lw.tee.Write(b)
+	}
+	lw.buf = append(lw.buf, b...)
+	for {
+		i := bytes.IndexAny(lw.buf, "\r\n")
+		if i < 0 {
+			break
+		}
+		if line := string(lw.buf[:i]); line != "" {
+			lw.fn(line)
+		}
+		lw.buf = lw.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+// flush hands over a last line that had no newline.
+func (lw *lineWriter) flush() {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if len(lw.buf) > 0 {
+		lw.fn(string(lw.buf))
+		lw.buf = nil
+	}
+}
+
+// watchWhisper points cmd's output at p. whisper.cpp prints segments on
+// stdout and progress on stderr, whisperx progress on stdout and Python
+// whisper segments on stdout, so both streams are read for both. With
+// verbose, stderr still reaches the terminal as it did before.
+func watchWhisper(cmd *exec.Cmd, p Progress, verbose io.Writer) (flush func()) {
+	handle := func(line string) {
+		if pct, ok := parseWhisperProgress(line); ok {
+			p.Processed(pct)
+		} else if seg, ok := parseWhisperSegment(line); ok {
+			p.Segment(seg)
+		}
+	}
+	stdout := &lineWriter{fn: handle}
+	stderr := &lineWriter{fn: handle, tee: verbose}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	return func() {
+		stdout.flush()
+		stderr.flush()
+	}
+}
+
+// ProbeDuration asks ffprobe how long the audio at path is, in seconds.
+func ProbeDuration(ctx context.Context, path string) (float64, error) {
+	out, err := exec.CommandContext(ctx, "ffprobe",
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		path,
+	).Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe: %w", err)
+	}
+	d, err := strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe: unexpected duration %q", strings.TrimSpace(string(out)))
+	}
+	return d, nil
+}
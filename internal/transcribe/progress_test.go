@@ -0,0 +1,142 @@
+package transcribe
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingProgress keeps everything a backend reports.
+type recordingProgress struct {
+	mu       sync.Mutex
+	uploads  [][2]int64
+	percents []float64
+	segments []Segment
+}
+
+func (r *recordingProgress) Uploaded(sent, total int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.uploads = append(r.uploads, [2]int64{sent, total})
+}
+
+func (r *recordingProgress) Processed(percent float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.percents = append(r.percents, percent)
+}
+
+func (r *recordingProgress) Segment(seg Segment) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.segments = append(r.segments, seg)
+}
+
+func TestParseWhisperProgress(t *testing.T) {
+	tests := []struct {
+		line string
+		want float64
+		ok   bool
+	}{
+		{"whisper_print_progress_callback: progress =  35%", 35, true},
+		{"whisper_print_progress_callback: progress = 100%", 100, true},
+		{"Progress: 12.50%...", 12.5, true},
+		{"whisper_init_from_file: loading model", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseWhisperProgress(tt.line)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%q = %v, %v; want %v, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseWhisperSegment(t *testing.T) {
+	tests := []struct {
+		line string
+		want Segment
+		ok   bool
+	}{
+		// whisper.cpp
+		{"[00:01:02.500 --> 00:01:05.000]   And so my fellow Americans", Segment{Start: 62.5, End: 65, Text: "And so my fellow Americans"}, true},
+		// Python whisper, under an hour
+		{"[02:03.000 --> 02:04.250]  ask not", Segment{Start: 123, End: 124.25, Text: "ask not"}, true},
+		{"Detected language: English", Segment{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseWhisperSegment(tt.line)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%q = %+v, %v; want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLineWriterSplitsOnCarriageReturns(t *testing.T) {
+	var lines []string
+	lw := &lineWriter{fn: func(line string) { lines = append(lines, line) }}
+	io.WriteString(lw, "Progress: 10.00%...\rProgress: 2")
+	io.WriteString(lw, "0.00%...\r\n[00:00.000 --> 00:01.000] hi")
+	lw.flush()
+	want := []string{"Progress: 10.00%...", "Progress: 20.00%...", "[00:00.000 --> 00:01.000] hi"}
+	if !slices.Equal(lines, want) {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+}
+
+func TestUploadProgressKeepsContentLength(t *testing.T) {
+	audio := strings.Repeat("x", 100_000)
+	var gotLength int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotLength = r.ContentLength
+		io.Copy(io.Discard, r.Body)
+		json.NewEncoder(w).Encode(map[string]any{
+			"metadata": map[string]any{"duration": 1.0},
+			"results": map[string]any{
+				"channels": []any{map[string]any{"alternatives": []any{map[string]any{"transcript": "test"}}}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	d := NewDeepgram("test-key", "nova-3")
+	d.baseURL = server.URL
+	tmp := filepath.Join(t.TempDir(), "test.ogg")
+	os.WriteFile(tmp, []byte(audio), 0644)
+
+	p := &recordingProgress{}
+	if _, err := d.Transcribe(t.Context(), tmp, TranscribeOpts{Progress: p}); err != nil {
+		t.Fatal(err)
+	}
+	if gotLength != int64(len(audio)) {
+		t.Errorf("server saw Content-Length %d, want %d", gotLength, len(audio))
+	}
+	if len(p.uploads) == 0 {
+		t.Fatal("no upload progress")
+	}
+	if last := p.uploads[len(p.uploads)-1]; last != [2]int64{int64(len(audio)), int64(len(audio))} {
+		t.Errorf("last upload = %v", last)
+	}
+}
+
+func TestWhisperProgressFlags(t *testing.T) {
+	p := &recordingProgress{}
+	cpp := NewWhisper("whisper-cli", "base").buildArgs("/tmp/a.wav", "/tmp/out", TranscribeOpts{Progress: p})
+	if !slices.Contains(cpp, "-pp") {
+		t.Errorf("whisper-cli args %v lack -pp", cpp)
+	}
+	x := NewWhisper("whisperx", "base").buildArgs("/tmp/a.wav", "/tmp/out", TranscribeOpts{Progress: p})
+	if i := slices.Index(x, "--print_progress"); i < 0 || x[i+1] != "True" {
+		t.Errorf("whisperx args %v lack --print_progress True", x)
+	}
+	// Nobody listening, nothing asked for.
+	if plain := NewWhisper("whisper-cli", "base").buildArgs("/tmp/a.wav", "/tmp/out", TranscribeOpts{}); slices.Contains(plain, "-pp") {
+		t.Errorf("args %v have -pp without progress", plain)
+	}
+}
@@ -0,0 +1,355 @@
+package transcribe
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Redaction categories, the things a Redactor masks.
+const (
+	RedactEmail = "email"
+	RedactPhone = "phone"
+	RedactCard  = "card"  // credit-card-like numbers
+	RedactTerms = "terms" // the configured sensitive terms
+)
+
+// RedactCategories lists every category, in the order reports give them.
+var RedactCategories = []string{RedactEmail, RedactPhone, RedactCard, RedactTerms}
+
+// redactMasks is what each category's matches are replaced with.
+var redactMasks = map[string]string{
+	RedactEmail: "[EMAIL]",
+	RedactPhone: "[PHONE]",
+	RedactCard:  "[CARD]",
+	RedactTerms: "[REDACTED]",
+}
+
+// Redaction sources.
+const (
+	RedactedByBackend = "backend"
+	RedactedLocally   = "local"
+)
+
+// Redaction is one thing masked in a transcript: what kind of thing, when it
+// was said and by whom, and who masked it. What it was is not kept, so a
+// report can go wherever the transcript does.
+type Redaction struct {
+	Category string  `json:"category"`
+	Mask     string  `json:"mask"`
+	Start    float64 `json:"start"`
+	End      float64 `json:"end"`
+	Speaker  string  `json:"speaker,omitempty"`
+	Source   string  `json:"source"`
+	// Estimated is set when no word timings covered the match, and Start and
+	// End are interpolated across its segment by where it fell in the text.
+	Estimated bool `json:"estimated,omitempty"`
+}
+
+// NativeRedaction reports whether t masks categories itself, given them in
+// TranscribeOpts.Redact. Deepgram does, for all but configured terms.
+func NativeRedaction(t Transcriber) bool {
+	_, ok := t.(*Deepgram)
+	return ok
+}
+
+var (
+	emailPattern = regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@[a-z0-9-]+(?:\.[a-z0-9-]+)*\.[a-z]{2,}\b`)
+	// An address read out: "alice at example dot com".
+	spokenEmailPattern = regexp.MustCompile(`(?i)\b[a-z0-9._-]+ at [a-z0-9-]+(?: dot [a-z0-9-]+)* dot (?:com|org|net|edu|gov|io|co|uk|de|fr)\b`)
+	cardPattern        = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	phonePattern       = regexp.MustCompile(`\+?\(?\d[\d ().-]{5,}\d`)
+	datePattern        = regexp.MustCompile(`^\d{4}[-/.]\d{1,2}[-/.]\d{1,2}$|^\d{4} ?- ?\d{4}$`)
+	// The masks backends leave, such as Deepgram's [EMAIL_ADDRESS_1].
+	backendMaskPattern = regexp.MustCompile(`\[[A-Z][A-Z_]*[A-Z](?:_\d+)?\]`)
+)
+
+// Redactor masks emails, phone numbers, card numbers and configured terms in
+// a Result. Build one with NewRedactor. A nil *Redactor is valid and masks
+// nothing.
+type Redactor struct {
+	categories []string
+	terms      *regexp.Regexp
+}
+
+// NewRedactor checks categories and compiles terms, which the terms category
+// masks as whole words regardless of case. It returns nil when categories is
+// empty.
+func NewRedactor(categories, terms []string) (*Redactor, error) {
+	if len(categories) == 0 {
+		return nil, nil
+	}
+	r := &Redactor{}
+	for _, c := range categories {
+		if !slices.Contains(RedactCategories, c) {
+			return nil, fmt.Errorf("unknown redaction category %q (use %s)", c, strings.Join(RedactCategories, ", "))
+		}
+	}
+	for _, c := range RedactCategories {
+		if slices.Contains(categories, c) {
+			r.categories = append(r.categories, c)
+		}
+	}
+	if slices.Contains(r.categories, RedactTerms) {
+		var words []string
+		for _, t := range terms {
+			if t = strings.TrimSpace(t); t != "" {
+				words = append(words, t)
+			}
+		}
+		if len(words) == 0 {
+			return nil, fmt.Errorf("redacting terms needs a list of them")
+		}
+		// Longest first, so "Project Falcon" is masked whole before "Falcon".
+		sort.Slice(words, func(i, j int) bool { return len(words[i]) > len(words[j]) })
+		patterns := make([]string, len(words))
+		for i, w := range words {
+			patterns[i] = strings.TrimPrefix(literalPattern(w), "(?i)")
+		}
+		r.terms = regexp.MustCompile(`(?i)(?:` + strings.Join(patterns, "|") + `)`)
+	}
+	return r, nil
+}
+
+// Categories are the categories r masks, in RedactCategories order.
+func (r *Redactor) Categories() []string {
+	if r == nil {
+		return nil
+	}
+	return r.categories
+}
+
+// Apply masks r's categories in res, in place: its text and each segment. It
+// returns what it masked in the segments, in the order it was said. With
+// native, the backend has already masked what it could, and the masks it
+// left are reported and replaced with Redactor's own, so a transcript reads
+// the same whichever did the masking.
+func (r *Redactor) Apply(res *Result, native bool) []Redaction {
+	if r == nil || res == nil {
+		return nil
+	}
+	if len(res.Segments) == 0 {
+		// With no segments there is only the text, spoken over the whole
+		// recording, to place things in.
+		seg := Segment{Start: 0, End: res.Duration, Text: res.Text}
+		out := r.redactSegment(&seg, res.Words, native)
+		res.Text = seg.Text
+		return out
+	}
+	res.Text = maskSpans(res.Text, r.find(res.Text, native))
+	var out []Redaction
+	for i := range res.Segments {
+		out = append(out, r.redactSegment(&res.Segments[i], res.Words, native)...)
+	}
+	return out
+}
+
+// redactSegment masks seg and places what it masked in time.
+func (r *Redactor) redactSegment(seg *Segment, words []Word, native bool) []Redaction {
+	spans := r.find(seg.Text, native)
+	if len(spans) == 0 {
+		return nil
+	}
+	placed := placeWords(seg.Text, words, seg.Start, seg.End)
+	out := make([]Redaction, len(spans))
+	for i, sp := range spans {
+		out[i] = Redaction{
+			Category: sp.category,
+			Mask:     redactMasks[sp.category],
+			Speaker:  seg.Speaker,
+			Source:   sp.source,
+		}
+		out[i].Start, out[i].End, out[i].Estimated = sp.times(seg, placed)
+	}
+	seg.Text = maskSpans(seg.Text, spans)
+	return out
+}
+
+// span is a stretch of text, as byte offsets, to mask.
+type span struct {
+	start, end int
+	category   string
+	source     string
+}
+
+// find lists the spans of text to mask, in order and not overlapping. When
+// two matches overlap, the backend's mask wins, then emails, then cards, so
+// that a card number is not also taken for a phone number.
+func (r *Redactor) find(text string, native bool) []span {
+	var spans []span
+	add := func(start, end int, category, source string) {
+		for _, sp := range spans {
+			if start < sp.end && sp.start < end {
+				return
+			}
+		}
+		spans = append(spans, span{start, end, category, source})
+	}
+	if native {
+		for _, loc := range backendMaskPattern.FindAllStringIndex(text, -1) {
+			if c := backendMaskCategory(text[loc[0]:loc[1]]); c != "" {
+				add(loc[0], loc[1], c, RedactedByBackend)
+			}
+		}
+	}
+	for _, c := range []string{RedactEmail, RedactCard, RedactPhone, RedactTerms} {
+		if !slices.Contains(r.categories, c) {
+			continue
+		}
+		for _, loc := range r.matches(c, text) {
+			add(loc[0], loc[1], c, RedactedLocally)
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	return spans
+}
+
+// matches finds category c in text.
+func (r *Redactor) matches(c, text string) [][]int {
+	switch c {
+	case RedactEmail:
+		return append(emailPattern.FindAllStringIndex(text, -1), spokenEmailPattern.FindAllStringIndex(text, -1)...)
+	case RedactCard:
+		return filterMatches(text, cardPattern.FindAllStringIndex(text, -1), func(s string) bool { return luhn(digits(s)) })
+	case RedactPhone:
+		return filterMatches(text, phonePattern.FindAllStringIndex(text, -1), phoneLike)
+	case RedactTerms:
+		return r.terms.FindAllStringIndex(text, -1)
+	}
+	return nil
+}
+
+func filterMatches(text string, locs [][]int, keep func(string) bool) [][]int {
+	var out [][]int
+	for _, loc := range locs {
+		if keep(text[loc[0]:loc[1]]) {
+			out = append(out, loc)
+		}
+	}
+	return out
+}
+
+// backendMaskCategory is the category of a mask a backend left, or empty for
+// a bracketed tag that is not one, such as whisper's [MUSIC].
+func backendMaskCategory(mask string) string {
+	switch {
+	case strings.Contains(mask, "EMAIL"):
+		return RedactEmail
+	case strings.Contains(mask, "PHONE"):
+		return RedactPhone
+	case strings.Contains(mask, "CARD"), strings.Contains(mask, "CVV"), strings.Contains(mask, "PCI"):
+		return RedactCard
+	}
+	return ""
+}
+
+func digits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// luhn reports whether the digits pass the Luhn check every card number
+// does, which keeps long numbers of other kinds from being masked as cards.
+func luhn(digits string) bool {
+	sum := 0
+	for i := range len(digits) {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return len(digits) > 0 && sum%10 == 0
+}
+
+// phoneLike reports whether a run of digits and separators looks like a
+// phone number rather than a date, a range of years or a plain number: 7 to
+// 15 digits, grouped or led by +, or at least 10 of them together.
+func phoneLike(s string) bool {
+	n := len(digits(s))
+	if n < 7 || n > 15 || datePattern.MatchString(s) {
+		return false
+	}
+	return strings.HasPrefix(s, "+") || n >= 10 || strings.ContainsAny(s, " ().-")
+}
+
+// placedWord is a word found in a segment's text.
+type placedWord struct {
+	start, end int
+	word       Word
+}
+
+// placeWords finds the words said between from and to in text, in order.
+// Words the text no longer has, such as fillers the post-processing took
+// out, are skipped.
+func placeWords(text string, words []Word, from, to float64) []placedWord {
+	const slack = 0.05
+	lower := strings.ToLower(text)
+	var placed []placedWord
+	at := 0
+	for _, w := range words {
+		if w.Start < from-slack || w.End > to+slack {
+			continue
+		}
+		lead, trail := splitVocabPunct(w.Text)
+		needle := strings.ToLower(w.Text[len(lead) : len(w.Text)-len(trail)])
+		if needle == "" {
+			continue
+		}
+		i := strings.Index(lower[at:], needle)
+		if i < 0 {
+			continue
+		}
+		start := at + i
+		at = start + len(needle)
+		placed = append(placed, placedWord{start, at, w})
+	}
+	return placed
+}
+
+// times is when sp was said: from the first word it covers to the last, or,
+// when it covers none, where it falls in seg's text scaled to seg's time.
+func (sp span) times(seg *Segment, placed []placedWord) (start, end float64, estimated bool) {
+	found := false
+	for _, p := range placed {
+		if p.start < sp.end && sp.start < p.end {
+			if !found || p.word.Start < start {
+				start = p.word.Start
+			}
+			if !found || p.word.End > end {
+				end = p.word.End
+			}
+			found = true
+		}
+	}
+	if found {
+		return start, end, false
+	}
+	n := float64(max(len(seg.Text), 1))
+	length := seg.End - seg.Start
+	return seg.Start + length*float64(sp.start)/n, seg.Start + length*float64(sp.end)/n, true
+}
+
+// maskSpans replaces each span of text with its category's mask.
+func maskSpans(text string, spans []span) string {
+	if len(spans) == 0 {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, sp := range spans {
+		b.WriteString(text[last:sp.start])
+		b.WriteString(redactMasks[sp.category])
+		last = sp.end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
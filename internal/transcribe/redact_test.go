@@ -0,0 +1,123 @@
+package transcribe
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactorCategories(t *testing.T) {
+	r, err := NewRedactor(RedactCategories, []string{"Project Falcon", "Falcon"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		in, want string
+	}{
+		{"Mail me at alice.smith@example.co.uk today.", "Mail me at [EMAIL] today."},
+		{"It's bob at example dot com.", "It's [EMAIL]."},
+		{"Call 555-123-4567 or +44 20 7946 0958.", "Call [PHONE] or [PHONE]."},
+		{"My number is (555) 123 4567.", "My number is [PHONE]."},
+		{"The card is 4111 1111 1111 1111, expiring soon.", "The card is [CARD], expiring soon."},
+		{"Amex 378282246310005 please.", "Amex [CARD] please."},
+		// Neither passes for a phone number or a card.
+		{"On 2026-10-18 we sold 1234567 units, 1234 5678 9012 3456 of them.", "On 2026-10-18 we sold 1234567 units, 1234 5678 9012 3456 of them."},
+		{"Project Falcon ships, and so does falcon two.", "[REDACTED] ships, and so does [REDACTED] two."},
+		{"Falconry is fine.", "Falconry is fine."},
+	}
+	for _, tt := range tests {
+		res := &Result{Text: tt.in}
+		r.Apply(res, false)
+		if res.Text != tt.want {
+			t.Errorf("Apply(%q) = %q, want %q", tt.in, res.Text, tt.want)
+		}
+	}
+}
+
+func TestRedactorPlacesWithWords(t *testing.T) {
+	r, _ := NewRedactor([]string{RedactEmail, RedactPhone}, nil)
+	res := &Result{
+		Text: "Hi. Write to alice@example.com. Or call 555-123-4567.",
+		Segments: []Segment{
+			{Start: 0, End: 1, Text: "Hi."},
+			{Start: 1, End: 4, Text: "Write to alice@example.com.", Speaker: "Alice"},
+			{Start: 5, End: 9, Text: "Or call 555-123-4567."},
+		},
+		Words: []Word{
+			{0.1, 0.5, "Hi."},
+			{1.2, 1.5, "Write"}, {1.5, 1.7, "to"}, {1.8, 3.1, "alice@example.com."},
+			{5.0, 5.2, "Or"}, {5.3, 5.6, "call"}, {5.8, 8.4, "555-123-4567."},
+		},
+	}
+	got := r.Apply(res, false)
+	if len(got) != 2 {
+		t.Fatalf("redactions = %+v", got)
+	}
+	if got[0] != (Redaction{Category: RedactEmail, Mask: "[EMAIL]", Start: 1.8, End: 3.1, Speaker: "Alice", Source: RedactedLocally}) {
+		t.Errorf("email = %+v", got[0])
+	}
+	if got[1].Category != RedactPhone || got[1].Start != 5.8 || got[1].End != 8.4 || got[1].Estimated {
+		t.Errorf("phone = %+v", got[1])
+	}
+	if res.Text != "Hi. Write to [EMAIL]. Or call [PHONE]." || res.Segments[2].Text != "Or call [PHONE]." {
+		t.Errorf("text = %q, segments = %+v", res.Text, res.Segments)
+	}
+}
+
+func TestRedactorEstimatesWithoutWords(t *testing.T) {
+	r, _ := NewRedactor([]string{RedactPhone}, nil)
+	res := &Result{Segments: []Segment{{Start: 10, End: 20, Text: "0123456789, or 555-123-4567"}}}
+	got := r.Apply(res, false)
+	if len(got) != 2 || !got[1].Estimated {
+		t.Fatalf("redactions = %+v", got)
+	}
+	// The second number is the last 12 of 27 bytes.
+	if got[1].Start < 15.5 || got[1].Start > 15.6 || got[1].End != 20 {
+		t.Errorf("placed at %v-%v", got[1].Start, got[1].End)
+	}
+}
+
+func TestRedactorNative(t *testing.T) {
+	r, _ := NewRedactor([]string{RedactEmail, RedactCard}, nil)
+	res := &Result{
+		Text:     "Send [EMAIL_ADDRESS_1] the [CREDIT_CARD_1]. [MUSIC]",
+		Segments: []Segment{{Start: 0, End: 4, Text: "Send [EMAIL_ADDRESS_1] the [CREDIT_CARD_1]. [MUSIC]"}},
+		Words:    []Word{{0, 0.4, "Send"}, {0.5, 1.5, "[EMAIL_ADDRESS_1]"}, {1.6, 1.8, "the"}, {2.0, 3.0, "[CREDIT_CARD_1]."}},
+	}
+	got := r.Apply(res, true)
+	if len(got) != 2 || got[0].Source != RedactedByBackend || got[1].Category != RedactCard || got[1].Start != 2.0 {
+		t.Errorf("redactions = %+v", got)
+	}
+	if want := "Send [EMAIL] the [CARD]. [MUSIC]"; res.Text != want || res.Segments[0].Text != want {
+		t.Errorf("text = %q", res.Text)
+	}
+
+	// Without native, a bracketed tag is the transcript's own.
+	res = &Result{Text: "[EMAIL_ADDRESS_1]"}
+	if got := r.Apply(res, false); len(got) != 0 {
+		t.Errorf("local pass reported %+v", got)
+	}
+}
+
+func TestNewRedactorErrors(t *testing.T) {
+	if r, err := NewRedactor(nil, []string{"x"}); r != nil || err != nil {
+		t.Errorf("no categories = %v, %v", r, err)
+	}
+	if _, err := NewRedactor([]string{"ssn"}, nil); err == nil || !strings.Contains(err.Error(), `"ssn"`) {
+		t.Errorf("unknown category = %v", err)
+	}
+	if _, err := NewRedactor([]string{RedactTerms}, []string{" "}); err == nil {
+		t.Error("terms without any should fail")
+	}
+	var nilRedactor *Redactor
+	if got := nilRedactor.Apply(&Result{Text: "a@b.com"}, false); got != nil {
+		t.Errorf("nil redactor = %+v", got)
+	}
+}
+
+func TestLuhn(t *testing.T) {
+	for s, want := range map[string]bool{"4111111111111111": true, "4111111111111112": false, "79927398713": true, "": false} {
+		if luhn(s) != want {
+			t.Errorf("luhn(%q) = %v", s, !want)
+		}
+	}
+}
@@ -6,11 +6,23 @@ import (
 	"strings"
 )
 
+// SchemaVersion is written into every JSON transcript and versions its
+// shape, as published by `audiomemo schema result`. The minor number goes up
+// when an optional field is added; the major number when a field is removed,
+// renamed, retyped or made optional.
+const SchemaVersion = "1.0"
+
 type Result struct {
-	Text     string    `json:"text"`
-	Segments []Segment `json:"segments,omitempty"`
-	Language string    `json:"language,omitempty"`
-	Duration float64   `json:"duration,omitempty"`
+	// SchemaVersion is set on the way out by Format; backends leave it empty.
+	SchemaVersion string    `json:"schema_version,omitempty"`
+	Text          string    `json:"text"`
+	Segments      []Segment `json:"segments,omitempty"`
+	Language      string    `json:"language,omitempty"`
+	Duration      float64   `json:"duration,omitempty"`
+	// Words are the timings of the words, from backends that give them. They
+	// are for redaction to place what it masks, and are not written out:
+	// post-processing rewrites the text they were spoken as.
+	Words []Word `json:"-"`
 }
 
 type Segment struct {
@@ -20,6 +32,13 @@ type Segment struct {
 	Speaker string  `json:"speaker,omitempty"`
 }
 
+// Word is one word as the backend heard it, with when it was said.
+type Word struct {
+	Start float64
+	End   float64
+	Text  string
+}
+
 func (r *Result) Format(f OutputFormat) string {
 	switch f {
 	case FormatJSON:
@@ -61,7 +80,9 @@ func (r *Result) formatText() string {
 }
 
 func (r *Result) formatJSON() string {
-	b, _ := json.MarshalIndent(r, "", "  ")
+	out := *r
+	out.SchemaVersion = SchemaVersion
+	b, _ := json.MarshalIndent(out, "", "  ")
 	return string(b)
 }
 
@@ -52,6 +52,12 @@ func TestResultFormatJSON(t *testing.T) {
 	if !strings.Contains(out, `"text"`) {
 		t.Errorf("expected JSON with text field, got:\n%s", out)
 	}
+	if !strings.Contains(out, `"schema_version": "`+SchemaVersion+`"`) {
+		t.Errorf("expected schema_version, got:\n%s", out)
+	}
+	if r.SchemaVersion != "" {
+		t.Error("Format modified the result")
+	}
 }
 
 func TestResultFormatTextFallsBackWhenNoSegments(t *testing.T) {
@@ -0,0 +1,50 @@
+package transcribe
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Speakers returns the distinct speaker labels in the order they first speak.
+// That order is the one a person remembers a meeting in, which is what makes
+// `--speakers "Alice,Bob"` usable without looking at the transcript first.
+func (r *Result) Speakers() []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, seg := range r.Segments {
+		if seg.Speaker == "" || seen[seg.Speaker] {
+			continue
+		}
+		seen[seg.Speaker] = true
+		out = append(out, seg.Speaker)
+	}
+	return out
+}
+
+// RenameSpeakers relabels every segment whose speaker appears in names.
+// Speakers missing from the map keep the label the backend gave them.
+func (r *Result) RenameSpeakers(names map[string]string) {
+	for i, seg := range r.Segments {
+		if name, ok := names[seg.Speaker]; ok && name != "" {
+			r.Segments[i].Speaker = name
+		}
+	}
+}
+
+// MapSpeakersInOrder pairs names with speakers by order of appearance. Naming
+// more people than the backend heard is an error, because it almost always
+// means diarization merged two voices and the mapping would be wrong for
+// everyone after the merge. Naming fewer is fine: the rest keep their labels.
+func MapSpeakersInOrder(speakers, names []string) (map[string]string, error) {
+	if len(names) > len(speakers) {
+		return nil, fmt.Errorf("%d speaker names given but only %d speakers detected (%s)",
+			len(names), len(speakers), strings.Join(speakers, ", "))
+	}
+	out := make(map[string]string, len(names))
+	for i, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			out[speakers[i]] = name
+		}
+	}
+	return out, nil
+}
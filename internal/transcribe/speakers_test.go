@@ -0,0 +1,51 @@
+package transcribe
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSpeakersInOrderOfFirstAppearance(t *testing.T) {
+	r := &Result{Segments: []Segment{
+		{Text: "hi", Speaker: "Speaker 1"},
+		{Text: "hello", Speaker: "Speaker 0"},
+		{Text: "(music)"},
+		{Text: "so", Speaker: "Speaker 1"},
+		{Text: "right", Speaker: "Speaker 2"},
+	}}
+	want := []string{"Speaker 1", "Speaker 0", "Speaker 2"}
+	if got := r.Speakers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Speakers() = %v, want %v", got, want)
+	}
+}
+
+func TestRenameSpeakers(t *testing.T) {
+	r := &Result{Segments: []Segment{
+		{Speaker: "Speaker 0"},
+		{Speaker: "Speaker 1"},
+		{Speaker: "Speaker 0"},
+	}}
+	r.RenameSpeakers(map[string]string{"Speaker 0": "Alice"})
+	got := []string{r.Segments[0].Speaker, r.Segments[1].Speaker, r.Segments[2].Speaker}
+	want := []string{"Alice", "Speaker 1", "Alice"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("speakers after rename = %v, want %v", got, want)
+	}
+}
+
+func TestMapSpeakersInOrder(t *testing.T) {
+	got, err := MapSpeakersInOrder([]string{"S1", "S0", "S2"}, []string{"Alice", "", "Carol"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"S1": "Alice", "S2": "Carol"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestMapSpeakersInOrderRejectsTooManyNames(t *testing.T) {
+	if _, err := MapSpeakersInOrder([]string{"S0"}, []string{"Alice", "Bob"}); err == nil {
+		t.Error("expected an error for more names than speakers")
+	}
+}
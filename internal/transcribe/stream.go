@@ -41,10 +41,15 @@ type Streamer struct {
 	storeInCloud     bool
 	baseURL          string // "wss://api.elevenlabs.io" default, overridable for tests
 	reconnectBackoff time.Duration
+	post             *PostProcessor    // cleanup applied to each commit; nil for none
+	voice            *VoiceInterpreter // spoken commands; nil for none
+	sink             CommitSink        // also told of each commit; nil for none
+	store            TranscriptStore   // keeps the transcript instead of a file; nil for none
 
 	Committed chan string // finalized text segments
 	Partial   chan string // in-progress text (replaced on each update)
 	Err       chan error  // fatal errors (auth, quota, etc. — not reconnectable)
+	Voice     chan string // spoken actions: VoiceScratch, VoiceMark, VoiceStop
 
 	connMu sync.RWMutex
 	conn   *websocket.Conn // nil while a reconnect is in flight
@@ -55,6 +60,8 @@ type Streamer struct {
 	committed []string // accumulated committed text
 	file      *os.File // transcript file, flushed on each commit
 	writer    *bufio.Writer
+	offsets   []int64 // where each commit starts in file, for scratching it
+	text      []byte  // the transcript as the store has it
 
 	once sync.Once
 }
@@ -69,6 +76,7 @@ func NewStreamer(apiKey string, storeInCloud bool) *Streamer {
 		Committed:        make(chan string, 64),
 		Partial:          make(chan string, 16),
 		Err:              make(chan error, 1),
+		Voice:            make(chan string, 16),
 	}
 }
 
@@ -135,8 +143,52 @@ func (e *scribeError) Error() string {
 	return fmt.Sprintf("elevenlabs error (%s): %s", e.msgType, e.detail)
 }
 
+// SetPostProcess cleans each commit before it is written to the live
+// transcript or delivered on Committed. Call it before Start.
+func (s *Streamer) SetPostProcess(p *PostProcessor) {
+	s.post = p
+}
+
+// SetVoiceCommands carries out spoken commands in each commit. Breaks and
+// punctuation are applied to the text; actions are taken out of it and sent
+// on Voice, a scratch having already dropped the previous commit from the
+// live transcript. Call it before Start.
+func (s *Streamer) SetVoiceCommands(v *VoiceInterpreter) {
+	s.voice = v
+}
+
+// CommitSink follows the live transcript as the file does: each commit, and
+// each scratch that takes the last one back. Its methods are called from the
+// goroutine reading the WebSocket, so they must not block.
+type CommitSink interface {
+	Commit(text string)
+	Scratch()
+}
+
+// SetSink hands each commit and scratch to sink as well. Call it before
+// Start.
+func (s *Streamer) SetSink(sink CommitSink) {
+	s.sink = sink
+}
+
+// TranscriptStore keeps the live transcript where an appended file will not
+// do, such as encrypted at rest. Load returns what an earlier session left,
+// and Save is given the whole transcript after each commit and scratch, on
+// the goroutine reading the WebSocket.
+type TranscriptStore interface {
+	Load() ([]byte, error)
+	Save(text []byte) error
+}
+
+// SetTranscriptStore keeps the live transcript in store rather than in the
+// file Start is given, which should then be empty. Call it before Start.
+func (s *Streamer) SetTranscriptStore(store TranscriptStore) {
+	s.store = store
+}
+
 // Start dials the ElevenLabs WebSocket endpoint and begins streaming audio
-// from pcmReader. It opens transcriptPath for appending committed transcripts.
+// from pcmReader. It opens transcriptPath for appending committed transcripts;
+// an empty path keeps them in memory only.
 // Returns nil after successfully connecting and spawning background goroutines.
 // Subsequent disconnects are handled by an internal supervisor that reconnects
 // automatically; only the initial dial failure is reported by Start.
@@ -147,13 +199,21 @@ func (s *Streamer) Start(ctx context.Context, pcmReader io.Reader, transcriptPat
 	}
 	s.conn = conn
 
-	f, err := os.OpenFile(transcriptPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		conn.Close()
-		return fmt.Errorf("failed to open transcript file: %w", err)
+	if s.store != nil {
+		if s.text, err = s.store.Load(); err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to open transcript: %w", err)
+		}
+	}
+	if transcriptPath != "" {
+		f, err := os.OpenFile(transcriptPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to open transcript file: %w", err)
+		}
+		s.file = f
+		s.writer = bufio.NewWriter(f)
 	}
-	s.file = f
-	s.writer = bufio.NewWriter(f)
 
 	derived, cancel := context.WithCancel(ctx)
 	s.cancel = cancel
@@ -258,23 +318,12 @@ func (s *Streamer) sendLoop(ctx context.Context, r io.Reader) {
 
 		n, err := r.Read(buf)
 		if n > 0 {
-			encoded := base64.StdEncoding.EncodeToString(buf[:n])
-			msg := audioChunkMsg{
-				MessageType: "input_audio_chunk",
-				AudioBase64: encoded,
-				Commit:      false,
-				SampleRate:  16000,
-			}
-			if data, jerr := json.Marshal(msg); jerr == nil {
-				s.connMu.RLock()
-				conn := s.conn
-				s.connMu.RUnlock()
-				if conn != nil {
-					_ = conn.WriteMessage(websocket.TextMessage, data)
-				}
-			}
+			s.sendChunk(buf[:n], false)
 		}
 		if err == io.EOF {
+			// The audio is over: commit what VAD is still holding back
+			// rather than waiting for a silence that will never come.
+			s.sendChunk(nil, true)
 			return
 		}
 		if err != nil {
@@ -283,6 +332,26 @@ func (s *Streamer) sendLoop(ctx context.Context, r io.Reader) {
 	}
 }
 
+func (s *Streamer) sendChunk(pcm []byte, commit bool) {
+	msg := audioChunkMsg{
+		MessageType: "input_audio_chunk",
+		AudioBase64: base64.StdEncoding.EncodeToString(pcm),
+		Commit:      commit,
+		SampleRate:  16000,
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	// sendLoop is the only writer besides Stop, so holding the read lock for
+	// the write is enough to keep the two from interleaving frames.
+	s.connMu.RLock()
+	defer s.connMu.RUnlock()
+	if s.conn != nil {
+		_ = s.conn.WriteMessage(websocket.TextMessage, data)
+	}
+}
+
 // recvLoop reads from the current connection until it dies or returns an
 // error message. Returns nil on clean shutdown, an error otherwise. The
 // supervisor decides whether to reconnect based on the error.
@@ -327,15 +396,33 @@ func (s *Streamer) recvLoop(ctx context.Context) error {
 			}
 
 		case "committed_transcript":
-			s.mu.Lock()
-			s.committed = append(s.committed, msg.Text)
-			if s.writer != nil {
-				fmt.Fprintln(s.writer, msg.Text)
-				s.writer.Flush()
+			text := msg.Text
+			if s.post != nil {
+				// A commit that was nothing but "um" is not worth a line.
+				if text = s.post.ApplyText(text); strings.TrimSpace(text) == "" {
+					continue
+				}
+			}
+			text, action := s.voice.Interpret(text)
+			if action != "" {
+				if action == VoiceScratch {
+					s.scratch()
+					if s.sink != nil {
+						s.sink.Scratch()
+					}
+				}
+				select {
+				case s.Voice <- action:
+				default:
+				}
+				continue
+			}
+			s.commit(text)
+			if s.sink != nil {
+				s.sink.Commit(text)
 			}
-			s.mu.Unlock()
 			select {
-			case s.Committed <- msg.Text:
+			case s.Committed <- text:
 			default:
 			}
 
@@ -354,6 +441,68 @@ func (s *Streamer) recvLoop(ctx context.Context) error {
 	}
 }
 
+// commit records text and appends it to the transcript file.
+func (s *Streamer) commit(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.committed = append(s.committed, text)
+	if s.store != nil {
+		s.offsets = append(s.offsets, int64(len(s.text)))
+		if line, ok := transcriptLine(text); ok {
+			s.text = append(s.text, line+"\n"...)
+			s.store.Save(s.text)
+		}
+		return
+	}
+	if s.writer == nil {
+		return
+	}
+	var off int64
+	if fi, err := s.file.Stat(); err == nil {
+		off = fi.Size()
+	}
+	s.offsets = append(s.offsets, off)
+	if line, ok := transcriptLine(text); ok {
+		fmt.Fprintln(s.writer, line)
+		s.writer.Flush()
+	}
+}
+
+// scratch drops the last commit, from the file as well. The file is cut
+// back to where the commit started rather than rewritten, so a transcript
+// appended to from an earlier session keeps what it had.
+func (s *Streamer) scratch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.committed) == 0 {
+		return
+	}
+	s.committed = s.committed[:len(s.committed)-1]
+	if (s.writer == nil && s.store == nil) || len(s.offsets) == 0 {
+		return
+	}
+	off := s.offsets[len(s.offsets)-1]
+	s.offsets = s.offsets[:len(s.offsets)-1]
+	if s.store != nil {
+		s.text = s.text[:off]
+		s.store.Save(s.text)
+		return
+	}
+	s.file.Truncate(off)
+}
+
+// transcriptLine is how a commit is written to the live transcript, one
+// commit to a line. A commit already starts on a line of its own, so a break
+// at either end of it stands for one newline fewer; a new line said on its
+// own writes nothing.
+func transcriptLine(text string) (string, bool) {
+	text = strings.TrimPrefix(text, "\n")
+	if text == "" {
+		return "", false
+	}
+	return strings.TrimSuffix(text, "\n"), true
+}
+
 // Stop cancels the session, closes the WebSocket connection, flushes and
 // closes the file, and closes the output channels.
 func (s *Streamer) Stop() {
@@ -383,12 +532,44 @@ func (s *Streamer) Stop() {
 		close(s.Committed)
 		close(s.Partial)
 		close(s.Err)
+		close(s.Voice)
 	})
 }
 
-// FullText returns all committed segments joined by spaces.
+// FullText returns all committed segments joined by spaces, or by the breaks
+// spoken between them.
 func (s *Streamer) FullText() string {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	return strings.Join(s.committed, " ")
+	return JoinCommits(s.committed)
+}
+
+// Finish waits for the commits still to come once the audio reader has hit
+// EOF, then stops the session and returns the full text. The last commit
+// arrives some time after the audio ends, so Finish returns once none has
+// arrived for idle, or with the session's error if it failed first.
+// Committed must not have another reader.
+func (s *Streamer) Finish(ctx context.Context, idle time.Duration) (string, error) {
+	defer s.Stop()
+	timer := time.NewTimer(idle)
+	defer timer.Stop()
+	errc := s.Err
+	for {
+		select {
+		case _, ok := <-s.Committed:
+			if !ok {
+				return s.FullText(), nil
+			}
+			timer.Reset(idle)
+		case err, ok := <-errc:
+			if ok && err != nil {
+				return "", err
+			}
+			errc = nil
+		case <-timer.C:
+			return s.FullText(), nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
 }
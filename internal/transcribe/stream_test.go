@@ -8,7 +8,9 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -248,6 +250,213 @@ func TestStreamerPartialTranscript(t *testing.T) {
 	}
 }
 
+// TestStreamerPostProcessesCommits verifies commits are cleaned before they
+// reach the channel and the live file, and that a commit cleaned down to
+// nothing is dropped.
+func TestStreamerPostProcessesCommits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := wsUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		for _, text := range []string{"Um.", "so, uh, we ship it"} {
+			msg, _ := json.Marshal(map[string]string{"message_type": "committed_transcript", "text": text})
+			conn.WriteMessage(websocket.TextMessage, msg)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	s := newTestStreamer(server)
+	post, err := NewPostProcessor(PostProcess{RemoveFillers: true, FixCapitalization: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetPostProcess(post)
+	tmpFile := filepath.Join(t.TempDir(), "transcript.txt")
+	pr, pw := io.Pipe()
+	pw.Close()
+
+	if err := s.Start(t.Context(), pr, tmpFile); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	defer s.Stop()
+
+	text, ok := waitChan(s.Committed, 2*time.Second)
+	if !ok {
+		t.Fatal("timed out waiting for committed transcript")
+	}
+	if text != "So, we ship it" {
+		t.Errorf("expected the filler-only commit dropped and the next cleaned, got %q", text)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	s.Stop()
+	data, _ := os.ReadFile(tmpFile)
+	if string(data) != "So, we ship it\n" {
+		t.Errorf("live file = %q", data)
+	}
+}
+
+// TestStreamerVoiceCommands checks that spoken commands shape the live file:
+// a paragraph break becomes a blank line, a scratch cuts the commit before it
+// back out of the file, and the actions are reported on Voice.
+// recordingSink notes what a Streamer hands its CommitSink.
+type recordingSink struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recordingSink) Commit(text string) { r.note("commit " + text) }
+func (r *recordingSink) Scratch()           { r.note("scratch") }
+
+func (r *recordingSink) note(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func TestStreamerVoiceCommands(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := wsUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		for _, text := range []string{"First point, new paragraph", "A mistake.", "Scratch that.", "Mark this.", "Second point."} {
+			msg, _ := json.Marshal(map[string]string{"message_type": "committed_transcript", "text": text})
+			conn.WriteMessage(websocket.TextMessage, msg)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	tmpFile := filepath.Join(dir, "transcript.txt")
+	// An earlier session's lines must survive a scratch.
+	os.WriteFile(tmpFile, []byte("Earlier.\n"), 0644)
+
+	s := newTestStreamer(server)
+	s.SetVoiceCommands(NewVoiceInterpreter(VoiceCommands{}))
+	sink := &recordingSink{}
+	s.SetSink(sink)
+	pr, pw := io.Pipe()
+	pw.Close()
+	if err := s.Start(t.Context(), pr, tmpFile); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	defer s.Stop()
+
+	for _, want := range []string{VoiceScratch, VoiceMark} {
+		if got, ok := waitChan(s.Voice, 2*time.Second); !ok || got != want {
+			t.Fatalf("voice action = %q, %v; want %q", got, ok, want)
+		}
+	}
+	var commits []string
+	for len(commits) < 3 {
+		text, ok := waitChan(s.Committed, 2*time.Second)
+		if !ok {
+			t.Fatalf("timed out after commits %q", commits)
+		}
+		commits = append(commits, text)
+	}
+	if commits[0] != "First point\n\n" {
+		t.Errorf("first commit = %q", commits[0])
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	s.Stop()
+	if got, want := s.FullText(), "First point\n\nSecond point."; got != want {
+		t.Errorf("FullText = %q, want %q", got, want)
+	}
+	data, _ := os.ReadFile(tmpFile)
+	if want := "Earlier.\nFirst point\n\nSecond point.\n"; string(data) != want {
+		t.Errorf("live file = %q, want %q", data, want)
+	}
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if want := []string{"commit First point\n\n", "commit A mistake.", "scratch", "commit Second point."}; !slices.Equal(sink.calls, want) {
+		t.Errorf("sink calls = %q, want %q", sink.calls, want)
+	}
+}
+
+// memoryStore is a TranscriptStore that keeps the transcript in memory.
+type memoryStore struct {
+	mu    sync.Mutex
+	text  string
+	saves int
+}
+
+func (m *memoryStore) Load() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return []byte(m.text), nil
+}
+
+func (m *memoryStore) Save(text []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.text = string(text)
+	m.saves++
+	return nil
+}
+
+func TestStreamerTranscriptStore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := wsUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		for _, text := range []string{"First point.", "A mistake.", "Scratch that.", "Second point."} {
+			msg, _ := json.Marshal(map[string]string{"message_type": "committed_transcript", "text": text})
+			conn.WriteMessage(websocket.TextMessage, msg)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	// An earlier session's lines must survive a scratch here too.
+	store := &memoryStore{text: "Earlier.\n"}
+	s := newTestStreamer(server)
+	s.SetVoiceCommands(NewVoiceInterpreter(VoiceCommands{}))
+	s.SetTranscriptStore(store)
+	pr, pw := io.Pipe()
+	pw.Close()
+	if err := s.Start(t.Context(), pr, ""); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	defer s.Stop()
+
+	if got, ok := waitChan(s.Voice, 2*time.Second); !ok || got != VoiceScratch {
+		t.Fatalf("voice action = %q, %v; want %q", got, ok, VoiceScratch)
+	}
+	for i := 0; i < 3; i++ {
+		if _, ok := waitChan(s.Committed, 2*time.Second); !ok {
+			t.Fatalf("timed out waiting for commit %d", i+1)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	s.Stop()
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if want := "Earlier.\nFirst point.\nSecond point.\n"; store.text != want {
+		t.Errorf("stored transcript = %q, want %q", store.text, want)
+	}
+	// Each commit and the scratch is saved as it happens, not at Stop.
+	if store.saves != 4 {
+		t.Errorf("saves = %d, want 4", store.saves)
+	}
+}
+
 // TestStreamerIncrementalFileWrite sends multiple committed_transcript messages and verifies
 // the file is updated after each one.
 func TestStreamerIncrementalFileWrite(t *testing.T) {
@@ -633,3 +842,50 @@ func TestStreamerDrainsPipeAfterSendFailure(t *testing.T) {
 	}
 	pw.Close()
 }
+
+// TestStreamerFinishCommitsOnEOF verifies that the end of the audio forces a
+// commit, and that Finish waits for it before returning the full text.
+func TestStreamerFinishCommitsOnEOF(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := wsUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		commit := func(text string) {
+			msg, _ := json.Marshal(map[string]string{"message_type": "committed_transcript", "text": text})
+			conn.WriteMessage(websocket.TextMessage, msg)
+		}
+		commit("turn on")
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var msg audioChunkMsg
+			if json.Unmarshal(data, &msg) == nil && msg.Commit {
+				// The final commit lags the end of the audio.
+				time.Sleep(50 * time.Millisecond)
+				commit("the kitchen lights")
+			}
+		}
+	}))
+	defer server.Close()
+
+	s := newTestStreamer(server)
+	pr, pw := io.Pipe()
+	if err := s.Start(t.Context(), pr, ""); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	pw.Write(make([]byte, 320))
+	pw.Close()
+
+	text, err := s.Finish(t.Context(), 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Finish returned error: %v", err)
+	}
+	if text != "turn on the kitchen lights" {
+		t.Errorf("Finish = %q", text)
+	}
+}
@@ -37,6 +37,20 @@ type TranscribeOpts struct {
 	Punctuate   bool
 	FillerWords bool
 	Numerals    bool
+	// Vocabulary lists names and jargon the backend should favour. Each
+	// backend maps it to its own biasing feature; those without one correct
+	// near-misses after the fact with CorrectVocabulary.
+	Vocabulary []string
+	// Translate asks for an English translation of the speech instead of a
+	// transcript. Only backends for which NativeTranslation is true honour it.
+	Translate bool
+	// Redact lists the redaction categories, such as RedactEmail, to mask in
+	// the transcript. Only backends for which NativeRedaction is true honour
+	// it; Redactor masks them locally whatever the backend.
+	Redact []string
+	// Progress, when set, hears about the upload, the model's progress and
+	// segments as they are decoded.
+	Progress Progress
 }
 
 type Transcriber interface {
@@ -0,0 +1,152 @@
+package transcribe
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ChatFunc sends a prompt to a language model and returns its reply. It is a
+// function rather than a client type so this package stays independent of
+// whichever endpoint the caller configured.
+type ChatFunc func(ctx context.Context, prompt string) (string, error)
+
+// translateBatch is how many segments go into one translation request. Small
+// enough that a local model keeps the numbering straight, large enough that
+// each line still has its neighbours for context.
+const translateBatch = 40
+
+// NativeTranslation reports whether t can translate speech into English
+// itself, with TranscribeOpts.Translate. Whisper and OpenAI's translations
+// endpoint only ever produce English.
+func NativeTranslation(t Transcriber) bool {
+	switch b := t.(type) {
+	case *OpenAI:
+		return true
+	case *Whisper:
+		return b.variant != variantFFmpegWhisper
+	}
+	return false
+}
+
+// TranslateWithLLM translates r segment by segment, so the result keeps the
+// original timing and speakers and lines up cue for cue with the source
+// subtitles. from may be empty when the backend did not report a language.
+func TranslateWithLLM(ctx context.Context, chat ChatFunc, r *Result, from, to string) (*Result, error) {
+	segs := r.segments()
+	out := &Result{Language: to, Duration: r.Duration}
+	var texts []string
+	for start := 0; start < len(segs); start += translateBatch {
+		batch := segs[start:min(start+translateBatch, len(segs))]
+		lines, err := translateLines(ctx, chat, batch, from, to)
+		if err != nil {
+			return nil, err
+		}
+		for i, seg := range batch {
+			seg.Text = lines[i]
+			out.Segments = append(out.Segments, seg)
+			texts = append(texts, lines[i])
+		}
+	}
+	out.Text = strings.Join(texts, " ")
+	if len(r.Segments) == 0 {
+		// The source had only text; do not invent a segment for it.
+		out.Segments = nil
+	}
+	return out, nil
+}
+
+var numberedLine = regexp.MustCompile(`^\[(\d+)\]\s*(.*)$`)
+
+func translateLines(ctx context.Context, chat ChatFunc, segs []Segment, from, to string) ([]string, error) {
+	var b strings.Builder
+	source := "its language"
+	if from != "" {
+		source = fmt.Sprintf("%q", from)
+	}
+	fmt.Fprintf(&b, "Translate each numbered line below from %s into the language with ISO 639-1 code %q. ", source, to)
+	fmt.Fprintf(&b, "The lines are consecutive parts of one transcript. Reply with exactly %d lines, each starting with its number in brackets as below, and nothing else.\n\n", len(segs))
+	for i, seg := range segs {
+		fmt.Fprintf(&b, "[%d] %s\n", i+1, strings.Join(strings.Fields(seg.Text), " "))
+	}
+
+	reply, err := chat(ctx, b.String())
+	if err != nil {
+		return nil, fmt.Errorf("translation request failed: %w", err)
+	}
+
+	lines := make([]string, len(segs))
+	got := 0
+	for _, line := range strings.Split(reply, "\n") {
+		m := numberedLine.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		n, _ := strconv.Atoi(m[1])
+		if n < 1 || n > len(segs) || lines[n-1] != "" {
+			continue
+		}
+		lines[n-1] = strings.TrimSpace(m[2])
+		got++
+	}
+	if got != len(segs) {
+		return nil, fmt.Errorf("translation returned %d of %d lines; segment timing would not line up", got, len(segs))
+	}
+	return lines, nil
+}
+
+// languageCodes maps the names and three-letter codes backends report to the
+// two-letter codes used for file names and comparisons. OpenAI reports
+// "english", ElevenLabs "eng", Deepgram and whisper "en".
+var languageCodes = map[string]string{
+	"english": "en", "eng": "en",
+	"german": "de", "deu": "de", "ger": "de",
+	"french": "fr", "fra": "fr", "fre": "fr",
+	"spanish": "es", "spa": "es",
+	"italian": "it", "ita": "it",
+	"portuguese": "pt", "por": "pt",
+	"dutch": "nl", "nld": "nl", "dut": "nl",
+	"polish": "pl", "pol": "pl",
+	"russian": "ru", "rus": "ru",
+	"ukrainian": "uk", "ukr": "uk",
+	"swedish": "sv", "swe": "sv",
+	"danish": "da", "dan": "da",
+	"norwegian": "no", "nor": "no",
+	"finnish": "fi", "fin": "fi",
+	"turkish": "tr", "tur": "tr",
+	"japanese": "ja", "jpn": "ja",
+	"chinese": "zh", "zho": "zh", "chi": "zh",
+	"korean": "ko", "kor": "ko",
+	"arabic": "ar", "ara": "ar",
+	"hindi": "hi", "hin": "hi",
+}
+
+// NormalizeLanguage returns the two-letter code for a language as a backend
+// reported it, or the input lowercased when it is not one this knows.
+func NormalizeLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if code, ok := languageCodes[lang]; ok {
+		return code
+	}
+	return lang
+}
+
+// bibliographicCodes are the ISO 639-2/B codes that ID3 frames and Matroska
+// tracks are tagged with, keyed by two-letter code.
+var bibliographicCodes = map[string]string{
+	"en": "eng", "de": "ger", "fr": "fre", "es": "spa", "it": "ita",
+	"pt": "por", "nl": "dut", "pl": "pol", "ru": "rus", "uk": "ukr",
+	"sv": "swe", "da": "dan", "no": "nor", "fi": "fin", "tr": "tur",
+	"ja": "jpn", "zh": "chi", "ko": "kor", "ar": "ara", "hi": "hin",
+}
+
+// LanguageCode3 returns the three-letter code for a language as a backend
+// reported it, or "und" (undetermined) when it is not one this knows.
+func LanguageCode3(lang string) string {
+	if code, ok := bibliographicCodes[NormalizeLanguage(lang)]; ok {
+		return code
+	}
+	return "und"
+}
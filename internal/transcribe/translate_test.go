@@ -0,0 +1,127 @@
+package transcribe
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// upperChat "translates" by upper-casing each numbered line, and records how
+// many requests it served.
+func upperChat(calls *int) ChatFunc {
+	line := regexp.MustCompile(`(?m)^\[(\d+)\] (.*)$`)
+	return func(ctx context.Context, prompt string) (string, error) {
+		*calls++
+		var b strings.Builder
+		for _, m := range line.FindAllStringSubmatch(prompt, -1) {
+			fmt.Fprintf(&b, "[%s] %s\n", m[1], strings.ToUpper(m[2]))
+		}
+		return b.String(), nil
+	}
+}
+
+func TestTranslateWithLLMKeepsTiming(t *testing.T) {
+	r := &Result{
+		Text:     "hallo welt wie geht's",
+		Language: "de",
+		Duration: 4,
+		Segments: []Segment{
+			{Start: 0, End: 2, Text: "hallo welt", Speaker: "Alice"},
+			{Start: 2, End: 4, Text: " wie geht's"},
+		},
+	}
+	calls := 0
+	got, err := TranslateWithLLM(t.Context(), upperChat(&calls), r, "de", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Segment{
+		{Start: 0, End: 2, Text: "HALLO WELT", Speaker: "Alice"},
+		{Start: 2, End: 4, Text: "WIE GEHT'S"},
+	}
+	for i := range want {
+		if got.Segments[i] != want[i] {
+			t.Errorf("segment %d = %+v, want %+v", i, got.Segments[i], want[i])
+		}
+	}
+	if got.Text != "HALLO WELT WIE GEHT'S" || got.Language != "en" || got.Duration != 4 {
+		t.Errorf("result = %+v", got)
+	}
+	if r.Segments[0].Text != "hallo welt" {
+		t.Error("source result was modified")
+	}
+}
+
+func TestTranslateWithLLMBatches(t *testing.T) {
+	r := &Result{}
+	for i := range translateBatch + 5 {
+		r.Segments = append(r.Segments, Segment{Start: float64(i), End: float64(i + 1), Text: fmt.Sprintf("line %d", i)})
+	}
+	calls := 0
+	got, err := TranslateWithLLM(t.Context(), upperChat(&calls), r, "", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("requests = %d, want 2", calls)
+	}
+	if last := got.Segments[len(got.Segments)-1]; last.Text != fmt.Sprintf("LINE %d", translateBatch+4) {
+		t.Errorf("last segment = %+v", last)
+	}
+}
+
+func TestTranslateWithLLMTextOnly(t *testing.T) {
+	calls := 0
+	got, err := TranslateWithLLM(t.Context(), upperChat(&calls), &Result{Text: "bonjour"}, "fr", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Text != "BONJOUR" || got.Segments != nil {
+		t.Errorf("result = %+v", got)
+	}
+}
+
+func TestTranslateWithLLMRejectsMissingLines(t *testing.T) {
+	chat := func(ctx context.Context, prompt string) (string, error) {
+		return "[1] only one", nil
+	}
+	r := &Result{Segments: []Segment{{Text: "eins"}, {Text: "zwei"}}}
+	if _, err := TranslateWithLLM(t.Context(), chat, r, "de", "en"); err == nil {
+		t.Error("expected an error when the reply drops a line")
+	}
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	for in, want := range map[string]string{"english": "en", "English": "en", "eng": "en", "de": "de", " FR ": "fr", "pt-br": "pt-br", "": ""} {
+		if got := NormalizeLanguage(in); got != want {
+			t.Errorf("NormalizeLanguage(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestLanguageCode3(t *testing.T) {
+	for in, want := range map[string]string{"en": "eng", "german": "ger", "deu": "ger", "": "und", "xx": "und"} {
+		if got := LanguageCode3(in); got != want {
+			t.Errorf("LanguageCode3(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNativeTranslation(t *testing.T) {
+	tests := []struct {
+		backend Transcriber
+		want    bool
+	}{
+		{NewOpenAI("k", "gpt-4o-transcribe"), true},
+		{NewWhisper("whisper-cli", "base"), true},
+		{&Whisper{variant: variantFFmpegWhisper}, false},
+		{NewDeepgram("k", "nova-3"), false},
+	}
+	for _, tt := range tests {
+		if got := NativeTranslation(tt.backend); got != tt.want {
+			t.Errorf("NativeTranslation(%s) = %v, want %v", tt.backend.Name(), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package transcribe
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// vocabularyPrompt renders key terms as the free-text prompt OpenAI and
+// whisper accept. Both treat the prompt as transcript that came before the
+// audio, so a plain list reads as context the model should stay consistent
+// with rather than an instruction it might echo back.
+func vocabularyPrompt(terms []string) string {
+	return strings.Join(terms, ", ")
+}
+
+// CorrectVocabulary rewrites near-misses of the given terms in r's text and
+// segments. It is the fallback for backends that have no way to bias
+// recognition up front: "Jo Golden" becomes "Joe Goldin" after the fact.
+//
+// Matching is deliberately conservative. A window of words must be within a
+// quarter of the term's length in edits, and terms shorter than four letters
+// only ever fix their capitalisation, because at that length almost any word
+// is one edit away from almost any other.
+func CorrectVocabulary(r *Result, terms []string) {
+	if r == nil || len(terms) == 0 {
+		return
+	}
+	r.Text = correctVocabularyText(r.Text, terms)
+	for i := range r.Segments {
+		r.Segments[i].Text = correctVocabularyText(r.Segments[i].Text, terms)
+	}
+}
+
+func correctVocabularyText(text string, terms []string) string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return text
+	}
+	changed := false
+	for _, term := range terms {
+		termWords := strings.Fields(term)
+		if len(termWords) == 0 {
+			continue
+		}
+		target := normalizeVocabWord(strings.Join(termWords, ""))
+		if target == "" {
+			continue
+		}
+		maxDist := len([]rune(target)) / 4
+
+		// A term can be heard as one word more than it has ("audio memo" for
+		// "AudioMemo"), so both window sizes are tried, the exact one first.
+		for _, size := range []int{len(termWords), len(termWords) + 1} {
+			for i := 0; i+size <= len(words); i++ {
+				window := words[i : i+size]
+				if vocabWindowHasTerm(window, term) {
+					continue
+				}
+				dist := vocabDistance(window, target)
+				if dist > maxDist {
+					continue
+				}
+				// The wider window must beat both of the narrower ones inside
+				// it, or "Goldin a" would swallow the "a".
+				if size > len(termWords) &&
+					(vocabDistance(window[1:], target) <= dist || vocabDistance(window[:size-1], target) <= dist) {
+					continue
+				}
+				lead, _ := splitVocabPunct(window[0])
+				_, trail := splitVocabPunct(window[len(window)-1])
+				replacement := lead + term + trail
+				words = append(words[:i], append([]string{replacement}, words[i+size:]...)...)
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return text
+	}
+	return strings.Join(words, " ")
+}
+
+// vocabDistance is the edit distance between the window and the normalised
+// term. An empty window, one that was all punctuation, never matches.
+func vocabDistance(window []string, target string) int {
+	heard := normalizeVocabWord(strings.Join(window, ""))
+	if heard == "" {
+		return len(target) + 1
+	}
+	return levenshtein(heard, target)
+}
+
+// vocabWindowHasTerm reports whether the window already spells the term,
+// ignoring surrounding punctuation, so "Goldin," is left alone.
+func vocabWindowHasTerm(window []string, term string) bool {
+	var b strings.Builder
+	for i, w := range window {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		lead, trail := splitVocabPunct(w)
+		b.WriteString(strings.TrimSuffix(strings.TrimPrefix(w, lead), trail))
+	}
+	return b.String() == term
+}
+
+// splitVocabPunct returns the punctuation wrapped around a word, so a
+// correction keeps the comma or full stop the backend put after it.
+func splitVocabPunct(w string) (lead, trail string) {
+	start := strings.IndexFunc(w, isVocabRune)
+	if start < 0 {
+		return w, ""
+	}
+	end := strings.LastIndexFunc(w, isVocabRune)
+	_, size := utf8.DecodeRuneInString(w[end:])
+	return w[:start], w[end+size:]
+}
+
+func isVocabRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func normalizeVocabWord(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if isVocabRune(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,83 @@
+package transcribe
+
+import "testing"
+
+func TestCorrectVocabularyFixesNearMisses(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  string
+	}{
+		{"multi-word name", "ask Jo Golden about it", []string{"Joe Goldin"}, "ask Joe Goldin about it"},
+		{"keeps punctuation", "thanks, Jo Golden.", []string{"Joe Goldin"}, "thanks, Joe Goldin."},
+		{"split compound", "open audio memo now", []string{"AudioMemo"}, "open AudioMemo now"},
+		{"capitalisation only", "the deepgram backend", []string{"Deepgram"}, "the Deepgram backend"},
+		{"short term case", "ask the ai", []string{"AI"}, "ask the AI"},
+		{"already correct", "ask Joe Goldin, please", []string{"Joe Goldin"}, "ask Joe Goldin, please"},
+		{"too far to touch", "ask John Golding about it", []string{"Joe Goldin"}, "ask John Golding about it"},
+		{"short term stays exact", "see the ale", []string{"AI"}, "see the ale"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Result{Text: tt.text}
+			CorrectVocabulary(r, tt.terms)
+			if r.Text != tt.want {
+				t.Errorf("got %q, want %q", r.Text, tt.want)
+			}
+		})
+	}
+}
+
+// The wider window exists for terms heard as two words. It must not eat a
+// real neighbouring word when the exact-size window already matches.
+func TestCorrectVocabularyDoesNotSwallowNeighbours(t *testing.T) {
+	r := &Result{Text: "Goldn a plan"}
+	CorrectVocabulary(r, []string{"Goldin"})
+	if r.Text != "Goldin a plan" {
+		t.Errorf("got %q, want %q", r.Text, "Goldin a plan")
+	}
+}
+
+func TestCorrectVocabularyRewritesSegments(t *testing.T) {
+	r := &Result{
+		Text: "Jo Golden here",
+		Segments: []Segment{
+			{Start: 0, End: 1, Text: "Jo Golden here", Speaker: "Speaker 0"},
+		},
+	}
+	CorrectVocabulary(r, []string{"Joe Goldin"})
+	if r.Segments[0].Text != "Joe Goldin here" {
+		t.Errorf("segment text = %q", r.Segments[0].Text)
+	}
+	if r.Segments[0].Speaker != "Speaker 0" {
+		t.Errorf("speaker changed to %q", r.Segments[0].Speaker)
+	}
+}
+
+func TestCorrectVocabularyLeavesUntouchedTextAlone(t *testing.T) {
+	// No match means no re-joining, so the backend's spacing survives.
+	r := &Result{Text: "line one\nline two"}
+	CorrectVocabulary(r, []string{"Deepgram"})
+	if r.Text != "line one\nline two" {
+		t.Errorf("got %q", r.Text)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"joegoldin", "jogolden", 2},
+		{"café", "cafe", 1},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
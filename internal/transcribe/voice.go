@@ -0,0 +1,273 @@
+package transcribe
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Actions a spoken command can ask for, as Interpret reports them. An action
+// is only taken when the command is the whole commit, said on its own between
+// pauses: said mid-sentence, "stop recording" is more likely to be about
+// recording than addressed to it.
+const (
+	VoiceScratch = "scratch" // drop the previous commit
+	VoiceMark    = "mark"    // bookmark this moment
+	VoiceStop    = "stop"    // end the recording
+)
+
+// VoiceCommands lists the phrases recognised while dictating. A nil list
+// means the default phrase; an empty one turns that command off.
+type VoiceCommands struct {
+	NewParagraph []string
+	NewLine      []string
+	Scratch      []string
+	Mark         []string
+	Stop         []string
+	// Punctuation maps a spoken word to the mark it stands for, for a
+	// backend that punctuates nothing itself. There is none by default:
+	// "period" and "colon" are ordinary words as well.
+	Punctuation map[string]string
+}
+
+// DefaultVoiceCommands are the phrases used where VoiceCommands leaves a
+// list nil.
+var DefaultVoiceCommands = VoiceCommands{
+	NewParagraph: []string{"new paragraph"},
+	NewLine:      []string{"new line"},
+	Scratch:      []string{"scratch that"},
+	Mark:         []string{"mark this"},
+	Stop:         []string{"stop recording"},
+}
+
+// VoiceInterpreter carries out the commands in dictated text. Build one with
+// NewVoiceInterpreter. A nil *VoiceInterpreter is valid and changes nothing.
+type VoiceInterpreter struct {
+	inline  []inlineCommand   // longest phrase first, so "new paragraph" beats "new"
+	actions map[string]string // normalised phrase -> action
+}
+
+// inlineCommand is a command that takes effect where it is said: a break, or
+// a punctuation mark attached to the word before it.
+type inlineCommand struct {
+	words  []string
+	insert string
+	attach bool
+}
+
+// NewVoiceInterpreter compiles c, filling in DefaultVoiceCommands where c
+// leaves a list nil.
+func NewVoiceInterpreter(c VoiceCommands) *VoiceInterpreter {
+	v := &VoiceInterpreter{actions: map[string]string{}}
+	addInline := func(phrases []string, insert string, attach bool) {
+		for _, p := range phrases {
+			if words := phraseWords(p); len(words) > 0 {
+				v.inline = append(v.inline, inlineCommand{words: words, insert: insert, attach: attach})
+			}
+		}
+	}
+	addInline(orDefault(c.NewParagraph, DefaultVoiceCommands.NewParagraph), "\n\n", false)
+	addInline(orDefault(c.NewLine, DefaultVoiceCommands.NewLine), "\n", false)
+	for spoken, mark := range c.Punctuation {
+		addInline([]string{spoken}, mark, true)
+	}
+	sort.SliceStable(v.inline, func(i, j int) bool { return len(v.inline[i].words) > len(v.inline[j].words) })
+
+	for action, phrases := range map[string][]string{
+		VoiceScratch: orDefault(c.Scratch, DefaultVoiceCommands.Scratch),
+		VoiceMark:    orDefault(c.Mark, DefaultVoiceCommands.Mark),
+		VoiceStop:    orDefault(c.Stop, DefaultVoiceCommands.Stop),
+	} {
+		for _, p := range phrases {
+			if key := strings.Join(phraseWords(p), " "); key != "" {
+				v.actions[key] = action
+			}
+		}
+	}
+	return v
+}
+
+func orDefault(phrases, def []string) []string {
+	if phrases == nil {
+		return def
+	}
+	return phrases
+}
+
+// phraseWords splits a phrase into words as they are compared: lower case,
+// with the punctuation a backend puts around them trimmed off.
+func phraseWords(s string) []string {
+	var words []string
+	for _, w := range strings.Fields(s) {
+		if w = normaliseWord(w); w != "" {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+func normaliseWord(w string) string {
+	return strings.ToLower(strings.TrimFunc(w, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}))
+}
+
+// Interpret carries out the commands in one commit. A commit that is nothing
+// but an action's phrase comes back empty, with the action to take. Otherwise
+// breaks and punctuation are put where they were said, and the phrases for
+// them taken out.
+func (v *VoiceInterpreter) Interpret(text string) (string, string) {
+	if v == nil {
+		return text, ""
+	}
+	if action, ok := v.actions[strings.Join(phraseWords(text), " ")]; ok {
+		return "", action
+	}
+
+	words := strings.Fields(text)
+	var out []string
+	capitalise := false
+	for i := 0; i < len(words); {
+		cmd, n := v.match(words[i:])
+		if n == 0 {
+			w := words[i]
+			if capitalise {
+				w = capitaliseFirst(w)
+				capitalise = false
+			}
+			out = append(out, w)
+			i++
+			continue
+		}
+		i += n
+		last := len(out) - 1
+		if cmd.attach {
+			// The backend may have punctuated around the spoken word
+			// ("Hello, comma, world."); the spoken mark replaces its guess.
+			if last >= 0 && !isBreak(out[last]) {
+				out[last] = strings.TrimRight(out[last], ".,;:!?") + cmd.insert
+			} else {
+				out = append(out, cmd.insert)
+			}
+			continue
+		}
+		if last >= 0 && !isBreak(out[last]) {
+			out[last] = strings.TrimRight(out[last], ",;:")
+		}
+		out = append(out, cmd.insert)
+		capitalise = true
+	}
+	return joinWords(out), ""
+}
+
+// match reports the inline command words starts with, and how many words it
+// takes up.
+func (v *VoiceInterpreter) match(words []string) (inlineCommand, int) {
+next:
+	for _, cmd := range v.inline {
+		if len(cmd.words) > len(words) {
+			continue
+		}
+		for i, w := range cmd.words {
+			if normaliseWord(words[i]) != w {
+				continue next
+			}
+		}
+		return cmd, len(cmd.words)
+	}
+	return inlineCommand{}, 0
+}
+
+// Apply carries out the commands in a batch result, so that it reads as the
+// live transcript did. Actions are taken only on a segment that is nothing
+// but the command, which is how a backend tends to segment a phrase said
+// between pauses. Breaks go into the text alone: a subtitle cue cannot hold
+// a blank line.
+func (v *VoiceInterpreter) Apply(r *Result) {
+	if v == nil || r == nil {
+		return
+	}
+	if len(r.Segments) == 0 {
+		r.Text, _ = v.Interpret(r.Text)
+		return
+	}
+	var segs []Segment
+	var texts []string
+	for _, seg := range r.Segments {
+		text, action := v.Interpret(seg.Text)
+		switch action {
+		case VoiceScratch:
+			if len(segs) > 0 {
+				segs, texts = segs[:len(segs)-1], texts[:len(texts)-1]
+			}
+			continue
+		case VoiceMark, VoiceStop:
+			continue
+		}
+		flat := strings.Join(strings.Fields(text), " ")
+		if flat == "" {
+			// A break said on its own belongs to the text between segments.
+			if len(texts) > 0 {
+				texts[len(texts)-1] += text
+			}
+			continue
+		}
+		seg.Text = flat
+		segs = append(segs, seg)
+		texts = append(texts, text)
+	}
+	r.Segments = segs
+	r.Text = JoinCommits(texts)
+}
+
+// JoinCommits joins live commits into running text, each separated from the
+// one before by CommitSeparator.
+func JoinCommits(commits []string) string {
+	var b strings.Builder
+	prev := ""
+	for _, c := range commits {
+		if c == "" {
+			continue
+		}
+		b.WriteString(CommitSeparator(prev, c))
+		b.WriteString(c)
+		prev = c
+	}
+	return b.String()
+}
+
+// CommitSeparator is what goes between two consecutive commits: a space,
+// except where prev ends or next starts a line, or next is a punctuation
+// mark said on its own. Nothing goes before the first.
+func CommitSeparator(prev, next string) string {
+	if prev == "" || strings.HasSuffix(prev, "\n") || strings.HasPrefix(next, "\n") || startsWithMark(next) {
+		return ""
+	}
+	return " "
+}
+
+func joinWords(words []string) string {
+	var b strings.Builder
+	for i, w := range words {
+		if i > 0 && !isBreak(w) && !isBreak(words[i-1]) && !startsWithMark(w) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(w)
+	}
+	return b.String()
+}
+
+func isBreak(w string) bool {
+	return strings.HasPrefix(w, "\n")
+}
+
+func startsWithMark(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return strings.ContainsRune(".,;:!?", r)
+}
+
+func capitaliseFirst(w string) string {
+	r, size := utf8.DecodeRuneInString(w)
+	return string(unicode.ToUpper(r)) + w[size:]
+}
@@ -0,0 +1,83 @@
+package transcribe
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVoiceInterpret(t *testing.T) {
+	v := NewVoiceInterpreter(VoiceCommands{Punctuation: map[string]string{"comma": ",", "period": "."}})
+	tests := []struct {
+		in, want, action string
+	}{
+		{"Scratch that.", "", VoiceScratch},
+		{"mark this", "", VoiceMark},
+		{"Stop recording!", "", VoiceStop},
+		{"we should stop recording sooner", "we should stop recording sooner", ""},
+		{"first point, new paragraph, second point", "first point\n\nSecond point", ""},
+		{"dear sam new line thanks", "dear sam\nThanks", ""},
+		{"New paragraph.", "\n\n", ""},
+		{"hello comma world period", "hello, world.", ""},
+		{"Hello, comma, world.", "Hello, world.", ""},
+	}
+	for _, tt := range tests {
+		got, action := v.Interpret(tt.in)
+		if got != tt.want || action != tt.action {
+			t.Errorf("Interpret(%q) = %q, %q; want %q, %q", tt.in, got, action, tt.want, tt.action)
+		}
+	}
+}
+
+func TestVoiceCommandsCustomPhrases(t *testing.T) {
+	v := NewVoiceInterpreter(VoiceCommands{Scratch: []string{"delete that", "undo"}, Stop: []string{}})
+	if _, action := v.Interpret("Undo."); action != VoiceScratch {
+		t.Errorf("custom scratch phrase: action = %q", action)
+	}
+	if _, action := v.Interpret("scratch that"); action != "" {
+		t.Errorf("a replaced default should no longer act, got %q", action)
+	}
+	if _, action := v.Interpret("stop recording"); action != "" {
+		t.Errorf("an empty list should turn the command off, got %q", action)
+	}
+	if got, _ := v.Interpret("one new paragraph two"); got != "one\n\nTwo" {
+		t.Errorf("a nil list should keep the default, got %q", got)
+	}
+}
+
+func TestNilVoiceInterpreter(t *testing.T) {
+	var v *VoiceInterpreter
+	if got, action := v.Interpret("scratch that"); got != "scratch that" || action != "" {
+		t.Errorf("nil interpreter changed the text: %q, %q", got, action)
+	}
+}
+
+func TestVoiceApply(t *testing.T) {
+	v := NewVoiceInterpreter(VoiceCommands{})
+	r := &Result{Segments: []Segment{
+		{Start: 0, End: 2, Text: "First point."},
+		{Start: 2, End: 3, Text: "New paragraph."},
+		{Start: 3, End: 5, Text: "A mistake."},
+		{Start: 5, End: 6, Text: "Scratch that."},
+		{Start: 6, End: 7, Text: "Mark this."},
+		{Start: 7, End: 9, Text: "Second point, new line, done."},
+		{Start: 9, End: 10, Text: "Stop recording."},
+	}}
+	v.Apply(r)
+	if want := "First point.\n\nSecond point\nDone."; r.Text != want {
+		t.Errorf("Text = %q, want %q", r.Text, want)
+	}
+	want := []Segment{
+		{Start: 0, End: 2, Text: "First point."},
+		{Start: 7, End: 9, Text: "Second point Done."},
+	}
+	if !reflect.DeepEqual(r.Segments, want) {
+		t.Errorf("Segments = %+v, want %+v", r.Segments, want)
+	}
+}
+
+func TestJoinCommits(t *testing.T) {
+	got := JoinCommits([]string{"One.", "Two\n\n", "Three", ".", "", "\nFour"})
+	if want := "One. Two\n\nThree.\nFour"; got != want {
+		t.Errorf("JoinCommits = %q, want %q", got, want)
+	}
+}
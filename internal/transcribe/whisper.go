@@ -6,10 +6,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/joegoldin/audiomemo/internal/encrypt"
 )
 
 // whisperVariant identifies which whisper implementation we're using.
@@ -114,6 +117,10 @@ func (w *Whisper) Transcribe(ctx context.Context, audioPath string, opts Transcr
 		return nil, err
 	}
 
+	if opts.Translate && w.variant == variantFFmpegWhisper {
+		return nil, fmt.Errorf("ffmpeg-whisper does not support translation")
+	}
+
 	// whisperx diarization requires a HuggingFace token
 	if opts.Diarize && w.variant == variantWhisperX && w.hfToken == "" {
 		return nil, fmt.Errorf("whisperx diarization requires a HuggingFace token (set HF_TOKEN env var or transcribe.whisper.hf_token in config)")
@@ -126,7 +133,13 @@ func (w *Whisper) Transcribe(ctx context.Context, audioPath string, opts Transcr
 		return nil, fmt.Errorf("audio file not found: %w", err)
 	}
 
-	tmpDir, err := os.MkdirTemp("", "audiomemo-whisper-*")
+	// The audio may have been decrypted, and what whisper writes here is
+	// its transcript, so neither goes in the shared temp directory.
+	tmp, err := encrypt.TempDir()
+	if err != nil {
+		return nil, err
+	}
+	tmpDir, err := os.MkdirTemp(tmp, "whisper-*")
 	if err != nil {
 		return nil, err
 	}
@@ -152,8 +165,18 @@ func (w *Whisper) Transcribe(ctx context.Context, audioPath string, opts Transcr
 	if opts.Verbose {
 		cmd.Stderr = os.Stderr
 	}
+	flush := func() {}
+	if opts.Progress != nil {
+		var verbose io.Writer
+		if opts.Verbose {
+			verbose = os.Stderr
+		}
+		flush = watchWhisper(cmd, opts.Progress, verbose)
+	}
 
-	if err := cmd.Run(); err != nil {
+	err = cmd.Run()
+	flush()
+	if err != nil {
 		return nil, fmt.Errorf("%s failed: %w", w.Name(), err)
 	}
 
@@ -163,7 +186,15 @@ func (w *Whisper) Transcribe(ctx context.Context, audioPath string, opts Transcr
 		return nil, fmt.Errorf("failed to read %s output at %s: %w", w.Name(), jsonPath, err)
 	}
 
-	return w.parseOutput(data)
+	result, err := w.parseOutput(data)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Translate {
+		// whisper reports the language it heard, not the one it wrote.
+		result.Language = "en"
+	}
+	return result, nil
 }
 
 // transcribeFFmpeg uses ffmpeg's built-in whisper audio filter (8.0+).
@@ -212,7 +243,13 @@ func (w *Whisper) transcribeFFmpeg(ctx context.Context, audioPath, tmpDir string
 		return nil, fmt.Errorf("failed to read ffmpeg whisper output at %s: %w", jsonPath, err)
 	}
 
-	return w.parseFFmpegWhisperOutput(data)
+	result, err := w.parseFFmpegWhisperOutput(data)
+	if err != nil {
+		return nil, err
+	}
+	// The whisper filter takes no prompt, so vocabulary is applied afterwards.
+	CorrectVocabulary(result, opts.Vocabulary)
+	return result, nil
 }
 
 // parseFFmpegWhisperOutput parses the JSON output from ffmpeg's whisper filter.
@@ -332,6 +369,12 @@ func (w *Whisper) buildWhisperArgs(audioPath, tmpDir, model string, opts Transcr
 	if opts.Language != "" {
 		args = append(args, "--language", opts.Language)
 	}
+	if len(opts.Vocabulary) > 0 {
+		args = append(args, "--initial_prompt", vocabularyPrompt(opts.Vocabulary))
+	}
+	if opts.Translate {
+		args = append(args, "--task", "translate")
+	}
 	args = append(args, audioPath)
 	return args
 }
@@ -350,6 +393,15 @@ func (w *Whisper) buildWhisperCPPArgs(audioPath, tmpDir, model string, opts Tran
 	if opts.Language != "" {
 		args = append(args, "-l", opts.Language)
 	}
+	if len(opts.Vocabulary) > 0 {
+		args = append(args, "--prompt", vocabularyPrompt(opts.Vocabulary))
+	}
+	if opts.Translate {
+		args = append(args, "--translate")
+	}
+	if opts.Progress != nil {
+		args = append(args, "-pp")
+	}
 	args = append(args, "-f", audioPath)
 	return args
 }
@@ -364,12 +416,21 @@ func (w *Whisper) buildWhisperXArgs(audioPath, tmpDir, model string, opts Transc
 	if opts.Language != "" {
 		args = append(args, "--language", opts.Language)
 	}
+	if len(opts.Vocabulary) > 0 {
+		args = append(args, "--initial_prompt", vocabularyPrompt(opts.Vocabulary))
+	}
+	if opts.Translate {
+		args = append(args, "--task", "translate")
+	}
 	if opts.Diarize {
 		args = append(args, "--diarize")
 		if w.hfToken != "" {
 			args = append(args, "--hf_token", w.hfToken)
 		}
 	}
+	if opts.Progress != nil {
+		args = append(args, "--print_progress", "True")
+	}
 	args = append(args, audioPath)
 	return args
 }
@@ -426,9 +487,10 @@ type whisperOutput struct {
 }
 
 type whisperSegment struct {
-	Start float64 `json:"start"`
-	End   float64 `json:"end"`
-	Text  string  `json:"text"`
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Text    string  `json:"text"`
+	Speaker string  `json:"speaker"` // whisperx --diarize, e.g. "SPEAKER_01"
 }
 
 type whisperCPPResult struct {
@@ -465,9 +527,10 @@ func (w *Whisper) parseOutput(data []byte) (*Result, error) {
 	}
 	for _, seg := range out.Segments {
 		result.Segments = append(result.Segments, Segment{
-			Start: seg.Start,
-			End:   seg.End,
-			Text:  strings.TrimSpace(seg.Text),
+			Start:   seg.Start,
+			End:     seg.End,
+			Text:    strings.TrimSpace(seg.Text),
+			Speaker: seg.Speaker,
 		})
 	}
 	// whisperx may omit top-level "text"; rebuild from segments
@@ -3,6 +3,7 @@ package transcribe
 import (
 	"context"
 	"os/exec"
+	"strings"
 	"testing"
 )
 
@@ -109,6 +110,48 @@ func TestWhisperXBuildArgs(t *testing.T) {
 	}
 }
 
+func TestWhisperBuildArgsVocabulary(t *testing.T) {
+	opts := TranscribeOpts{Model: "base", Vocabulary: []string{"AudioMemo", "Joe Goldin"}}
+	tests := []struct {
+		binary string
+		flag   string
+	}{
+		{"whisper", "--initial_prompt"},
+		{"whisperx", "--initial_prompt"},
+		{"whisper-cli", "--prompt"},
+	}
+	for _, tt := range tests {
+		args := NewWhisper(tt.binary, "base").buildArgs("/tmp/test.wav", "/tmp/out", opts)
+		found := false
+		for i, a := range args {
+			if a == tt.flag && i+1 < len(args) && args[i+1] == "AudioMemo, Joe Goldin" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s: expected %s \"AudioMemo, Joe Goldin\" in args: %v", tt.binary, tt.flag, args)
+		}
+	}
+}
+
+func TestWhisperBuildArgsTranslate(t *testing.T) {
+	opts := TranscribeOpts{Model: "base", Translate: true}
+	tests := []struct {
+		binary string
+		want   string
+	}{
+		{"whisper", "--task translate"},
+		{"whisperx", "--task translate"},
+		{"whisper-cli", "--translate"},
+	}
+	for _, tt := range tests {
+		args := strings.Join(NewWhisper(tt.binary, "base").buildArgs("/tmp/test.wav", "/tmp/out", opts), " ")
+		if !strings.Contains(args, tt.want) {
+			t.Errorf("%s: expected %q in args: %s", tt.binary, tt.want, args)
+		}
+	}
+}
+
 func TestDetectVariant(t *testing.T) {
 	tests := []struct {
 		binary  string
@@ -286,6 +329,23 @@ func TestParseOutputWhisperXNoTopText(t *testing.T) {
 	}
 }
 
+func TestParseOutputWhisperXSpeakers(t *testing.T) {
+	w := &Whisper{variant: variantWhisperX}
+	data := []byte(`{
+		"segments": [
+			{"start": 0.0, "end": 3.0, "text": "Hello", "speaker": "SPEAKER_01"},
+			{"start": 3.0, "end": 6.0, "text": "Hi", "speaker": "SPEAKER_00"}
+		]
+	}`)
+	result, err := w.parseOutput(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Segments[0].Speaker != "SPEAKER_01" || result.Segments[1].Speaker != "SPEAKER_00" {
+		t.Errorf("speakers = %q, %q", result.Segments[0].Speaker, result.Segments[1].Speaker)
+	}
+}
+
 func TestResolveWhisperCPPModelPath(t *testing.T) {
 	// Direct path should pass through
 	p := resolveWhisperCPPModel("/some/path/ggml-base.bin")
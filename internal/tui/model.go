@@ -25,6 +25,10 @@ const (
 // ("" when streaming started) rendered dim below the transcript.
 type StartFunc func() (*record.Recorder, *transcribe.Streamer, string, error)
 
+// MarkFunc saves a bookmark made at elapsed into the recording, when "mark
+// this" is said.
+type MarkFunc func(elapsed time.Duration) error
+
 type Model struct {
 	state        State
 	recorder     *record.Recorder
@@ -47,6 +51,7 @@ type Model struct {
 	transcript   TranscriptViewport
 	streamErr    error
 	streamNote   string // e.g. "live transcription unavailable: ..."
+	onMark       MarkFunc
 }
 
 // ShouldTranscribe returns true if the user pressed Q to quit-and-transcribe.
@@ -80,6 +85,7 @@ type doneMsg struct{ err error }
 type committedMsg string
 type partialMsg string
 type streamErrMsg error
+type voiceMsg string
 
 func NewModel(rec *record.Recorder, opts record.RecordOpts) *Model {
 	return &Model{
@@ -126,13 +132,18 @@ func (m *Model) SetStreamNote(note string) {
 	m.streamNote = note
 }
 
+// SetMarkFunc sets where spoken marks are saved. Without one they are not.
+func (m *Model) SetMarkFunc(f MarkFunc) {
+	m.onMark = f
+}
+
 func (m *Model) Init() tea.Cmd {
 	cmds := []tea.Cmd{tickCmd()}
 	if m.state == StateRecording {
 		cmds = append(cmds, listenLevel(m.recorder), listenDone(m.recorder))
 	}
 	if m.streamer != nil {
-		cmds = append(cmds, listenCommitted(m.streamer), listenPartial(m.streamer), listenStreamErr(m.streamer))
+		cmds = append(cmds, listenCommitted(m.streamer), listenPartial(m.streamer), listenStreamErr(m.streamer), listenVoice(m.streamer))
 	}
 	if m.state == StateReady {
 		return cmds[0] // just tickCmd for ready state
@@ -193,6 +204,16 @@ func listenStreamErr(s *transcribe.Streamer) tea.Cmd {
 	}
 }
 
+func listenVoice(s *transcribe.Streamer) tea.Cmd {
+	return func() tea.Msg {
+		action, ok := <-s.Voice
+		if !ok {
+			return nil
+		}
+		return voiceMsg(action)
+	}
+}
+
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
@@ -248,10 +269,42 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// the user that streaming stopped; recording continues unaffected.
 		m.streamErr = error(msg)
 		return m, nil
+
+	case voiceMsg:
+		return m.handleVoice(string(msg))
 	}
 	return m, nil
 }
 
+// handleVoice carries out a command spoken while recording. The streamer has
+// already taken a scratch out of the live transcript file; the view follows
+// it. A spoken stop is q.
+func (m *Model) handleVoice(action string) (tea.Model, tea.Cmd) {
+	switch action {
+	case transcribe.VoiceScratch:
+		m.transcript.ScratchLast()
+	case transcribe.VoiceMark:
+		if m.onMark != nil {
+			at := time.Since(m.startTime)
+			if err := m.onMark(at); err != nil {
+				m.streamNote = fmt.Sprintf("mark not saved: %v", err)
+			} else {
+				m.savedMessage = "Marked at " + formatDuration(at)
+			}
+		}
+	case transcribe.VoiceStop:
+		if m.state == StateRecording {
+			m.recorder.Stop()
+			m.state = StateSaved
+			if m.clipsMode {
+				m.clipDone = true
+			}
+			return m, tea.Quit
+		}
+	}
+	return m, listenVoice(m.streamer)
+}
+
 func (m *Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg.String() {
@@ -310,7 +363,7 @@ func (m *Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.muted = false
 			cmds := []tea.Cmd{listenLevel(m.recorder), listenDone(m.recorder)}
 			if m.streamer != nil {
-				cmds = append(cmds, listenCommitted(m.streamer), listenPartial(m.streamer), listenStreamErr(m.streamer))
+				cmds = append(cmds, listenCommitted(m.streamer), listenPartial(m.streamer), listenStreamErr(m.streamer), listenVoice(m.streamer))
 			}
 			return m, tea.Batch(cmds...)
 		}
@@ -8,6 +8,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/joegoldin/audiomemo/internal/record"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
 )
 
 func testOpts() record.RecordOpts {
@@ -152,3 +153,32 @@ func TestModelQuitsWhenRecordingEndsOnItsOwn(t *testing.T) {
 		t.Errorf("command returned %T, want tea.QuitMsg", cmd())
 	}
 }
+
+func TestModelSpokenMarkAndScratch(t *testing.T) {
+	m := advance(NewModelWithStreamer(nil, testOpts(), transcribe.NewStreamer("key", false)))
+	var marks []time.Duration
+	m.SetMarkFunc(func(at time.Duration) error {
+		marks = append(marks, at)
+		return nil
+	})
+
+	m.Update(committedMsg("keep this"))
+	m.Update(committedMsg("not this"))
+	next, _ := m.Update(voiceMsg(transcribe.VoiceScratch))
+	m = next.(*Model)
+	if view := m.View(); !strings.Contains(view, "keep this") || strings.Contains(view, "not this") {
+		t.Errorf("scratch should drop the last commit, got view:\n%s", view)
+	}
+
+	next, cmd := m.Update(voiceMsg(transcribe.VoiceMark))
+	m = next.(*Model)
+	if len(marks) != 1 {
+		t.Fatalf("marks = %v, want one", marks)
+	}
+	if !strings.Contains(m.View(), "Marked at 00:00:00") {
+		t.Errorf("expected the mark to be acknowledged, got view:\n%s", m.View())
+	}
+	if cmd == nil || m.state != StateRecording {
+		t.Error("a mark should keep recording and keep listening")
+	}
+}
@@ -0,0 +1,182 @@
+package tui
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SpeakerSample is one diarized speaker as the labelling TUI presents it: the
+// label the backend gave, a line they said, and where in the audio to play it.
+type SpeakerSample struct {
+	Label    string
+	Quote    string
+	Start    float64 // seconds into the recording
+	Duration float64 // seconds
+	Name     string  // pre-filled name, e.g. from the recording's metadata
+}
+
+// SpeakerPlayer builds the command that plays a sample. It returns an unstarted
+// command so the TUI can kill a sample that is still playing when the user
+// moves on. A nil player means there is no audio to play.
+type SpeakerPlayer func(SpeakerSample) *exec.Cmd
+
+// LabelSpeakersResult maps each label the user named to that name. Labels the
+// user skipped are absent. Cancelled is set on ctrl+c, and means nothing
+// should be written.
+type LabelSpeakersResult struct {
+	Names     map[string]string
+	Cancelled bool
+}
+
+// speakerPlayedMsg reports that a sample finished. It carries the command so
+// the exit of a sample killed by a replay is not mistaken for the replay's.
+type speakerPlayedMsg struct {
+	cmd *exec.Cmd
+	err error
+}
+
+type speakerLabelModel struct {
+	samples   []SpeakerSample
+	cursor    int
+	input     simpleInput
+	names     map[string]string
+	player    SpeakerPlayer
+	playing   *exec.Cmd
+	message   string
+	cancelled bool
+	done      bool
+}
+
+// RunLabelSpeakers asks for a name for each speaker in turn, playing a sample
+// of their voice as each one comes up.
+func RunLabelSpeakers(samples []SpeakerSample, player SpeakerPlayer, opts ...tea.ProgramOption) (LabelSpeakersResult, error) {
+	m := newSpeakerLabelModel(samples, player)
+	final, err := tea.NewProgram(m, opts...).Run()
+	m.stopPlaying()
+	if err != nil {
+		return LabelSpeakersResult{}, err
+	}
+	fm := final.(*speakerLabelModel)
+	return LabelSpeakersResult{Names: fm.names, Cancelled: fm.cancelled}, nil
+}
+
+func newSpeakerLabelModel(samples []SpeakerSample, player SpeakerPlayer) *speakerLabelModel {
+	m := &speakerLabelModel{
+		samples: samples,
+		input:   newSimpleInput("name (enter to keep label)"),
+		names:   map[string]string{},
+		player:  player,
+	}
+	if player == nil {
+		m.message = "no audio to play: naming from quotes only"
+	}
+	if len(samples) > 0 {
+		m.input.SetValue(samples[0].Name)
+	}
+	return m
+}
+
+func (m *speakerLabelModel) Init() tea.Cmd {
+	return m.play()
+}
+
+// play starts the current speaker's sample, stopping whatever was playing.
+func (m *speakerLabelModel) play() tea.Cmd {
+	m.stopPlaying()
+	if m.player == nil || m.cursor >= len(m.samples) {
+		return nil
+	}
+	cmd := m.player(m.samples[m.cursor])
+	if err := cmd.Start(); err != nil {
+		m.message = fmt.Sprintf("playback failed: %v", err)
+		return nil
+	}
+	m.playing = cmd
+	return func() tea.Msg {
+		return speakerPlayedMsg{cmd: cmd, err: cmd.Wait()}
+	}
+}
+
+func (m *speakerLabelModel) stopPlaying() {
+	if m.playing != nil && m.playing.Process != nil {
+		m.playing.Process.Kill()
+	}
+	m.playing = nil
+}
+
+func (m *speakerLabelModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case speakerPlayedMsg:
+		// A sample killed because the user moved on is not worth reporting.
+		if msg.cmd != m.playing {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.message = fmt.Sprintf("playback failed: %v", msg.err)
+		}
+		m.playing = nil
+		return m, nil
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m *speakerLabelModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.cancelled = true
+		return m, tea.Quit
+	case "tab":
+		return m, m.play()
+	case "enter":
+		if name := strings.TrimSpace(m.input.Value()); name != "" && name != m.samples[m.cursor].Label {
+			m.names[m.samples[m.cursor].Label] = name
+		}
+		return m.advance()
+	case "esc":
+		return m.advance()
+	default:
+		m.input.HandleKey(msg.String())
+	}
+	return m, nil
+}
+
+func (m *speakerLabelModel) advance() (tea.Model, tea.Cmd) {
+	m.cursor++
+	if m.cursor >= len(m.samples) {
+		m.done = true
+		m.stopPlaying()
+		return m, tea.Quit
+	}
+	m.input.SetValue(m.samples[m.cursor].Name)
+	return m, m.play()
+}
+
+func (m *speakerLabelModel) View() string {
+	if m.done || m.cancelled || m.cursor >= len(m.samples) {
+		return ""
+	}
+	s := m.samples[m.cursor]
+	var b strings.Builder
+	b.WriteString("\n")
+	b.WriteString("  " + dmTitleStyle.Render(fmt.Sprintf("Who is %s?", s.Label)) +
+		dmDimStyle.Render(fmt.Sprintf("  (%d of %d)", m.cursor+1, len(m.samples))) + "\n\n")
+	b.WriteString("  " + dmDimStyle.Render(fmt.Sprintf("at %s:", formatDuration(time.Duration(s.Start*float64(time.Second))))) + "\n")
+	b.WriteString("  " + dmAccentStyle.Render("“"+s.Quote+"”") + "\n\n")
+	b.WriteString("  Name: " + m.input.View() + "\n\n")
+	if m.message != "" {
+		b.WriteString("  " + dmDimStyle.Render(m.message) + "\n\n")
+	}
+	keys := "[enter] save  [esc] skip  [ctrl+c] cancel"
+	if m.player != nil {
+		keys = "[tab] replay  " + keys
+	}
+	b.WriteString("  " + dmDimStyle.Render(keys) + "\n")
+	return b.String()
+}
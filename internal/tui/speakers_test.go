@@ -0,0 +1,68 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func speakerTestSamples() []SpeakerSample {
+	return []SpeakerSample{
+		{Label: "Speaker 0", Quote: "shall we start"},
+		{Label: "Speaker 1", Quote: "sure", Name: "Bob"},
+		{Label: "Speaker 2", Quote: "one more thing"},
+	}
+}
+
+func typeString(m *speakerLabelModel, s string) {
+	for _, r := range s {
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+func TestLabelSpeakersNamesSkipsAndPrefills(t *testing.T) {
+	m := newSpeakerLabelModel(speakerTestSamples(), nil)
+	typeString(m, "Alice")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := m.input.Value(); got != "Bob" {
+		t.Errorf("second input prefilled with %q, want Bob", got)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if cmd == nil || !m.done {
+		t.Fatal("expected the model to finish after the last speaker")
+	}
+	want := map[string]string{"Speaker 0": "Alice", "Speaker 1": "Bob"}
+	if len(m.names) != len(want) {
+		t.Fatalf("names = %v, want %v", m.names, want)
+	}
+	for k, v := range want {
+		if m.names[k] != v {
+			t.Errorf("names[%q] = %q, want %q", k, m.names[k], v)
+		}
+	}
+}
+
+func TestLabelSpeakersCtrlCCancels(t *testing.T) {
+	m := newSpeakerLabelModel(speakerTestSamples(), nil)
+	typeString(m, "Alice")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if !m.cancelled {
+		t.Error("expected ctrl+c to cancel")
+	}
+}
+
+func TestLabelSpeakersWithoutPlayerSaysSo(t *testing.T) {
+	m := newSpeakerLabelModel(speakerTestSamples(), nil)
+	if m.Init() != nil {
+		t.Error("expected no playback command without a player")
+	}
+	view := m.View()
+	for _, want := range []string{"Who is Speaker 0?", "shall we start", "no audio to play"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+}
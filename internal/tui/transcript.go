@@ -6,6 +6,7 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
 )
 
 var transcriptDimStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#666666"))
@@ -13,9 +14,9 @@ var transcriptDimStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#666666"
 // TranscriptViewport displays scrollable transcript text with auto-scroll.
 type TranscriptViewport struct {
 	viewport   viewport.Model
-	committed  string // accumulated committed text
-	partial    string // current partial text (shown dim)
-	cursor     string // styled single-cell VU cursor at the insertion point
+	commits    []string // committed text, one entry per commit
+	partial    string   // current partial text (shown dim)
+	cursor     string   // styled single-cell VU cursor at the insertion point
 	autoScroll bool
 	width      int
 	height     int
@@ -35,11 +36,7 @@ func NewTranscriptViewport(width, height int) TranscriptViewport {
 // AppendCommitted appends text to the committed transcript, clears partial,
 // rebuilds viewport content, and scrolls to bottom if autoScroll is enabled.
 func (t *TranscriptViewport) AppendCommitted(text string) {
-	if t.committed == "" {
-		t.committed = text
-	} else {
-		t.committed = t.committed + " " + text
-	}
+	t.commits = append(t.commits, text)
 	t.partial = ""
 	t.rebuildContent()
 	if t.autoScroll {
@@ -47,6 +44,16 @@ func (t *TranscriptViewport) AppendCommitted(text string) {
 	}
 }
 
+// ScratchLast removes the last commit, as a spoken "scratch that" does to
+// the live transcript file.
+func (t *TranscriptViewport) ScratchLast() {
+	if len(t.commits) == 0 {
+		return
+	}
+	t.commits = t.commits[:len(t.commits)-1]
+	t.rebuildContent()
+}
+
 // SetPartial sets the current partial (in-progress) text shown in dim style
 // and scrolls to bottom if autoScroll is enabled, so wrapped partial text
 // stays in view as it grows.
@@ -132,14 +139,15 @@ func (t TranscriptViewport) IsAutoScroll() bool {
 
 // rebuildContent rebuilds the viewport content from committed + partial text.
 func (t *TranscriptViewport) rebuildContent() {
-	t.viewport.SetContent(wrapTranscript(t.committed, t.partial, t.width, t.cursor))
+	t.viewport.SetContent(wrapTranscript(transcribe.JoinCommits(t.commits), t.partial, t.width, t.cursor))
 }
 
 // wrapTranscript word-wraps committed + partial together so the partial text
 // continues onto new lines instead of overflowing the last committed line.
 // Partial words are rendered with transcriptDimStyle; committed words are
 // rendered plain. Word boundaries are spaces; words longer than width stay
-// on their own line (they are not broken). The styled single-cell cursor is
+// on their own line (they are not broken). Newlines in committed, from a
+// spoken "new line" or "new paragraph", are kept. The styled single-cell cursor is
 // appended directly after the last word; one cell is reserved for it on the
 // final line so it never wraps onto a line by itself.
 func wrapTranscript(committed, partial string, width int, cursor string) string {
@@ -150,10 +158,16 @@ func wrapTranscript(committed, partial string, width int, cursor string) string
 	type wrapWord struct {
 		text string
 		dim  bool
+		brk  bool // a line break rather than a word
 	}
 	var words []wrapWord
-	for _, w := range strings.Fields(committed) {
-		words = append(words, wrapWord{text: w})
+	for i, line := range strings.Split(committed, "\n") {
+		if i > 0 {
+			words = append(words, wrapWord{brk: true})
+		}
+		for _, w := range strings.Fields(line) {
+			words = append(words, wrapWord{text: w})
+		}
 	}
 	for _, w := range strings.Fields(partial) {
 		words = append(words, wrapWord{text: w, dim: true})
@@ -170,6 +184,11 @@ func wrapTranscript(committed, partial string, width int, cursor string) string
 	var b strings.Builder
 	lineLen := 0
 	for i, w := range words {
+		if w.brk {
+			b.WriteByte('\n')
+			lineLen = 0
+			continue
+		}
 		wordLen := len(w.text)
 		fitLen := wordLen
 		if i == len(words)-1 {
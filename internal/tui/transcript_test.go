@@ -201,3 +201,31 @@ func TestTranscriptViewportClearsPartialOnCommit(t *testing.T) {
 		t.Errorf("expected partial to be cleared after AppendCommitted, got %q", tv.partial)
 	}
 }
+
+func TestWrapTranscriptKeepsSpokenBreaks(t *testing.T) {
+	out := wrapTranscript("Dear Sam,\nThanks for this.\n\nNext", "wip", 80, "")
+	lines := strings.Split(out, "\n")
+	if len(lines) != 4 || lines[0] != "Dear Sam," || lines[1] != "Thanks for this." || lines[2] != "" {
+		t.Fatalf("got lines %q", lines)
+	}
+	if !strings.HasPrefix(lines[3], "Next ") {
+		t.Errorf("partial should follow the last word, got %q", lines[3])
+	}
+}
+
+func TestTranscriptViewportScratchLast(t *testing.T) {
+	tv := NewTranscriptViewport(80, 24)
+	tv.AppendCommitted("keep this")
+	tv.AppendCommitted("not this")
+	tv.ScratchLast()
+
+	view := tv.viewport.View()
+	if !strings.Contains(view, "keep this") || strings.Contains(view, "not this") {
+		t.Errorf("expected only the first commit, got: %q", view)
+	}
+	tv.ScratchLast()
+	tv.ScratchLast() // nothing left to scratch
+	if view := tv.viewport.View(); strings.Contains(view, "keep this") {
+		t.Errorf("expected an empty transcript, got: %q", view)
+	}
+}
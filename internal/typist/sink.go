@@ -0,0 +1,196 @@
+package typist
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ToolNames are the typing programs with built-in support: wtype for
+// Wayland, xdotool for X11, and ydotool, which types through uinput under
+// either and needs its daemon running.
+var ToolNames = []string{"wtype", "xdotool", "ydotool"}
+
+// Command is one run of a typing program. The text goes on stdin wherever
+// the program reads it from there, so text starting with a dash is never
+// taken for a flag.
+type Command struct {
+	Args  []string
+	Stdin string
+}
+
+// Tool says how one typing program types and erases.
+type Tool struct {
+	Type  func(text string) Command
+	Erase func(n int) Command // nil when the program cannot erase
+}
+
+// NamedTool returns the built-in tool called name, pausing keyDelay between
+// keystrokes; zero leaves the program's own default.
+func NamedTool(name string, keyDelay time.Duration) (Tool, error) {
+	ms := strconv.FormatInt(keyDelay.Milliseconds(), 10)
+	delay := func(flag string) []string {
+		if keyDelay <= 0 {
+			return nil
+		}
+		return []string{flag, ms}
+	}
+	switch name {
+	case "wtype":
+		return Tool{
+			Type: func(text string) Command {
+				return Command{Args: concat([]string{"wtype"}, delay("-d"), []string{"-"}), Stdin: text}
+			},
+			Erase: func(n int) Command {
+				args := concat([]string{"wtype"}, delay("-d"))
+				for range n {
+					args = append(args, "-k", "BackSpace")
+				}
+				return Command{Args: args}
+			},
+		}, nil
+	case "xdotool":
+		return Tool{
+			Type: func(text string) Command {
+				return Command{Args: concat([]string{"xdotool", "type"}, delay("--delay"), []string{"--file", "-"}), Stdin: text}
+			},
+			Erase: func(n int) Command {
+				return Command{Args: concat([]string{"xdotool", "key"}, delay("--delay"), []string{"--repeat", strconv.Itoa(n), "BackSpace"})}
+			},
+		}, nil
+	case "ydotool":
+		return Tool{
+			Type: func(text string) Command {
+				return Command{Args: concat([]string{"ydotool", "type"}, delay("--key-delay"), []string{"--file", "/dev/stdin"}), Stdin: text}
+			},
+			Erase: func(n int) Command {
+				// 14 is KEY_BACKSPACE; each press is a down and an up.
+				args := concat([]string{"ydotool", "key"}, delay("--key-delay"))
+				for range n {
+					args = append(args, "14:1", "14:0")
+				}
+				return Command{Args: args}
+			},
+		}, nil
+	}
+	return Tool{}, fmt.Errorf("unknown typing tool %q (want %s)", name, strings.Join(ToolNames, ", "))
+}
+
+// CustomTool runs typeCmd with the text on stdin, and eraseCmd with the
+// number of characters to erase as its last argument. Without an eraseCmd a
+// scratch cannot take typed text back.
+func CustomTool(typeCmd, eraseCmd []string) Tool {
+	t := Tool{
+		Type: func(text string) Command {
+			return Command{Args: typeCmd, Stdin: text}
+		},
+	}
+	if len(eraseCmd) > 0 {
+		t.Erase = func(n int) Command {
+			return Command{Args: concat(eraseCmd, []string{strconv.Itoa(n)})}
+		}
+	}
+	return t
+}
+
+func concat(parts ...[]string) []string {
+	var out []string
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+var errNoErase = errors.New("the typing command has no erase command, so a scratch cannot take typed text back")
+
+type commandSink struct {
+	tool Tool
+	run  func(Command) error
+}
+
+// NewCommandSink types through tool, handing each command to run: Run to
+// carry it out, or DryRun to print it.
+func NewCommandSink(tool Tool, run func(Command) error) Sink {
+	return commandSink{tool: tool, run: run}
+}
+
+func (s commandSink) Type(text string) error {
+	return s.run(s.tool.Type(text))
+}
+
+func (s commandSink) Erase(n int) error {
+	if s.tool.Erase == nil {
+		return errNoErase
+	}
+	return s.run(s.tool.Erase(n))
+}
+
+func (s commandSink) Close() error { return nil }
+
+// Run carries out c, naming the program and what it printed if it fails.
+func Run(c Command) error {
+	cmd := exec.Command(c.Args[0], c.Args[1:]...)
+	cmd.Stdin = strings.NewReader(c.Stdin)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s: %w\n%s", c.Args[0], err, msg)
+		}
+		return fmt.Errorf("%s: %w", c.Args[0], err)
+	}
+	return nil
+}
+
+// DryRun returns a run that prints each command to w, one line each, with
+// its stdin quoted after it, instead of carrying it out.
+func DryRun(w io.Writer) func(Command) error {
+	return func(c Command) error {
+		line := strings.Join(c.Args, " ")
+		if c.Stdin != "" {
+			line += " < " + strconv.Quote(c.Stdin)
+		}
+		_, err := fmt.Fprintln(w, line)
+		return err
+	}
+}
+
+// pipeSink writes to a named pipe, opened on first use so that its reader
+// can start after the recording does. Until then commits wait in the queue.
+type pipeSink struct {
+	path string
+	f    *os.File
+}
+
+// NewPipeSink writes typed text to the named pipe at path, and an erasure as
+// that many backspace characters (\b) for the reader to act on.
+func NewPipeSink(path string) Sink {
+	return &pipeSink{path: path}
+}
+
+func (p *pipeSink) write(s string) error {
+	if p.f == nil {
+		f, err := os.OpenFile(p.path, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return err
+		}
+		p.f = f
+	}
+	_, err := p.f.WriteString(s)
+	return err
+}
+
+func (p *pipeSink) Type(text string) error { return p.write(text) }
+
+func (p *pipeSink) Erase(n int) error { return p.write(strings.Repeat("\b", n)) }
+
+func (p *pipeSink) Close() error {
+	if p.f == nil {
+		return nil
+	}
+	return p.f.Close()
+}
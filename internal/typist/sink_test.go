@@ -0,0 +1,109 @@
+package typist
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestNamedToolCommands(t *testing.T) {
+	tests := []struct {
+		name      string
+		delay     time.Duration
+		typeArgs  []string
+		eraseArgs []string
+	}{
+		{"wtype", 0, []string{"wtype", "-"}, []string{"wtype", "-k", "BackSpace", "-k", "BackSpace"}},
+		{"wtype", 12 * time.Millisecond, []string{"wtype", "-d", "12", "-"}, []string{"wtype", "-d", "12", "-k", "BackSpace", "-k", "BackSpace"}},
+		{"xdotool", 0, []string{"xdotool", "type", "--file", "-"}, []string{"xdotool", "key", "--repeat", "2", "BackSpace"}},
+		{"ydotool", 5 * time.Millisecond, []string{"ydotool", "type", "--key-delay", "5", "--file", "/dev/stdin"}, []string{"ydotool", "key", "--key-delay", "5", "14:1", "14:0", "14:1", "14:0"}},
+	}
+	for _, tt := range tests {
+		tool, err := NamedTool(tt.name, tt.delay)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := tool.Type("-n hi")
+		if !slices.Equal(c.Args, tt.typeArgs) || c.Stdin != "-n hi" {
+			t.Errorf("%s type = %q < %q, want %q", tt.name, c.Args, c.Stdin, tt.typeArgs)
+		}
+		if got := tool.Erase(2).Args; !slices.Equal(got, tt.eraseArgs) {
+			t.Errorf("%s erase = %q, want %q", tt.name, got, tt.eraseArgs)
+		}
+	}
+	if _, err := NamedTool("dotool", 0); err == nil {
+		t.Error("expected an error for an unknown tool")
+	}
+}
+
+func TestCustomToolWithoutEraseCannotScratch(t *testing.T) {
+	var ran []Command
+	sink := NewCommandSink(CustomTool([]string{"mytype", "--stdin"}, nil), func(c Command) error {
+		ran = append(ran, c)
+		return nil
+	})
+	if err := sink.Type("hello"); err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) != 1 || !slices.Equal(ran[0].Args, []string{"mytype", "--stdin"}) || ran[0].Stdin != "hello" {
+		t.Errorf("ran %+v", ran)
+	}
+	if err := sink.Erase(3); err != errNoErase {
+		t.Errorf("Erase = %v, want errNoErase", err)
+	}
+}
+
+func TestDryRunPrintsCommands(t *testing.T) {
+	var buf bytes.Buffer
+	tool, _ := NamedTool("xdotool", 0)
+	sink := NewCommandSink(tool, DryRun(&buf))
+	sink.Type("Hi\n")
+	sink.Erase(3)
+	want := "xdotool type --file - < \"Hi\\n\"\nxdotool key --repeat 3 BackSpace\n"
+	if buf.String() != want {
+		t.Errorf("printed %q, want %q", buf.String(), want)
+	}
+}
+
+func TestRunReportsFailure(t *testing.T) {
+	if err := Run(Command{Args: []string{"sh", "-c", "cat >/dev/null; echo no display >&2; exit 1"}, Stdin: "x"}); err == nil {
+		t.Fatal("expected an error")
+	} else if !bytes.Contains([]byte(err.Error()), []byte("no display")) {
+		t.Errorf("error %q should carry the tool's output", err)
+	}
+}
+
+func TestPipeSinkWaitsForReader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dictation")
+	if err := syscall.Mkfifo(path, 0600); err != nil {
+		t.Skipf("mkfifo: %v", err)
+	}
+	ty := New(NewPipeSink(path), 0)
+	ty.Commit("Hello")
+	ty.Scratch()
+	ty.Commit("Hi.")
+	time.Sleep(20 * time.Millisecond) // nobody reading yet; the queue holds
+
+	got := make(chan []byte)
+	go func() {
+		f, err := os.Open(path)
+		if err != nil {
+			got <- nil
+			return
+		}
+		defer f.Close()
+		var buf bytes.Buffer
+		buf.ReadFrom(f)
+		got <- buf.Bytes()
+	}()
+	if err := ty.Close(2 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if data := <-got; string(data) != "Hi." {
+		t.Errorf("reader got %q", data)
+	}
+}
@@ -0,0 +1,213 @@
+// Package typist types a live transcript into whatever window has focus, as
+// it is committed, for `record --type-into`.
+//
+// Commits arrive on the goroutine reading the realtime WebSocket, which must
+// never wait on a typing tool, so a Typist queues them and one goroutine of
+// its own does the typing, in order. A tool that falls behind gets the
+// backlog as one string rather than one call per commit, and a scratch of a
+// commit that has not been typed yet just takes it out of the queue.
+package typist
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+// Sink is where typed text goes: a typing tool, or a named pipe.
+type Sink interface {
+	// Type enters text where the cursor is.
+	Type(text string) error
+	// Erase takes back the last n characters typed.
+	Erase(n int) error
+	Close() error
+}
+
+// op is one queued change: a commit to type, or a scratch when scratch is
+// set.
+type op struct {
+	text    string
+	scratch bool
+}
+
+// Typist feeds commits to a Sink in order, at most one call every interval.
+// It satisfies transcribe.CommitSink.
+type Typist struct {
+	sink     Sink
+	interval time.Duration
+
+	mu      sync.Mutex
+	queue   []op
+	closing bool
+	errs    []error
+	wake    chan struct{}
+	done    chan struct{}
+
+	typed []typed // commits on screen, oldest first; owned by run
+}
+
+// typed is a commit as it went to the sink.
+type typed struct {
+	commit string
+	keys   string // commit with the separator typed before it
+}
+
+// New starts a Typist typing into sink.
+func New(sink Sink, interval time.Duration) *Typist {
+	t := &Typist{
+		sink:     sink,
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+// Commit queues text to be typed. It never blocks.
+func (t *Typist) Commit(text string) {
+	if text == "" {
+		return
+	}
+	t.enqueue(op{text: text})
+}
+
+// Scratch takes back the last commit: out of the queue if it has not been
+// typed yet, otherwise by erasing it.
+func (t *Typist) Scratch() {
+	t.mu.Lock()
+	if n := len(t.queue); n > 0 && !t.queue[n-1].scratch {
+		t.queue = t.queue[:n-1]
+		t.mu.Unlock()
+		return
+	}
+	t.mu.Unlock()
+	t.enqueue(op{scratch: true})
+}
+
+func (t *Typist) enqueue(o op) {
+	t.mu.Lock()
+	t.queue = append(t.queue, o)
+	t.mu.Unlock()
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Close waits up to timeout for the queue to be typed, then closes the sink.
+// It returns what went wrong while typing, and says how much was left if the
+// sink never caught up: a named pipe nobody opened, or a wedged tool.
+func (t *Typist) Close(timeout time.Duration) error {
+	t.mu.Lock()
+	t.closing = true
+	t.mu.Unlock()
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+
+	var left error
+	select {
+	case <-t.done:
+	case <-time.After(timeout):
+		t.mu.Lock()
+		if n := len(t.queue); n > 0 {
+			left = fmt.Errorf("gave up with %d commits not typed", n)
+		}
+		t.mu.Unlock()
+	}
+	t.mu.Lock()
+	errs := append(append([]error(nil), t.errs...), left)
+	t.mu.Unlock()
+	return errors.Join(append(errs, t.sink.Close())...)
+}
+
+func (t *Typist) run() {
+	defer close(t.done)
+	for {
+		t.mu.Lock()
+		batch := t.takeBatch()
+		closing := t.closing
+		t.mu.Unlock()
+
+		if len(batch) == 0 {
+			if closing {
+				return
+			}
+			<-t.wake
+			continue
+		}
+		t.apply(batch)
+		if t.interval > 0 {
+			time.Sleep(t.interval)
+		}
+	}
+}
+
+// takeBatch takes the next change off the queue: a scratch, or every commit
+// up to the next scratch. Called with mu held.
+func (t *Typist) takeBatch() []op {
+	if len(t.queue) == 0 {
+		return nil
+	}
+	n := 1
+	if !t.queue[0].scratch {
+		for n < len(t.queue) && !t.queue[n].scratch {
+			n++
+		}
+	}
+	batch := append([]op(nil), t.queue[:n]...)
+	t.queue = t.queue[n:]
+	return batch
+}
+
+func (t *Typist) apply(batch []op) {
+	if batch[0].scratch {
+		if len(t.typed) == 0 {
+			return
+		}
+		last := t.typed[len(t.typed)-1]
+		if err := t.sink.Erase(utf8.RuneCountInString(last.keys)); err != nil {
+			t.fail(err)
+			return
+		}
+		t.typed = t.typed[:len(t.typed)-1]
+		return
+	}
+
+	prev := ""
+	if len(t.typed) > 0 {
+		prev = t.typed[len(t.typed)-1].commit
+	}
+	var text strings.Builder
+	for _, o := range batch {
+		keys := transcribe.CommitSeparator(prev, o.text) + o.text
+		text.WriteString(keys)
+		t.typed = append(t.typed, typed{commit: o.text, keys: keys})
+		prev = o.text
+	}
+	if err := t.sink.Type(text.String()); err != nil {
+		// Whatever the sink managed to type is unknown; erasing it later
+		// would only guess, so it is not remembered.
+		t.typed = t.typed[:len(t.typed)-len(batch)]
+		t.fail(err)
+	}
+}
+
+// fail records err once; a missing tool would otherwise fail every commit.
+func (t *Typist) fail(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, e := range t.errs {
+		if e.Error() == err.Error() {
+			return
+		}
+	}
+	t.errs = append(t.errs, err)
+}
@@ -0,0 +1,137 @@
+package typist
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSink records what it is asked to do. Until release is closed it holds
+// the first call, so a test can queue commits behind it.
+type fakeSink struct {
+	mu      sync.Mutex
+	calls   []string
+	release chan struct{}
+	err     error
+}
+
+func newFakeSink() *fakeSink {
+	s := &fakeSink{release: make(chan struct{})}
+	close(s.release)
+	return s
+}
+
+func (s *fakeSink) record(call string) error {
+	<-s.release
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, call)
+	return s.err
+}
+
+func (s *fakeSink) Type(text string) error { return s.record("type " + text) }
+func (s *fakeSink) Erase(n int) error      { return s.record("erase " + strings.Repeat("<", n)) }
+func (s *fakeSink) Close() error           { return nil }
+
+func (s *fakeSink) got() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.calls)
+}
+
+func TestTypistTypesCommitsWithSeparators(t *testing.T) {
+	sink := newFakeSink()
+	ty := New(sink, 0)
+	for _, c := range []string{"Hello there.", "How are you", "?", "Fine\n\n", "Thanks."} {
+		ty.Commit(c)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := ty.Close(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"type Hello there.", "type  How are you", "type ?", "type  Fine\n\n", "type Thanks."}
+	if got := sink.got(); !slices.Equal(got, want) {
+		t.Errorf("calls = %q, want %q", got, want)
+	}
+}
+
+func TestTypistScratchErasesTypedCommit(t *testing.T) {
+	sink := newFakeSink()
+	ty := New(sink, 0)
+	ty.Commit("Keep.")
+	time.Sleep(10 * time.Millisecond)
+	ty.Commit("Oops")
+	time.Sleep(10 * time.Millisecond)
+	ty.Scratch()
+	time.Sleep(10 * time.Millisecond)
+	ty.Commit("Next.")
+	if err := ty.Close(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	// " Oops" is five characters, separator included.
+	want := []string{"type Keep.", "type  Oops", "erase <<<<<", "type  Next."}
+	if got := sink.got(); !slices.Equal(got, want) {
+		t.Errorf("calls = %q, want %q", got, want)
+	}
+}
+
+func TestTypistBatchesBacklogAndDropsQueuedScratch(t *testing.T) {
+	sink := &fakeSink{release: make(chan struct{})}
+	ty := New(sink, 0)
+	ty.Commit("One.") // held by the sink
+	time.Sleep(10 * time.Millisecond)
+	ty.Commit("Two.")
+	ty.Commit("Mistake.")
+	ty.Scratch() // never typed, so never erased
+	ty.Commit("Three.")
+	close(sink.release)
+	if err := ty.Close(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"type One.", "type  Two. Three."}
+	if got := sink.got(); !slices.Equal(got, want) {
+		t.Errorf("calls = %q, want %q", got, want)
+	}
+}
+
+func TestTypistRateLimits(t *testing.T) {
+	sink := newFakeSink()
+	ty := New(sink, 50*time.Millisecond)
+	start := time.Now()
+	ty.Commit("One.")
+	time.Sleep(10 * time.Millisecond)
+	ty.Scratch()
+	ty.Close(time.Second)
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("two calls took %v, want at least two intervals", elapsed)
+	}
+}
+
+func TestTypistReportsEachFailureOnce(t *testing.T) {
+	sink := newFakeSink()
+	sink.err = errors.New("wtype: not found")
+	ty := New(sink, 0)
+	ty.Commit("One.")
+	time.Sleep(10 * time.Millisecond)
+	ty.Commit("Two.")
+	err := ty.Close(time.Second)
+	if err == nil || strings.Count(err.Error(), "not found") != 1 {
+		t.Errorf("Close = %v, want the failure once", err)
+	}
+}
+
+func TestTypistCloseGivesUpOnStuckSink(t *testing.T) {
+	sink := &fakeSink{release: make(chan struct{})}
+	defer close(sink.release)
+	ty := New(sink, 0)
+	ty.Commit("One.")
+	time.Sleep(10 * time.Millisecond)
+	ty.Commit("Two.")
+	err := ty.Close(50 * time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "1 commits not typed") {
+		t.Errorf("Close = %v, want it to say what was left", err)
+	}
+}
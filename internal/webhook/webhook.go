@@ -0,0 +1,343 @@
+// Package webhook POSTs finished transcripts to the endpoints in [webhooks].
+//
+// Each delivery is signed with the endpoint's secret so a receiver can tell
+// it came from us and was not replayed, retried with a doubling backoff while
+// the receiver is down, and written to a dead-letter file if it never gets
+// through, so that nothing is lost to an outage: each line of that file holds
+// the payload exactly as it would have been sent.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+// SchemaVersion versions the payload. A minor bump adds optional fields; a
+// major bump removes, renames or retypes one.
+const SchemaVersion = "1.0"
+
+// Payload.Event values, also sent as the X-Audiomemo-Event header.
+const (
+	EventTranscript = "transcript.completed"
+	EventTest       = "webhook.test" // from `audiomemo webhook test`
+)
+
+// Headers on every delivery. Signature is only sent to an endpoint with a
+// secret.
+const (
+	HeaderEvent     = "X-Audiomemo-Event"
+	HeaderDelivery  = "X-Audiomemo-Delivery"
+	HeaderTimestamp = "X-Audiomemo-Timestamp"
+	HeaderSignature = "X-Audiomemo-Signature"
+)
+
+// Defaults for a Sender field left at zero.
+const (
+	DefaultTimeout = 10 * time.Second
+	DefaultBackoff = 2 * time.Second
+	// maxRetryAfter caps how long a receiver's Retry-After can hold a run.
+	maxRetryAfter = time.Minute
+)
+
+// Payload is the JSON body of a delivery.
+type Payload struct {
+	SchemaVersion string             `json:"schema_version"`
+	Event         string             `json:"event"`
+	ID            string             `json:"id"`      // the same on every retry, for deduplication
+	Created       string             `json:"created"` // RFC 3339
+	Recording     Recording          `json:"recording"`
+	Result        *transcribe.Result `json:"result"`
+	Summary       *Summary           `json:"summary,omitempty"`
+}
+
+// Recording describes what was transcribed. Paths are absent for audio read
+// from stdin, which was never saved.
+type Recording struct {
+	Path       string  `json:"path,omitempty"`
+	Label      string  `json:"label,omitempty"`
+	Transcript string  `json:"transcript,omitempty"`
+	Backend    string  `json:"backend"`
+	Language   string  `json:"language,omitempty"`
+	Duration   float64 `json:"duration,omitempty"` // seconds
+}
+
+// Summary is what --summarize made of the transcript, when it ran.
+type Summary struct {
+	Title       string `json:"title"`
+	Summary     string `json:"summary"`
+	ActionItems string `json:"action_items"`
+}
+
+// NewPayload starts a payload for event with a fresh delivery ID. The
+// result is copied with its schema version set, as `transcribe -f json`
+// writes it.
+func NewPayload(event string, rec Recording, result *transcribe.Result, now time.Time) Payload {
+	var r *transcribe.Result
+	if result != nil {
+		c := *result
+		c.SchemaVersion = transcribe.SchemaVersion
+		r = &c
+	}
+	return Payload{
+		SchemaVersion: SchemaVersion,
+		Event:         event,
+		ID:            newID(),
+		Created:       now.UTC().Format(time.RFC3339),
+		Recording:     rec,
+		Result:        r,
+	}
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Sign returns the signature header for body sent at timestamp (Unix
+// seconds): "sha256=" and the hex HMAC-SHA256, keyed with secret, of the
+// timestamp, a dot, and the body. Covering the timestamp lets a receiver
+// refuse an old delivery sent again.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a delivery's signature header in constant time, and that its
+// timestamp is within tolerance of now. It is what a receiver written in Go
+// needs, and what the tests use.
+func Verify(secret, timestamp, signature string, body []byte, tolerance time.Duration, now time.Time) error {
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("bad timestamp %q", timestamp)
+	}
+	if age := now.Sub(time.Unix(sec, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("timestamp is %s off", age.Round(time.Second))
+	}
+	if !hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature)) {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+// Endpoint is one receiver.
+type Endpoint struct {
+	URL    string
+	Secret string // empty sends the delivery unsigned
+}
+
+// Sender delivers payloads.
+type Sender struct {
+	Client     *http.Client  // nil for one with Timeout
+	Timeout    time.Duration // per attempt
+	Retries    int           // attempts after the first
+	Backoff    time.Duration // before the first retry, doubling after each
+	DeadLetter string        // file to append undeliverable payloads to; empty keeps none
+
+	// Seal, when set, encrypts each dead letter's payload, which is saved as
+	// SealedPayload in place of Payload.
+	Seal func(context.Context, []byte) ([]byte, error)
+
+	// sleep waits between attempts; tests replace it to run without delay.
+	sleep func(context.Context, time.Duration) error
+}
+
+// StatusError is a delivery the receiver answered with a non-2xx status.
+type StatusError struct {
+	Code   int
+	Status string
+	Body   string // the start of the response, which often says why
+}
+
+func (e *StatusError) Error() string {
+	if e.Body != "" {
+		return e.Status + ": " + e.Body
+	}
+	return e.Status
+}
+
+// retryable reports whether the same delivery might succeed later: the
+// receiver was down, overloaded or asked us to slow down. Any other 4xx means
+// the request itself is wrong and sending it again would not help.
+func (e *StatusError) retryable() bool {
+	return e.Code >= 500 || e.Code == http.StatusRequestTimeout || e.Code == http.StatusTooManyRequests
+}
+
+// Deliver POSTs p to ep, retrying as the Sender says. If every attempt fails
+// the payload goes to the dead-letter file, and the error says so.
+func (s *Sender) Deliver(ctx context.Context, ep Endpoint, p Payload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	backoff := s.Backoff
+	if backoff <= 0 {
+		backoff = DefaultBackoff
+	}
+	attempts := 0
+	for {
+		attempts++
+		var retryAfter time.Duration
+		retryAfter, err = s.post(ctx, ep, p.Event, p.ID, body)
+		if err == nil {
+			return nil
+		}
+		var status *StatusError
+		if errors.As(err, &status) && !status.retryable() {
+			break
+		}
+		if attempts > s.Retries || ctx.Err() != nil {
+			break
+		}
+		wait := max(backoff, retryAfter)
+		if err := s.wait(ctx, wait); err != nil {
+			break
+		}
+		backoff *= 2
+	}
+
+	err = fmt.Errorf("%s: gave up after %d attempts: %w", ep.URL, attempts, err)
+	if s.DeadLetter == "" {
+		return err
+	}
+	// An interrupted run still saves what it could not deliver.
+	if dlErr := s.deadLetter(context.WithoutCancel(ctx), ep, p, body, attempts, err); dlErr != nil {
+		return fmt.Errorf("%w; and could not save it: %v", err, dlErr)
+	}
+	return fmt.Errorf("%w; saved to %s", err, s.DeadLetter)
+}
+
+func (s *Sender) wait(ctx context.Context, d time.Duration) error {
+	if s.sleep != nil {
+		return s.sleep(ctx, d)
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Send makes one attempt, with no retry and no dead letter, as `webhook
+// test` wants.
+func (s *Sender) Send(ctx context.Context, ep Endpoint, p Payload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	_, err = s.post(ctx, ep, p.Event, p.ID, body)
+	return err
+}
+
+// post makes one attempt. A 429 or 503 may come with how long to wait,
+// which is returned alongside the error.
+func (s *Sender) post(ctx context.Context, ep Endpoint, event, id string, body []byte) (time.Duration, error) {
+	client := s.Client
+	if client == nil {
+		timeout := s.Timeout
+		if timeout <= 0 {
+			timeout = DefaultTimeout
+		}
+		client = &http.Client{Timeout: timeout}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "audiomemo-webhook/"+SchemaVersion)
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderDelivery, id)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if ep.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(ep.Secret, timestamp, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return 0, nil
+	}
+	var retryAfter time.Duration
+	if sec, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && sec > 0 {
+		retryAfter = min(time.Duration(sec)*time.Second, maxRetryAfter)
+	}
+	return retryAfter, &StatusError{Code: resp.StatusCode, Status: resp.Status, Body: strings.TrimSpace(string(snippet))}
+}
+
+// DeadLetter is one line of the dead-letter file. It has Payload, or, when
+// the Sender seals them, SealedPayload: the same bytes encrypted, which
+// encoding/json writes as base64.
+type DeadLetter struct {
+	URL           string          `json:"url"`
+	Event         string          `json:"event"`
+	ID            string          `json:"id"`
+	FailedAt      string          `json:"failed_at"` // RFC 3339
+	Attempts      int             `json:"attempts"`
+	Error         string          `json:"error"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+	SealedPayload []byte          `json:"sealed_payload,omitempty"`
+}
+
+// deadLetter appends the failed delivery to the dead-letter file. The file
+// holds transcripts, so only its owner can read it, and a payload that
+// cannot be sealed is not saved at all.
+func (s *Sender) deadLetter(ctx context.Context, ep Endpoint, p Payload, body []byte, attempts int, cause error) error {
+	dl := DeadLetter{
+		URL:      ep.URL,
+		Event:    p.Event,
+		ID:       p.ID,
+		FailedAt: time.Now().UTC().Format(time.RFC3339),
+		Attempts: attempts,
+		Error:    cause.Error(),
+		Payload:  body,
+	}
+	if s.Seal != nil {
+		sealed, err := s.Seal(ctx, body)
+		if err != nil {
+			return err
+		}
+		dl.Payload, dl.SealedPayload = nil, sealed
+	}
+	line, err := json.Marshal(dl)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.DeadLetter), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.DeadLetter, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	return errors.Join(err, f.Close())
+}
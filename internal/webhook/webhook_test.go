@@ -0,0 +1,271 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+// receiver is a webhook endpoint that answers with the statuses it is given,
+// in turn, and keeps what it was sent.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	got      []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.got = append(rc.got, r)
+	rc.bodies = append(rc.bodies, body)
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	if status == http.StatusTooManyRequests {
+		w.Header().Set("Retry-After", "7")
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, http.StatusText(status))
+}
+
+// noSleep records the waits a Sender would have made.
+func noSleep(waits *[]time.Duration) func(context.Context, time.Duration) error {
+	return func(_ context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return nil
+	}
+}
+
+func testPayload() Payload {
+	return NewPayload(EventTranscript, Recording{Path: "/r/standup.ogg", Label: "standup", Backend: "deepgram"},
+		&transcribe.Result{Text: "Ship it.", Language: "en"}, time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC))
+}
+
+func TestDeliverSignsThePayload(t *testing.T) {
+	rc := &receiver{}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	s := &Sender{}
+	p := testPayload()
+	if err := s.Deliver(context.Background(), Endpoint{URL: srv.URL, Secret: "s3cret"}, p); err != nil {
+		t.Fatal(err)
+	}
+	r, body := rc.got[0], rc.bodies[0]
+	if r.Header.Get(HeaderEvent) != EventTranscript || r.Header.Get(HeaderDelivery) != p.ID {
+		t.Errorf("headers = %v", r.Header)
+	}
+	if err := Verify("s3cret", r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body, time.Minute, time.Now()); err != nil {
+		t.Errorf("signature: %v", err)
+	}
+	if err := Verify("wrong", r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body, time.Minute, time.Now()); err == nil {
+		t.Error("a signature made with another secret should not verify")
+	}
+
+	var got Payload
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.SchemaVersion != SchemaVersion || got.Created != "2026-10-18T09:00:00Z" || got.Recording.Label != "standup" {
+		t.Errorf("payload = %+v", got)
+	}
+	if got.Result.SchemaVersion != transcribe.SchemaVersion || got.Result.Text != "Ship it." {
+		t.Errorf("result = %+v, want it as transcribe -f json writes it", got.Result)
+	}
+}
+
+func TestDeliverUnsignedWithoutSecret(t *testing.T) {
+	rc := &receiver{}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	if err := (&Sender{}).Deliver(context.Background(), Endpoint{URL: srv.URL}, testPayload()); err != nil {
+		t.Fatal(err)
+	}
+	if sig := rc.got[0].Header.Get(HeaderSignature); sig != "" {
+		t.Errorf("signature = %q, want none", sig)
+	}
+}
+
+func TestVerifyRejectsOldDelivery(t *testing.T) {
+	body := []byte(`{}`)
+	sent := time.Now().Add(-10 * time.Minute)
+	ts := "1"
+	if err := Verify("k", ts, Sign("k", ts, body), body, 5*time.Minute, sent); err == nil {
+		t.Error("want a stale timestamp refused")
+	}
+}
+
+func TestDeliverRetriesWithBackoff(t *testing.T) {
+	rc := &receiver{statuses: []int{500, 503, 429}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	var waits []time.Duration
+	s := &Sender{Retries: 4, Backoff: time.Second, sleep: noSleep(&waits)}
+	p := testPayload()
+	if err := s.Deliver(context.Background(), Endpoint{URL: srv.URL}, p); err != nil {
+		t.Fatal(err)
+	}
+	if len(rc.got) != 4 {
+		t.Fatalf("%d attempts, want 4", len(rc.got))
+	}
+	// Doubling, except that the 429's Retry-After of 7s outlasts the 4s.
+	if want := []time.Duration{time.Second, 2 * time.Second, 7 * time.Second}; !slices.Equal(waits, want) {
+		t.Errorf("waits = %v, want %v", waits, want)
+	}
+	for _, r := range rc.got {
+		if r.Header.Get(HeaderDelivery) != p.ID {
+			t.Error("every retry should carry the same delivery ID")
+		}
+	}
+}
+
+func TestDeliverDeadLettersWhenRetriesRunOut(t *testing.T) {
+	rc := &receiver{statuses: []int{502, 502, 502}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	dead := filepath.Join(t.TempDir(), "state", "webhooks-failed.jsonl")
+	var waits []time.Duration
+	s := &Sender{Retries: 2, DeadLetter: dead, sleep: noSleep(&waits)}
+	p := testPayload()
+	err := s.Deliver(context.Background(), Endpoint{URL: srv.URL}, p)
+	if err == nil || !strings.Contains(err.Error(), "gave up after 3 attempts") || !strings.Contains(err.Error(), dead) {
+		t.Fatalf("Deliver = %v", err)
+	}
+
+	data, err := os.ReadFile(dead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dl DeadLetter
+	if err := json.Unmarshal(data, &dl); err != nil {
+		t.Fatal(err)
+	}
+	if dl.URL != srv.URL || dl.ID != p.ID || dl.Attempts != 3 || !strings.Contains(dl.Error, "502") {
+		t.Errorf("dead letter = %+v", dl)
+	}
+	// The payload is kept byte for byte, ready to be sent again.
+	if string(dl.Payload) != string(rc.bodies[0]) {
+		t.Errorf("dead-letter payload differs from what was sent")
+	}
+	if info, _ := os.Stat(dead); info.Mode().Perm() != 0600 {
+		t.Errorf("dead-letter file mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestDeliverSealsDeadLetters(t *testing.T) {
+	rc := &receiver{statuses: []int{502}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	dead := filepath.Join(t.TempDir(), "webhooks-failed.jsonl")
+	var waits []time.Duration
+	seal := func(_ context.Context, plain []byte) ([]byte, error) {
+		return append([]byte("sealed:"), plain...), nil
+	}
+	s := &Sender{DeadLetter: dead, Seal: seal, sleep: noSleep(&waits)}
+	p := testPayload()
+	if err := s.Deliver(context.Background(), Endpoint{URL: srv.URL}, p); err == nil {
+		t.Fatal("want an error")
+	}
+
+	data, err := os.ReadFile(dead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), p.Result.Text) {
+		t.Error("the dead letter holds the transcript in plaintext")
+	}
+	var dl DeadLetter
+	if err := json.Unmarshal(data, &dl); err != nil {
+		t.Fatal(err)
+	}
+	if dl.Payload != nil || string(dl.SealedPayload) != "sealed:"+string(rc.bodies[0]) {
+		t.Errorf("dead letter = %+v", dl)
+	}
+	if dl.ID != p.ID || dl.URL != srv.URL {
+		t.Errorf("dead letter lost its envelope: %+v", dl)
+	}
+}
+
+func TestDeliverSavesNothingItCannotSeal(t *testing.T) {
+	rc := &receiver{statuses: []int{502}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	dead := filepath.Join(t.TempDir(), "webhooks-failed.jsonl")
+	var waits []time.Duration
+	seal := func(context.Context, []byte) ([]byte, error) { return nil, errors.New("age is not installed") }
+	s := &Sender{DeadLetter: dead, Seal: seal, sleep: noSleep(&waits)}
+	err := s.Deliver(context.Background(), Endpoint{URL: srv.URL}, testPayload())
+	if err == nil || !strings.Contains(err.Error(), "could not save it: age is not installed") {
+		t.Errorf("Deliver = %v", err)
+	}
+	if _, err := os.Stat(dead); !os.IsNotExist(err) {
+		t.Error("an unsealed dead letter was written")
+	}
+}
+
+func TestDeliverDoesNotRetryClientErrors(t *testing.T) {
+	rc := &receiver{statuses: []int{401}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	var waits []time.Duration
+	s := &Sender{Retries: 4, sleep: noSleep(&waits)}
+	err := s.Deliver(context.Background(), Endpoint{URL: srv.URL}, testPayload())
+	if err == nil || !strings.Contains(err.Error(), "401 Unauthorized") {
+		t.Errorf("Deliver = %v", err)
+	}
+	if len(rc.got) != 1 || len(waits) != 0 {
+		t.Errorf("%d attempts and %d waits, want one attempt", len(rc.got), len(waits))
+	}
+}
+
+func TestDeliverRetriesUnreachableEndpoint(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	var waits []time.Duration
+	s := &Sender{Retries: 1, sleep: noSleep(&waits)}
+	if err := s.Deliver(context.Background(), Endpoint{URL: url}, testPayload()); err == nil {
+		t.Fatal("want an error")
+	}
+	if len(waits) != 1 {
+		t.Errorf("waits = %v, want one retry", waits)
+	}
+}
+
+func TestSendMakesOneAttempt(t *testing.T) {
+	rc := &receiver{statuses: []int{500}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	s := &Sender{Retries: 3, DeadLetter: filepath.Join(t.TempDir(), "dead.jsonl")}
+	if err := s.Send(context.Background(), Endpoint{URL: srv.URL}, testPayload()); err == nil {
+		t.Fatal("want an error")
+	}
+	if len(rc.got) != 1 {
+		t.Errorf("%d attempts, want 1", len(rc.got))
+	}
+	if _, err := os.Stat(s.DeadLetter); !os.IsNotExist(err) {
+		t.Error("a test send should not be dead-lettered")
+	}
+}
@@ -0,0 +1,138 @@
+// Package wyoming implements the speech-to-text side of the Wyoming protocol
+// that Home Assistant's voice pipelines use to talk to STT engines.
+//
+// Every message is an event: one line of JSON naming its type and the length
+// of what follows, then that many bytes of JSON data and of binary payload.
+// Audio chunks carry raw PCM as their payload.
+package wyoming
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// protocolVersion is the Wyoming version written into each event header.
+const protocolVersion = "1.5.4"
+
+// maxEventBytes bounds an event's data and payload, so a confused client
+// cannot make the server allocate without limit. Audio arrives in chunks of
+// a few kilobytes.
+const maxEventBytes = 16 << 20
+
+// Event is one Wyoming message.
+type Event struct {
+	Type    string
+	Data    json.RawMessage // nil when the event has no data
+	Payload []byte
+}
+
+type header struct {
+	Type          string          `json:"type"`
+	Version       string          `json:"version,omitempty"`
+	Data          json.RawMessage `json:"data,omitempty"`
+	DataLength    int             `json:"data_length,omitempty"`
+	PayloadLength int             `json:"payload_length,omitempty"`
+}
+
+// NewEvent builds an event whose data is v encoded as JSON.
+func NewEvent(typ string, v any, payload []byte) (*Event, error) {
+	e := &Event{Type: typ, Payload: payload}
+	if v != nil {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		e.Data = data
+	}
+	return e, nil
+}
+
+// Decode unmarshals the event's data into v. An event without data leaves v
+// unchanged.
+func (e *Event) Decode(v any) error {
+	if len(e.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return fmt.Errorf("wyoming: bad %s data: %w", e.Type, err)
+	}
+	return nil
+}
+
+// ReadEvent reads the next event. Older clients put the data in the header
+// line itself; when both forms are present they are merged, with the
+// separate data block winning.
+func ReadEvent(r *bufio.Reader) (*Event, error) {
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		if err == io.EOF && len(line) > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	var h header
+	if err := json.Unmarshal(line, &h); err != nil {
+		return nil, fmt.Errorf("wyoming: bad event header: %w", err)
+	}
+	if h.Type == "" {
+		return nil, fmt.Errorf("wyoming: event header has no type")
+	}
+	if h.DataLength < 0 || h.PayloadLength < 0 || h.DataLength+h.PayloadLength > maxEventBytes {
+		return nil, fmt.Errorf("wyoming: %s event too large", h.Type)
+	}
+
+	e := &Event{Type: h.Type, Data: h.Data}
+	if h.DataLength > 0 {
+		data := make([]byte, h.DataLength)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		if e.Data, err = mergeData(h.Data, data); err != nil {
+			return nil, fmt.Errorf("wyoming: bad %s data: %w", h.Type, err)
+		}
+	}
+	if h.PayloadLength > 0 {
+		e.Payload = make([]byte, h.PayloadLength)
+		if _, err := io.ReadFull(r, e.Payload); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+func mergeData(inline, extra json.RawMessage) (json.RawMessage, error) {
+	if len(inline) == 0 {
+		return extra, nil
+	}
+	var merged map[string]json.RawMessage
+	if err := json.Unmarshal(inline, &merged); err != nil {
+		return nil, err
+	}
+	var more map[string]json.RawMessage
+	if err := json.Unmarshal(extra, &more); err != nil {
+		return nil, err
+	}
+	for k, v := range more {
+		merged[k] = v
+	}
+	return json.Marshal(merged)
+}
+
+// WriteEvent writes e with its data in a separate block, as current Wyoming
+// peers expect.
+func WriteEvent(w io.Writer, e *Event) error {
+	h := header{Type: e.Type, Version: protocolVersion, DataLength: len(e.Data), PayloadLength: len(e.Payload)}
+	line, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 0, len(line)+1+len(e.Data)+len(e.Payload))
+	buf = append(buf, line...)
+	buf = append(buf, '\n')
+	buf = append(buf, e.Data...)
+	buf = append(buf, e.Payload...)
+	_, err = w.Write(buf)
+	return err
+}
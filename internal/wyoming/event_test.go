@@ -0,0 +1,84 @@
+package wyoming
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEventRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	e, err := NewEvent("audio-chunk", AudioFormat{Rate: 16000, Width: 2, Channels: 1}, []byte{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteEvent(&buf, e); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteEvent(&buf, &Event{Type: "audio-stop"}); err != nil {
+		t.Fatal(err)
+	}
+
+	line, _, _ := strings.Cut(buf.String(), "\n")
+	var h header
+	if err := json.Unmarshal([]byte(line), &h); err != nil {
+		t.Fatalf("header %q: %v", line, err)
+	}
+	if h.Type != "audio-chunk" || h.DataLength != len(e.Data) || h.PayloadLength != 4 || h.Version == "" {
+		t.Errorf("header = %+v", h)
+	}
+
+	r := bufio.NewReader(&buf)
+	got, err := ReadEvent(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f AudioFormat
+	if err := got.Decode(&f); err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != "audio-chunk" || f.Rate != 16000 || !bytes.Equal(got.Payload, []byte{1, 2, 3, 4}) {
+		t.Errorf("got %+v with format %+v", got, f)
+	}
+	got, err = ReadEvent(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != "audio-stop" || got.Data != nil || got.Payload != nil {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestReadEventMergesInlineData(t *testing.T) {
+	extra := `{"language":"de"}`
+	input := `{"type":"transcribe","data":{"name":"whisper","language":"en"},"data_length":` +
+		`17}` + "\n" + extra
+	e, err := ReadEvent(bufio.NewReader(strings.NewReader(input)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var d transcribeData
+	if err := e.Decode(&d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Name != "whisper" || d.Language != "de" {
+		t.Errorf("data = %+v, want the name from the header and the language from the data block", d)
+	}
+}
+
+func TestReadEventErrors(t *testing.T) {
+	tests := map[string]string{
+		"not json":  "hello\n",
+		"no type":   `{"data_length":0}` + "\n",
+		"too large": `{"type":"audio-chunk","payload_length":1073741824}` + "\n",
+		"truncated": `{"type":"audio-chunk","payload_length":10}` + "\nabc",
+		"no end":    `{"type":"describe"}`,
+	}
+	for name, input := range tests {
+		if _, err := ReadEvent(bufio.NewReader(strings.NewReader(input))); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package wyoming
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+)
+
+// Info is the answer to describe: the ASR programs this server offers.
+type Info struct {
+	ASR []Program `json:"asr"`
+}
+
+type Attribution struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Program is an ASR engine. Home Assistant lists its models as the choices
+// for a pipeline's speech-to-text.
+type Program struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Attribution Attribution `json:"attribution"`
+	Installed   bool        `json:"installed"`
+	Version     string      `json:"version,omitempty"`
+	Models      []Model     `json:"models"`
+}
+
+type Model struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Attribution Attribution `json:"attribution"`
+	Installed   bool        `json:"installed"`
+	Languages   []string    `json:"languages"`
+	Version     string      `json:"version,omitempty"`
+}
+
+// Server answers Wyoming ASR clients. Each connection handles one utterance
+// at a time, in the order transcribe, audio-start, audio-chunk...,
+// audio-stop, and gets a transcript event back.
+type Server struct {
+	Info       Info
+	NewSession SessionFunc
+	// ErrorLog receives failures that only the server's operator can act
+	// on; nil discards them.
+	ErrorLog func(err error)
+
+	wg sync.WaitGroup
+}
+
+type transcribeData struct {
+	Name     string `json:"name,omitempty"`
+	Language string `json:"language,omitempty"`
+}
+
+type transcriptData struct {
+	Text     string `json:"text"`
+	Language string `json:"language,omitempty"`
+}
+
+type errorData struct {
+	Text string `json:"text"`
+	Code string `json:"code,omitempty"`
+}
+
+// Serve accepts connections on ln until ctx ends, then closes ln and waits
+// for the connections in progress.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	defer s.wg.Wait()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			// Closing the connection unblocks the read when ctx ends.
+			stop := context.AfterFunc(ctx, func() { conn.Close() })
+			defer stop()
+			if err := s.ServeConn(ctx, conn); err != nil {
+				s.logError(err)
+			}
+		}()
+	}
+}
+
+func (s *Server) logError(err error) {
+	if s.ErrorLog != nil {
+		s.ErrorLog(err)
+	}
+}
+
+// ServeConn speaks the protocol on one connection until the client hangs up.
+func (s *Server) ServeConn(ctx context.Context, conn io.ReadWriter) error {
+	r := bufio.NewReader(conn)
+	var req Request
+	var session Session
+	// failed is set once the client has been told its utterance failed, so
+	// the rest of its audio is dropped quietly.
+	var failed bool
+	defer func() {
+		if session != nil {
+			session.Close()
+		}
+	}()
+
+	reply := func(typ string, v any) error {
+		e, err := NewEvent(typ, v, nil)
+		if err != nil {
+			return err
+		}
+		return WriteEvent(conn, e)
+	}
+	// fail tells the client its utterance failed; only a failure to tell it
+	// ends the connection.
+	fail := func(err error) error {
+		s.logError(err)
+		if session != nil {
+			session.Close()
+			session = nil
+		}
+		failed = true
+		return reply("error", errorData{Text: err.Error(), Code: "stt-error"})
+	}
+	start := func(e *Event) error {
+		if err := e.Decode(&req.Format); err != nil {
+			return err
+		}
+		var err error
+		session, err = s.NewSession(ctx, req)
+		return err
+	}
+
+	for {
+		e, err := ReadEvent(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		switch e.Type {
+		case "describe":
+			err = reply("info", s.Info)
+
+		case "ping":
+			err = WriteEvent(conn, &Event{Type: "pong", Data: e.Data})
+
+		case "transcribe":
+			var d transcribeData
+			if err = e.Decode(&d); err == nil {
+				req.Model, req.Language = d.Name, d.Language
+			}
+
+		case "audio-start":
+			if session != nil {
+				session.Close()
+				session = nil
+			}
+			failed = false
+			if serr := start(e); serr != nil {
+				err = fail(serr)
+			}
+
+		case "audio-chunk":
+			if failed {
+				break
+			}
+			if session == nil {
+				// Some clients skip audio-start; every chunk carries the
+				// format too.
+				if serr := start(e); serr != nil {
+					err = fail(serr)
+					break
+				}
+			}
+			if werr := session.Write(e.Payload); werr != nil {
+				err = fail(werr)
+			}
+
+		case "audio-stop":
+			var text string
+			if session != nil {
+				var ferr error
+				text, ferr = session.Finish(ctx)
+				session = nil
+				if ferr != nil {
+					err = fail(ferr)
+				}
+			}
+			if err == nil && !failed {
+				// Even no audio at all gets a transcript: the client is
+				// waiting for one.
+				err = reply("transcript", transcriptData{Text: text, Language: req.Language})
+			}
+			req = Request{}
+			failed = false
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
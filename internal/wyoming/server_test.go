@@ -0,0 +1,251 @@
+package wyoming
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/encrypt"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+// TestMain removes the private temp directory the tests' temp files went in,
+// as execute does for a real run.
+func TestMain(m *testing.M) {
+	code := m.Run()
+	encrypt.RemoveTempDir()
+	os.Exit(code)
+}
+
+// fakeBackend checks the WAV it is given and returns a fixed transcript.
+type fakeBackend struct {
+	text    string
+	err     error
+	gotWAV  []byte
+	gotOpts transcribe.TranscribeOpts
+}
+
+func (f *fakeBackend) Name() string { return "fake" }
+
+func (f *fakeBackend) Transcribe(ctx context.Context, path string, opts transcribe.TranscribeOpts) (*transcribe.Result, error) {
+	f.gotWAV, _ = os.ReadFile(path)
+	f.gotOpts = opts
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &transcribe.Result{Text: f.text}, nil
+}
+
+// client is a minimal Wyoming client, as Home Assistant would use.
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func startServer(t *testing.T, newSession SessionFunc) *client {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	srv := &Server{
+		Info: Info{ASR: []Program{{
+			Name:      "audiomemo",
+			Installed: true,
+			Models:    []Model{{Name: "fake", Installed: true, Languages: []string{"en", "de"}}},
+		}}},
+		NewSession: newSession,
+	}
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(ctx, ln) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	})
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	t.Cleanup(func() { conn.Close() })
+	return &client{t: t, conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (c *client) send(typ string, data any, payload []byte) {
+	c.t.Helper()
+	e, err := NewEvent(typ, data, payload)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if err := WriteEvent(c.conn, e); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *client) expect(typ string, v any) {
+	c.t.Helper()
+	e, err := ReadEvent(c.r)
+	if err != nil {
+		c.t.Fatalf("waiting for %s: %v", typ, err)
+	}
+	if e.Type != typ {
+		c.t.Fatalf("got %s event (%s), want %s", e.Type, e.Data, typ)
+	}
+	if err := e.Decode(v); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func batchSessions(backend *fakeBackend) SessionFunc {
+	return func(ctx context.Context, req Request) (Session, error) {
+		return NewBatchSession(backend, transcribe.TranscribeOpts{Model: req.Model, Language: req.Language}, nil, req.Format)
+	}
+}
+
+func (c *client) sendUtterance(chunks ...[]byte) {
+	c.t.Helper()
+	format := map[string]int{"rate": 16000, "width": 2, "channels": 1}
+	c.send("audio-start", format, nil)
+	for _, chunk := range chunks {
+		c.send("audio-chunk", format, chunk)
+	}
+	c.send("audio-stop", map[string]int{"timestamp": 1000}, nil)
+}
+
+func TestDescribe(t *testing.T) {
+	c := startServer(t, batchSessions(&fakeBackend{}))
+	c.send("describe", nil, nil)
+	var info Info
+	c.expect("info", &info)
+	if len(info.ASR) != 1 || info.ASR[0].Models[0].Name != "fake" || !info.ASR[0].Installed {
+		t.Errorf("info = %+v", info)
+	}
+}
+
+func TestPing(t *testing.T) {
+	c := startServer(t, batchSessions(&fakeBackend{}))
+	c.send("ping", map[string]string{"text": "hi"}, nil)
+	var pong map[string]string
+	c.expect("pong", &pong)
+	if pong["text"] != "hi" {
+		t.Errorf("pong = %v", pong)
+	}
+}
+
+func TestBatchTranscription(t *testing.T) {
+	backend := &fakeBackend{text: "turn on the lights"}
+	c := startServer(t, batchSessions(backend))
+
+	c.send("transcribe", transcribeData{Name: "fake", Language: "en"}, nil)
+	c.sendUtterance(bytes.Repeat([]byte{1, 0}, 100), bytes.Repeat([]byte{2, 0}, 60))
+	var got transcriptData
+	c.expect("transcript", &got)
+	if got.Text != "turn on the lights" || got.Language != "en" {
+		t.Errorf("transcript = %+v", got)
+	}
+	if backend.gotOpts.Model != "fake" || backend.gotOpts.Language != "en" {
+		t.Errorf("opts = %+v", backend.gotOpts)
+	}
+
+	wav := backend.gotWAV
+	if len(wav) != 44+320 || string(wav[0:4]) != "RIFF" || string(wav[8:12]) != "WAVE" || string(wav[36:40]) != "data" {
+		t.Fatalf("not a WAV with the audio: %d bytes, header %q", len(wav), wav[:min(len(wav), 44)])
+	}
+	le := binary.LittleEndian
+	if rate, bits, size := le.Uint32(wav[24:28]), le.Uint16(wav[34:36]), le.Uint32(wav[40:44]); rate != 16000 || bits != 16 || size != 320 {
+		t.Errorf("rate %d, bits %d, data size %d", rate, bits, size)
+	}
+
+	// The connection stays open for the next utterance, which starts over
+	// without the previous language.
+	backend.text = "and off again"
+	c.sendUtterance([]byte{0, 0})
+	got = transcriptData{}
+	c.expect("transcript", &got)
+	if got.Text != "and off again" || got.Language != "" || backend.gotOpts.Language != "" {
+		t.Errorf("second transcript = %+v with opts %+v", got, backend.gotOpts)
+	}
+}
+
+func TestAudioWithoutStart(t *testing.T) {
+	c := startServer(t, batchSessions(&fakeBackend{text: "hello"}))
+	c.send("audio-chunk", map[string]int{"rate": 16000, "width": 2, "channels": 1}, []byte{0, 0})
+	c.send("audio-stop", nil, nil)
+	var got transcriptData
+	c.expect("transcript", &got)
+	if got.Text != "hello" {
+		t.Errorf("transcript = %+v", got)
+	}
+}
+
+func TestNoAudioGetsEmptyTranscript(t *testing.T) {
+	backend := &fakeBackend{text: "should not be asked"}
+	c := startServer(t, batchSessions(backend))
+	c.sendUtterance()
+	var got transcriptData
+	c.expect("transcript", &got)
+	if got.Text != "" || backend.gotWAV != nil {
+		t.Errorf("transcript = %+v, backend saw %d bytes", got, len(backend.gotWAV))
+	}
+}
+
+func TestErrors(t *testing.T) {
+	backend := &fakeBackend{err: errors.New("quota exceeded")}
+	c := startServer(t, batchSessions(backend))
+
+	c.sendUtterance([]byte{0, 0})
+	var e errorData
+	c.expect("error", &e)
+	if !strings.Contains(e.Text, "quota exceeded") {
+		t.Errorf("error = %+v", e)
+	}
+
+	// A bad format fails at audio-start; the chunks that follow are dropped
+	// rather than each reporting the same failure.
+	bad := map[string]int{"rate": 0, "width": 2, "channels": 1}
+	c.send("audio-start", bad, nil)
+	c.send("audio-chunk", bad, []byte{0, 0})
+	c.send("audio-chunk", bad, []byte{0, 0})
+	c.send("audio-stop", nil, nil)
+	c.expect("error", &e)
+	if !strings.Contains(e.Text, "unsupported audio format") {
+		t.Errorf("error = %+v", e)
+	}
+
+	// The connection still works afterwards.
+	backend.err, backend.text = nil, "recovered"
+	c.sendUtterance([]byte{0, 0})
+	var got transcriptData
+	c.expect("transcript", &got)
+	if got.Text != "recovered" {
+		t.Errorf("transcript = %+v", got)
+	}
+}
+
+func TestBatchSessionBuffersInPrivateTempDir(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	t.Cleanup(encrypt.RemoveTempDir)
+	backend := &fakeBackend{text: "Hi."}
+	s, err := NewBatchSession(backend, transcribe.TranscribeOpts{}, nil, AudioFormat{Rate: 16000, Width: 2, Channels: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	dir, _ := encrypt.TempDir()
+	if got := filepath.Dir(s.(*batchSession).file.Name()); got != dir {
+		t.Errorf("buffered audio in %s, want %s", got, dir)
+	}
+}
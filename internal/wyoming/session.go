@@ -0,0 +1,195 @@
+package wyoming
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/joegoldin/audiomemo/internal/encrypt"
+	"github.com/joegoldin/audiomemo/internal/transcribe"
+)
+
+// AudioFormat describes the PCM in audio-start and audio-chunk events.
+type AudioFormat struct {
+	Rate     int `json:"rate"`
+	Width    int `json:"width"` // bytes per sample
+	Channels int `json:"channels"`
+}
+
+func (f AudioFormat) String() string {
+	return fmt.Sprintf("%d Hz, %d-bit, %d channel(s)", f.Rate, f.Width*8, f.Channels)
+}
+
+func (f AudioFormat) valid() bool {
+	return f.Rate > 0 && f.Width > 0 && f.Width <= 4 && f.Channels > 0
+}
+
+// Request is what a client asked for before sending an utterance: the
+// transcribe event's model and language, and the audio-start format.
+type Request struct {
+	Model    string
+	Language string
+	Format   AudioFormat
+}
+
+// Session transcribes one utterance as its audio arrives.
+type Session interface {
+	Write(pcm []byte) error
+	// Finish is called at audio-stop and returns the transcript.
+	Finish(ctx context.Context) (string, error)
+	// Close releases the session when the client leaves before audio-stop.
+	Close()
+}
+
+// SessionFunc starts a session for a request.
+type SessionFunc func(ctx context.Context, req Request) (Session, error)
+
+// batchSession buffers the utterance into a WAV file and hands it to a batch
+// backend at the end. Voice commands are a few seconds long, so waiting for
+// the whole of one costs little.
+type batchSession struct {
+	backend transcribe.Transcriber
+	opts    transcribe.TranscribeOpts
+	post    *transcribe.PostProcessor
+	file    *os.File
+	format  AudioFormat
+	size    int64
+}
+
+// NewBatchSession starts a session that transcribes with backend once the
+// audio has ended.
+func NewBatchSession(backend transcribe.Transcriber, opts transcribe.TranscribeOpts, post *transcribe.PostProcessor, format AudioFormat) (Session, error) {
+	if !format.valid() {
+		return nil, fmt.Errorf("unsupported audio format: %s", format)
+	}
+	dir, err := encrypt.TempDir()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(dir, "wyoming-*.wav")
+	if err != nil {
+		return nil, err
+	}
+	// The header is rewritten with the real sizes in Finish.
+	if err := writeWAVHeader(f, format, 0); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return &batchSession{backend: backend, opts: opts, post: post, file: f, format: format}, nil
+}
+
+func (s *batchSession) Write(pcm []byte) error {
+	n, err := s.file.Write(pcm)
+	s.size += int64(n)
+	return err
+}
+
+func (s *batchSession) Finish(ctx context.Context) (string, error) {
+	defer s.Close()
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	if err := writeWAVHeader(s.file, s.format, s.size); err != nil {
+		return "", err
+	}
+	if err := s.file.Close(); err != nil {
+		return "", err
+	}
+	if s.size == 0 {
+		return "", nil
+	}
+	result, err := s.backend.Transcribe(ctx, s.file.Name(), s.opts)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", s.backend.Name(), err)
+	}
+	s.post.Apply(result)
+	return result.Text, nil
+}
+
+func (s *batchSession) Close() {
+	s.file.Close()
+	os.Remove(s.file.Name())
+}
+
+// writeWAVHeader writes a canonical 44-byte PCM WAV header for size bytes of
+// audio.
+func writeWAVHeader(w io.Writer, f AudioFormat, size int64) error {
+	blockAlign := f.Width * f.Channels
+	h := struct {
+		RIFF          [4]byte
+		ChunkSize     uint32
+		WAVE          [4]byte
+		Fmt           [4]byte
+		FmtSize       uint32
+		AudioFormat   uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+		Data          [4]byte
+		DataSize      uint32
+	}{
+		RIFF:          [4]byte{'R', 'I', 'F', 'F'},
+		ChunkSize:     uint32(36 + size),
+		WAVE:          [4]byte{'W', 'A', 'V', 'E'},
+		Fmt:           [4]byte{'f', 'm', 't', ' '},
+		FmtSize:       16,
+		AudioFormat:   1, // PCM
+		Channels:      uint16(f.Channels),
+		SampleRate:    uint32(f.Rate),
+		ByteRate:      uint32(f.Rate * blockAlign),
+		BlockAlign:    uint16(blockAlign),
+		BitsPerSample: uint16(f.Width * 8),
+		Data:          [4]byte{'d', 'a', 't', 'a'},
+		DataSize:      uint32(size),
+	}
+	return binary.Write(w, binary.LittleEndian, h)
+}
+
+// StreamFormat is the only audio the realtime streamer accepts. It is also
+// what Home Assistant sends.
+var StreamFormat = AudioFormat{Rate: 16000, Width: 2, Channels: 1}
+
+// streamIdle is how long a realtime session waits after audio-stop for
+// another commit before it settles on the transcript it has.
+const streamIdle = 1500 * time.Millisecond
+
+// streamSession feeds the utterance to a realtime streamer as it arrives,
+// so the transcript is ready almost as soon as the speaker stops.
+type streamSession struct {
+	streamer *transcribe.Streamer
+	pw       *io.PipeWriter
+}
+
+// NewStreamSession starts streamer on the utterance. The audio must be in
+// StreamFormat.
+func NewStreamSession(ctx context.Context, streamer *transcribe.Streamer, format AudioFormat) (Session, error) {
+	if format != StreamFormat {
+		return nil, fmt.Errorf("realtime transcription needs %s audio, got %s", StreamFormat, format)
+	}
+	pr, pw := io.Pipe()
+	if err := streamer.Start(ctx, pr, ""); err != nil {
+		return nil, err
+	}
+	return &streamSession{streamer: streamer, pw: pw}, nil
+}
+
+func (s *streamSession) Write(pcm []byte) error {
+	_, err := s.pw.Write(pcm)
+	return err
+}
+
+func (s *streamSession) Finish(ctx context.Context) (string, error) {
+	s.pw.Close()
+	return s.streamer.Finish(ctx, streamIdle)
+}
+
+func (s *streamSession) Close() {
+	s.pw.CloseWithError(io.ErrClosedPipe)
+	s.streamer.Stop()
+}
@@ -4,7 +4,9 @@
 // STUB_LOUD_SECONDS, then digital silence — and exits when the recorder asks
 // it to stop by writing "q" to stdin, exactly as ffmpeg does. It honours -t by
 // exiting when that many seconds have passed, which is how real ffmpeg ends a
-// --max-duration recording.
+// --max-duration recording. Given a file to read with -i, as record
+// --input-file passes, it hears speech throughout and exits when the
+// STUB_LOUD_SECONDS are up, as ffmpeg does at the end of the file.
 package main
 
 import (
@@ -42,6 +44,15 @@ func main() {
 		}
 	}
 
+	fromFile := false
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == "-i" {
+			if fi, err := os.Stat(args[i+1]); err == nil && fi.Mode().IsRegular() {
+				fromFile = true
+			}
+		}
+	}
+
 	maxDuration := time.Duration(0)
 	for i := 0; i < len(args)-1; i++ {
 		if args[i] != "-t" {
@@ -79,6 +90,9 @@ func main() {
 		}
 		level := "-18.00"
 		if time.Since(start) >= loudFor {
+			if fromFile {
+				return
+			}
 			level = "-inf"
 		}
 		fmt.Fprintf(os.Stderr, "[Parsed_ametadata_2 @ 0x1] lavfi.astats.Overall.RMS_level=%s\n", level)